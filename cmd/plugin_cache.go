@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hootsuite/atlantis/server/events/terraform"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// PluginCacheCmd reports on and cleans up the terraform plugin cache that
+// the server shares between projects.
+type PluginCacheCmd struct {
+	// Out is where the output is written. If nil, it's os.Stdout.
+	Out io.Writer
+	// SilenceOutput set to true means errors aren't printed.
+	// Useful for testing to keep the logs clean.
+	SilenceOutput bool
+}
+
+// Init returns the runnable cobra command.
+func (p *PluginCacheCmd) Init() *cobra.Command {
+	var dataDir string
+	c := &cobra.Command{
+		Use:   "plugin-cache",
+		Short: "Manage the terraform plugin cache",
+		Long: `Manage the terraform plugin cache
+
+The server caches the provider plugins terraform downloads in <data-dir>/plugin-cache,
+or $` + terraform.PluginCacheDirEnv + ` if it's set, so each one is only downloaded once.`,
+	}
+	c.PersistentFlags().StringVar(&dataDir, DataDirFlag, "~/.atlantis", "Path to the server's data directory.")
+
+	c.AddCommand(&cobra.Command{
+		Use:   "size",
+		Short: "Print the plugins in the cache and their total size",
+		RunE: withErrPrint(p.SilenceOutput, func(cmd *cobra.Command, args []string) error {
+			cache, err := p.cache(dataDir)
+			if err != nil {
+				return err
+			}
+			plugins, err := cache.Plugins()
+			if err != nil {
+				return err
+			}
+			var total int64
+			for _, plugin := range plugins {
+				fmt.Fprintf(p.out(), "%s\t%s\n", formatSize(plugin.Size), plugin.Path) // nolint: errcheck
+				total += plugin.Size
+			}
+			fmt.Fprintf(p.out(), "%d plugins using %s in %s\n", len(plugins), formatSize(total), cache.Dir) // nolint: errcheck
+			return nil
+		}),
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "gc",
+		Short: "Remove all but the newest version of each plugin from the cache",
+		Long: `Remove all but the newest version of each plugin from the cache
+
+Older versions are downloaded again the next time a project needs them. It waits for any
+terraform init that's using the cache, but a plan or apply that's already past its init can
+fail if one of its plugins is removed, so run it when no commands are running.`,
+		RunE: withErrPrint(p.SilenceOutput, func(cmd *cobra.Command, args []string) error {
+			cache, err := p.cache(dataDir)
+			if err != nil {
+				return err
+			}
+			removed, err := cache.GC()
+			var freed int64
+			for _, plugin := range removed {
+				fmt.Fprintf(p.out(), "removed %s\n", plugin.Path) // nolint: errcheck
+				freed += plugin.Size
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(p.out(), "removed %d plugins, freeing %s\n", len(removed), formatSize(freed)) // nolint: errcheck
+			return nil
+		}),
+	})
+	return c
+}
+
+// cache returns the plugin cache the server uses with dataDir.
+func (p *PluginCacheCmd) cache(dataDir string) (*terraform.PluginCache, error) {
+	// Expand ~ the same way the server does.
+	if strings.HasPrefix(dataDir, "~/") {
+		expanded, err := homedir.Expand(dataDir)
+		if err != nil {
+			return nil, errors.Wrap(err, "determining home directory")
+		}
+		dataDir = expanded
+	}
+	return &terraform.PluginCache{Dir: terraform.PluginCacheDir(dataDir)}, nil
+}
+
+func (p *PluginCacheCmd) out() io.Writer {
+	if p.Out == nil {
+		return os.Stdout
+	}
+	return p.Out
+}
+
+// formatSize formats a number of bytes for people, ex. 1.5 MB.
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size := float64(bytes) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if size < unit {
+			return fmt.Sprintf("%.1f %s", size, suffix)
+		}
+		size /= unit
+	}
+	return fmt.Sprintf("%.1f TB", size)
+}
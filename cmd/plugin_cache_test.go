@@ -0,0 +1,42 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hootsuite/atlantis/cmd"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestPluginCache_SizeAndGC(t *testing.T) {
+	t.Log("size should report the cache's plugins and gc should remove old versions")
+	dataDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(dataDir) // nolint: errcheck
+	pluginDir := filepath.Join(dataDir, "plugin-cache", "linux_amd64")
+	Ok(t, os.MkdirAll(pluginDir, 0700))
+	Ok(t, ioutil.WriteFile(filepath.Join(pluginDir, "terraform-provider-aws_v1.6.0_x4"), make([]byte, 1024), 0700))
+	Ok(t, ioutil.WriteFile(filepath.Join(pluginDir, "terraform-provider-aws_v1.9.0_x4"), make([]byte, 2048), 0700))
+
+	var out bytes.Buffer
+	c := (&cmd.PluginCacheCmd{Out: &out}).Init()
+	c.SetArgs([]string{"size", "--data-dir", dataDir})
+	Ok(t, c.Execute())
+	Contains(t, "2 plugins using 3.0 KB in "+filepath.Join(dataDir, "plugin-cache"), lines(out.String()))
+
+	out.Reset()
+	c = (&cmd.PluginCacheCmd{Out: &out}).Init()
+	c.SetArgs([]string{"gc", "--data-dir", dataDir})
+	Ok(t, c.Execute())
+	Contains(t, "removed 1 plugins, freeing 1.0 KB", lines(out.String()))
+	_, err = os.Stat(filepath.Join(pluginDir, "terraform-provider-aws_v1.6.0_x4"))
+	Assert(t, os.IsNotExist(err), "exp old version to be removed")
+}
+
+func lines(s string) []string {
+	return strings.Split(strings.TrimSpace(s), "\n")
+}
@@ -3,6 +3,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -18,3 +19,15 @@ func Execute() {
 		os.Exit(1)
 	}
 }
+
+// withErrPrint prints out any errors f returns to a terminal in red, unless
+// silence is true.
+func withErrPrint(silence bool, f func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		err := f(cmd, args)
+		if err != nil && !silence {
+			fmt.Fprintf(os.Stderr, "\033[31mError: %s\033[39m\n\n", err.Error())
+		}
+		return err
+	}
+}
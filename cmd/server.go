@@ -3,8 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"regexp"
 
 	"strings"
+	"time"
 
 	"github.com/hootsuite/atlantis/server"
 	"github.com/mitchellh/go-homedir"
@@ -16,29 +18,114 @@ import (
 // To add a new flag you must:
 // 1. Add a const with the flag name (in alphabetic order).
 // 2. Add a new field to server.Config and set the mapstructure tag equal to the flag name.
-// 3. Add your flag's description etc. to the stringFlags, intFlags, or boolFlags slices.
+// 3. Add your flag's description etc. to the stringFlags, intFlags, durationFlags, or boolFlags slices.
 const (
-	AtlantisURLFlag     = "atlantis-url"
-	ConfigFlag          = "config"
-	DataDirFlag         = "data-dir"
-	GHHostnameFlag      = "gh-hostname"
-	GHTokenFlag         = "gh-token"
-	GHUserFlag          = "gh-user"
-	GHWebHookSecret     = "gh-webhook-secret"
-	GitlabHostnameFlag  = "gitlab-hostname"
-	GitlabTokenFlag     = "gitlab-token"
-	GitlabUserFlag      = "gitlab-user"
-	GitlabWebHookSecret = "gitlab-webhook-secret"
-	LogLevelFlag        = "log-level"
-	PortFlag            = "port"
-	RequireApprovalFlag = "require-approval"
+	AllowUICancelFlag          = "allow-ui-cancel"
+	ApplyTimeoutFlag           = "apply-timeout"
+	AtlantisURLFlag            = "atlantis-url"
+	AzureDevopsHostnameFlag    = "azuredevops-hostname"
+	AzureDevopsTokenFlag       = "azuredevops-token"
+	AzureDevopsUserFlag        = "azuredevops-user"
+	AzureDevopsWebHookPassword = "azuredevops-webhook-password"
+	AzureDevopsWebHookUser     = "azuredevops-webhook-user"
+	CommentModeFlag            = "comment-mode"
+	CommentTemplatesDirFlag    = "comment-templates-dir"
+	ConfigFlag                 = "config"
+	DataDirFlag                = "data-dir"
+	ForkPRPolicyFlag           = "fork-pr-policy"
+	GHAppIDFlag                = "gh-app-id"
+	GHAppKeyFileFlag           = "gh-app-key-file"
+	GHChecksFlag               = "gh-checks"
+	GHHostnameFlag             = "gh-hostname"
+	GHTokenFlag                = "gh-token"
+	GHUserFlag                 = "gh-user"
+	GHWebHookSecret            = "gh-webhook-secret"
+	GiteaBaseURLFlag           = "gitea-base-url"
+	GiteaTokenFlag             = "gitea-token"
+	GiteaUserFlag              = "gitea-user"
+	GiteaWebHookSecret         = "gitea-webhook-secret"
+	GitlabCAFileFlag           = "gitlab-ca-file"
+	GitlabHostnameFlag         = "gitlab-hostname"
+	GitlabTokenFlag            = "gitlab-token"
+	GitlabUserFlag             = "gitlab-user"
+	GitlabWebHookSecret        = "gitlab-webhook-secret"
+	HidePrevPlanCommentsFlag   = "hide-prev-plan-comments"
+	HookTimeoutFlag            = "hook-timeout"
+	InitTimeoutFlag            = "init-timeout"
+	LocalSecretsFileFlag       = "local-secrets-file"
+	LogLevelFlag               = "log-level"
+	MaxFinishedJobsFlag        = "max-finished-jobs"
+	PlanTimeoutFlag            = "plan-timeout"
+	PortFlag                   = "port"
+	RedactEnvVarsFlag          = "redact-env-vars"
+	RepoWhitelistFlag          = "repo-whitelist"
+	RequireApprovalFlag        = "require-approval"
+	TFDownloadURLFlag          = "tf-download-url"
+	VaultAddrFlag              = "vault-addr"
+	VaultTokenFlag             = "vault-token"
 )
 
+// GithubHostsKey is the config file key for additional GitHub hosts. There's
+// no flag for it since each host has multiple settings.
+const GithubHostsKey = "github-hosts"
+
+// RedactRegexesKey is the config file key for the regexes whose matches are
+// redacted from output. There's no flag for it since regexes can contain
+// commas.
+const RedactRegexesKey = "redact-regexes"
+
+// ProjectEnvKey is the config file key for the environment variables set
+// for projects' commands. There's no flag for it since each variable has
+// multiple settings.
+const ProjectEnvKey = "project-env"
+
+// ProjectSecretsKey is the config file key for the secrets projects can use
+// for their own environment variables. There's no flag for it since each
+// entry has multiple settings.
+const ProjectSecretsKey = "project-secrets"
+
 var stringFlags = []stringFlag{
 	{
 		name:        AtlantisURLFlag,
 		description: "URL that Atlantis can be reached at. Defaults to http://$(hostname):$port where $port is from --" + PortFlag + ".",
 	},
+	{
+		name:        AzureDevopsHostnameFlag,
+		description: "Hostname of your Azure DevOps installation, ex. devops.example.com. If using dev.azure.com, no need to set.",
+		value:       "dev.azure.com",
+	},
+	{
+		name:        AzureDevopsUserFlag,
+		description: "Azure DevOps username of API user.",
+	},
+	{
+		name:        AzureDevopsTokenFlag,
+		description: "Azure DevOps personal access token of API user. Can also be specified via the ATLANTIS_AZUREDEVOPS_TOKEN environment variable.",
+		env:         "ATLANTIS_AZUREDEVOPS_TOKEN",
+	},
+	{
+		name: AzureDevopsWebHookUser,
+		description: "Optional basic auth username used to validate Azure DevOps service hooks." +
+			" If not specified, Atlantis won't be able to validate that the incoming webhook call came from Azure DevOps.",
+	},
+	{
+		name: AzureDevopsWebHookPassword,
+		description: "Optional basic auth password used to validate Azure DevOps service hooks. Must be set with --" + AzureDevopsWebHookUser + "." +
+			" Can also be specified via the ATLANTIS_AZUREDEVOPS_WEBHOOK_PASSWORD environment variable.",
+		env: "ATLANTIS_AZUREDEVOPS_WEBHOOK_PASSWORD",
+	},
+	{
+		name: CommentModeFlag,
+		description: "How Atlantis comments back with the results of commands. Either new, command, or project." +
+			" 'new' creates a new comment every time. 'command' keeps one comment per command and environment and edits it on each run." +
+			" 'project' keeps one comment per project and environment and edits it on each run. Edited comments keep previous runs in a collapsed section.",
+		value: "new",
+	},
+	{
+		name: CommentTemplatesDirFlag,
+		description: "Directory of templates that override the markdown Atlantis comments with, ex. plan-success.tmpl." +
+			" See the README for the template names and their data. Templates that aren't in the directory use the defaults.",
+	},
 	{
 		name:        ConfigFlag,
 		description: "Path to config file.",
@@ -48,6 +135,17 @@ var stringFlags = []stringFlag{
 		description: "Path to directory to store Atlantis data.",
 		value:       "~/.atlantis",
 	},
+	{
+		name: ForkPRPolicyFlag,
+		description: "Whether commands can be run on pull requests from forked repositories. Either allow, deny, or maintainers." +
+			" Atlantis runs the fork's code, including its atlantis.yaml hooks, with its own credentials so 'deny' is recommended for public repos." +
+			" 'maintainers' only allows users with write access to the base repo to run commands on pull requests from forks.",
+		value: "allow",
+	},
+	{
+		name:        GHAppKeyFileFlag,
+		description: "Path to the private key of the GitHub App set by --" + GHAppIDFlag + ".",
+	},
 	{
 		name:        GHHostnameFlag,
 		description: "Hostname of your Github Enterprise installation. If using github.com, no need to set.",
@@ -55,7 +153,7 @@ var stringFlags = []stringFlag{
 	},
 	{
 		name:        GHUserFlag,
-		description: "GitHub username of API user.",
+		description: "GitHub username of API user. If using a GitHub App, optionally set to the app's bot name so it can be @mentioned.",
 	},
 	{
 		name:        GHTokenFlag,
@@ -69,6 +167,31 @@ var stringFlags = []stringFlag{
 			"Can also be specified via the ATLANTIS_GH_WEBHOOK_SECRET environment variable.",
 		env: "ATLANTIS_GH_WEBHOOK_SECRET",
 	},
+	{
+		name:        GiteaBaseURLFlag,
+		description: "Base URL of your Gitea or Forgejo installation, ex. https://gitea.example.com. If using gitea.com, no need to set.",
+		value:       "https://gitea.com",
+	},
+	{
+		name:        GiteaUserFlag,
+		description: "Gitea username of API user.",
+	},
+	{
+		name:        GiteaTokenFlag,
+		description: "Gitea token of API user. Can also be specified via the ATLANTIS_GITEA_TOKEN environment variable.",
+		env:         "ATLANTIS_GITEA_TOKEN",
+	},
+	{
+		name: GiteaWebHookSecret,
+		description: "Optional secret used to validate Gitea webhooks." +
+			" If not specified, Atlantis won't be able to validate that the incoming webhook call came from Gitea. " +
+			"Can also be specified via the ATLANTIS_GITEA_WEBHOOK_SECRET environment variable.",
+		env: "ATLANTIS_GITEA_WEBHOOK_SECRET",
+	},
+	{
+		name:        GitlabCAFileFlag,
+		description: "Path to a PEM encoded CA bundle to trust when connecting to your GitLab installation, ex. if it uses certificates signed by an internal CA.",
+	},
 	{
 		name:        GitlabHostnameFlag,
 		description: "Hostname of your GitLab Enterprise installation. If using gitlab.com, no need to set.",
@@ -90,13 +213,65 @@ var stringFlags = []stringFlag{
 			"Can also be specified via the ATLANTIS_GITLAB_WEBHOOK_SECRET environment variable.",
 		env: "ATLANTIS_GITLAB_WEBHOOK_SECRET",
 	},
+	{
+		name: LocalSecretsFileFlag,
+		description: "Path to a YAML file of secrets, ex. '{secret/data/atlantis: {db_password: hunter2}}', to read the secrets that " + ProjectEnvKey +
+			" and projects' env set from instead of Vault. Meant for development and testing.",
+	},
 	{
 		name:        LogLevelFlag,
 		description: "Log level. Either debug, info, warn, or error.",
 		value:       "info",
 	},
+	{
+		name: RedactEnvVarsFlag,
+		description: "Comma separated list of environment variables whose values are redacted from the output Atlantis posts, ex. 'AWS_SECRET_ACCESS_KEY,DB_PASSWORD'." +
+			" The tokens and secrets Atlantis is configured with are always redacted.",
+	},
+	{
+		name: RepoWhitelistFlag,
+		description: "Comma separated list of repositories that Atlantis will run commands for. " +
+			"Each repo is specified as {hostname}/{owner}/{repo} and * can be used as a wildcard, ex. 'github.com/hootsuite/*'. " +
+			"Events from all other repos are ignored. Defaults to allowing all repos.",
+		value: "*",
+	},
+	{
+		name: TFDownloadURLFlag,
+		description: "Base URL to download the versions of Terraform that projects need from if they aren't in $PATH as terraform{version}." +
+			" Must have the same layout as releases.hashicorp.com, ex. a local mirror.",
+		value: "https://releases.hashicorp.com",
+	},
+	{
+		name: VaultAddrFlag,
+		description: "URL of a Vault server, or one with a compatible API, to read the secrets that " + ProjectEnvKey +
+			" and projects' env set from, ex. https://vault.example.com:8200.",
+	},
+	{
+		name:        VaultTokenFlag,
+		description: "Token to authenticate with --" + VaultAddrFlag + ". Can also be specified via the ATLANTIS_VAULT_TOKEN environment variable.",
+		env:         "ATLANTIS_VAULT_TOKEN",
+	},
 }
 var boolFlags = []boolFlag{
+	{
+		name: AllowUICancelFlag,
+		description: "Allow running commands to be canceled from their page in the Atlantis UI." +
+			" The UI has no authentication so only set this if it's only reachable by people who should be able to cancel commands.",
+		value: false,
+	},
+	{
+		name: GHChecksFlag,
+		description: "Report plan and apply results on GitHub as a check run per project instead of a single commit status." +
+			" Requires --" + GHAppIDFlag + " since only GitHub Apps can create check runs, and a GitHub version that supports the Checks API.",
+		value: false,
+	},
+	{
+		name: HidePrevPlanCommentsFlag,
+		description: "Hide previous plan comments on a pull request when a new plan is run in the same environment." +
+			" On GitHub they're minimized as outdated. Other hosts can't hide comments so they're edited to show they're outdated." +
+			" Ignored unless --" + CommentModeFlag + " is new since the other modes update their comments in place.",
+		value: false,
+	},
 	{
 		name:        RequireApprovalFlag,
 		description: "Require pull requests to be \"Approved\" before allowing the apply command to be run.",
@@ -104,12 +279,41 @@ var boolFlags = []boolFlag{
 	},
 }
 var intFlags = []intFlag{
+	{
+		name: GHAppIDFlag,
+		description: "ID of a GitHub App to authenticate as instead of a user. The app must be installed on exactly one account." +
+			" Requires --" + GHAppKeyFileFlag + ".",
+	},
+	{
+		name:        MaxFinishedJobsFlag,
+		description: "Number of finished commands whose output is kept for the jobs page. Output is kept in memory so it's lost when Atlantis restarts.",
+		value:       100,
+	},
 	{
 		name:        PortFlag,
 		description: "Port to bind to.",
 		value:       4141,
 	},
 }
+var durationFlags = []durationFlag{
+	{
+		name: ApplyTimeoutFlag,
+		description: "Maximum time terraform apply can run for before it's stopped, ex. 2h. Defaults to no limit." +
+			" Stopping an apply can leave its changes partially made so set this generously.",
+	},
+	{
+		name:        HookTimeoutFlag,
+		description: "Maximum time the commands for each stage in a project's atlantis.yaml, ex. pre_plan, can run for before they're stopped, ex. 10m. Defaults to no limit.",
+	},
+	{
+		name:        InitTimeoutFlag,
+		description: "Maximum time terraform init and selecting the environment can run for before they're stopped, ex. 10m. Defaults to no limit.",
+	},
+	{
+		name:        PlanTimeoutFlag,
+		description: "Maximum time terraform plan can run for before it's stopped, ex. 30m. Defaults to no limit.",
+	},
+}
 
 type stringFlag struct {
 	name        string
@@ -122,6 +326,11 @@ type intFlag struct {
 	description string
 	value       int
 }
+type durationFlag struct {
+	name        string
+	description string
+	value       time.Duration
+}
 type boolFlag struct {
 	name        string
 	description string
@@ -169,10 +378,10 @@ Flags can also be set in a yaml config file (see --` + ConfigFlag + `).
 Config file values are overridden by environment variables which in turn are overridden by flags.`,
 		SilenceErrors: true,
 		SilenceUsage:  s.SilenceOutput,
-		PreRunE: s.withErrPrint(func(cmd *cobra.Command, args []string) error {
+		PreRunE: withErrPrint(s.SilenceOutput, func(cmd *cobra.Command, args []string) error {
 			return s.preRun()
 		}),
-		RunE: s.withErrPrint(func(cmd *cobra.Command, args []string) error {
+		RunE: withErrPrint(s.SilenceOutput, func(cmd *cobra.Command, args []string) error {
 			return s.run()
 		}),
 	}
@@ -198,6 +407,12 @@ Config file values are overridden by environment variables which in turn are ove
 		s.Viper.BindPFlag(f.name, c.Flags().Lookup(f.name)) // nolint: errcheck
 	}
 
+	// Set duration flags.
+	for _, f := range durationFlags {
+		c.Flags().Duration(f.name, f.value, f.description)
+		s.Viper.BindPFlag(f.name, c.Flags().Lookup(f.name)) // nolint: errcheck
+	}
+
 	// Set bool flags.
 	for _, f := range boolFlags {
 		c.Flags().Bool(f.name, f.value, f.description)
@@ -248,27 +463,122 @@ func validate(config server.Config) error {
 	if logLevel != "debug" && logLevel != "info" && logLevel != "warn" && logLevel != "error" {
 		return errors.New("invalid log level: not one of debug, info, warn, error")
 	}
-	vcsErr := fmt.Errorf("--%s/--%s or --%s/--%s must be set", GHUserFlag, GHTokenFlag, GitlabUserFlag, GitlabTokenFlag)
+	forkPRPolicy := config.ForkPRPolicy
+	if forkPRPolicy != "allow" && forkPRPolicy != "deny" && forkPRPolicy != "maintainers" {
+		return fmt.Errorf("invalid --%s: not one of allow, deny, maintainers", ForkPRPolicyFlag)
+	}
+	for name, timeout := range map[string]time.Duration{
+		ApplyTimeoutFlag: config.ApplyTimeout,
+		HookTimeoutFlag:  config.HookTimeout,
+		InitTimeoutFlag:  config.InitTimeout,
+		PlanTimeoutFlag:  config.PlanTimeout,
+	} {
+		if timeout < 0 {
+			return fmt.Errorf("invalid --%s: can't be negative", name)
+		}
+	}
+	if config.MaxFinishedJobs < 0 {
+		return fmt.Errorf("invalid --%s: can't be negative", MaxFinishedJobsFlag)
+	}
+	commentMode := config.CommentMode
+	if commentMode != "new" && commentMode != "command" && commentMode != "project" {
+		return fmt.Errorf("invalid --%s: not one of new, command, project", CommentModeFlag)
+	}
+	vcsErr := fmt.Errorf("--%s/--%s, --%s/--%s, --%s/--%s, --%s/--%s or --%s/--%s must be set", GHUserFlag, GHTokenFlag, GHAppIDFlag, GHAppKeyFileFlag, GitlabUserFlag, GitlabTokenFlag, GiteaUserFlag, GiteaTokenFlag, AzureDevopsUserFlag, AzureDevopsTokenFlag)
 
 	// The following combinations are valid.
 	// 1. github user and token
-	// 2. gitlab user and token
-	// 3. all 4 set
+	// 2. github app id and key file (and optionally github user)
+	// 3. gitlab user and token
+	// 4. gitea user and token
+	// 5. azure devops user and token
+	// 6. any combination of the above
 	// We validate using contradiction (I think).
-	if config.GithubUser != "" && config.GithubToken == "" || config.GithubToken != "" && config.GithubUser == "" {
+	githubApp := config.GithubAppID != 0
+	if githubApp != (config.GithubAppKeyFile != "") {
+		return vcsErr
+	}
+	// Hosts from github-hosts are checked in validateGithubHosts.
+	primaryGithubToken := config.GithubUser != "" && !githubApp
+	if config.GithubChecks && (primaryGithubToken || !githubApp && len(config.GithubHosts) == 0) {
+		return fmt.Errorf("--%s requires --%s", GHChecksFlag, GHAppIDFlag)
+	}
+	if githubApp && config.GithubToken != "" {
+		return fmt.Errorf("--%s and --%s cannot both be set", GHTokenFlag, GHAppIDFlag)
+	}
+	if !githubApp && (config.GithubUser != "" && config.GithubToken == "" || config.GithubToken != "" && config.GithubUser == "") {
 		return vcsErr
 	}
 	if config.GitlabUser != "" && config.GitlabToken == "" || config.GitlabToken != "" && config.GitlabUser == "" {
 		return vcsErr
 	}
+	if config.GiteaUser != "" && config.GiteaToken == "" || config.GiteaToken != "" && config.GiteaUser == "" {
+		return vcsErr
+	}
+	if config.AzureDevopsUser != "" && config.AzureDevopsToken == "" || config.AzureDevopsToken != "" && config.AzureDevopsUser == "" {
+		return vcsErr
+	}
+	if (config.AzureDevopsWebHookUser == "") != (config.AzureDevopsWebHookPassword == "") {
+		return fmt.Errorf("--%s and --%s must both be set", AzureDevopsWebHookUser, AzureDevopsWebHookPassword)
+	}
+	for _, r := range config.RedactRegexes {
+		if _, err := regexp.Compile(r); err != nil {
+			return fmt.Errorf("invalid %s: %s", RedactRegexesKey, err)
+		}
+	}
+	if config.VaultAddr != "" && config.LocalSecretsFile != "" {
+		return fmt.Errorf("--%s and --%s cannot both be set", VaultAddrFlag, LocalSecretsFileFlag)
+	}
+	if (config.VaultAddr == "") != (config.VaultToken == "") {
+		return fmt.Errorf("--%s and --%s must both be set", VaultAddrFlag, VaultTokenFlag)
+	}
+	for _, e := range config.ProjectEnv {
+		if err := e.EnvVar().Validate(); err != nil {
+			return fmt.Errorf("invalid %s: %s", ProjectEnvKey, err)
+		}
+	}
+	for _, s := range config.ProjectSecrets {
+		if s.Path == "" {
+			return fmt.Errorf("invalid %s: path must be set", ProjectSecretsKey)
+		}
+	}
+	if err := validateGithubHosts(config); err != nil {
+		return err
+	}
 	// At this point, we know that there can't be a single user/token without
 	// its pair, but we haven't checked if any user/token is set at all.
-	if config.GithubUser == "" && config.GitlabUser == "" {
+	if config.GithubUser == "" && !githubApp && config.GitlabUser == "" && config.GiteaUser == "" && config.AzureDevopsUser == "" && len(config.GithubHosts) == 0 {
 		return vcsErr
 	}
 	return nil
 }
 
+// validateGithubHosts validates the additional GitHub hosts set with the
+// github-hosts config file key.
+func validateGithubHosts(config server.Config) error {
+	hostnames := make(map[string]bool)
+	if config.GithubUser != "" || config.GithubAppID != 0 {
+		hostnames[config.GithubHostname] = true
+	}
+	for _, host := range config.GithubHosts {
+		if host.Hostname == "" {
+			return fmt.Errorf("invalid %s: hostname must be set", GithubHostsKey)
+		}
+		if hostnames[host.Hostname] {
+			return fmt.Errorf("invalid %s: host %s is configured more than once", GithubHostsKey, host.Hostname)
+		}
+		hostnames[host.Hostname] = true
+		hostApp := host.AppID != 0
+		if hostApp != (host.AppKeyFile != "") || hostApp && host.Token != "" || !hostApp && (host.User == "" || host.Token == "") {
+			return fmt.Errorf("invalid %s: host %s must set either user and token or app-id and app-key-file", GithubHostsKey, host.Hostname)
+		}
+		if config.GithubChecks && !hostApp {
+			return fmt.Errorf("--%s requires every GitHub host to use a GitHub App but host %s uses a token", GHChecksFlag, host.Hostname)
+		}
+	}
+	return nil
+}
+
 // setAtlantisURL sets the externally accessible URL for atlantis.
 func setAtlantisURL(config *server.Config) error {
 	if config.AtlantisURL == "" {
@@ -299,15 +609,5 @@ func setDataDir(config *server.Config) error {
 func trimAtSymbolFromUsers(config *server.Config) {
 	config.GithubUser = strings.TrimPrefix(config.GithubUser, "@")
 	config.GitlabUser = strings.TrimPrefix(config.GitlabUser, "@")
-}
-
-// withErrPrint prints out any errors to a terminal in red.
-func (s *ServerCmd) withErrPrint(f func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
-	return func(cmd *cobra.Command, args []string) error {
-		err := f(cmd, args)
-		if err != nil && !s.SilenceOutput {
-			fmt.Fprintf(os.Stderr, "\033[31mError: %s\033[39m\n\n", err.Error())
-		}
-		return err
-	}
+	config.GiteaUser = strings.TrimPrefix(config.GiteaUser, "@")
 }
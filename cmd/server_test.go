@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hootsuite/atlantis/cmd"
 	"github.com/hootsuite/atlantis/server"
@@ -102,8 +103,305 @@ func TestExecute_ValidateLogLevel(t *testing.T) {
 	Equals(t, "invalid log level: not one of debug, info, warn, error", err.Error())
 }
 
+func TestExecute_ValidateCommentMode(t *testing.T) {
+	t.Log("Should validate comment mode.")
+	c := setup(map[string]interface{}{
+		cmd.CommentModeFlag: "invalid",
+		cmd.GHUserFlag:      "user",
+		cmd.GHTokenFlag:     "token",
+	})
+	err := c.Execute()
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "invalid --comment-mode: not one of new, command, project", err.Error())
+}
+
+func TestExecute_ValidateTimeouts(t *testing.T) {
+	t.Log("Should validate that timeouts aren't negative.")
+	c := setup(map[string]interface{}{
+		cmd.PlanTimeoutFlag: "-1m",
+		cmd.GHUserFlag:      "user",
+		cmd.GHTokenFlag:     "token",
+	})
+	err := c.Execute()
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "invalid --plan-timeout: can't be negative", err.Error())
+}
+
+func TestExecute_ValidateMaxFinishedJobs(t *testing.T) {
+	t.Log("Should validate that the number of finished jobs isn't negative.")
+	c := setup(map[string]interface{}{
+		cmd.MaxFinishedJobsFlag: -1,
+		cmd.GHUserFlag:          "user",
+		cmd.GHTokenFlag:         "token",
+	})
+	err := c.Execute()
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "invalid --max-finished-jobs: can't be negative", err.Error())
+}
+
+func TestExecute_ValidateForkPRPolicy(t *testing.T) {
+	t.Log("Should validate fork pr policy.")
+	c := setup(map[string]interface{}{
+		cmd.ForkPRPolicyFlag: "invalid",
+		cmd.GHUserFlag:       "user",
+		cmd.GHTokenFlag:      "token",
+	})
+	err := c.Execute()
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "invalid --fork-pr-policy: not one of allow, deny, maintainers", err.Error())
+}
+
+func TestExecute_GithubApp(t *testing.T) {
+	t.Log("Should use the GitHub App flags that are set.")
+	c := setup(map[string]interface{}{
+		cmd.GHAppIDFlag:      1234,
+		cmd.GHAppKeyFileFlag: "key.pem",
+		cmd.GHChecksFlag:     true,
+	})
+	err := c.Execute()
+	Ok(t, err)
+	Equals(t, int64(1234), passedConfig.GithubAppID)
+	Equals(t, "key.pem", passedConfig.GithubAppKeyFile)
+	Equals(t, true, passedConfig.GithubChecks)
+}
+
+func TestExecute_GithubChecksWithoutApp(t *testing.T) {
+	t.Log("Should error if GitHub checks are enabled without a GitHub App.")
+	c := setup(map[string]interface{}{
+		cmd.GHUserFlag:   "user",
+		cmd.GHTokenFlag:  "token",
+		cmd.GHChecksFlag: true,
+	})
+	err := c.Execute()
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "--gh-checks requires --gh-app-id", err.Error())
+}
+
+func TestExecute_GithubAppAndToken(t *testing.T) {
+	t.Log("Should error if both a GitHub token and a GitHub App are set.")
+	c := setup(map[string]interface{}{
+		cmd.GHAppIDFlag:      1,
+		cmd.GHAppKeyFileFlag: "key.pem",
+		cmd.GHUserFlag:       "user",
+		cmd.GHTokenFlag:      "token",
+	})
+	err := c.Execute()
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "--gh-token and --gh-app-id cannot both be set", err.Error())
+}
+
+func TestExecute_GithubHosts(t *testing.T) {
+	t.Log("Should parse additional GitHub hosts from the config file.")
+	tmpFile := tempFile(t, `---
+gh-user: "user"
+gh-token: "token"
+github-hosts:
+- hostname: "ghe1.example.com"
+  user: "ghe-user"
+  token: "ghe-token"
+  webhook-secret: "ghe-secret"
+- hostname: "ghe2.example.com"
+  app-id: 1234
+  app-key-file: "key.pem"`)
+	defer os.Remove(tmpFile) // nolint: errcheck
+	c := setup(map[string]interface{}{
+		cmd.ConfigFlag: tmpFile,
+	})
+	err := c.Execute()
+	Ok(t, err)
+	Equals(t, []server.GithubHostConfig{
+		{
+			Hostname:      "ghe1.example.com",
+			User:          "ghe-user",
+			Token:         "ghe-token",
+			WebHookSecret: "ghe-secret",
+		},
+		{
+			Hostname:   "ghe2.example.com",
+			AppID:      1234,
+			AppKeyFile: "key.pem",
+		},
+	}, passedConfig.GithubHosts)
+}
+
+func TestExecute_RedactRegexes(t *testing.T) {
+	t.Log("Should parse the redact regexes from the config file.")
+	tmpFile := tempFile(t, `---
+gh-user: "user"
+gh-token: "token"
+redact-regexes:
+- "AKIA[0-9A-Z]{16}"
+- "password=(\\S+)"`)
+	defer os.Remove(tmpFile) // nolint: errcheck
+	c := setup(map[string]interface{}{
+		cmd.ConfigFlag: tmpFile,
+	})
+	err := c.Execute()
+	Ok(t, err)
+	Equals(t, []string{"AKIA[0-9A-Z]{16}", `password=(\S+)`}, passedConfig.RedactRegexes)
+}
+
+func TestExecute_ValidateRedactRegexes(t *testing.T) {
+	t.Log("Should validate the redact regexes.")
+	tmpFile := tempFile(t, `---
+gh-user: "user"
+gh-token: "token"
+redact-regexes: ["("]`)
+	defer os.Remove(tmpFile) // nolint: errcheck
+	c := setup(map[string]interface{}{
+		cmd.ConfigFlag: tmpFile,
+	})
+	err := c.Execute()
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "invalid redact-regexes: error parsing regexp: missing closing ): `(`", err.Error())
+}
+
+func TestExecute_ProjectEnv(t *testing.T) {
+	t.Log("Should parse the project environment variables from the config file.")
+	tmpFile := tempFile(t, `---
+gh-user: "user"
+gh-token: "token"
+local-secrets-file: "secrets.yaml"
+project-env:
+- {name: AWS_REGION, value: us-east-1}
+- {repo: "github.com/owner/*", path: infra, workspaces: [production], name: DB_PASSWORD, secret: "secret/data/db#password"}
+project-secrets:
+- {repo: github.com/owner/infra, path: "secret/data/infra/*"}`)
+	defer os.Remove(tmpFile) // nolint: errcheck
+	c := setup(map[string]interface{}{
+		cmd.ConfigFlag: tmpFile,
+	})
+	err := c.Execute()
+	Ok(t, err)
+	Equals(t, "secrets.yaml", passedConfig.LocalSecretsFile)
+	Equals(t, []server.ProjectEnvConfig{
+		{Name: "AWS_REGION", Value: "us-east-1"},
+		{Repo: "github.com/owner/*", Path: "infra", Workspaces: []string{"production"}, Name: "DB_PASSWORD", Secret: "secret/data/db#password"},
+	}, passedConfig.ProjectEnv)
+	Equals(t, []server.ProjectSecretsConfig{{Repo: "github.com/owner/infra", Path: "secret/data/infra/*"}}, passedConfig.ProjectSecrets)
+}
+
+func TestExecute_ValidateProjectEnv(t *testing.T) {
+	cases := []struct {
+		description string
+		config      string
+		expErr      string
+	}{
+		{
+			"a variable's name is invalid",
+			"project-env:\n- {name: 1A, value: a}",
+			`invalid project-env: "1A" isn't a valid environment variable name`,
+		},
+		{
+			"a variable sets a value and a file",
+			"project-env:\n- {name: A, value: a, file: a.txt}",
+			"invalid project-env: A must set only one of value, file or secret",
+		},
+		{
+			"a variable's secret has no key",
+			"project-env:\n- {name: A, secret: secret/data/a}",
+			`invalid project-env: A: secret "secret/data/a" must be {path}#{key}`,
+		},
+		{
+			"both vault and a local secrets file are set",
+			"vault-addr: https://vault.example.com\nvault-token: token\nlocal-secrets-file: secrets.yaml",
+			"--vault-addr and --local-secrets-file cannot both be set",
+		},
+		{
+			"vault is set without a token",
+			"vault-addr: https://vault.example.com",
+			"--vault-addr and --vault-token must both be set",
+		},
+		{
+			"allowed project secrets have no path",
+			"project-secrets:\n- {repo: github.com/owner/repo}",
+			"invalid project-secrets: path must be set",
+		},
+	}
+	for _, c := range cases {
+		t.Log("When " + c.description)
+		tmpFile := tempFile(t, "gh-user: user\ngh-token: token\n"+c.config)
+		cmd := setup(map[string]interface{}{
+			cmd.ConfigFlag: tmpFile,
+		})
+		err := cmd.Execute()
+		os.Remove(tmpFile) // nolint: errcheck
+		Assert(t, err != nil, "should be an error")
+		Equals(t, c.expErr, err.Error())
+	}
+}
+
+func TestExecute_ValidateGithubHosts(t *testing.T) {
+	cases := []struct {
+		description string
+		config      string
+		expErr      string
+	}{
+		{
+			"only github-hosts is set",
+			"github-hosts:\n- {hostname: ghe.example.com, user: user, token: token}",
+			"",
+		},
+		{
+			"a host is missing its hostname",
+			"github-hosts:\n- {user: user, token: token}",
+			"invalid github-hosts: hostname must be set",
+		},
+		{
+			"a host is missing its token",
+			"github-hosts:\n- {hostname: ghe.example.com, user: user}",
+			"invalid github-hosts: host ghe.example.com must set either user and token or app-id and app-key-file",
+		},
+		{
+			"a host is missing its app key file",
+			"github-hosts:\n- {hostname: ghe.example.com, app-id: 1}",
+			"invalid github-hosts: host ghe.example.com must set either user and token or app-id and app-key-file",
+		},
+		{
+			"a host sets both a token and an app",
+			"github-hosts:\n- {hostname: ghe.example.com, token: token, app-id: 1, app-key-file: key.pem}",
+			"invalid github-hosts: host ghe.example.com must set either user and token or app-id and app-key-file",
+		},
+		{
+			"a host is the same as --gh-hostname",
+			"gh-user: user\ngh-token: token\ngithub-hosts:\n- {hostname: github.com, user: user, token: token}",
+			"invalid github-hosts: host github.com is configured more than once",
+		},
+		{
+			"a host is configured twice",
+			"github-hosts:\n- {hostname: ghe.example.com, user: user, token: token}\n- {hostname: ghe.example.com, user: user, token: token}",
+			"invalid github-hosts: host ghe.example.com is configured more than once",
+		},
+		{
+			"checks are enabled and every host uses an app",
+			"gh-checks: true\ngithub-hosts:\n- {hostname: ghe.example.com, app-id: 1, app-key-file: key.pem}",
+			"",
+		},
+		{
+			"checks are enabled and a host uses a token",
+			"gh-checks: true\ngh-app-id: 1\ngh-app-key-file: key.pem\ngithub-hosts:\n- {hostname: ghe.example.com, user: user, token: token}",
+			"--gh-checks requires every GitHub host to use a GitHub App but host ghe.example.com uses a token",
+		},
+	}
+	for _, c := range cases {
+		t.Log("When " + c.description)
+		tmpFile := tempFile(t, c.config)
+		cmd := setup(map[string]interface{}{
+			cmd.ConfigFlag: tmpFile,
+		})
+		err := cmd.Execute()
+		os.Remove(tmpFile) // nolint: errcheck
+		if c.expErr == "" {
+			Ok(t, err)
+		} else {
+			Assert(t, err != nil, "should be an error")
+			Equals(t, c.expErr, err.Error())
+		}
+	}
+}
+
 func TestExecute_ValidateVCSConfig(t *testing.T) {
-	expErr := "--gh-user/--gh-token or --gitlab-user/--gitlab-token must be set"
+	expErr := "--gh-user/--gh-token, --gh-app-id/--gh-app-key-file, --gitlab-user/--gitlab-token, --gitea-user/--gitea-token or --azuredevops-user/--azuredevops-token must be set"
 	cases := []struct {
 		description string
 		flags       map[string]interface{}
@@ -158,6 +456,37 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"just github app id set",
+			map[string]interface{}{
+				cmd.GHAppIDFlag: 1,
+			},
+			true,
+		},
+		{
+			"just github app key file set",
+			map[string]interface{}{
+				cmd.GHAppKeyFileFlag: "key.pem",
+			},
+			true,
+		},
+		{
+			"github app id and key file set and should be successful",
+			map[string]interface{}{
+				cmd.GHAppIDFlag:      1,
+				cmd.GHAppKeyFileFlag: "key.pem",
+			},
+			false,
+		},
+		{
+			"github app and github user set and should be successful",
+			map[string]interface{}{
+				cmd.GHAppIDFlag:      1,
+				cmd.GHAppKeyFileFlag: "key.pem",
+				cmd.GHUserFlag:       "user",
+			},
+			false,
+		},
 		{
 			"github user and github token set and should be successful",
 			map[string]interface{}{
@@ -174,6 +503,50 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"just gitea user set",
+			map[string]interface{}{
+				cmd.GiteaUserFlag: "user",
+			},
+			true,
+		},
+		{
+			"just gitea token set",
+			map[string]interface{}{
+				cmd.GiteaTokenFlag: "token",
+			},
+			true,
+		},
+		{
+			"gitea user and gitea token set and should be successful",
+			map[string]interface{}{
+				cmd.GiteaUserFlag:  "user",
+				cmd.GiteaTokenFlag: "token",
+			},
+			false,
+		},
+		{
+			"just azure devops user set",
+			map[string]interface{}{
+				cmd.AzureDevopsUserFlag: "user",
+			},
+			true,
+		},
+		{
+			"just azure devops token set",
+			map[string]interface{}{
+				cmd.AzureDevopsTokenFlag: "token",
+			},
+			true,
+		},
+		{
+			"azure devops user and azure devops token set and should be successful",
+			map[string]interface{}{
+				cmd.AzureDevopsUserFlag:  "user",
+				cmd.AzureDevopsTokenFlag: "token",
+			},
+			false,
+		},
 		{
 			"github and gitlab user and github and gitlab token set and should be successful",
 			map[string]interface{}{
@@ -198,6 +571,20 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 	}
 }
 
+func TestExecute_ValidateAzureDevopsWebHookCredentials(t *testing.T) {
+	for _, flag := range []string{cmd.AzureDevopsWebHookUser, cmd.AzureDevopsWebHookPassword} {
+		t.Log("Should error if only --" + flag + " is set")
+		c := setup(map[string]interface{}{
+			cmd.AzureDevopsUserFlag:  "user",
+			cmd.AzureDevopsTokenFlag: "token",
+			flag:                     "value",
+		})
+		err := c.Execute()
+		Assert(t, err != nil, "should be an error")
+		Equals(t, "--azuredevops-webhook-user and --azuredevops-webhook-password must both be set", err.Error())
+	}
+}
+
 func TestExecute_Defaults(t *testing.T) {
 	t.Log("Should set the defaults for all unspecified flags.")
 	c := setup(map[string]interface{}{
@@ -225,10 +612,32 @@ func TestExecute_Defaults(t *testing.T) {
 	Ok(t, err)
 	Equals(t, dataDir, passedConfig.DataDir)
 	Equals(t, "github.com", passedConfig.GithubHostname)
+	Equals(t, int64(0), passedConfig.GithubAppID)
+	Equals(t, "", passedConfig.GithubAppKeyFile)
+	Equals(t, false, passedConfig.GithubChecks)
 	Equals(t, "gitlab.com", passedConfig.GitlabHostname)
+	Equals(t, "", passedConfig.GitlabCAFile)
+	Equals(t, "https://gitea.com", passedConfig.GiteaBaseURL)
+	Equals(t, "", passedConfig.GiteaWebHookSecret)
+	Equals(t, "dev.azure.com", passedConfig.AzureDevopsHostname)
+	Equals(t, "", passedConfig.AzureDevopsWebHookUser)
+	Equals(t, "", passedConfig.AzureDevopsWebHookPassword)
 	Equals(t, "info", passedConfig.LogLevel)
 	Equals(t, false, passedConfig.RequireApproval)
 	Equals(t, 4141, passedConfig.Port)
+	Equals(t, 100, passedConfig.MaxFinishedJobs)
+	Equals(t, false, passedConfig.AllowUICancel)
+	Equals(t, "*", passedConfig.RepoWhitelist)
+	Equals(t, "allow", passedConfig.ForkPRPolicy)
+	Equals(t, "new", passedConfig.CommentMode)
+	Equals(t, "", passedConfig.CommentTemplatesDir)
+	Equals(t, "", passedConfig.RedactEnvVars)
+	Equals(t, false, passedConfig.HidePrevPlanComments)
+	Equals(t, time.Duration(0), passedConfig.InitTimeout)
+	Equals(t, time.Duration(0), passedConfig.PlanTimeout)
+	Equals(t, time.Duration(0), passedConfig.ApplyTimeout)
+	Equals(t, time.Duration(0), passedConfig.HookTimeout)
+	Equals(t, "https://releases.hashicorp.com", passedConfig.TFDownloadURL)
 }
 
 func TestExecute_ExpandHomeDir(t *testing.T) {
@@ -273,54 +682,127 @@ func TestExecute_GitlabUser(t *testing.T) {
 func TestExecute_Flags(t *testing.T) {
 	t.Log("Should use all flags that are set.")
 	c := setup(map[string]interface{}{
-		cmd.AtlantisURLFlag:     "url",
-		cmd.DataDirFlag:         "path",
-		cmd.GHHostnameFlag:      "ghhostname",
-		cmd.GHUserFlag:          "user",
-		cmd.GHTokenFlag:         "token",
-		cmd.GHWebHookSecret:     "secret",
-		cmd.GitlabHostnameFlag:  "gitlab-hostname",
-		cmd.GitlabUserFlag:      "gitlab-user",
-		cmd.GitlabTokenFlag:     "gitlab-token",
-		cmd.GitlabWebHookSecret: "gitlab-secret",
-		cmd.LogLevelFlag:        "debug",
-		cmd.PortFlag:            8181,
-		cmd.RequireApprovalFlag: true,
+		cmd.AllowUICancelFlag:          true,
+		cmd.ApplyTimeoutFlag:           "2h",
+		cmd.AtlantisURLFlag:            "url",
+		cmd.AzureDevopsHostnameFlag:    "devops.example.com",
+		cmd.AzureDevopsUserFlag:        "azuredevops-user",
+		cmd.AzureDevopsTokenFlag:       "azuredevops-token",
+		cmd.AzureDevopsWebHookUser:     "webhook-user",
+		cmd.AzureDevopsWebHookPassword: "webhook-password",
+		cmd.DataDirFlag:                "path",
+		cmd.ForkPRPolicyFlag:           "deny",
+		cmd.CommentModeFlag:            "project",
+		cmd.CommentTemplatesDirFlag:    "templates",
+		cmd.GHHostnameFlag:             "ghhostname",
+		cmd.GHUserFlag:                 "user",
+		cmd.GHTokenFlag:                "token",
+		cmd.GHWebHookSecret:            "secret",
+		cmd.GiteaBaseURLFlag:           "http://localhost:3000",
+		cmd.GiteaUserFlag:              "gitea-user",
+		cmd.GiteaTokenFlag:             "gitea-token",
+		cmd.GiteaWebHookSecret:         "gitea-secret",
+		cmd.GitlabCAFileFlag:           "ca.pem",
+		cmd.GitlabHostnameFlag:         "gitlab-hostname",
+		cmd.GitlabUserFlag:             "gitlab-user",
+		cmd.GitlabTokenFlag:            "gitlab-token",
+		cmd.GitlabWebHookSecret:        "gitlab-secret",
+		cmd.HidePrevPlanCommentsFlag:   true,
+		cmd.HookTimeoutFlag:            "5m",
+		cmd.InitTimeoutFlag:            "10m",
+		cmd.LogLevelFlag:               "debug",
+		cmd.MaxFinishedJobsFlag:        10,
+		cmd.PlanTimeoutFlag:            "30m",
+		cmd.PortFlag:                   8181,
+		cmd.RedactEnvVarsFlag:          "SECRET",
+		cmd.RepoWhitelistFlag:          "github.com/owner/*",
+		cmd.RequireApprovalFlag:        true,
+		cmd.TFDownloadURLFlag:          "https://mirror.example.com",
+		cmd.VaultAddrFlag:              "https://vault.example.com",
+		cmd.VaultTokenFlag:             "vault-token",
 	})
 	err := c.Execute()
 	Ok(t, err)
 
 	Equals(t, "url", passedConfig.AtlantisURL)
+	Equals(t, "devops.example.com", passedConfig.AzureDevopsHostname)
+	Equals(t, "azuredevops-user", passedConfig.AzureDevopsUser)
+	Equals(t, "azuredevops-token", passedConfig.AzureDevopsToken)
+	Equals(t, "webhook-user", passedConfig.AzureDevopsWebHookUser)
+	Equals(t, "webhook-password", passedConfig.AzureDevopsWebHookPassword)
 	Equals(t, "path", passedConfig.DataDir)
+	Equals(t, "deny", passedConfig.ForkPRPolicy)
+	Equals(t, "project", passedConfig.CommentMode)
+	Equals(t, "templates", passedConfig.CommentTemplatesDir)
 	Equals(t, "ghhostname", passedConfig.GithubHostname)
 	Equals(t, "user", passedConfig.GithubUser)
 	Equals(t, "token", passedConfig.GithubToken)
 	Equals(t, "secret", passedConfig.GithubWebHookSecret)
+	Equals(t, "http://localhost:3000", passedConfig.GiteaBaseURL)
+	Equals(t, "gitea-user", passedConfig.GiteaUser)
+	Equals(t, "gitea-token", passedConfig.GiteaToken)
+	Equals(t, "gitea-secret", passedConfig.GiteaWebHookSecret)
+	Equals(t, "ca.pem", passedConfig.GitlabCAFile)
 	Equals(t, "gitlab-hostname", passedConfig.GitlabHostname)
 	Equals(t, "gitlab-user", passedConfig.GitlabUser)
 	Equals(t, "gitlab-token", passedConfig.GitlabToken)
 	Equals(t, "gitlab-secret", passedConfig.GitlabWebHookSecret)
+	Equals(t, true, passedConfig.HidePrevPlanComments)
 	Equals(t, "debug", passedConfig.LogLevel)
 	Equals(t, 8181, passedConfig.Port)
+	Equals(t, 10, passedConfig.MaxFinishedJobs)
+	Equals(t, true, passedConfig.AllowUICancel)
+	Equals(t, "SECRET", passedConfig.RedactEnvVars)
+	Equals(t, "github.com/owner/*", passedConfig.RepoWhitelist)
 	Equals(t, true, passedConfig.RequireApproval)
+	Equals(t, 10*time.Minute, passedConfig.InitTimeout)
+	Equals(t, 30*time.Minute, passedConfig.PlanTimeout)
+	Equals(t, 2*time.Hour, passedConfig.ApplyTimeout)
+	Equals(t, 5*time.Minute, passedConfig.HookTimeout)
+	Equals(t, "https://mirror.example.com", passedConfig.TFDownloadURL)
+	Equals(t, "https://vault.example.com", passedConfig.VaultAddr)
+	Equals(t, "vault-token", passedConfig.VaultToken)
 }
 
 func TestExecute_ConfigFile(t *testing.T) {
 	t.Log("Should use all the values from the config file.")
 	tmpFile := tempFile(t, `---
+allow-ui-cancel: true
+apply-timeout: "2h"
 atlantis-url: "url"
+azuredevops-hostname: "devops.example.com"
+azuredevops-user: "azuredevops-user"
+azuredevops-token: "azuredevops-token"
+azuredevops-webhook-user: "webhook-user"
+azuredevops-webhook-password: "webhook-password"
 data-dir: "path"
+fork-pr-policy: "deny"
+comment-mode: "project"
+comment-templates-dir: "templates"
 gh-hostname: "ghhostname"
 gh-user: "user"
 gh-token: "token"
 gh-webhook-secret: "secret"
+gitea-base-url: "http://localhost:3000"
+gitea-user: "gitea-user"
+gitea-token: "gitea-token"
+gitea-webhook-secret: "gitea-secret"
+gitlab-ca-file: "ca.pem"
 gitlab-hostname: "gitlab-hostname"
 gitlab-user: "gitlab-user"
 gitlab-token: "gitlab-token"
 gitlab-webhook-secret: "gitlab-secret"
+hide-prev-plan-comments: true
+hook-timeout: "5m"
+init-timeout: "10m"
 log-level: "debug"
+max-finished-jobs: 10
+plan-timeout: "30m"
 port: 8181
-require-approval: true`)
+redact-env-vars: "SECRET"
+repo-whitelist: "github.com/owner/*"
+require-approval: true
+tf-download-url: "https://mirror.example.com"`)
 	defer os.Remove(tmpFile) // nolint: errcheck
 	c := setup(map[string]interface{}{
 		cmd.ConfigFlag: tmpFile,
@@ -329,18 +811,40 @@ require-approval: true`)
 	err := c.Execute()
 	Ok(t, err)
 	Equals(t, "url", passedConfig.AtlantisURL)
+	Equals(t, "devops.example.com", passedConfig.AzureDevopsHostname)
+	Equals(t, "azuredevops-user", passedConfig.AzureDevopsUser)
+	Equals(t, "azuredevops-token", passedConfig.AzureDevopsToken)
+	Equals(t, "webhook-user", passedConfig.AzureDevopsWebHookUser)
+	Equals(t, "webhook-password", passedConfig.AzureDevopsWebHookPassword)
 	Equals(t, "path", passedConfig.DataDir)
+	Equals(t, "deny", passedConfig.ForkPRPolicy)
+	Equals(t, "project", passedConfig.CommentMode)
+	Equals(t, "templates", passedConfig.CommentTemplatesDir)
 	Equals(t, "ghhostname", passedConfig.GithubHostname)
 	Equals(t, "user", passedConfig.GithubUser)
 	Equals(t, "token", passedConfig.GithubToken)
 	Equals(t, "secret", passedConfig.GithubWebHookSecret)
+	Equals(t, "http://localhost:3000", passedConfig.GiteaBaseURL)
+	Equals(t, "gitea-user", passedConfig.GiteaUser)
+	Equals(t, "gitea-token", passedConfig.GiteaToken)
+	Equals(t, "gitea-secret", passedConfig.GiteaWebHookSecret)
 	Equals(t, "gitlab-hostname", passedConfig.GitlabHostname)
 	Equals(t, "gitlab-user", passedConfig.GitlabUser)
 	Equals(t, "gitlab-token", passedConfig.GitlabToken)
 	Equals(t, "gitlab-secret", passedConfig.GitlabWebHookSecret)
+	Equals(t, true, passedConfig.HidePrevPlanComments)
 	Equals(t, "debug", passedConfig.LogLevel)
 	Equals(t, 8181, passedConfig.Port)
+	Equals(t, 10, passedConfig.MaxFinishedJobs)
+	Equals(t, true, passedConfig.AllowUICancel)
+	Equals(t, "SECRET", passedConfig.RedactEnvVars)
+	Equals(t, "github.com/owner/*", passedConfig.RepoWhitelist)
 	Equals(t, true, passedConfig.RequireApproval)
+	Equals(t, 10*time.Minute, passedConfig.InitTimeout)
+	Equals(t, 30*time.Minute, passedConfig.PlanTimeout)
+	Equals(t, 2*time.Hour, passedConfig.ApplyTimeout)
+	Equals(t, 5*time.Minute, passedConfig.HookTimeout)
+	Equals(t, "https://mirror.example.com", passedConfig.TFDownloadURL)
 }
 
 func TestExecute_EnvironmentOverride(t *testing.T) {
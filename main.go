@@ -15,8 +15,10 @@ func main() {
 	}
 	version := &cmd.VersionCmd{Viper: v}
 	bootstrap := &cmd.BootstrapCmd{}
+	pluginCache := &cmd.PluginCacheCmd{}
 	cmd.RootCmd.AddCommand(server.Init())
 	cmd.RootCmd.AddCommand(version.Init())
 	cmd.RootCmd.AddCommand(bootstrap.Init())
+	cmd.RootCmd.AddCommand(pluginCache.Init())
 	cmd.Execute()
 }
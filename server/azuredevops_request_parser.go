@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hootsuite/atlantis/server/events/vcs"
+)
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_azuredevops_request_parser.go AzureDevopsRequestParser
+
+// AzureDevopsRequestParser parses and validates Azure DevOps requests.
+type AzureDevopsRequestParser interface {
+	// Validate validates that the request was sent with the basic auth user
+	// and password configured on the service hook.
+	// If user and password are empty it does not check the credentials.
+	// It then parses the request as a vcs.AzureDevopsPullCommentEvent or
+	// vcs.AzureDevopsPullEvent depending on the event type in the payload.
+	// If the event type is not recognized it will return nil but will not
+	// return an error.
+	// Usage:
+	//	event, err := AzureDevopsRequestParser.Validate(r, user, password)
+	//	if err != nil {
+	//		return
+	//	}
+	//	switch event := event.(type) {
+	//	case vcs.AzureDevopsPullCommentEvent:
+	//		// handle
+	//	case vcs.AzureDevopsPullEvent:
+	//		// handle
+	//	default:
+	//		// unsupported event
+	//	}
+	Validate(r *http.Request, user []byte, password []byte) (interface{}, error)
+}
+
+// DefaultAzureDevopsRequestParser parses Azure DevOps requests.
+type DefaultAzureDevopsRequestParser struct{}
+
+// Validate returns the parsed event.
+// If user or password are not empty, it checks that the request's basic auth
+// credentials match and returns an error if they don't.
+// If both are empty, it does not check the credentials.
+func (d *DefaultAzureDevopsRequestParser) Validate(r *http.Request, user []byte, password []byte) (interface{}, error) {
+	// Azure DevOps service hooks can't sign requests so the only way to
+	// authenticate them is with basic auth.
+	if len(user) != 0 || len(password) != 0 {
+		reqUser, reqPassword, ok := r.BasicAuth()
+		if !ok {
+			return nil, errors.New("missing basic auth credentials")
+		}
+		userMatches := subtle.ConstantTimeCompare([]byte(reqUser), user) == 1
+		passwordMatches := subtle.ConstantTimeCompare([]byte(reqPassword), password) == 1
+		if !userMatches || !passwordMatches {
+			return nil, errors.New("basic auth credentials check failed")
+		}
+	}
+
+	bytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	// All service hooks share an envelope with the event type. The resource
+	// depends on the event type.
+	var envelope struct {
+		EventType string          `json:"eventType"`
+		Resource  json.RawMessage `json:"resource"`
+	}
+	if err := json.Unmarshal(bytes, &envelope); err != nil {
+		return nil, err
+	}
+
+	switch envelope.EventType {
+	case vcs.AzureDevopsPullCommentedEventType:
+		var event vcs.AzureDevopsPullCommentEvent
+		if err := json.Unmarshal(envelope.Resource, &event); err != nil {
+			return nil, err
+		}
+		return event, nil
+	case vcs.AzureDevopsPullCreatedEventType, vcs.AzureDevopsPullUpdatedEventType:
+		event := vcs.AzureDevopsPullEvent{EventType: envelope.EventType}
+		if err := json.Unmarshal(envelope.Resource, &event.PullRequest); err != nil {
+			return nil, err
+		}
+		return event, nil
+	}
+	return nil, nil
+}
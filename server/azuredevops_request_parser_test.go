@@ -0,0 +1,80 @@
+package server_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/hootsuite/atlantis/server/events/vcs/fixtures"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+var azureDevopsParser = server.DefaultAzureDevopsRequestParser{}
+
+func TestAzureDevopsValidate_MissingCredentials(t *testing.T) {
+	t.Log("If credentials are set and the request has no basic auth an error is returned")
+	req := azureDevopsRequest(t, fixtures.AzureDevopsPullCommentEventJSON)
+	_, err := azureDevopsParser.Validate(req, []byte("user"), []byte("password"))
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "missing basic auth credentials", err.Error())
+}
+
+func TestAzureDevopsValidate_InvalidCredentials(t *testing.T) {
+	t.Log("If the basic auth credentials don't match an error is returned")
+	for _, creds := range [][]string{{"user", "other"}, {"other", "password"}} {
+		req := azureDevopsRequest(t, fixtures.AzureDevopsPullCommentEventJSON)
+		req.SetBasicAuth(creds[0], creds[1])
+		_, err := azureDevopsParser.Validate(req, []byte("user"), []byte("password"))
+		Assert(t, err != nil, "should be an error")
+		Equals(t, "basic auth credentials check failed", err.Error())
+	}
+}
+
+func TestAzureDevopsValidate_ValidCredentials(t *testing.T) {
+	t.Log("If the basic auth credentials match then the event is returned")
+	req := azureDevopsRequest(t, fixtures.AzureDevopsPullCommentEventJSON)
+	req.SetBasicAuth("user", "password")
+	event, err := azureDevopsParser.Validate(req, []byte("user"), []byte("password"))
+	Ok(t, err)
+	comment := event.(vcs.AzureDevopsPullCommentEvent)
+	Equals(t, "atlantis plan", comment.Comment.Content)
+	Equals(t, "commenter@example.com", comment.Comment.Author.UniqueName)
+	Equals(t, 1, comment.PullRequest.PullRequestID)
+	Equals(t, "https://org@dev.azure.com/org/project/_git/repo", comment.PullRequest.Repository.RemoteURL)
+}
+
+func TestAzureDevopsValidate_NoCredentials(t *testing.T) {
+	t.Log("If there are no credentials then we ignore basic auth and return the event")
+	req := azureDevopsRequest(t, fixtures.AzureDevopsPullUpdatedEventJSON)
+	event, err := azureDevopsParser.Validate(req, nil, nil)
+	Ok(t, err)
+	pull := event.(vcs.AzureDevopsPullEvent)
+	Equals(t, vcs.AzureDevopsPullUpdatedEventType, pull.EventType)
+	Equals(t, "completed", pull.PullRequest.Status)
+	Equals(t, 1, pull.PullRequest.PullRequestID)
+}
+
+func TestAzureDevopsValidate_InvalidEvent(t *testing.T) {
+	t.Log("If the event is malformed there should be an error")
+	req := azureDevopsRequest(t, "{")
+	_, err := azureDevopsParser.Validate(req, nil, nil)
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "unexpected end of JSON input", err.Error())
+}
+
+func TestAzureDevopsValidate_UnrecognizedEvent(t *testing.T) {
+	t.Log("If the event is not one we care about we return nil")
+	req := azureDevopsRequest(t, `{"eventType": "git.push", "resource": {}}`)
+	event, err := azureDevopsParser.Validate(req, nil, nil)
+	Ok(t, err)
+	Equals(t, nil, event)
+}
+
+func azureDevopsRequest(t *testing.T, body string) *http.Request {
+	req, err := http.NewRequest("POST", "http://localhost/event", bytes.NewBufferString(body))
+	Ok(t, err)
+	req.Header.Set("Request-Id", "|0f0d8a3a.1")
+	return req
+}
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -16,8 +17,10 @@ import (
 )
 
 type ApplyExecutor struct {
-	VCSClient         vcs.ClientProxy
-	Terraform         *terraform.Client
+	VCSClient vcs.ClientProxy
+	Terraform *terraform.Client
+	// Terragrunt runs the commands of projects that use terragrunt.
+	Terragrunt        terraform.Runner
 	RequireApproval   bool
 	Run               *run.Run
 	Workspace         Workspace
@@ -49,11 +52,16 @@ func (a *ApplyExecutor) Execute(ctx *CommandContext) CommandResponse {
 		if err != nil {
 			return err
 		}
+		// terraform and terragrunt copy modules into these so there could
+		// be copies of the plans in them
+		if info.IsDir() && (info.Name() == ".terraform" || info.Name() == terragruntCacheDir) {
+			return filepath.SkipDir
+		}
 		// if the plan is for the right env,
 		if !info.IsDir() && info.Name() == ctx.Command.Environment+".tfplan" {
 			rel, _ := filepath.Rel(repoDir, filepath.Dir(path))
 			plans = append(plans, models.Plan{
-				Project:   models.NewProject(ctx.BaseRepo.FullName, rel),
+				Project:   models.NewProject(ctx.BaseRepo.Hostname, ctx.BaseRepo.FullName, rel),
 				LocalPath: path,
 			})
 		}
@@ -66,16 +74,27 @@ func (a *ApplyExecutor) Execute(ctx *CommandContext) CommandResponse {
 		return CommandResponse{Failure: "No plans found for that environment."}
 	}
 	var paths []string
+	var projectPaths []string
+	byPath := make(map[string]models.Plan)
 	for _, p := range plans {
 		paths = append(paths, p.LocalPath)
+		projectPaths = append(projectPaths, p.Project.Path)
+		byPath[p.Project.Path] = p
 	}
 	ctx.Log.Info("found %d plan(s) in our workspace: %v", len(plans), paths)
 
+	// Terragrunt projects are applied after the projects they depend on.
 	results := []ProjectResult{}
-	for _, plan := range plans {
+	for _, projectPath := range sortByDependencies(ctx.Log, repoDir, projectPaths) {
+		plan := byPath[projectPath]
+		if ctx.Canceled() {
+			results = append(results, ProjectResult{Path: plan.LocalPath, ProjectPath: plan.Project.Path, Failure: "The apply was canceled before it ran for this project."})
+			continue
+		}
 		ctx.Log.Info("running apply for project at path %q", plan.Project.Path)
 		result := a.apply(ctx, repoDir, plan)
 		result.Path = plan.LocalPath
+		result.ProjectPath = plan.Project.Path
 		results = append(results, result)
 	}
 	return CommandResponse{ProjectResults: results}
@@ -88,28 +107,62 @@ func (a *ApplyExecutor) apply(ctx *CommandContext, repoDir string, plan models.P
 	}
 	config := preExecute.ProjectConfig
 	terraformVersion := preExecute.TerraformVersion
+	tf, err := terraformRunner(config, a.Terraform, a.Terragrunt)
+	if err != nil {
+		return ProjectResult{Error: err}
+	}
 
 	applyExtraArgs := config.GetExtraArguments(ctx.Command.Name.String())
 	absolutePath := filepath.Join(repoDir, plan.Project.Path)
 	env := ctx.Command.Environment
 	tfApplyCmd := append(append(append([]string{"apply", "-no-color"}, applyExtraArgs...), ctx.Command.Flags...), plan.LocalPath)
-	output, err := a.Terraform.RunCommandWithVersion(ctx.Log, absolutePath, tfApplyCmd, terraformVersion, env)
+	runApply := func(cmdCtx context.Context) (string, error) {
+		output, err := tf.RunCommandWithVersion(cmdCtx, ctx.Log, ctx.Output, absolutePath, tfApplyCmd, terraformVersion, env)
+		a.Webhooks.Send(ctx.Log, webhooks.ApplyResult{ // nolint: errcheck
+			Workspace: env,
+			User:      ctx.User,
+			Repo:      ctx.BaseRepo,
+			Pull:      ctx.Pull,
+			Success:   err == nil,
+		})
+		return output, err
+	}
 
-	a.Webhooks.Send(ctx.Log, webhooks.ApplyResult{ // nolint: errcheck
-		Workspace: env,
-		User:      ctx.User,
-		Repo:      ctx.BaseRepo,
-		Pull:      ctx.Pull,
-		Success:   err == nil,
-	})
+	steps := config.Workflow.Steps(ctx.Command.Name)
+	if steps != nil {
+		w := &workflowRun{
+			ctx:              ctx,
+			terraform:        tf,
+			run:              a.Run,
+			config:           config,
+			path:             absolutePath,
+			terraformVersion: terraformVersion,
+			command:          runApply,
+		}
+		output, res := w.runSteps(steps)
+		if res != (ProjectResult{}) {
+			return res
+		}
+		ctx.Log.Info("apply succeeded")
+		return ProjectResult{ApplySuccess: output}
+	}
 
+	applyCtx, cancel := run.WithTimeout(config.commandContext(ctx.Context), config.Timeouts.Apply)
+	output, err := runApply(applyCtx)
+	cancel()
+	if res, ok := timeoutResult(err, "`terraform apply`"); ok {
+		return res
+	}
 	if err != nil {
 		return ProjectResult{Error: fmt.Errorf("%s\n%s", err.Error(), output)}
 	}
 	ctx.Log.Info("apply succeeded")
 
 	if len(config.PostApply) > 0 {
-		_, err := a.Run.Execute(ctx.Log, config.PostApply, absolutePath, env, terraformVersion, "post_apply")
+		err := runHooks(ctx, a.Run, config, config.PostApply, absolutePath, terraformVersion, "post_apply")
+		if res, ok := timeoutResult(err, "the post_apply commands"); ok {
+			return res
+		}
 		if err != nil {
 			return ProjectResult{Error: errors.Wrap(err, "running post apply commands")}
 		}
@@ -1,6 +1,9 @@
 package events
 
 import (
+	"context"
+	"io"
+
 	"github.com/hootsuite/atlantis/server/events/models"
 	"github.com/hootsuite/atlantis/server/events/vcs"
 	"github.com/hootsuite/atlantis/server/logging"
@@ -14,4 +17,22 @@ type CommandContext struct {
 	Command  *Command
 	Log      *logging.SimpleLogger
 	VCSHost  vcs.Host
+	// Context is canceled when the command is canceled, which stops the
+	// processes the command is running.
+	Context context.Context
+	// SensitiveValues are secrets found while running the command, ex. the
+	// values of variables marked sensitive. They're redacted from the
+	// command's output.
+	SensitiveValues []string
+	// Output receives the output of terraform and the project's hooks as
+	// they run. It's nil if the output isn't streamed anywhere.
+	Output io.Writer
+	// JobURL is the page of the Atlantis UI that shows Output or an empty
+	// string if there isn't one.
+	JobURL string
+}
+
+// Canceled returns true if the command was canceled.
+func (c *CommandContext) Canceled() bool {
+	return c.Context != nil && c.Context.Err() != nil
 }
@@ -4,7 +4,9 @@ import (
 	"fmt"
 
 	"github.com/google/go-github/github"
+	"github.com/hootsuite/atlantis/server/events/jobs"
 	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/redaction"
 	"github.com/hootsuite/atlantis/server/events/vcs"
 	"github.com/hootsuite/atlantis/server/logging"
 	"github.com/hootsuite/atlantis/server/recovery"
@@ -30,6 +32,30 @@ type GitlabMergeRequestGetter interface {
 	GetMergeRequest(repoFullName string, pullNum int) (*gitlab.MergeRequest, error)
 }
 
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_gitea_pull_getter.go GiteaPullGetter
+
+type GiteaPullGetter interface {
+	GetPullRequest(repo models.Repo, pullNum int) (*vcs.GiteaPullRequest, error)
+}
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_azuredevops_pull_getter.go AzureDevopsPullGetter
+
+type AzureDevopsPullGetter interface {
+	GetPullRequest(repo models.Repo, pullNum int) (*vcs.AzureDevopsPullRequest, error)
+}
+
+// The fork PR policies control whether commands can be run on pull requests
+// whose head branch is in a different repo than the base branch.
+const (
+	// AllowForkPRs allows anyone to run commands on pull requests from forks.
+	AllowForkPRs = "allow"
+	// DenyForkPRs rejects all commands on pull requests from forks.
+	DenyForkPRs = "deny"
+	// MaintainersForkPRs only allows users with write access to the base
+	// repo to run commands on pull requests from forks.
+	MaintainersForkPRs = "maintainers"
+)
+
 // CommandHandler is the first step when processing a comment command.
 type CommandHandler struct {
 	PlanExecutor             Executor
@@ -39,11 +65,33 @@ type CommandHandler struct {
 	VCSClient                vcs.ClientProxy
 	GithubPullGetter         GithubPullGetter
 	GitlabMergeRequestGetter GitlabMergeRequestGetter
+	GiteaPullGetter          GiteaPullGetter
+	AzureDevopsPullGetter    AzureDevopsPullGetter
 	CommitStatusUpdater      CommitStatusUpdater
 	EventParser              EventParsing
 	EnvLocker                EnvLocker
 	MarkdownRenderer         *MarkdownRenderer
 	Logger                   logging.SimpleLogging
+	// ForkPRPolicy is one of AllowForkPRs, DenyForkPRs or MaintainersForkPRs.
+	// If empty, commands on pull requests from forks are allowed.
+	ForkPRPolicy string
+	// CommentMode is one of NewCommentMode, CommandCommentMode or
+	// ProjectCommentMode. If empty, a new comment is created for every command.
+	CommentMode    string
+	CommentUpdater *CommentUpdater
+	// HidePrevPlanComments hides the comments for previous plans in an
+	// environment when it's planned again. Only used in NewCommentMode.
+	HidePrevPlanComments bool
+	// Redactor masks secrets in the results before they're posted.
+	Redactor *redaction.Redactor
+	// Jobs stores the output of commands as they run so it can be viewed in
+	// the UI. If nil, the output isn't stored.
+	Jobs *jobs.Store
+	// JobURL returns the URL of the UI page for the job with id. Commit
+	// statuses link to it if it's set.
+	JobURL func(id string) string
+
+	running runningCommands
 }
 
 // ExecuteCommand executes the command
@@ -54,6 +102,10 @@ func (c *CommandHandler) ExecuteCommand(baseRepo models.Repo, headRepo models.Re
 		pull, headRepo, err = c.getGithubData(baseRepo, pullNum)
 	} else if vcsHost == vcs.Gitlab {
 		pull, err = c.getGitlabData(baseRepo.FullName, pullNum)
+	} else if vcsHost == vcs.Gitea {
+		pull, headRepo, err = c.getGiteaData(baseRepo, pullNum)
+	} else if vcsHost == vcs.AzureDevops {
+		pull, headRepo, err = c.getAzureDevopsData(baseRepo, pullNum)
 	}
 
 	log := c.buildLogger(baseRepo.FullName, pullNum)
@@ -100,6 +152,36 @@ func (c *CommandHandler) getGitlabData(repoFullName string, pullNum int) (models
 	return pull, nil
 }
 
+func (c *CommandHandler) getGiteaData(baseRepo models.Repo, pullNum int) (models.PullRequest, models.Repo, error) {
+	if c.GiteaPullGetter == nil {
+		return models.PullRequest{}, models.Repo{}, errors.New("Atlantis not configured to support Gitea")
+	}
+	giteaPull, err := c.GiteaPullGetter.GetPullRequest(baseRepo, pullNum)
+	if err != nil {
+		return models.PullRequest{}, models.Repo{}, errors.Wrap(err, "making pull request API call to Gitea")
+	}
+	pull, repo, err := c.EventParser.ParseGiteaPull(giteaPull)
+	if err != nil {
+		return pull, repo, errors.Wrap(err, "extracting required fields from pull request data")
+	}
+	return pull, repo, nil
+}
+
+func (c *CommandHandler) getAzureDevopsData(baseRepo models.Repo, pullNum int) (models.PullRequest, models.Repo, error) {
+	if c.AzureDevopsPullGetter == nil {
+		return models.PullRequest{}, models.Repo{}, errors.New("Atlantis not configured to support Azure DevOps")
+	}
+	adPull, err := c.AzureDevopsPullGetter.GetPullRequest(baseRepo, pullNum)
+	if err != nil {
+		return models.PullRequest{}, models.Repo{}, errors.Wrap(err, "making pull request API call to Azure DevOps")
+	}
+	pull, repo, err := c.EventParser.ParseAzureDevopsPull(adPull)
+	if err != nil {
+		return pull, repo, errors.Wrap(err, "extracting required fields from pull request data")
+	}
+	return pull, repo, nil
+}
+
 func (c *CommandHandler) buildLogger(repoFullName string, pullNum int) *logging.SimpleLogger {
 	src := fmt.Sprintf("%s#%d", repoFullName, pullNum)
 	return logging.NewSimpleLogger(src, c.Logger.Underlying(), true, c.Logger.GetLevel())
@@ -119,18 +201,38 @@ func (c *CommandHandler) run(ctx *CommandContext) {
 		return
 	}
 
-	c.CommitStatusUpdater.Update(ctx.BaseRepo, ctx.Pull, vcs.Pending, ctx.Command, ctx.VCSHost) // nolint: errcheck
-	if !c.EnvLocker.TryLock(ctx.BaseRepo.FullName, ctx.Command.Environment, ctx.Pull.Num) {
+	if ctx.HeadRepo.FullName != ctx.BaseRepo.FullName {
+		if rejection := c.forkRejection(ctx); rejection != "" {
+			ctx.Log.Warn("rejecting command on pull request from fork %s: %s", ctx.HeadRepo.FullName, rejection)
+			c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull, rejection, ctx.VCSHost) // nolint: errcheck
+			return
+		}
+	}
+
+	if ctx.Command.Name == Cancel {
+		c.cancel(ctx)
+		return
+	}
+
+	if c.Jobs != nil && ctx.Command.Name != Help {
+		job := c.startJob(ctx)
+		defer c.Jobs.Finish(job)
+	}
+	c.CommitStatusUpdater.Update(ctx.BaseRepo, ctx.Pull, vcs.Pending, ctx.Command, ctx.JobURL, ctx.VCSHost) // nolint: errcheck
+	if !c.EnvLocker.TryLock(ctx.BaseRepo.Hostname, ctx.BaseRepo.FullName, ctx.Command.Environment, ctx.Pull.Num) {
 		errMsg := fmt.Sprintf(
 			"The %s environment is currently locked by another"+
 				" command that is running for this pull request."+
-				" Wait until the previous command is complete and try again.",
-			ctx.Command.Environment)
+				" Wait until the previous command is complete and try again"+
+				" or stop it with `atlantis cancel %s`.",
+			ctx.Command.Environment, ctx.Command.Environment)
 		ctx.Log.Warn(errMsg)
 		c.updatePull(ctx, CommandResponse{Failure: errMsg})
 		return
 	}
-	defer c.EnvLocker.Unlock(ctx.BaseRepo.FullName, ctx.Command.Environment, ctx.Pull.Num)
+	defer c.EnvLocker.Unlock(ctx.BaseRepo.Hostname, ctx.BaseRepo.FullName, ctx.Command.Environment, ctx.Pull.Num)
+	finished := c.running.start(ctx)
+	defer finished()
 
 	var cr CommandResponse
 	switch ctx.Command.Name {
@@ -143,10 +245,87 @@ func (c *CommandHandler) run(ctx *CommandContext) {
 	default:
 		ctx.Log.Err("failed to determine desired command, neither plan nor apply")
 	}
+	if ctx.Canceled() {
+		ctx.Log.Warn("command was canceled")
+	}
 	c.updatePull(ctx, cr)
 }
 
+// cancel handles the cancel command in ctx by canceling the command running
+// for the same environment.
+func (c *CommandHandler) cancel(ctx *CommandContext) {
+	if !c.CancelCommand(ctx.BaseRepo.Hostname, ctx.BaseRepo.FullName, ctx.Pull.Num, ctx.Command.Environment, "@"+ctx.User.Username) {
+		ctx.Log.Info("no command to cancel in the %s environment", ctx.Command.Environment)
+		c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull, fmt.Sprintf("There's no command running for the `%s` environment.", ctx.Command.Environment), ctx.VCSHost) // nolint: errcheck
+	}
+}
+
+// CancelCommand cancels the plan or apply running for the repo on the VCS host
+// with repoHostname, pull request and environment and comments on the pull request that canceledBy canceled
+// it. Terraform and the hooks are interrupted and the command's results are
+// commented once they've stopped. It returns false if there's no command
+// running.
+func (c *CommandHandler) CancelCommand(repoHostname string, repoFullName string, pullNum int, env string, canceledBy string) bool {
+	ctx := c.running.cancel(repoHostname, repoFullName, pullNum, env)
+	if ctx == nil {
+		return false
+	}
+	ctx.Log.Warn("canceling command at the request of %s", canceledBy)
+	comment := fmt.Sprintf("%s canceled the running `%s` for the `%s` environment. Its results will be commented once it stops.", canceledBy, ctx.Command.Name.String(), env)
+	c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull, comment, ctx.VCSHost) // nolint: errcheck
+	return true
+}
+
+// startJob starts a job for the command in ctx and sets ctx up to stream the
+// command's output to it.
+func (c *CommandHandler) startJob(ctx *CommandContext) *jobs.Job {
+	job := c.Jobs.Start(&jobs.Job{
+		RepoHostname: ctx.BaseRepo.Hostname,
+		RepoFullName: ctx.BaseRepo.FullName,
+		PullNum:      ctx.Pull.Num,
+		PullURL:      ctx.Pull.URL,
+		Command:      ctx.Command.Name.String(),
+		Environment:  ctx.Command.Environment,
+		// The sensitive values are read for each line since they're only
+		// found once each project is set up.
+		Redact: func(line string) string {
+			return c.Redactor.Redact(line, ctx.SensitiveValues...)
+		},
+	})
+	ctx.Output = job
+	if c.JobURL != nil {
+		ctx.JobURL = c.JobURL(job.ID)
+	}
+	return job
+}
+
+// forkRejection returns the reason why the command in ctx can't be run on
+// a pull request from a fork or an empty string if it's allowed to run.
+// Pull requests from forks are dangerous because we'd be checking out and
+// running the fork's code, including its hooks, with our credentials.
+func (c *CommandHandler) forkRejection(ctx *CommandContext) string {
+	switch c.ForkPRPolicy {
+	case DenyForkPRs:
+		return fmt.Sprintf("Atlantis commands can't be run on pull requests from forked repositories."+
+			" This pull request is from %s. Atlantis would run that repo's code, including any hooks in its %s files,"+
+			" with this server's credentials so it has been configured to reject them.", ctx.HeadRepo.FullName, ProjectConfigFile)
+	case MaintainersForkPRs:
+		hasAccess, err := c.VCSClient.UserHasWriteAccess(ctx.BaseRepo, ctx.User, ctx.VCSHost)
+		if err != nil {
+			ctx.Log.Err("checking if %s has write access to %s: %s", ctx.User.Username, ctx.BaseRepo.FullName, err)
+		}
+		if !hasAccess {
+			return fmt.Sprintf("Atlantis commands on pull requests from forked repositories can only be run by users with write access to %s."+
+				" This pull request is from %s. Atlantis would run that repo's code, including any hooks in its %s files,"+
+				" with this server's credentials so a maintainer must review the changes and run the command.",
+				ctx.BaseRepo.FullName, ctx.HeadRepo.FullName, ProjectConfigFile)
+		}
+	}
+	return ""
+}
+
 func (c *CommandHandler) updatePull(ctx *CommandContext, res CommandResponse) {
+	res = c.redact(ctx, res)
 	// Log if we got any errors or failures.
 	if res.Error != nil {
 		ctx.Log.Err(res.Error.Error())
@@ -156,8 +335,77 @@ func (c *CommandHandler) updatePull(ctx *CommandContext, res CommandResponse) {
 
 	// Update the pull request's status icon and comment back.
 	c.CommitStatusUpdater.UpdateProjectResult(ctx, res) // nolint: errcheck
-	comment := c.MarkdownRenderer.Render(res, ctx.Command.Name, ctx.Log.History.String(), ctx.Command.Verbose)
-	c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull, comment, ctx.VCSHost) // nolint: errcheck
+	c.comment(ctx, res)
+}
+
+// comment comments back on the pull request with res according to the
+// comment mode.
+func (c *CommandHandler) comment(ctx *CommandContext, res CommandResponse) {
+	log := c.Redactor.Redact(ctx.Log.History.String(), ctx.SensitiveValues...)
+	if ctx.Command.Name == Help || c.CommentMode == "" || c.CommentMode == NewCommentMode {
+		comment := c.MarkdownRenderer.Render(res, ctx.Command.Name, log, ctx.Command.Verbose)
+		if ctx.Command.Name == Plan && c.HidePrevPlanComments {
+			// A new plan replaces the plans for the environment so the
+			// comments for the old plans are outdated.
+			marker := CommentMarker(fmt.Sprintf("plan %s", ctx.Command.Environment))
+			if err := c.VCSClient.HideComments(ctx.BaseRepo, ctx.Pull, marker, ctx.VCSHost); err != nil {
+				ctx.Log.Warn("hiding previous plan comments: %s", err)
+			}
+			comment = marker + "\n" + comment
+		}
+		c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull, comment, ctx.VCSHost) // nolint: errcheck
+		return
+	}
+
+	// In project mode each project gets its own comment. Errors that aren't
+	// for a specific project go in the command's comment.
+	if c.CommentMode == ProjectCommentMode && res.Error == nil && res.Failure == "" {
+		for _, result := range res.ProjectResults {
+			key := fmt.Sprintf("project %s %s", result.ProjectPath, ctx.Command.Environment)
+			comment := c.MarkdownRenderer.Render(CommandResponse{ProjectResults: []ProjectResult{result}}, ctx.Command.Name, log, ctx.Command.Verbose)
+			c.updateComment(ctx, key, comment)
+		}
+		return
+	}
+	key := fmt.Sprintf("command %s %s", ctx.Command.Name, ctx.Command.Environment)
+	c.updateComment(ctx, key, c.MarkdownRenderer.Render(res, ctx.Command.Name, log, ctx.Command.Verbose))
+}
+
+// redact returns res with secrets masked in its output and errors.
+func (c *CommandHandler) redact(ctx *CommandContext, res CommandResponse) CommandResponse {
+	redact := func(s string) string {
+		return c.Redactor.Redact(s, ctx.SensitiveValues...)
+	}
+	if res.Error != nil {
+		res.Error = errors.New(redact(res.Error.Error()))
+	}
+	res.Failure = redact(res.Failure)
+	var results []ProjectResult
+	for _, result := range res.ProjectResults {
+		if result.Error != nil {
+			result.Error = errors.New(redact(result.Error.Error()))
+		}
+		result.Failure = redact(result.Failure)
+		if result.PlanSuccess != nil {
+			result.PlanSuccess = &PlanSuccess{
+				TerraformOutput: redact(result.PlanSuccess.TerraformOutput),
+				LockURL:         result.PlanSuccess.LockURL,
+			}
+		}
+		result.ApplySuccess = redact(result.ApplySuccess)
+		results = append(results, result)
+	}
+	res.ProjectResults = results
+	return res
+}
+
+// updateComment updates the comment for key. If that fails, it falls back to
+// creating a new comment so the results aren't lost.
+func (c *CommandHandler) updateComment(ctx *CommandContext, key string, comment string) {
+	if err := c.CommentUpdater.UpdateComment(ctx.BaseRepo, ctx.Pull, key, comment, ctx.VCSHost); err != nil {
+		ctx.Log.Warn("updating comment, creating a new one instead: %s", err)
+		c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull, comment, ctx.VCSHost) // nolint: errcheck
+	}
 }
 
 // logPanics logs and creates a comment on the pull request for panics
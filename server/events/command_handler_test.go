@@ -11,7 +11,9 @@ import (
 	"github.com/hootsuite/atlantis/server/events"
 	"github.com/hootsuite/atlantis/server/events/mocks"
 	"github.com/hootsuite/atlantis/server/events/mocks/matchers"
+	"github.com/hootsuite/atlantis/server/events/jobs"
 	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/redaction"
 	"github.com/hootsuite/atlantis/server/events/models/fixtures"
 	"github.com/hootsuite/atlantis/server/events/vcs"
 	vcsmocks "github.com/hootsuite/atlantis/server/events/vcs/mocks"
@@ -28,6 +30,8 @@ var vcsClient *vcsmocks.MockClientProxy
 var ghStatus *mocks.MockCommitStatusUpdater
 var githubGetter *mocks.MockGithubPullGetter
 var gitlabGetter *mocks.MockGitlabMergeRequestGetter
+var giteaGetter *mocks.MockGiteaPullGetter
+var azureDevopsGetter *mocks.MockAzureDevopsPullGetter
 var envLocker *mocks.MockEnvLocker
 var ch events.CommandHandler
 var logBytes *bytes.Buffer
@@ -43,6 +47,8 @@ func setup(t *testing.T) {
 	vcsClient = vcsmocks.NewMockClientProxy()
 	githubGetter = mocks.NewMockGithubPullGetter()
 	gitlabGetter = mocks.NewMockGitlabMergeRequestGetter()
+	giteaGetter = mocks.NewMockGiteaPullGetter()
+	azureDevopsGetter = mocks.NewMockAzureDevopsPullGetter()
 	logger := logmocks.NewMockSimpleLogging()
 	logBytes = new(bytes.Buffer)
 	When(logger.Underlying()).ThenReturn(log.New(logBytes, "", 0))
@@ -57,6 +63,8 @@ func setup(t *testing.T) {
 		MarkdownRenderer:         &events.MarkdownRenderer{},
 		GithubPullGetter:         githubGetter,
 		GitlabMergeRequestGetter: gitlabGetter,
+		GiteaPullGetter:          giteaGetter,
+		AzureDevopsPullGetter:    azureDevopsGetter,
 		Logger: logger,
 	}
 }
@@ -64,7 +72,7 @@ func setup(t *testing.T) {
 func TestExecuteCommand_LogPanics(t *testing.T) {
 	t.Log("if there is a panic it is commented back on the pull request")
 	setup(t)
-	When(ghStatus.Update(fixtures.Repo, fixtures.Pull, vcs.Pending, nil, vcs.Github)).ThenPanic("panic")
+	When(ghStatus.Update(fixtures.Repo, fixtures.Pull, vcs.Pending, nil, "", vcs.Github)).ThenPanic("panic")
 	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, 1, nil, vcs.Github)
 	_, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString(), matchers.AnyVcsHost()).GetCapturedArguments()
 	Assert(t, strings.Contains(comment, "Error: goroutine panic"), "comment should be about a goroutine panic")
@@ -102,6 +110,52 @@ func TestExecuteCommand_GitlabMergeRequestErr(t *testing.T) {
 	Equals(t, "[ERROR] hootsuite/atlantis#1: Making merge request API call to GitLab: err\n", logBytes.String())
 }
 
+func TestExecuteCommand_NoGiteaPullGetter(t *testing.T) {
+	t.Log("if CommandHandler was constructed with a nil GiteaPullGetter an error should be logged")
+	setup(t)
+	ch.GiteaPullGetter = nil
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, 1, nil, vcs.Gitea)
+	Equals(t, "[ERROR] hootsuite/atlantis#1: Atlantis not configured to support Gitea\n", logBytes.String())
+}
+
+func TestExecuteCommand_GiteaPullErr(t *testing.T) {
+	t.Log("if getting the gitea pull request fails an error should be logged")
+	setup(t)
+	When(giteaGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(nil, errors.New("err"))
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, nil, vcs.Gitea)
+	Equals(t, "[ERROR] hootsuite/atlantis#1: Making pull request API call to Gitea: err\n", logBytes.String())
+}
+
+func TestExecuteCommand_GiteaHeadRepo(t *testing.T) {
+	t.Log("the head repo of a gitea pull request should come from the pull request")
+	setup(t)
+	ch.ForkPRPolicy = events.DenyForkPRs
+	giteaPull := &vcs.GiteaPullRequest{Number: fixtures.Pull.Num}
+	headRepo := models.Repo{FullName: "fork/atlantis"}
+	When(giteaGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(giteaPull, nil)
+	When(eventParsing.ParseGiteaPull(giteaPull)).ThenReturn(fixtures.Pull, headRepo, nil)
+	ch.ExecuteCommand(fixtures.Repo, models.Repo{}, fixtures.User, fixtures.Pull.Num, &events.Command{Name: events.Plan}, vcs.Gitea)
+	_, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.EqModelsRepo(fixtures.Repo), matchers.EqModelsPullRequest(fixtures.Pull), AnyString(), matchers.EqVcsHost(vcs.Gitea)).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "This pull request is from fork/atlantis"), "comment should be about the fork: %s", comment)
+	planner.VerifyWasCalled(Never()).Execute(matchers.AnyPtrToEventsCommandContext())
+}
+
+func TestExecuteCommand_NoAzureDevopsPullGetter(t *testing.T) {
+	t.Log("if CommandHandler was constructed with a nil AzureDevopsPullGetter an error should be logged")
+	setup(t)
+	ch.AzureDevopsPullGetter = nil
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, 1, nil, vcs.AzureDevops)
+	Equals(t, "[ERROR] hootsuite/atlantis#1: Atlantis not configured to support Azure DevOps\n", logBytes.String())
+}
+
+func TestExecuteCommand_AzureDevopsPullErr(t *testing.T) {
+	t.Log("if getting the azure devops pull request fails an error should be logged")
+	setup(t)
+	When(azureDevopsGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(nil, errors.New("err"))
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, nil, vcs.AzureDevops)
+	Equals(t, "[ERROR] hootsuite/atlantis#1: Making pull request API call to Azure DevOps: err\n", logBytes.String())
+}
+
 func TestExecuteCommand_GithubPullParseErr(t *testing.T) {
 	t.Log("if parsing the returned github pull request fails an error should be logged")
 	setup(t)
@@ -141,13 +195,14 @@ func TestExecuteCommand_EnvLocked(t *testing.T) {
 
 	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
 	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
-	When(envLocker.TryLock(fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(false)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(false)
 	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
 
 	msg := "The env environment is currently locked by another" +
 		" command that is running for this pull request." +
-		" Wait until the previous command is complete and try again."
-	ghStatus.VerifyWasCalledOnce().Update(fixtures.Repo, fixtures.Pull, vcs.Pending, &cmd, vcs.Github)
+		" Wait until the previous command is complete and try again" +
+		" or stop it with `atlantis cancel env`."
+	ghStatus.VerifyWasCalledOnce().Update(fixtures.Repo, fixtures.Pull, vcs.Pending, &cmd, "", vcs.Github)
 	_, response := ghStatus.VerifyWasCalledOnce().UpdateProjectResult(matchers.AnyPtrToEventsCommandContext(), matchers.AnyEventsCommandResponse()).GetCapturedArguments()
 	Equals(t, msg, response.Failure)
 	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.Repo, fixtures.Pull,
@@ -168,7 +223,7 @@ func TestExecuteCommand_FullRun(t *testing.T) {
 		}
 		When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
 		When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
-		When(envLocker.TryLock(fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(true)
+		When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(true)
 		switch c {
 		case events.Help:
 			When(helper.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(cmdResponse)
@@ -180,10 +235,267 @@ func TestExecuteCommand_FullRun(t *testing.T) {
 
 		ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
 
-		ghStatus.VerifyWasCalledOnce().Update(fixtures.Repo, fixtures.Pull, vcs.Pending, &cmd, vcs.Github)
+		ghStatus.VerifyWasCalledOnce().Update(fixtures.Repo, fixtures.Pull, vcs.Pending, &cmd, "", vcs.Github)
 		_, response := ghStatus.VerifyWasCalledOnce().UpdateProjectResult(matchers.AnyPtrToEventsCommandContext(), matchers.AnyEventsCommandResponse()).GetCapturedArguments()
 		Equals(t, cmdResponse, response)
 		vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString(), matchers.AnyVcsHost())
-		envLocker.VerifyWasCalledOnce().Unlock(fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)
+		envLocker.VerifyWasCalledOnce().Unlock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)
 	}
 }
+
+func TestExecuteCommand_CommandCommentMode(t *testing.T) {
+	t.Log("in command comment mode the comment for the command and environment should be updated")
+	setup(t)
+	ch.CommentMode = events.CommandCommentMode
+	ch.CommentUpdater = &events.CommentUpdater{VCSClient: vcsClient}
+	cmd := events.Command{Name: events.Plan, Environment: "env"}
+	marker := "<!-- atlantis: command plan env -->"
+	pull := &github.PullRequest{}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(true)
+	When(planner.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(events.CommandResponse{
+		ProjectResults: []events.ProjectResult{{Path: "dir1", ProjectPath: "dir1", Failure: "failure"}, {Path: "dir2", ProjectPath: "dir2", Failure: "failure"}},
+	})
+	When(vcsClient.FindComment(fixtures.Repo, fixtures.Pull, marker, vcs.Github)).ThenReturn(&vcs.Comment{ID: "1", Body: marker + "\nprevious"}, nil)
+
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+
+	_, _, _, comment, _ := vcsClient.VerifyWasCalledOnce().UpdateComment(matchers.EqModelsRepo(fixtures.Repo), matchers.EqModelsPullRequest(fixtures.Pull), EqString("1"), AnyString(), matchers.EqVcsHost(vcs.Github)).GetCapturedArguments()
+	Assert(t, strings.HasPrefix(comment, marker+"\nRan Plan in 2 directories"), "comment should have both projects: %s", comment)
+	Assert(t, strings.Contains(comment, "previous"), "comment should have the previous run: %s", comment)
+	vcsClient.VerifyWasCalled(Never()).CreateComment(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString(), matchers.AnyVcsHost())
+}
+
+func TestExecuteCommand_ProjectCommentMode(t *testing.T) {
+	t.Log("in project comment mode each project should get its own comment")
+	setup(t)
+	ch.CommentMode = events.ProjectCommentMode
+	ch.CommentUpdater = &events.CommentUpdater{VCSClient: vcsClient}
+	cmd := events.Command{Name: events.Apply, Environment: "env"}
+	pull := &github.PullRequest{}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(true)
+	When(applier.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(events.CommandResponse{
+		ProjectResults: []events.ProjectResult{{Path: "dir1", ProjectPath: "dir1", ApplySuccess: "applied dir1"}, {Path: "dir2", ProjectPath: "dir2", Failure: "failure"}},
+	})
+
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+
+	vcsClient.VerifyWasCalledOnce().FindComment(fixtures.Repo, fixtures.Pull, "<!-- atlantis: project dir1 env -->", vcs.Github)
+	vcsClient.VerifyWasCalledOnce().FindComment(fixtures.Repo, fixtures.Pull, "<!-- atlantis: project dir2 env -->", vcs.Github)
+	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.Repo, fixtures.Pull, "<!-- atlantis: project dir1 env -->\n```diff\napplied dir1\n```\n\n", vcs.Github)
+	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.Repo, fixtures.Pull, "<!-- atlantis: project dir2 env -->\n**Apply Failed**: failure\n\n\n", vcs.Github)
+}
+
+func TestExecuteCommand_CommentModeUpdateErr(t *testing.T) {
+	t.Log("if the comment can't be updated a new comment should be created instead")
+	setup(t)
+	ch.CommentMode = events.CommandCommentMode
+	ch.CommentUpdater = &events.CommentUpdater{VCSClient: vcsClient}
+	cmd := events.Command{Name: events.Plan, Environment: "env"}
+	pull := &github.PullRequest{}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(true)
+	When(planner.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(events.CommandResponse{Failure: "failure"})
+	When(vcsClient.FindComment(fixtures.Repo, fixtures.Pull, "<!-- atlantis: command plan env -->", vcs.Github)).ThenReturn(nil, errors.New("err"))
+
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+
+	_, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.EqModelsRepo(fixtures.Repo), matchers.EqModelsPullRequest(fixtures.Pull), AnyString(), matchers.EqVcsHost(vcs.Github)).GetCapturedArguments()
+	Assert(t, strings.HasPrefix(comment, "**Plan Failed**: failure"), "comment should not have a marker: %s", comment)
+}
+
+func TestExecuteCommand_HidePrevPlanComments(t *testing.T) {
+	t.Log("when hiding previous plan comments, plans should hide the old plan comments for the environment and mark their comment")
+	setup(t)
+	ch.HidePrevPlanComments = true
+	marker := "<!-- atlantis: plan env -->"
+	pull := &github.PullRequest{}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, "env", fixtures.Pull.Num)).ThenReturn(true)
+	When(planner.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(events.CommandResponse{Failure: "failure"})
+	When(applier.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(events.CommandResponse{Failure: "failure"})
+
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &events.Command{Name: events.Plan, Environment: "env"}, vcs.Github)
+	vcsClient.VerifyWasCalledOnce().HideComments(fixtures.Repo, fixtures.Pull, marker, vcs.Github)
+	_, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.EqModelsRepo(fixtures.Repo), matchers.EqModelsPullRequest(fixtures.Pull), AnyString(), matchers.EqVcsHost(vcs.Github)).GetCapturedArguments()
+	Assert(t, strings.HasPrefix(comment, marker+"\n**Plan Failed**"), "comment should start with the marker: %s", comment)
+
+	t.Log("applies shouldn't hide plan comments")
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &events.Command{Name: events.Apply, Environment: "env"}, vcs.Github)
+	vcsClient.VerifyWasCalledOnce().HideComments(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString(), matchers.AnyVcsHost())
+}
+
+func TestExecuteCommand_Redacts(t *testing.T) {
+	t.Log("secrets should be redacted from the output before it's commented")
+	setup(t)
+	redactor, err := redaction.NewRedactor([]string{`password=(\S+)`}, []string{"token-value"})
+	Ok(t, err)
+	ch.Redactor = redactor
+	pull := &github.PullRequest{}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, "env", fixtures.Pull.Num)).ThenReturn(true)
+	When(planner.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(events.CommandResponse{
+		ProjectResults: []events.ProjectResult{
+			{Path: "dir1", PlanSuccess: &events.PlanSuccess{TerraformOutput: "password=hunter22 token=token-value"}},
+			{Path: "dir2", Error: errors.New("error with token-value")},
+		},
+	})
+
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &events.Command{Name: events.Plan, Environment: "env"}, vcs.Github)
+
+	_, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.EqModelsRepo(fixtures.Repo), matchers.EqModelsPullRequest(fixtures.Pull), AnyString(), matchers.EqVcsHost(vcs.Github)).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "password=[redacted] token=[redacted]"), "plan output should be redacted: %s", comment)
+	Assert(t, strings.Contains(comment, "error with [redacted]"), "error should be redacted: %s", comment)
+	Assert(t, !strings.Contains(comment, "hunter22") && !strings.Contains(comment, "token-value"), "comment should not contain secrets: %s", comment)
+}
+
+func TestExecuteCommand_Jobs(t *testing.T) {
+	t.Log("the command's output should go to a job that the statuses link to and that's finished with the command")
+	setup(t)
+	ch.Jobs = jobs.NewStore(10)
+	ch.JobURL = func(id string) string { return "https://atlantis/jobs/" + id }
+	cmd := events.Command{Name: events.Plan, Environment: "env"}
+	pull := &github.PullRequest{}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(true)
+	When(planner.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(events.CommandResponse{Failure: "failure"})
+
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+
+	Equals(t, 1, len(ch.Jobs.List()))
+	job := ch.Jobs.List()[0]
+	Equals(t, fixtures.Repo.FullName, job.RepoFullName)
+	Equals(t, "plan", job.Command)
+	Equals(t, "env", job.Environment)
+	Assert(t, !job.Finished().IsZero(), "job should be finished")
+	ghStatus.VerifyWasCalledOnce().Update(fixtures.Repo, fixtures.Pull, vcs.Pending, &cmd, "https://atlantis/jobs/"+job.ID, vcs.Github)
+	ctx := planner.VerifyWasCalledOnce().Execute(matchers.AnyPtrToEventsCommandContext()).GetCapturedArguments()
+	Equals(t, job, ctx.Output)
+	Equals(t, "https://atlantis/jobs/"+job.ID, ctx.JobURL)
+}
+
+func TestExecuteCommand_ForkDenied(t *testing.T) {
+	t.Log("if the fork pr policy is deny then commands on pull requests from forks should be rejected with a comment")
+	setup(t)
+	ch.ForkPRPolicy = events.DenyForkPRs
+	pull := &github.PullRequest{}
+	forkRepo := models.Repo{FullName: "fork/atlantis"}
+	cmd := events.Command{Name: events.Plan, Environment: "env"}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, forkRepo, nil)
+
+	ch.ExecuteCommand(fixtures.Repo, models.Repo{}, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+	_, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString(), matchers.AnyVcsHost()).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "can't be run on pull requests from forked repositories"), "comment should explain forks are rejected, got %q", comment)
+	Assert(t, strings.Contains(comment, "fork/atlantis"), "comment should name the fork, got %q", comment)
+	planner.VerifyWasCalled(Never()).Execute(matchers.AnyPtrToEventsCommandContext())
+	envLocker.VerifyWasCalled(Never()).TryLock(AnyString(), AnyString(), AnyString(), AnyInt())
+}
+
+func TestExecuteCommand_ForkMaintainersNoAccess(t *testing.T) {
+	t.Log("if the fork pr policy is maintainers and the user doesn't have write access the command should be rejected with a comment")
+	setup(t)
+	ch.ForkPRPolicy = events.MaintainersForkPRs
+	pull := &github.PullRequest{}
+	forkRepo := models.Repo{FullName: "fork/atlantis"}
+	cmd := events.Command{Name: events.Plan, Environment: "env"}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, forkRepo, nil)
+	When(vcsClient.UserHasWriteAccess(fixtures.Repo, fixtures.User, vcs.Github)).ThenReturn(false, nil)
+
+	ch.ExecuteCommand(fixtures.Repo, models.Repo{}, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+	_, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString(), matchers.AnyVcsHost()).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "can only be run by users with write access to hootsuite/atlantis"), "comment should explain only maintainers can run commands, got %q", comment)
+	planner.VerifyWasCalled(Never()).Execute(matchers.AnyPtrToEventsCommandContext())
+}
+
+func TestExecuteCommand_ForkMaintainersWithAccess(t *testing.T) {
+	t.Log("if the fork pr policy is maintainers and the user has write access the command should run")
+	setup(t)
+	ch.ForkPRPolicy = events.MaintainersForkPRs
+	pull := &github.PullRequest{}
+	forkRepo := models.Repo{FullName: "fork/atlantis"}
+	cmd := events.Command{Name: events.Plan, Environment: "env"}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, forkRepo, nil)
+	When(vcsClient.UserHasWriteAccess(fixtures.Repo, fixtures.User, vcs.Github)).ThenReturn(true, nil)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(true)
+	When(planner.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(events.CommandResponse{})
+
+	ch.ExecuteCommand(fixtures.Repo, models.Repo{}, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+	planner.VerifyWasCalledOnce().Execute(matchers.AnyPtrToEventsCommandContext())
+}
+
+func TestExecuteCommand_ForkAllowed(t *testing.T) {
+	t.Log("if the fork pr policy is allow then commands on pull requests from forks should run")
+	setup(t)
+	ch.ForkPRPolicy = events.AllowForkPRs
+	pull := &github.PullRequest{}
+	forkRepo := models.Repo{FullName: "fork/atlantis"}
+	cmd := events.Command{Name: events.Plan, Environment: "env"}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, forkRepo, nil)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(true)
+	When(planner.Execute(matchers.AnyPtrToEventsCommandContext())).ThenReturn(events.CommandResponse{})
+
+	ch.ExecuteCommand(fixtures.Repo, models.Repo{}, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+	planner.VerifyWasCalledOnce().Execute(matchers.AnyPtrToEventsCommandContext())
+	vcsClient.VerifyWasCalled(Never()).UserHasWriteAccess(matchers.AnyModelsRepo(), matchers.AnyModelsUser(), matchers.AnyVcsHost())
+}
+
+func TestExecuteCommand_CancelNothingRunning(t *testing.T) {
+	t.Log("if there's no command running, cancel should say so")
+	setup(t)
+	pull := &github.PullRequest{}
+	cmd := events.Command{Name: events.Cancel, Environment: "env"}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
+
+	ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.Repo, fixtures.Pull, "There's no command running for the `env` environment.", vcs.Github)
+	ghStatus.VerifyWasCalled(Never()).Update(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsCommitStatus(), matchers.AnyPtrToEventsCommand(), AnyString(), matchers.AnyVcsHost())
+	envLocker.VerifyWasCalled(Never()).TryLock(AnyString(), AnyString(), AnyString(), AnyInt())
+}
+
+func TestCancelCommand(t *testing.T) {
+	t.Log("canceling a running command should cancel its context and comment on the pull request")
+	setup(t)
+	pull := &github.PullRequest{}
+	cmd := events.Command{Name: events.Plan, Environment: "env"}
+	When(githubGetter.GetPullRequest(fixtures.Repo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.Repo, nil)
+	When(envLocker.TryLock(fixtures.Repo.Hostname, fixtures.Repo.FullName, cmd.Environment, fixtures.Pull.Num)).ThenReturn(true)
+	// The mocks aren't safe to use from multiple goroutines so the command
+	// waits until CancelCommand has returned before it uses them again.
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	When(planner.Execute(matchers.AnyPtrToEventsCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(*events.CommandContext)
+		close(started)
+		<-ctx.Context.Done()
+		<-canceled
+		return []ReturnValue{events.CommandResponse{Failure: "canceled"}}
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		ch.ExecuteCommand(fixtures.Repo, fixtures.Repo, fixtures.User, fixtures.Pull.Num, &cmd, vcs.Github)
+		close(finished)
+	}()
+	<-started
+	Assert(t, !ch.CancelCommand(fixtures.Repo.Hostname, fixtures.Repo.FullName, fixtures.Pull.Num, "other", "@user"), "exp nothing to cancel in another environment")
+	Assert(t, !ch.CancelCommand("other.example.com", fixtures.Repo.FullName, fixtures.Pull.Num, "env", "@user"), "exp nothing to cancel on another host")
+	Assert(t, ch.CancelCommand(fixtures.Repo.Hostname, fixtures.Repo.FullName, fixtures.Pull.Num, "env", "@user"), "exp command to be canceled")
+	close(canceled)
+	<-finished
+
+	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.Repo, fixtures.Pull, "@user canceled the running `plan` for the `env` environment. Its results will be commented once it stops.", vcs.Github)
+	Assert(t, !ch.CancelCommand(fixtures.Repo.Hostname, fixtures.Repo.FullName, fixtures.Pull.Num, "env", "@user"), "exp nothing to cancel once the command finished")
+}
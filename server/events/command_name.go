@@ -6,6 +6,7 @@ const (
 	Apply CommandName = iota
 	Plan
 	Help
+	Cancel
 	// Adding more? Don't forget to update String() below
 )
 
@@ -17,6 +18,8 @@ func (c CommandName) String() string {
 		return "plan"
 	case Help:
 		return "help"
+	case Cancel:
+		return "cancel"
 	}
 	return ""
 }
@@ -0,0 +1,145 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/pkg/errors"
+)
+
+// The comment modes control whether Atlantis creates a new comment for every
+// command or keeps a single comment up to date.
+const (
+	// NewCommentMode creates a new comment with the results of every command.
+	NewCommentMode = "new"
+	// CommandCommentMode keeps one comment per command and environment, ex.
+	// one for `atlantis plan staging`, and updates it on each run.
+	CommandCommentMode = "command"
+	// ProjectCommentMode keeps one comment per project and environment and
+	// updates it with the results of every command run in that project.
+	ProjectCommentMode = "project"
+)
+
+// maxCommentLength is the most characters we put in an updated comment. It's
+// GitHub's limit which is the lowest of the VCS hosts we support. We drop
+// the oldest previous runs to stay under it.
+const maxCommentLength = 65536
+
+// previousRunsMarker separates the latest run from the previous runs in an
+// updated comment. previousRunMarker separates each previous run.
+const previousRunsMarker = "<!-- atlantis: previous runs -->"
+const previousRunMarker = "<!-- atlantis: previous run -->"
+
+const previousRunsHeader = "<details><summary>Previous runs</summary>\n\n"
+const previousRunsFooter = "\n</details>"
+
+// truncatedWarning is appended to the latest run if it had to be truncated
+// to fit in a comment.
+const truncatedWarning = "\n\n**Warning**: Output truncated."
+
+// CommentUpdater keeps a single comment per key on a pull request up to
+// date. Each comment starts with a hidden marker containing its key so it can
+// be found on the next run.
+type CommentUpdater struct {
+	VCSClient vcs.ClientProxy
+}
+
+// UpdateComment replaces the comment for key on the pull request with
+// comment. The comment it replaces is moved into a collapsed "Previous runs"
+// section. If there's no comment for key yet, one is created.
+func (c *CommentUpdater) UpdateComment(repo models.Repo, pull models.PullRequest, key string, comment string, host vcs.Host) error {
+	marker := CommentMarker(key)
+	existing, err := c.VCSClient.FindComment(repo, pull, marker, host)
+	if err != nil {
+		return errors.Wrap(err, "finding existing comment")
+	}
+	if existing == nil {
+		return c.VCSClient.CreateComment(repo, pull, c.render(marker, comment, nil), host)
+	}
+	latest, previous := c.parse(marker, existing.Body)
+	previous = append([]string{latest}, previous...)
+	return c.VCSClient.UpdateComment(repo, pull, existing.ID, c.render(marker, comment, previous), host)
+}
+
+// CommentMarker returns the hidden marker that identifies the comment for key.
+func CommentMarker(key string) string {
+	return fmt.Sprintf("<!-- atlantis: %s -->", key)
+}
+
+// render returns the body of a comment with marker, the latest run and the
+// previous runs, newest first. It drops the oldest previous runs if the
+// comment would be too long and truncates the latest run if it's too long on
+// its own.
+func (c *CommentUpdater) render(marker string, latest string, previous []string) string {
+	body := marker + "\n" + latest
+	if len(body) > maxCommentLength {
+		return truncate(body, maxCommentLength)
+	}
+	for len(previous) > 0 {
+		section := "\n" + previousRunsMarker + "\n" + previousRunsHeader +
+			strings.Join(previous, "\n"+previousRunMarker+"\n") + previousRunsFooter
+		if len(body)+len(section) <= maxCommentLength {
+			return body + section
+		}
+		previous = previous[:len(previous)-1]
+	}
+	return body
+}
+
+// truncate cuts s so that, with truncatedWarning appended, it's at most max
+// bytes. It cuts between runes and closes the code block and details sections
+// left open so the warning renders outside of them.
+func truncate(s string, max int) string {
+	cut := max - len(truncatedWarning)
+	for {
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		closers := openMarkupClosers(s[:cut])
+		over := cut + len(closers) + len(truncatedWarning) - max
+		if over <= 0 {
+			return s[:cut] + closers + truncatedWarning
+		}
+		cut -= over
+	}
+}
+
+// openMarkupClosers returns what closes the code block and details sections
+// that are open at the end of s.
+func openMarkupClosers(s string) string {
+	inCodeBlock := false
+	openDetails := 0
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		openDetails += strings.Count(line, "<details>") - strings.Count(line, "</details>")
+	}
+	var closers string
+	if inCodeBlock {
+		closers += "\n```"
+	}
+	for i := 0; i < openDetails; i++ {
+		closers += "\n</details>"
+	}
+	return closers
+}
+
+// parse splits the body of a comment created by render into the latest run
+// and the previous runs.
+func (c *CommentUpdater) parse(marker string, body string) (string, []string) {
+	body = strings.TrimPrefix(strings.TrimPrefix(body, marker), "\n")
+	split := strings.SplitN(body, "\n"+previousRunsMarker+"\n", 2)
+	if len(split) == 1 {
+		return body, nil
+	}
+	section := strings.TrimSuffix(strings.TrimPrefix(split[1], previousRunsHeader), previousRunsFooter)
+	return split[0], strings.Split(section, "\n"+previousRunMarker+"\n")
+}
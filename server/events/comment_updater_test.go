@@ -0,0 +1,105 @@
+package events_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/hootsuite/atlantis/server/events"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/hootsuite/atlantis/server/events/vcs/mocks"
+	"github.com/hootsuite/atlantis/server/events/vcs/mocks/matchers"
+	. "github.com/hootsuite/atlantis/testing"
+	. "github.com/petergtz/pegomock"
+)
+
+const commentMarker = "<!-- atlantis: command plan default -->"
+
+func TestUpdateComment_NoExistingComment(t *testing.T) {
+	t.Log("if there's no comment for the key, one is created with the marker")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClientProxy()
+	u := events.CommentUpdater{VCSClient: client}
+	When(client.FindComment(repoModel, pullModel, commentMarker, vcs.Github)).ThenReturn(nil, nil)
+	Ok(t, u.UpdateComment(repoModel, pullModel, "command plan default", "run 1", vcs.Github))
+	client.VerifyWasCalledOnce().CreateComment(repoModel, pullModel, commentMarker+"\nrun 1", vcs.Github)
+}
+
+func TestUpdateComment_FindErr(t *testing.T) {
+	t.Log("if finding the existing comment fails, an error is returned")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClientProxy()
+	u := events.CommentUpdater{VCSClient: client}
+	When(client.FindComment(repoModel, pullModel, commentMarker, vcs.Github)).ThenReturn(nil, errors.New("err"))
+	err := u.UpdateComment(repoModel, pullModel, "command plan default", "run 1", vcs.Github)
+	Assert(t, err != nil, "expected error")
+	Equals(t, "finding existing comment: err", err.Error())
+}
+
+func TestUpdateComment_PreviousRuns(t *testing.T) {
+	t.Log("updating a comment should move the latest run into the previous runs, newest first")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClientProxy()
+	u := events.CommentUpdater{VCSClient: client}
+
+	// Run 1 creates the comment.
+	When(client.FindComment(repoModel, pullModel, commentMarker, vcs.Github)).ThenReturn(nil, nil)
+	Ok(t, u.UpdateComment(repoModel, pullModel, "command plan default", "run 1", vcs.Github))
+	_, _, body, _ := client.VerifyWasCalledOnce().CreateComment(matchers.EqModelsRepo(repoModel), matchers.EqModelsPullRequest(pullModel), AnyString(), matchers.EqVcsHost(vcs.Github)).GetCapturedArguments()
+
+	// Runs 2 and 3 update it.
+	for _, run := range []string{"run 2", "run 3"} {
+		When(client.FindComment(repoModel, pullModel, commentMarker, vcs.Github)).ThenReturn(&vcs.Comment{ID: "1", Body: body}, nil)
+		Ok(t, u.UpdateComment(repoModel, pullModel, "command plan default", run, vcs.Github))
+		_, _, _, body, _ = client.VerifyWasCalled(AtLeast(1)).UpdateComment(matchers.EqModelsRepo(repoModel), matchers.EqModelsPullRequest(pullModel), EqString("1"), AnyString(), matchers.EqVcsHost(vcs.Github)).GetCapturedArguments()
+	}
+	Equals(t, commentMarker+"\nrun 3\n"+
+		"<!-- atlantis: previous runs -->\n"+
+		"<details><summary>Previous runs</summary>\n\n"+
+		"run 2\n<!-- atlantis: previous run -->\nrun 1\n"+
+		"</details>", body)
+}
+
+func TestUpdateComment_TooLong(t *testing.T) {
+	t.Log("the oldest previous runs should be dropped if the comment would be too long")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClientProxy()
+	u := events.CommentUpdater{VCSClient: client}
+	long := strings.Repeat("a", 40000)
+	existing := commentMarker + "\n" + long + "\n" +
+		"<!-- atlantis: previous runs -->\n" +
+		"<details><summary>Previous runs</summary>\n\n" +
+		"run 1\n" +
+		"</details>"
+	When(client.FindComment(repoModel, pullModel, commentMarker, vcs.Github)).ThenReturn(&vcs.Comment{ID: "1", Body: existing}, nil)
+	Ok(t, u.UpdateComment(repoModel, pullModel, "command plan default", long, vcs.Github))
+	client.VerifyWasCalledOnce().UpdateComment(repoModel, pullModel, "1", commentMarker+"\n"+long, vcs.Github)
+}
+
+func TestUpdateComment_LatestTooLong(t *testing.T) {
+	t.Log("the latest run should be truncated if it's too long on its own")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClientProxy()
+	u := events.CommentUpdater{VCSClient: client}
+	existing := commentMarker + "\nrun 1"
+	When(client.FindComment(repoModel, pullModel, commentMarker, vcs.Github)).ThenReturn(&vcs.Comment{ID: "1", Body: existing}, nil)
+	Ok(t, u.UpdateComment(repoModel, pullModel, "command plan default", strings.Repeat("a", 70000), vcs.Github))
+	warning := "\n\n**Warning**: Output truncated."
+	expBody := commentMarker + "\n" + strings.Repeat("a", 65536-len(commentMarker)-1-len(warning)) + warning
+	client.VerifyWasCalledOnce().UpdateComment(repoModel, pullModel, "1", expBody, vcs.Github)
+}
+
+func TestUpdateComment_LatestTooLongMarkup(t *testing.T) {
+	t.Log("truncating the latest run shouldn't split a character or leave its code block and details open")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClientProxy()
+	u := events.CommentUpdater{VCSClient: client}
+	When(client.FindComment(repoModel, pullModel, commentMarker, vcs.Github)).ThenReturn(nil, nil)
+	plan := "<details><summary>Show Output</summary>\n\n```diff\n" + strings.Repeat("é", 40000) + "\n```\n</details>"
+	Ok(t, u.UpdateComment(repoModel, pullModel, "command plan default", plan, vcs.Github))
+	_, _, body, _ := client.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString(), matchers.AnyVcsHost()).GetCapturedArguments()
+	Assert(t, len(body) <= 65536, "exp body to fit in a comment, was %d bytes", len(body))
+	Assert(t, utf8.ValidString(body), "exp body to be valid UTF-8")
+	Assert(t, strings.HasSuffix(body, "é\n```\n</details>\n\n**Warning**: Output truncated."), "exp the code block and details to be closed before the warning")
+}
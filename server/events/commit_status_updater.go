@@ -10,8 +10,14 @@ import (
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_commit_status_updater.go CommitStatusUpdater
 
+// aggregateStatusContext is the context of the commit status that summarizes
+// the result of a command across all projects.
+const aggregateStatusContext = "Atlantis"
+
 type CommitStatusUpdater interface {
-	Update(repo models.Repo, pull models.PullRequest, status vcs.CommitStatus, cmd *Command, host vcs.Host) error
+	// Update sets the status of cmd across all projects. url is linked from
+	// the status if it isn't empty.
+	Update(repo models.Repo, pull models.PullRequest, status vcs.CommitStatus, cmd *Command, url string, host vcs.Host) error
 	UpdateProjectResult(ctx *CommandContext, res CommandResponse) error
 }
 
@@ -19,23 +25,34 @@ type DefaultCommitStatusUpdater struct {
 	Client vcs.ClientProxy
 }
 
-func (d *DefaultCommitStatusUpdater) Update(repo models.Repo, pull models.PullRequest, status vcs.CommitStatus, cmd *Command, host vcs.Host) error {
-	description := fmt.Sprintf("%s %s", strings.Title(cmd.Name.String()), strings.Title(status.String()))
-	return d.Client.UpdateStatus(repo, pull, status, description, host)
+func (d *DefaultCommitStatusUpdater) Update(repo models.Repo, pull models.PullRequest, status vcs.CommitStatus, cmd *Command, url string, host vcs.Host) error {
+	return d.Client.UpdateStatus(repo, pull, status, aggregateStatusContext, statusDescription(cmd, status), url, host)
 }
 
+// UpdateProjectResult sets the status of each project in res and then the
+// aggregate status. If setting a project's status fails, the others and the
+// aggregate status are still set and the first error is returned.
 func (d *DefaultCommitStatusUpdater) UpdateProjectResult(ctx *CommandContext, res CommandResponse) error {
 	var status vcs.CommitStatus
+	var projectErr error
 	if res.Error != nil || res.Failure != "" {
 		status = vcs.Failed
 	} else {
 		var statuses []vcs.CommitStatus
 		for _, p := range res.ProjectResults {
 			statuses = append(statuses, p.Status())
+			// Each project gets its own status so that branch protection can
+			// require specific projects.
+			if err := d.Client.UpdateStatus(ctx.BaseRepo, ctx.Pull, p.Status(), projectStatusName(ctx.Command, p.ProjectPath), projectStatusDescription(ctx.Command, p), ctx.JobURL, ctx.VCSHost); err != nil && projectErr == nil {
+				projectErr = err
+			}
 		}
 		status = d.worstStatus(statuses)
 	}
-	return d.Update(ctx.BaseRepo, ctx.Pull, status, ctx.Command, ctx.VCSHost)
+	if err := d.Update(ctx.BaseRepo, ctx.Pull, status, ctx.Command, ctx.JobURL, ctx.VCSHost); err != nil {
+		return err
+	}
+	return projectErr
 }
 
 func (d *DefaultCommitStatusUpdater) worstStatus(ss []vcs.CommitStatus) vcs.CommitStatus {
@@ -46,3 +63,29 @@ func (d *DefaultCommitStatusUpdater) worstStatus(ss []vcs.CommitStatus) vcs.Comm
 	}
 	return vcs.Success
 }
+
+// commandStatusName returns the name of the status for cmd across all
+// projects, ex. "atlantis/plan".
+func commandStatusName(cmd *Command) string {
+	return "atlantis/" + cmd.Name.String()
+}
+
+// projectStatusName returns the name of the status for cmd in the project at
+// path, ex. "atlantis/plan: path/env".
+func projectStatusName(cmd *Command, path string) string {
+	return fmt.Sprintf("%s: %s/%s", commandStatusName(cmd), path, cmd.Environment)
+}
+
+// statusDescription returns a description of the status, ex. "Plan Success".
+func statusDescription(cmd *Command, status vcs.CommitStatus) string {
+	return fmt.Sprintf("%s %s", strings.Title(cmd.Name.String()), strings.Title(status.String()))
+}
+
+// projectStatusDescription returns a description of the status of a project's
+// result, ex. "Plan Success" or "Plan Timed Out".
+func projectStatusDescription(cmd *Command, p ProjectResult) string {
+	if p.TimedOut {
+		return fmt.Sprintf("%s Timed Out", strings.Title(cmd.Name.String()))
+	}
+	return statusDescription(cmd, p.Status())
+}
@@ -35,9 +35,9 @@ func TestUpdate(t *testing.T) {
 	RegisterMockTestingT(t)
 	client := mocks.NewMockClientProxy()
 	s := events.DefaultCommitStatusUpdater{Client: client}
-	err := s.Update(repoModel, pullModel, status, &cmd, vcs.Github)
+	err := s.Update(repoModel, pullModel, status, &cmd, "", vcs.Github)
 	Ok(t, err)
-	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, status, "Plan Success", vcs.Github)
+	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, status, "Atlantis", "Plan Success", "", vcs.Github)
 }
 
 func TestUpdateProjectResult_Error(t *testing.T) {
@@ -52,7 +52,7 @@ func TestUpdateProjectResult_Error(t *testing.T) {
 	s := events.DefaultCommitStatusUpdater{Client: client}
 	err := s.UpdateProjectResult(ctx, events.CommandResponse{Error: errors.New("err")})
 	Ok(t, err)
-	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Failed, "Plan Failed", vcs.Github)
+	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Failed, "Atlantis", "Plan Failed", "", vcs.Github)
 }
 
 func TestUpdateProjectResult_Failure(t *testing.T) {
@@ -67,7 +67,7 @@ func TestUpdateProjectResult_Failure(t *testing.T) {
 	s := events.DefaultCommitStatusUpdater{Client: client}
 	err := s.UpdateProjectResult(ctx, events.CommandResponse{Failure: "failure"})
 	Ok(t, err)
-	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Failed, "Plan Failed", vcs.Github)
+	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Failed, "Atlantis", "Plan Failed", "", vcs.Github)
 }
 
 func TestUpdateProjectResult(t *testing.T) {
@@ -135,6 +135,54 @@ func TestUpdateProjectResult(t *testing.T) {
 		s := events.DefaultCommitStatusUpdater{Client: client}
 		err := s.UpdateProjectResult(ctx, resp)
 		Ok(t, err)
-		client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, c.Expected, "Plan "+strings.Title(c.Expected.String()), vcs.Github)
+		client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, c.Expected, "Atlantis", "Plan "+strings.Title(c.Expected.String()), "", vcs.Github)
 	}
 }
+
+func TestUpdateProjectResult_PerProject(t *testing.T) {
+	t.Log("should set a status for each project as well as the aggregate status")
+	RegisterMockTestingT(t)
+	ctx := &events.CommandContext{
+		BaseRepo: repoModel,
+		Pull:     pullModel,
+		Command:  &events.Command{Name: events.Apply, Environment: "staging"},
+		VCSHost:  vcs.Gitlab,
+	}
+	client := mocks.NewMockClientProxy()
+	s := events.DefaultCommitStatusUpdater{Client: client}
+	err := s.UpdateProjectResult(ctx, events.CommandResponse{
+		ProjectResults: []events.ProjectResult{
+			{ProjectPath: "."},
+			{ProjectPath: "modules/db", Error: errors.New("err")},
+			{ProjectPath: "modules/vpc", TimedOut: true, Failure: "Timed out after 1m0s running `terraform apply`."},
+		},
+	})
+	Ok(t, err)
+	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Success, "atlantis/apply: ./staging", "Apply Success", "", vcs.Gitlab)
+	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Failed, "atlantis/apply: modules/db/staging", "Apply Failed", "", vcs.Gitlab)
+	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Failed, "atlantis/apply: modules/vpc/staging", "Apply Timed Out", "", vcs.Gitlab)
+	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Failed, "Atlantis", "Apply Failed", "", vcs.Gitlab)
+}
+
+func TestUpdateProjectResult_PerProjectErr(t *testing.T) {
+	t.Log("if setting a project's status fails the other statuses should still be set and the error returned")
+	RegisterMockTestingT(t)
+	ctx := &events.CommandContext{
+		BaseRepo: repoModel,
+		Pull:     pullModel,
+		Command:  &events.Command{Name: events.Plan, Environment: "staging"},
+		VCSHost:  vcs.Github,
+	}
+	client := mocks.NewMockClientProxy()
+	When(client.UpdateStatus(repoModel, pullModel, vcs.Success, "atlantis/plan: ./staging", "Plan Success", "", vcs.Github)).ThenReturn(errors.New("err"))
+	s := events.DefaultCommitStatusUpdater{Client: client}
+	err := s.UpdateProjectResult(ctx, events.CommandResponse{
+		ProjectResults: []events.ProjectResult{
+			{ProjectPath: "."},
+			{ProjectPath: "modules/db"},
+		},
+	})
+	Equals(t, errors.New("err"), err)
+	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Success, "atlantis/plan: modules/db/staging", "Plan Success", "", vcs.Github)
+	client.VerifyWasCalledOnce().UpdateStatus(repoModel, pullModel, vcs.Success, "Atlantis", "Plan Success", "", vcs.Github)
+}
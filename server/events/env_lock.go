@@ -8,12 +8,13 @@ import (
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_env_locker.go EnvLocker
 
 type EnvLocker interface {
-	TryLock(repoFullName string, env string, pullNum int) bool
-	Unlock(repoFullName, env string, pullNum int)
+	TryLock(repoHostname string, repoFullName string, env string, pullNum int) bool
+	Unlock(repoHostname string, repoFullName string, env string, pullNum int)
 }
 
 // EnvLock is used to prevent multiple runs and commands from occurring at the same time for a single
-// repo, pull, and environment
+// repo, pull, and environment. Repos are identified by their hostname as well as their full name since
+// repos on different hosts can have the same name.
 type EnvLock struct {
 	mutex sync.Mutex
 	locks map[string]interface{}
@@ -26,11 +27,11 @@ func NewEnvLock() *EnvLock {
 }
 
 // TryLock returns true if you acquired the lock and false if someone else already has the lock
-func (c *EnvLock) TryLock(repoFullName string, env string, pullNum int) bool {
+func (c *EnvLock) TryLock(repoHostname string, repoFullName string, env string, pullNum int) bool {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	key := c.key(repoFullName, env, pullNum)
+	key := c.key(repoHostname, repoFullName, env, pullNum)
 	if _, ok := c.locks[key]; !ok {
 		c.locks[key] = true
 		return true
@@ -39,12 +40,12 @@ func (c *EnvLock) TryLock(repoFullName string, env string, pullNum int) bool {
 }
 
 // Unlock unlocks the repo and environment
-func (c *EnvLock) Unlock(repoFullName, env string, pullNum int) {
+func (c *EnvLock) Unlock(repoHostname string, repoFullName string, env string, pullNum int) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	delete(c.locks, c.key(repoFullName, env, pullNum))
+	delete(c.locks, c.key(repoHostname, repoFullName, env, pullNum))
 }
 
-func (c *EnvLock) key(repo string, env string, pull int) string {
-	return fmt.Sprintf("%s/%s/%d", repo, env, pull)
+func (c *EnvLock) key(repoHostname string, repo string, env string, pull int) string {
+	return fmt.Sprintf("%s/%s/%s/%d", repoHostname, repo, env, pull)
 }
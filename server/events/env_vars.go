@@ -0,0 +1,279 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hootsuite/atlantis/server/events/run"
+	"github.com/hootsuite/atlantis/server/events/secrets"
+	"github.com/pkg/errors"
+)
+
+// EnvVar is an environment variable that's set for a project's commands.
+// Its value is either Value, the contents of File or the value of Secret.
+type EnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+	// File is the path to a file whose contents, without trailing newlines,
+	// are the value. For a project's config file it's relative to the
+	// project and must be in it.
+	File string `yaml:"file"`
+	// Secret is a reference to a secret in the secrets store whose value is
+	// the value, ex. secret/data/atlantis#db_password. For a project's config
+	// file it must be allowed by the server's AllowedSecrets.
+	Secret string `yaml:"secret"`
+	// Workspaces are the workspaces, or environments, it's set for. If
+	// empty, it's set for all of them.
+	Workspaces []string `yaml:"workspaces"`
+}
+
+// ServerEnvVar is an environment variable from the server's config that's
+// set for the commands of the projects it matches.
+type ServerEnvVar struct {
+	EnvVar
+	// Repo matches the repos it's set for like a --repo-whitelist rule
+	// matches {hostname}/{owner}/{repo}, ex. github.com/owner/*. If empty,
+	// it's set for all repos.
+	Repo string
+	// Path is the path of the project in the repo it's set for, ex. ., or
+	// all the projects if it's empty.
+	Path string
+}
+
+// Validate returns an error if e isn't a valid environment variable.
+func (e EnvVar) Validate() error {
+	if !envNameRegex.MatchString(e.Name) {
+		return fmt.Errorf("%q isn't a valid environment variable name", e.Name)
+	}
+	if run.ReservedEnvVar(e.Name) {
+		return fmt.Errorf("%s is set by Atlantis and can't be overridden", e.Name)
+	}
+	sources := 0
+	for _, s := range []string{e.Value, e.File, e.Secret} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("%s must set only one of value, file or secret", e.Name)
+	}
+	if e.Secret != "" {
+		if _, _, err := secrets.ParseRef(e.Secret); err != nil {
+			return errors.Wrap(err, e.Name)
+		}
+	}
+	return nil
+}
+
+// isSensitive returns true if e's value comes from somewhere that could hold
+// secrets, in which case it's redacted from output.
+func (e EnvVar) isSensitive() bool {
+	return e.File != "" || e.Secret != ""
+}
+
+func (e EnvVar) setFor(workspace string) bool {
+	if len(e.Workspaces) == 0 {
+		return true
+	}
+	for _, w := range e.Workspaces {
+		if w == workspace {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedSecret lets the projects in the repos it matches use the secrets at
+// the paths it matches for their own environment variables. Without one, a
+// pull request could read any secret the server can.
+type AllowedSecret struct {
+	// Repo matches the repos it applies to like a --repo-whitelist rule
+	// matches {hostname}/{owner}/{repo}, ex. github.com/owner/*. If empty,
+	// it applies to all repos.
+	Repo string
+	// Path is the path of the secrets it allows, ex. secret/data/infra. A *
+	// at the end matches any suffix, ex. secret/data/infra/*.
+	Path string
+}
+
+func (s ServerEnvVar) matches(repoHostname string, repoFullName string, projectPath string) bool {
+	if s.Path != "" && filepath.Clean(s.Path) != filepath.Clean(projectPath) {
+		return false
+	}
+	return s.Repo == "" || matchesRepo(s.Repo, repoHostname, repoFullName)
+}
+
+func (a AllowedSecret) matches(repoHostname string, repoFullName string, secretPath string) bool {
+	if a.Repo != "" && !matchesRepo(a.Repo, repoHostname, repoFullName) {
+		return false
+	}
+	return matchesPattern(strings.Trim(a.Path, "/"), secretPath)
+}
+
+// matchesRepo returns true if pattern matches the repo the same way a
+// --repo-whitelist rule does. The hostname is part of what's matched so repos
+// with the same name on different VCS hosts can't get each other's variables.
+func matchesRepo(pattern string, repoHostname string, repoFullName string) bool {
+	return (&RepoWhitelist{}).matchesRule(pattern, fmt.Sprintf("%s/%s", repoHostname, repoFullName))
+}
+
+// matchesPattern returns true if s is pattern or, if pattern ends with *,
+// starts with the rest of pattern.
+func matchesPattern(pattern string, s string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(s, strings.TrimSuffix(pattern, "*"))
+	}
+	return s == pattern
+}
+
+// EnvVarResolver finds the values of the environment variables for projects'
+// commands.
+type EnvVarResolver struct {
+	// ServerEnvVars are set for the projects they match. The project's own
+	// environment variables take precedence.
+	ServerEnvVars []ServerEnvVar
+	// Secrets is where the values of secrets are read from. If nil,
+	// environment variables that use secrets are an error.
+	Secrets secrets.Store
+	// AllowedSecrets are the secrets projects can use for their own
+	// environment variables. ServerEnvVars can use any secret.
+	AllowedSecrets []AllowedSecret
+}
+
+// Resolve returns the environment variables to set for the commands of the
+// project at projectPath in repoFullName on the VCS host repoHostname when
+// they're run for workspace.
+// projectDir is the project on disk and projectVars are the project's own
+// environment variables. It also returns the values that came from files or
+// secrets so they can be redacted. If no variables are set, the map is nil.
+// Errors never contain values.
+func (r *EnvVarResolver) Resolve(repoHostname string, repoFullName string, projectPath string, workspace string, projectDir string, projectVars []EnvVar) (map[string]string, []string, error) {
+	var vars []EnvVar
+	var fromProject []bool
+	if r != nil {
+		for _, s := range r.ServerEnvVars {
+			if s.matches(repoHostname, repoFullName, projectPath) {
+				vars = append(vars, s.EnvVar)
+				fromProject = append(fromProject, false)
+			}
+		}
+	}
+	for _, v := range projectVars {
+		vars = append(vars, v)
+		fromProject = append(fromProject, true)
+	}
+
+	var env map[string]string
+	var sensitive []string
+	for i, v := range vars {
+		if !v.setFor(workspace) {
+			continue
+		}
+		if env == nil {
+			env = make(map[string]string)
+		}
+		var value string
+		var err error
+		if fromProject[i] {
+			value, err = r.projectValue(v, repoHostname, repoFullName, projectDir)
+		} else {
+			value, err = r.value(v)
+		}
+		if err != nil {
+			return nil, sensitive, errors.Wrapf(err, "setting environment variable %s", v.Name)
+		}
+		env[v.Name] = value
+		if v.isSensitive() && value != "" {
+			sensitive = append(sensitive, value)
+		}
+	}
+	return env, sensitive, nil
+}
+
+// projectValue returns the value of v, one of the project's own variables.
+// Anyone who can open a pull request can change those so its file must be in
+// projectDir and its secret allowed by AllowedSecrets.
+func (r *EnvVarResolver) projectValue(v EnvVar, repoHostname string, repoFullName string, projectDir string) (string, error) {
+	switch {
+	case v.File != "":
+		if filepath.IsAbs(v.File) {
+			return "", fmt.Errorf("file %s must be relative to the project", v.File)
+		}
+		// Symlinks are resolved so they can't point outside the project.
+		path, err := filepath.EvalSymlinks(filepath.Join(projectDir, v.File))
+		if err != nil {
+			return "", errors.Wrapf(err, "reading %s", v.File)
+		}
+		dir, err := filepath.EvalSymlinks(projectDir)
+		if err != nil {
+			return "", errors.Wrapf(err, "reading %s", v.File)
+		}
+		if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("file %s must be in the project", v.File)
+		}
+		return readValue(path, v.File)
+	case v.Secret != "":
+		path, _, err := secrets.ParseRef(v.Secret)
+		if err != nil {
+			return "", err
+		}
+		if r != nil && !r.secretAllowed(repoHostname, repoFullName, path) {
+			return "", fmt.Errorf("secret %q isn't allowed for %s/%s by the server's project-secrets", path, repoHostname, repoFullName)
+		}
+	}
+	return r.value(v)
+}
+
+// secretAllowed returns true if the projects in repoFullName on repoHostname
+// can use the secret at path.
+func (r *EnvVarResolver) secretAllowed(repoHostname string, repoFullName string, path string) bool {
+	// Paths like secret/data/infra/../other would get around the patterns.
+	for _, part := range strings.Split(path, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	for _, a := range r.AllowedSecrets {
+		if a.matches(repoHostname, repoFullName, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// value returns the value of v.
+func (r *EnvVarResolver) value(v EnvVar) (string, error) {
+	switch {
+	case v.File != "":
+		return readValue(v.File, v.File)
+	case v.Secret != "":
+		if r == nil || r.Secrets == nil {
+			return "", errors.New("no secrets store is configured")
+		}
+		path, key, err := secrets.ParseRef(v.Secret)
+		if err != nil {
+			return "", err
+		}
+		return r.Secrets.Get(path, key)
+	}
+	return v.Value, nil
+}
+
+// readValue returns the contents of the file at path without trailing
+// newlines. name is the file in errors.
+func readValue(path string, name string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading %s", name)
+	}
+	return strings.TrimRight(string(contents), "\r\n"), nil
+}
+
+// commandContext returns ctx with the project's environment variables set
+// for the commands run with it.
+func (c *ProjectConfig) commandContext(ctx context.Context) context.Context {
+	return run.WithEnv(ctx, c.Env)
+}
@@ -0,0 +1,133 @@
+package events_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events"
+	"github.com/hootsuite/atlantis/server/events/secrets"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Log("the variables for the project and workspace should be returned, with the project's taking precedence")
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	Ok(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("server-token\n"), 0600))
+	r := &events.EnvVarResolver{
+		ServerEnvVars: []events.ServerEnvVar{
+			{EnvVar: events.EnvVar{Name: "ALL", Value: "all"}},
+			{EnvVar: events.EnvVar{Name: "TOKEN", File: filepath.Join(dir, "token")}, Repo: "github.com/owner/*"},
+			{EnvVar: events.EnvVar{Name: "OTHER_REPO", Value: "x"}, Repo: "github.com/other/repo"},
+			{EnvVar: events.EnvVar{Name: "OTHER_PATH", Value: "x"}, Repo: "github.com/owner/repo", Path: "other"},
+			{EnvVar: events.EnvVar{Name: "REGION", Value: "server-region"}, Path: "infra"},
+		},
+	}
+	env, sensitive, err := r.Resolve("github.com", "owner/repo", "infra", "staging", dir, []events.EnvVar{
+		{Name: "REGION", Value: "us-east-1"},
+		{Name: "PROD_ONLY", Value: "x", Workspaces: []string{"production"}},
+		{Name: "STAGING_ONLY", Value: "staging", Workspaces: []string{"production", "staging"}},
+	})
+	Ok(t, err)
+	Equals(t, map[string]string{"ALL": "all", "TOKEN": "server-token", "REGION": "us-east-1", "STAGING_ONLY": "staging"}, env)
+	Equals(t, []string{"server-token"}, sensitive)
+}
+
+func TestResolve_Errors(t *testing.T) {
+	t.Log("variables whose values can't be found should be an error that doesn't contain any values")
+	var r *events.EnvVarResolver
+	env, _, err := r.Resolve("github.com", "owner/repo", ".", "default", "/tmp", nil)
+	Ok(t, err)
+	Assert(t, env == nil, "exp no variables")
+
+	_, _, err = r.Resolve("github.com", "owner/repo", ".", "default", "/tmp", []events.EnvVar{{Name: "A", Secret: "secret/a#key"}})
+	Equals(t, "setting environment variable A: no secrets store is configured", err.Error())
+	_, _, err = r.Resolve("github.com", "owner/repo", ".", "default", "/tmp", []events.EnvVar{{Name: "A", File: "atlantis-missing-file"}})
+	Equals(t, "setting environment variable A: reading atlantis-missing-file: lstat /tmp/atlantis-missing-file: no such file or directory", err.Error())
+}
+
+func TestResolve_ProjectFiles(t *testing.T) {
+	t.Log("the project's own variables should only be able to read files in the project")
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	projectDir := filepath.Join(dir, "project")
+	Ok(t, os.MkdirAll(filepath.Join(projectDir, "creds"), 0700))
+	Ok(t, ioutil.WriteFile(filepath.Join(projectDir, "creds", "token"), []byte("project-token\n"), 0600))
+	Ok(t, ioutil.WriteFile(filepath.Join(dir, "server-token"), []byte("server-token\n"), 0600))
+	Ok(t, os.Symlink(filepath.Join(dir, "server-token"), filepath.Join(projectDir, "link")))
+	r := &events.EnvVarResolver{}
+
+	env, _, err := r.Resolve("github.com", "owner/repo", ".", "default", projectDir, []events.EnvVar{{Name: "A", File: "creds/../creds/token"}})
+	Ok(t, err)
+	Equals(t, map[string]string{"A": "project-token"}, env)
+
+	cases := map[string]string{
+		filepath.Join(dir, "server-token"): "setting environment variable A: file " + filepath.Join(dir, "server-token") + " must be relative to the project",
+		"../server-token":                  "setting environment variable A: file ../server-token must be in the project",
+		"link":                             "setting environment variable A: file link must be in the project",
+	}
+	for file, expErr := range cases {
+		_, _, err := r.Resolve("github.com", "owner/repo", ".", "default", projectDir, []events.EnvVar{{Name: "A", File: file}})
+		Assert(t, err != nil, "exp an error for %s", file)
+		Equals(t, expErr, err.Error())
+	}
+}
+
+func TestResolve_ProjectSecrets(t *testing.T) {
+	t.Log("the project's own variables should only be able to read the secrets the server allows for the repo")
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	Ok(t, ioutil.WriteFile(filepath.Join(dir, "secrets.yaml"), []byte("{secret/infra/db: {password: a}, secret/other: {password: b}}"), 0600))
+	r := &events.EnvVarResolver{
+		ServerEnvVars:  []events.ServerEnvVar{{EnvVar: events.EnvVar{Name: "SERVER", Secret: "secret/other#password"}}},
+		Secrets:        &secrets.LocalStore{Path: filepath.Join(dir, "secrets.yaml")},
+		AllowedSecrets: []events.AllowedSecret{{Repo: "github.com/owner/*", Path: "secret/infra/*"}},
+	}
+
+	env, _, err := r.Resolve("github.com", "owner/repo", ".", "default", dir, []events.EnvVar{{Name: "A", Secret: "secret/infra/db#password"}})
+	Ok(t, err)
+	Equals(t, map[string]string{"SERVER": "b", "A": "a"}, env)
+
+	cases := []struct {
+		host   string
+		repo   string
+		secret string
+		expErr string
+	}{
+		{"github.com", "owner/repo", "secret/other#password", `setting environment variable A: secret "secret/other" isn't allowed for github.com/owner/repo by the server's project-secrets`},
+		{"github.com", "owner/repo", "secret/infra/../other#password", `setting environment variable A: secret "secret/infra/../other" isn't allowed for github.com/owner/repo by the server's project-secrets`},
+		{"github.com", "other/repo", "secret/infra/db#password", `setting environment variable A: secret "secret/infra/db" isn't allowed for github.com/other/repo by the server's project-secrets`},
+		{"gitlab.example.com", "owner/repo", "secret/infra/db#password", `setting environment variable A: secret "secret/infra/db" isn't allowed for gitlab.example.com/owner/repo by the server's project-secrets`},
+	}
+	for _, c := range cases {
+		_, _, err := r.Resolve(c.host, c.repo, ".", "default", dir, []events.EnvVar{{Name: "A", Secret: c.secret}})
+		Assert(t, err != nil, "exp an error for %s", c.secret)
+		Equals(t, c.expErr, err.Error())
+	}
+}
+
+func TestResolve_SameRepoOnDifferentHosts(t *testing.T) {
+	t.Log("variables for a repo on one VCS host shouldn't be set for a repo with the same name on another")
+	r := &events.EnvVarResolver{
+		ServerEnvVars: []events.ServerEnvVar{
+			{EnvVar: events.EnvVar{Name: "TOKEN", Value: "github"}, Repo: "github.com/owner/repo"},
+			{EnvVar: events.EnvVar{Name: "TOKEN", Value: "gitlab"}, Repo: "gitlab.example.com/owner/*"},
+		},
+	}
+	env, _, err := r.Resolve("github.com", "owner/repo", ".", "default", "/tmp", nil)
+	Ok(t, err)
+	Equals(t, map[string]string{"TOKEN": "github"}, env)
+
+	env, _, err = r.Resolve("gitlab.example.com", "owner/repo", ".", "default", "/tmp", nil)
+	Ok(t, err)
+	Equals(t, map[string]string{"TOKEN": "gitlab"}, env)
+
+	env, _, err = r.Resolve("evil.example.com", "owner/repo", ".", "default", "/tmp", nil)
+	Ok(t, err)
+	Assert(t, env == nil, "exp no variables")
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/pkg/errors"
+)
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_github_check_run_creator.go GithubCheckRunCreator
+
+type GithubCheckRunCreator interface {
+	CreateCheckRun(repo models.Repo, pull models.PullRequest, run vcs.CheckRun) error
+}
+
+// tfErrRegex matches the first line of a terraform >= 0.12 error.
+var tfErrRegex = regexp.MustCompile(`^Error: (.+)$`)
+
+// tfErrLocationRegex matches the line of a terraform >= 0.12 error that
+// points to where the error is, ex. "  on main.tf line 12, in resource...".
+var tfErrLocationRegex = regexp.MustCompile(`^\s+on (\S+) line (\d+)`)
+
+// tfParseErrRegex matches terraform < 0.12 config parsing errors, ex.
+// "Error parsing /path/main.tf: At 3:5: unexpected token".
+var tfParseErrRegex = regexp.MustCompile(`Error parsing (\S+): At (\d+):\d+: (.+)$`)
+
+// GithubChecksUpdater is a CommitStatusUpdater that reports the results of
+// commands on GitHub as check runs. It creates a check run for each
+// project as well as one for the command as a whole. Terraform errors that
+// reference a file and line are added as annotations.
+// Commands on other VCS hosts are passed to Fallback.
+type GithubChecksUpdater struct {
+	Client   GithubCheckRunCreator
+	Fallback CommitStatusUpdater
+}
+
+func (g *GithubChecksUpdater) Update(repo models.Repo, pull models.PullRequest, status vcs.CommitStatus, cmd *Command, url string, host vcs.Host) error {
+	if host != vcs.Github {
+		return g.Fallback.Update(repo, pull, status, cmd, url, host)
+	}
+	return g.Client.CreateCheckRun(repo, pull, vcs.CheckRun{
+		Name:       commandStatusName(cmd),
+		Status:     status,
+		Title:      statusDescription(cmd, status),
+		Summary:    fmt.Sprintf("Running `%s` for environment `%s`.", cmd.Name.String(), cmd.Environment),
+		DetailsURL: url,
+	})
+}
+
+// UpdateProjectResult creates a check run for each project in res and then
+// the aggregate one. If creating a project's check run fails, the others and
+// the aggregate one, which is failed, are still created and the errors are
+// returned together.
+func (g *GithubChecksUpdater) UpdateProjectResult(ctx *CommandContext, res CommandResponse) error {
+	if ctx.VCSHost != vcs.Github {
+		return g.Fallback.UpdateProjectResult(ctx, res)
+	}
+
+	if res.Error != nil || res.Failure != "" {
+		summary := res.Failure
+		if res.Error != nil {
+			summary = "```\n" + res.Error.Error() + "\n```"
+		}
+		return g.Client.CreateCheckRun(ctx.BaseRepo, ctx.Pull, vcs.CheckRun{
+			Name:       commandStatusName(ctx.Command),
+			Status:     vcs.Failed,
+			Title:      statusDescription(ctx.Command, vcs.Failed),
+			Summary:    summary,
+			DetailsURL: ctx.JobURL,
+		})
+	}
+
+	status := vcs.Success
+	var projectSummaries []string
+	var projectErrs []string
+	for _, p := range res.ProjectResults {
+		run := g.projectCheckRun(ctx, p)
+		if err := g.Client.CreateCheckRun(ctx.BaseRepo, ctx.Pull, run); err != nil {
+			projectErrs = append(projectErrs, fmt.Sprintf("creating check run for %s: %s", p.ProjectPath, err))
+			status = vcs.Failed
+			projectSummaries = append(projectSummaries, fmt.Sprintf("- `%s`: %s, but its check run couldn't be created", p.ProjectPath, run.Status.String()))
+			continue
+		}
+		if run.Status == vcs.Failed {
+			status = vcs.Failed
+		}
+		projectSummaries = append(projectSummaries, fmt.Sprintf("- `%s`: %s", p.ProjectPath, run.Status.String()))
+	}
+	if err := g.Client.CreateCheckRun(ctx.BaseRepo, ctx.Pull, vcs.CheckRun{
+		Name:       commandStatusName(ctx.Command),
+		Status:     status,
+		Title:      statusDescription(ctx.Command, status),
+		Summary:    strings.Join(projectSummaries, "\n"),
+		DetailsURL: ctx.JobURL,
+	}); err != nil {
+		projectErrs = append(projectErrs, err.Error())
+	}
+	if len(projectErrs) > 0 {
+		return errors.New(strings.Join(projectErrs, "; "))
+	}
+	return nil
+}
+
+// projectCheckRun returns the check run for a single project's result.
+func (g *GithubChecksUpdater) projectCheckRun(ctx *CommandContext, p ProjectResult) vcs.CheckRun {
+	run := vcs.CheckRun{
+		Name:       projectStatusName(ctx.Command, p.ProjectPath),
+		Status:     p.Status(),
+		Title:      projectStatusDescription(ctx.Command, p),
+		DetailsURL: ctx.JobURL,
+	}
+	switch {
+	case p.Error != nil:
+		run.Summary = "```\n" + p.Error.Error() + "\n```"
+		run.Annotations = g.annotations(ctx, p.ProjectPath, p.Error.Error())
+	case p.Failure != "":
+		run.Summary = p.Failure
+	case p.PlanSuccess != nil:
+		run.Summary = fmt.Sprintf("To discard this plan, delete the [lock](%s).", p.PlanSuccess.LockURL)
+		run.Text = "```diff\n" + p.PlanSuccess.TerraformOutput + "\n```"
+	default:
+		run.Summary = "```diff\n" + p.ApplySuccess + "\n```"
+	}
+	return run
+}
+
+// annotations parses terraform errors in output that reference a file and
+// line. projectPath is the path of the project relative to the repo root.
+func (g *GithubChecksUpdater) annotations(ctx *CommandContext, projectPath string, output string) []vcs.CheckRunAnnotation {
+	var annotations []vcs.CheckRunAnnotation
+	var lastErr string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := tfParseErrRegex.FindStringSubmatch(line); match != nil {
+			if a, ok := g.annotation(ctx, projectPath, match[1], match[2], match[3]); ok {
+				annotations = append(annotations, a)
+			}
+			continue
+		}
+		if match := tfErrRegex.FindStringSubmatch(line); match != nil {
+			lastErr = match[1]
+			continue
+		}
+		if match := tfErrLocationRegex.FindStringSubmatch(line); match != nil && lastErr != "" {
+			if a, ok := g.annotation(ctx, projectPath, match[1], match[2], lastErr); ok {
+				annotations = append(annotations, a)
+			}
+		}
+	}
+	return annotations
+}
+
+// annotation returns an annotation for file at line. If file is relative it's
+// relative to the project. If it's absolute it must be inside the directory
+// the repo was cloned into, otherwise ok is false.
+func (g *GithubChecksUpdater) annotation(ctx *CommandContext, projectPath string, file string, line string, message string) (a vcs.CheckRunAnnotation, ok bool) {
+	lineNum, err := strconv.Atoi(line)
+	if err != nil {
+		return a, false
+	}
+	path := filepath.Join(projectPath, file)
+	if filepath.IsAbs(file) {
+		// Clones are at {data-dir}/repos/{owner}/{repo}/{pull}/{env}/.
+		cloneSuffix := string(filepath.Separator) + filepath.Join(ctx.BaseRepo.FullName, strconv.Itoa(ctx.Pull.Num), ctx.Command.Environment) + string(filepath.Separator)
+		i := strings.Index(file, cloneSuffix)
+		if i == -1 {
+			return a, false
+		}
+		path = file[i+len(cloneSuffix):]
+	}
+	return vcs.CheckRunAnnotation{
+		Path:    filepath.ToSlash(path),
+		Line:    lineNum,
+		Message: message,
+	}, true
+}
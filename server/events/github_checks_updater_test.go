@@ -0,0 +1,192 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events"
+	"github.com/hootsuite/atlantis/server/events/mocks"
+	"github.com/hootsuite/atlantis/server/events/mocks/matchers"
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	. "github.com/hootsuite/atlantis/testing"
+	. "github.com/petergtz/pegomock"
+)
+
+var checksRepo = models.Repo{FullName: "owner/repo"}
+var checksPull = models.PullRequest{Num: 1}
+var checksCtx = &events.CommandContext{
+	BaseRepo: checksRepo,
+	Pull:     checksPull,
+	Command:  &events.Command{Name: events.Plan, Environment: "default"},
+	VCSHost:  vcs.Github,
+}
+
+func TestGithubChecksUpdater_Update(t *testing.T) {
+	t.Log("should create a check run for the command")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockGithubCheckRunCreator()
+	u := events.GithubChecksUpdater{Client: client}
+	err := u.Update(checksRepo, checksPull, vcs.Pending, checksCtx.Command, "", vcs.Github)
+	Ok(t, err)
+	client.VerifyWasCalledOnce().CreateCheckRun(checksRepo, checksPull, vcs.CheckRun{
+		Name:    "atlantis/plan",
+		Status:  vcs.Pending,
+		Title:   "Plan Pending",
+		Summary: "Running `plan` for environment `default`.",
+	})
+}
+
+func TestGithubChecksUpdater_Fallback(t *testing.T) {
+	t.Log("should use the fallback for other VCS hosts")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockGithubCheckRunCreator()
+	fallback := mocks.NewMockCommitStatusUpdater()
+	u := events.GithubChecksUpdater{Client: client, Fallback: fallback}
+	ctx := &events.CommandContext{
+		BaseRepo: checksRepo,
+		Pull:     checksPull,
+		Command:  checksCtx.Command,
+		VCSHost:  vcs.Gitlab,
+	}
+
+	Ok(t, u.Update(checksRepo, checksPull, vcs.Pending, ctx.Command, "", vcs.Gitlab))
+	Ok(t, u.UpdateProjectResult(ctx, events.CommandResponse{}))
+	fallback.VerifyWasCalledOnce().Update(checksRepo, checksPull, vcs.Pending, ctx.Command, "", vcs.Gitlab)
+	fallback.VerifyWasCalledOnce().UpdateProjectResult(ctx, events.CommandResponse{})
+	client.VerifyWasCalled(Never()).CreateCheckRun(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsCheckRun())
+}
+
+func TestGithubChecksUpdater_UpdateProjectResultError(t *testing.T) {
+	t.Log("should fail the command's check run if the command errored")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockGithubCheckRunCreator()
+	u := events.GithubChecksUpdater{Client: client}
+	err := u.UpdateProjectResult(checksCtx, events.CommandResponse{Failure: "No Terraform files were modified."})
+	Ok(t, err)
+	client.VerifyWasCalledOnce().CreateCheckRun(checksRepo, checksPull, vcs.CheckRun{
+		Name:    "atlantis/plan",
+		Status:  vcs.Failed,
+		Title:   "Plan Failed",
+		Summary: "No Terraform files were modified.",
+	})
+}
+
+func TestGithubChecksUpdater_UpdateProjectResult(t *testing.T) {
+	t.Log("should create a check run per project and one for the command")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockGithubCheckRunCreator()
+	u := events.GithubChecksUpdater{Client: client}
+	err := u.UpdateProjectResult(checksCtx, events.CommandResponse{
+		ProjectResults: []events.ProjectResult{
+			{
+				ProjectPath: "staging",
+				PlanSuccess: &events.PlanSuccess{
+					TerraformOutput: "No changes.",
+					LockURL:         "lock-url",
+				},
+			},
+			{
+				ProjectPath: "production",
+				Failure:     "This project is currently locked.",
+			},
+		},
+	})
+	Ok(t, err)
+
+	_, _, runs := client.VerifyWasCalled(Times(3)).CreateCheckRun(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsCheckRun()).GetAllCapturedArguments()
+	Equals(t, []vcs.CheckRun{
+		{
+			Name:    "atlantis/plan: staging/default",
+			Status:  vcs.Success,
+			Title:   "Plan Success",
+			Summary: "To discard this plan, delete the [lock](lock-url).",
+			Text:    "```diff\nNo changes.\n```",
+		},
+		{
+			Name:    "atlantis/plan: production/default",
+			Status:  vcs.Failed,
+			Title:   "Plan Failed",
+			Summary: "This project is currently locked.",
+		},
+		{
+			Name:    "atlantis/plan",
+			Status:  vcs.Failed,
+			Title:   "Plan Failed",
+			Summary: "- `staging`: success\n- `production`: failed",
+		},
+	}, runs)
+}
+
+func TestGithubChecksUpdater_UpdateProjectResultClientErr(t *testing.T) {
+	t.Log("if a project's check run can't be created the others and a failed one for the command should still be created")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockGithubCheckRunCreator()
+	u := events.GithubChecksUpdater{Client: client}
+	stagingRun := vcs.CheckRun{
+		Name:    "atlantis/plan: staging/default",
+		Status:  vcs.Success,
+		Title:   "Plan Success",
+		Summary: "To discard this plan, delete the [lock](lock-url).",
+		Text:    "```diff\nNo changes.\n```",
+	}
+	When(client.CreateCheckRun(checksRepo, checksPull, stagingRun)).ThenReturn(errors.New("err"))
+	err := u.UpdateProjectResult(checksCtx, events.CommandResponse{
+		ProjectResults: []events.ProjectResult{
+			{ProjectPath: "staging", PlanSuccess: &events.PlanSuccess{TerraformOutput: "No changes.", LockURL: "lock-url"}},
+			{ProjectPath: "production", PlanSuccess: &events.PlanSuccess{TerraformOutput: "No changes.", LockURL: "lock-url"}},
+		},
+	})
+	Equals(t, "creating check run for staging: err", err.Error())
+
+	_, _, runs := client.VerifyWasCalled(Times(3)).CreateCheckRun(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsCheckRun()).GetAllCapturedArguments()
+	Equals(t, "atlantis/plan: production/default", runs[1].Name)
+	Equals(t, vcs.CheckRun{
+		Name:    "atlantis/plan",
+		Status:  vcs.Failed,
+		Title:   "Plan Failed",
+		Summary: "- `staging`: success, but its check run couldn't be created\n- `production`: success",
+	}, runs[2])
+}
+
+func TestGithubChecksUpdater_Annotations(t *testing.T) {
+	t.Log("should annotate terraform errors that reference a file and line")
+	RegisterMockTestingT(t)
+	cases := []struct {
+		description string
+		output      string
+		expected    []vcs.CheckRunAnnotation
+	}{
+		{
+			"no file references",
+			"exit status 1\nError: something went wrong",
+			nil,
+		},
+		{
+			"terraform >= 0.12 error",
+			"exit status 1\nError: Unsupported argument\n\n  on main.tf line 12, in resource \"null_resource\" \"a\":\n  12:   foo = \"bar\"\n",
+			[]vcs.CheckRunAnnotation{{Path: "staging/main.tf", Line: 12, Message: "Unsupported argument"}},
+		},
+		{
+			"terraform < 0.12 parse error in the clone",
+			"exit status 1\nError: Error parsing /data/repos/owner/repo/1/default/staging/main.tf: At 3:5: unexpected token",
+			[]vcs.CheckRunAnnotation{{Path: "staging/main.tf", Line: 3, Message: "unexpected token"}},
+		},
+		{
+			"terraform < 0.12 parse error outside the clone",
+			"exit status 1\nError: Error parsing /tmp/module/main.tf: At 3:5: unexpected token",
+			nil,
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.description)
+		client := mocks.NewMockGithubCheckRunCreator()
+		u := events.GithubChecksUpdater{Client: client}
+		err := u.UpdateProjectResult(checksCtx, events.CommandResponse{
+			ProjectResults: []events.ProjectResult{{ProjectPath: "staging", Error: errors.New(c.output)}},
+		})
+		Ok(t, err)
+		_, _, runs := client.VerifyWasCalled(Times(2)).CreateCheckRun(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsCheckRun()).GetAllCapturedArguments()
+		Equals(t, c.expected, runs[0].Annotations)
+	}
+}
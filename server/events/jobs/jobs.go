@@ -0,0 +1,220 @@
+// Package jobs keeps the output of running commands so it can be streamed to
+// the Atlantis UI while they run and viewed after they're done.
+package jobs
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is the number of lines a subscriber can fall behind by
+// before it's dropped.
+const subscriberBuffer = 1000
+
+// Job is the output of a single command, ex. "atlantis plan staging" on one
+// pull request. It's an io.Writer so the output of terraform and the
+// project's hooks can be written to it as they run.
+type Job struct {
+	ID string
+	// RepoHostname is the hostname of the VCS host the repo is on, ex.
+	// "github.com".
+	RepoHostname string
+	RepoFullName string
+	PullNum      int
+	PullURL      string
+	Command      string
+	Environment  string
+	Started      time.Time
+	// Redact masks secrets in each line before it's stored. If nil, lines are
+	// stored as is.
+	Redact func(line string) string
+
+	seq         int
+	mutex       sync.Mutex
+	lines       []string
+	partial     []byte
+	finished    time.Time
+	subscribers map[chan string]bool
+}
+
+// Write appends the complete lines in p to the job's output and sends them to
+// the subscribers. A trailing partial line is held until it's completed or
+// the job finishes.
+func (j *Job) Write(p []byte) (int, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if !j.finished.IsZero() {
+		return 0, fmt.Errorf("job %s is finished", j.ID)
+	}
+	j.partial = append(j.partial, p...)
+	for {
+		i := bytes.IndexByte(j.partial, '\n')
+		if i < 0 {
+			break
+		}
+		j.addLine(string(j.partial[:i]))
+		j.partial = j.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Finish marks the job as finished and closes the subscribers' channels.
+func (j *Job) Finish() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if !j.finished.IsZero() {
+		return
+	}
+	if len(j.partial) > 0 {
+		j.addLine(string(j.partial))
+		j.partial = nil
+	}
+	j.finished = time.Now()
+	for ch := range j.subscribers {
+		close(ch)
+	}
+	j.subscribers = nil
+}
+
+// Finished returns when the job finished or the zero time if it's still
+// running.
+func (j *Job) Finished() time.Time {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.finished
+}
+
+// Lines returns the output of the job so far.
+func (j *Job) Lines() []string {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return append([]string(nil), j.lines...)
+}
+
+// Subscribe returns the output of the job so far and a channel that receives
+// the lines written after that. The channel is closed when the job finishes,
+// immediately if it already has, or if the subscriber falls too far behind,
+// in which case it should subscribe again. Unsubscribe must be called with
+// the channel once it's no longer read.
+func (j *Job) Subscribe() ([]string, <-chan string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	ch := make(chan string, subscriberBuffer)
+	if !j.finished.IsZero() {
+		close(ch)
+	} else {
+		if j.subscribers == nil {
+			j.subscribers = make(map[chan string]bool)
+		}
+		j.subscribers[ch] = true
+	}
+	return append([]string(nil), j.lines...), ch
+}
+
+// Unsubscribe stops sending lines to ch.
+func (j *Job) Unsubscribe(ch <-chan string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	for sub := range j.subscribers {
+		if sub == ch {
+			delete(j.subscribers, sub)
+			close(sub)
+		}
+	}
+}
+
+// addLine must be called with the mutex held.
+func (j *Job) addLine(line string) {
+	if j.Redact != nil {
+		line = j.Redact(line)
+	}
+	j.lines = append(j.lines, line)
+	for ch := range j.subscribers {
+		select {
+		case ch <- line:
+		default:
+			// Don't let a slow subscriber block the command.
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Store keeps the running jobs and the most recently finished ones.
+type Store struct {
+	// MaxFinished is the number of finished jobs that are kept. Older ones are
+	// dropped when a job finishes.
+	MaxFinished int
+
+	mutex  sync.Mutex
+	jobs   map[string]*Job
+	nextID int
+}
+
+// NewStore returns a store that keeps up to maxFinished finished jobs.
+func NewStore(maxFinished int) *Store {
+	return &Store{
+		MaxFinished: maxFinished,
+		jobs:        make(map[string]*Job),
+	}
+}
+
+// Start adds job to the store with a new ID and start time.
+func (s *Store) Start(job *Job) *Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.jobs == nil {
+		s.jobs = make(map[string]*Job)
+	}
+	s.nextID++
+	job.seq = s.nextID
+	job.Started = time.Now()
+	// The start time is included so IDs aren't reused after a restart, which
+	// would make old links point at the wrong job.
+	job.ID = fmt.Sprintf("%d-%d", job.Started.Unix(), s.nextID)
+	s.jobs[job.ID] = job
+	return job
+}
+
+// Finish finishes job and drops the oldest finished jobs if there are more
+// than MaxFinished.
+func (s *Store) Finish(job *Job) {
+	job.Finish()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var finished []*Job
+	for _, j := range s.jobs {
+		if !j.Finished().IsZero() {
+			finished = append(finished, j)
+		}
+	}
+	if len(finished) <= s.MaxFinished {
+		return
+	}
+	sort.Slice(finished, func(i, k int) bool { return finished[i].seq < finished[k].seq })
+	for _, j := range finished[:len(finished)-s.MaxFinished] {
+		delete(s.jobs, j.ID)
+	}
+}
+
+// Get returns the job with id or nil if it doesn't exist.
+func (s *Store) Get(id string) *Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.jobs[id]
+}
+
+// List returns the jobs in the store, most recently started first.
+func (s *Store) List() []*Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var jobs []*Job
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].seq > jobs[k].seq })
+	return jobs
+}
@@ -0,0 +1,105 @@
+package jobs_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events/jobs"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestJob_Write(t *testing.T) {
+	t.Log("complete lines should be stored and partial lines held until they're completed or the job finishes")
+	job := &jobs.Job{}
+	n, err := job.Write([]byte("line1\nli"))
+	Ok(t, err)
+	Equals(t, 8, n)
+	Equals(t, []string{"line1"}, job.Lines())
+	_, err = job.Write([]byte("ne2\nline3"))
+	Ok(t, err)
+	Equals(t, []string{"line1", "line2"}, job.Lines())
+	job.Finish()
+	Equals(t, []string{"line1", "line2", "line3"}, job.Lines())
+	Assert(t, !job.Finished().IsZero(), "job should be finished")
+
+	t.Log("writing to a finished job should error")
+	_, err = job.Write([]byte("line4\n"))
+	Assert(t, err != nil, "expected error")
+}
+
+func TestJob_Redact(t *testing.T) {
+	t.Log("lines should be redacted before they're stored")
+	job := &jobs.Job{Redact: func(line string) string { return strings.Replace(line, "secret", "[redacted]", -1) }}
+	_, err := job.Write([]byte("password: secret\n"))
+	Ok(t, err)
+	Equals(t, []string{"password: [redacted]"}, job.Lines())
+}
+
+func TestJob_Subscribe(t *testing.T) {
+	t.Log("subscribers should get the output so far and then each new line until the job finishes")
+	job := &jobs.Job{}
+	_, err := job.Write([]byte("line1\n"))
+	Ok(t, err)
+	lines, ch := job.Subscribe()
+	Equals(t, []string{"line1"}, lines)
+	_, err = job.Write([]byte("line2\n"))
+	Ok(t, err)
+	job.Finish()
+	var received []string
+	for line := range ch {
+		received = append(received, line)
+	}
+	Equals(t, []string{"line2"}, received)
+
+	t.Log("subscribing to a finished job should return a closed channel")
+	lines, ch = job.Subscribe()
+	Equals(t, []string{"line1", "line2"}, lines)
+	_, ok := <-ch
+	Equals(t, false, ok)
+}
+
+func TestJob_Unsubscribe(t *testing.T) {
+	t.Log("unsubscribing should close the channel")
+	job := &jobs.Job{}
+	_, ch := job.Subscribe()
+	job.Unsubscribe(ch)
+	_, ok := <-ch
+	Equals(t, false, ok)
+	_, err := job.Write([]byte("line\n"))
+	Ok(t, err)
+}
+
+func TestJob_SlowSubscriber(t *testing.T) {
+	t.Log("subscribers that fall too far behind should be dropped so they don't block the command")
+	job := &jobs.Job{}
+	_, ch := job.Subscribe()
+	for i := 0; i < 2000; i++ {
+		_, err := fmt.Fprintf(job, "line%d\n", i)
+		Ok(t, err)
+	}
+	received := 0
+	for range ch {
+		received++
+	}
+	Assert(t, received < 2000, "subscriber should have been dropped, got %d lines", received)
+	Equals(t, 2000, len(job.Lines()))
+}
+
+func TestStore(t *testing.T) {
+	t.Log("the store should list jobs newest first and only keep the most recently finished jobs")
+	store := jobs.NewStore(1)
+	first := store.Start(&jobs.Job{RepoFullName: "owner/repo", PullNum: 1})
+	second := store.Start(&jobs.Job{RepoFullName: "owner/repo", PullNum: 2})
+	third := store.Start(&jobs.Job{RepoFullName: "owner/repo", PullNum: 3})
+	Assert(t, first.ID != second.ID, "IDs should be unique")
+	Assert(t, !first.Started.IsZero(), "job should have a start time")
+	Equals(t, first, store.Get(first.ID))
+	Equals(t, []*jobs.Job{third, second, first}, store.List())
+
+	store.Finish(first)
+	store.Finish(third)
+	Equals(t, []*jobs.Job{third, second}, store.List())
+	Assert(t, store.Get(first.ID) == nil, "oldest finished job should be dropped")
+	Assert(t, store.Get("unknown") == nil, "unknown job should be nil")
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path"
 	"time"
@@ -46,8 +47,56 @@ func New(dataDir string) (*BoltLocker, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "starting BoltDB")
 	}
+	b := &BoltLocker{db, []byte(bucketName)}
+	if err := b.migrateLegacyLocks(); err != nil {
+		return nil, errors.Wrap(err, "starting BoltDB")
+	}
 	// todo: close BoltDB when server is sigtermed
-	return &BoltLocker{db, []byte(bucketName)}, nil
+	return b, nil
+}
+
+// migrateLegacyLocks moves the locks saved before lock keys included the
+// repo's hostname to their current keys so they can still be listed and
+// released. The hostname comes from the URL of the lock's pull request. Locks
+// whose pull request doesn't have a URL are left as they are.
+func (b *BoltLocker) migrateLegacyLocks() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.bucket)
+		legacy := make(map[string]models.ProjectLock)
+		err := bucket.ForEach(func(k []byte, v []byte) error {
+			var lock models.ProjectLock
+			if err := json.Unmarshal(v, &lock); err != nil {
+				return errors.Wrapf(err, "deserializing lock at key %q", string(k))
+			}
+			if lock.Project.RepoHostname != "" {
+				return nil
+			}
+			u, err := url.Parse(lock.Pull.URL)
+			if err != nil || u.Hostname() == "" {
+				return nil
+			}
+			lock.Project.RepoHostname = u.Hostname()
+			legacy[string(k)] = lock
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Bolt doesn't allow changing a bucket while iterating over it.
+		for oldKey, lock := range legacy {
+			serialized, err := json.Marshal(lock)
+			if err != nil {
+				return errors.Wrap(err, "serializing lock")
+			}
+			if err := bucket.Put([]byte(b.key(lock.Project, lock.Env)), serialized); err != nil {
+				return errors.Wrapf(err, "migrating lock at key %q", oldKey)
+			}
+			if err := bucket.Delete([]byte(oldKey)); err != nil {
+				return errors.Wrapf(err, "migrating lock at key %q", oldKey)
+			}
+		}
+		return nil
+	})
 }
 
 // NewWithDB is used for testing.
@@ -147,13 +196,14 @@ func (b BoltLocker) List() ([]models.ProjectLock, error) {
 }
 
 // UnlockByPull deletes all locks associated with that pull request and returns them.
-func (b BoltLocker) UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error) {
+func (b BoltLocker) UnlockByPull(repoHostname string, repoFullName string, pullNum int) ([]models.ProjectLock, error) {
 	var locks []models.ProjectLock
 	err := b.db.View(func(tx *bolt.Tx) error {
 		c := tx.Bucket(b.bucket).Cursor()
 
-		// we can use the repoFullName as a prefix search since that's the first part of the key
-		for k, v := c.Seek([]byte(repoFullName)); k != nil && bytes.HasPrefix(k, []byte(repoFullName)); k, v = c.Next() {
+		// we can use the repo as a prefix search since that's the first part of the key
+		prefix := []byte(fmt.Sprintf("%s/%s/", repoHostname, repoFullName))
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
 			var lock models.ProjectLock
 			if err := json.Unmarshal(v, &lock); err != nil {
 				return errors.Wrapf(err, "deserializing lock at key %q", string(k))
@@ -206,5 +256,5 @@ func (b BoltLocker) GetLock(p models.Project, env string) (*models.ProjectLock,
 }
 
 func (b BoltLocker) key(p models.Project, env string) string {
-	return fmt.Sprintf("%s/%s/%s", p.RepoFullName, p.Path, env)
+	return fmt.Sprintf("%s/%s/%s/%s", p.RepoHostname, p.RepoFullName, p.Path, env)
 }
@@ -5,6 +5,7 @@ import (
 
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/boltdb/bolt"
@@ -17,7 +18,7 @@ import (
 )
 
 var lockBucket = "bucket"
-var project = models.NewProject("owner/repo", "parent/child")
+var project = models.NewProject("github.com", "owner/repo", "parent/child")
 var env = "default"
 var pullNum = 1
 var lock = models.ProjectLock{
@@ -67,7 +68,7 @@ func TestListMultipleLocks(t *testing.T) {
 
 	for _, r := range repos {
 		newLock := lock
-		newLock.Project = models.NewProject(r, "path")
+		newLock.Project = models.NewProject("github.com", r, "path")
 		_, _, err := b.TryLock(newLock)
 		Ok(t, err)
 	}
@@ -119,7 +120,7 @@ func TestLockingExistingLock(t *testing.T) {
 	t.Log("...succeed if the new project has a different path")
 	{
 		newLock := lock
-		newLock.Project = models.NewProject(project.RepoFullName, "different/path")
+		newLock.Project = models.NewProject(project.RepoHostname, project.RepoFullName, "different/path")
 		acquired, currLock, err := b.TryLock(newLock)
 		Ok(t, err)
 		Equals(t, true, acquired)
@@ -139,7 +140,17 @@ func TestLockingExistingLock(t *testing.T) {
 	t.Log("...succeed if the new project has a different repoName")
 	{
 		newLock := lock
-		newLock.Project = models.NewProject("different/repo", project.Path)
+		newLock.Project = models.NewProject(project.RepoHostname, "different/repo", project.Path)
+		acquired, currLock, err := b.TryLock(newLock)
+		Ok(t, err)
+		Equals(t, true, acquired)
+		Equals(t, newLock, currLock)
+	}
+
+	t.Log("...succeed if the new project is a repo with the same name on a different host")
+	{
+		newLock := lock
+		newLock.Project = models.NewProject("ghe.example.com", project.RepoFullName, project.Path)
 		acquired, currLock, err := b.TryLock(newLock)
 		Ok(t, err)
 		Equals(t, true, acquired)
@@ -234,7 +245,7 @@ func TestUnlockByPullNone(t *testing.T) {
 	db, b := newTestDB()
 	defer cleanupDB(db)
 
-	_, err := b.UnlockByPull("any/repo", 1)
+	_, err := b.UnlockByPull("github.com", "any/repo", 1)
 	Ok(t, err)
 }
 
@@ -247,7 +258,7 @@ func TestUnlockByPullOne(t *testing.T) {
 
 	t.Log("...delete nothing when its the same repo but a different pull")
 	{
-		_, err := b.UnlockByPull(project.RepoFullName, pullNum+1)
+		_, err := b.UnlockByPull(project.RepoHostname, project.RepoFullName, pullNum+1)
 		Ok(t, err)
 		ls, err := b.List()
 		Ok(t, err)
@@ -255,7 +266,23 @@ func TestUnlockByPullOne(t *testing.T) {
 	}
 	t.Log("...delete nothing when its the same pull but a different repo")
 	{
-		_, err := b.UnlockByPull("different/repo", pullNum)
+		_, err := b.UnlockByPull(project.RepoHostname, "different/repo", pullNum)
+		Ok(t, err)
+		ls, err := b.List()
+		Ok(t, err)
+		Equals(t, 1, len(ls))
+	}
+	t.Log("...delete nothing when its the same repo and pull but a different host")
+	{
+		_, err := b.UnlockByPull("ghe.example.com", project.RepoFullName, pullNum)
+		Ok(t, err)
+		ls, err := b.List()
+		Ok(t, err)
+		Equals(t, 1, len(ls))
+	}
+	t.Log("...delete nothing when its a repo whose name starts with the same name")
+	{
+		_, err := b.UnlockByPull(project.RepoHostname, project.RepoFullName+"2", pullNum)
 		Ok(t, err)
 		ls, err := b.List()
 		Ok(t, err)
@@ -263,7 +290,7 @@ func TestUnlockByPullOne(t *testing.T) {
 	}
 	t.Log("...delete the lock when its the same repo and pull")
 	{
-		_, err := b.UnlockByPull(project.RepoFullName, pullNum)
+		_, err := b.UnlockByPull(project.RepoHostname, project.RepoFullName, pullNum)
 		Ok(t, err)
 		ls, err := b.List()
 		Ok(t, err)
@@ -280,7 +307,7 @@ func TestUnlockByPullAfterUnlock(t *testing.T) {
 	_, err = b.Unlock(project, env)
 	Ok(t, err)
 
-	_, err = b.UnlockByPull(project.RepoFullName, pullNum)
+	_, err = b.UnlockByPull(project.RepoHostname, project.RepoFullName, pullNum)
 	Ok(t, err)
 	ls, err := b.List()
 	Ok(t, err)
@@ -310,7 +337,7 @@ func TestUnlockByPullMatching(t *testing.T) {
 	Equals(t, 3, len(ls))
 
 	// should all be unlocked
-	_, err = b.UnlockByPull(project.RepoFullName, pullNum)
+	_, err = b.UnlockByPull(project.RepoHostname, project.RepoFullName, pullNum)
 	Ok(t, err)
 	ls, err = b.List()
 	Ok(t, err)
@@ -343,6 +370,44 @@ func TestGetLock(t *testing.T) {
 }
 
 // newTestDB returns a TestDB using a temporary path.
+func TestNew_MigratesLegacyLocks(t *testing.T) {
+	t.Log("locks saved before keys included the hostname should be moved to their new keys")
+	dataDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(dataDir) // nolint: errcheck
+	db, err := bolt.Open(filepath.Join(dataDir, "atlantis.db"), 0600, nil)
+	Ok(t, err)
+	Ok(t, db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte("runLocks"))
+		if err != nil {
+			return err
+		}
+		// Before the hostname was added, projects didn't have one and keys
+		// were {repoFullName}/{path}/{env}.
+		legacy := `{"Project": {"RepoFullName": "owner/repo", "Path": "parent/child"}, "Pull": {"Num": 1, "URL": "https://github.com/owner/repo/pull/1"}, "User": {"Username": "lkysow"}, "Env": "default"}`
+		if err := bucket.Put([]byte("owner/repo/parent/child/default"), []byte(legacy)); err != nil {
+			return err
+		}
+		// A lock whose pull request doesn't have a URL can't be migrated.
+		return bucket.Put([]byte("owner/other/./default"), []byte(`{"Project": {"RepoFullName": "owner/other", "Path": "."}, "Env": "default"}`))
+	}))
+	Ok(t, db.Close())
+
+	b, err := boltdb.New(dataDir)
+	Ok(t, err)
+	l, err := b.GetLock(project, env)
+	Ok(t, err)
+	Assert(t, l != nil, "exp the legacy lock at its new key")
+	Equals(t, project, l.Project)
+	unlocked, err := b.UnlockByPull("github.com", "owner/repo", 1)
+	Ok(t, err)
+	Equals(t, 1, len(unlocked))
+	ls, err := b.List()
+	Ok(t, err)
+	Equals(t, 1, len(ls))
+	Equals(t, "owner/other", ls[0].Project.RepoFullName)
+}
+
 func newTestDB() (*bolt.DB, *boltdb.BoltLocker) {
 	// Retrieve a temporary path.
 	f, err := ioutil.TempFile("", "")
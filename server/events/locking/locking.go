@@ -18,7 +18,7 @@ type Backend interface {
 	Unlock(project models.Project, env string) (*models.ProjectLock, error)
 	List() ([]models.ProjectLock, error)
 	GetLock(project models.Project, env string) (*models.ProjectLock, error)
-	UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error)
+	UnlockByPull(repoHostname string, repoFullName string, pullNum int) ([]models.ProjectLock, error)
 }
 
 // TryLockResponse results from an attempted lock.
@@ -42,7 +42,7 @@ type Locker interface {
 	TryLock(p models.Project, env string, pull models.PullRequest, user models.User) (TryLockResponse, error)
 	Unlock(key string) (*models.ProjectLock, error)
 	List() (map[string]models.ProjectLock, error)
-	UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error)
+	UnlockByPull(repoHostname string, repoFullName string, pullNum int) ([]models.ProjectLock, error)
 	GetLock(key string) (*models.ProjectLock, error)
 }
 
@@ -53,8 +53,8 @@ func NewClient(backend Backend) *Client {
 	}
 }
 
-// keyRegex matches and captures {repoFullName}/{path}/{env} where path can have multiple /'s in it.
-var keyRegex = regexp.MustCompile(`^(.*?\/.*?)\/(.*)\/(.*)$`)
+// keyRegex matches and captures {repoHostname}/{repoFullName}/{path}/{env} where path can have multiple /'s in it.
+var keyRegex = regexp.MustCompile(`^(.*?)\/(.*?\/.*?)\/(.*)\/(.*)$`)
 
 // TryLock attempts to acquire a lock to a project and environment.
 func (c *Client) TryLock(p models.Project, env string, pull models.PullRequest, user models.User) (TryLockResponse, error) {
@@ -99,8 +99,8 @@ func (c *Client) List() (map[string]models.ProjectLock, error) {
 }
 
 // UnlockByPull deletes all locks associated with that pull request.
-func (c *Client) UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error) {
-	return c.backend.UnlockByPull(repoFullName, pullNum)
+func (c *Client) UnlockByPull(repoHostname string, repoFullName string, pullNum int) ([]models.ProjectLock, error) {
+	return c.backend.UnlockByPull(repoHostname, repoFullName, pullNum)
 }
 
 // GetLock attempts to get the lock stored at key. If successful,
@@ -122,14 +122,14 @@ func (c *Client) GetLock(key string) (*models.ProjectLock, error) {
 }
 
 func (c *Client) key(p models.Project, env string) string {
-	return fmt.Sprintf("%s/%s/%s", p.RepoFullName, p.Path, env)
+	return fmt.Sprintf("%s/%s/%s/%s", p.RepoHostname, p.RepoFullName, p.Path, env)
 }
 
 func (c *Client) lockKeyToProjectEnv(key string) (models.Project, string, error) {
 	matches := keyRegex.FindStringSubmatch(key)
-	if len(matches) != 4 {
+	if len(matches) != 5 {
 		return models.Project{}, "", errors.New("invalid key format")
 	}
 
-	return models.Project{RepoFullName: matches[1], Path: matches[2]}, matches[3], nil
+	return models.Project{RepoHostname: matches[1], RepoFullName: matches[2], Path: matches[3]}, matches[4], nil
 }
@@ -15,7 +15,7 @@ import (
 	. "github.com/petergtz/pegomock"
 )
 
-var project = models.NewProject("owner/repo", "path")
+var project = models.NewProject("github.com", "owner/repo", "path")
 var env = "env"
 var pull = models.PullRequest{}
 var user = models.User{}
@@ -41,7 +41,7 @@ func TestTryLock_Success(t *testing.T) {
 	l := locking.NewClient(backend)
 	r, err := l.TryLock(project, env, pull, user)
 	Ok(t, err)
-	Equals(t, locking.TryLockResponse{LockAcquired: true, CurrLock: currLock, LockKey: "owner/repo/path/env"}, r)
+	Equals(t, locking.TryLockResponse{LockAcquired: true, CurrLock: currLock, LockKey: "github.com/owner/repo/path/env"}, r)
 }
 
 func TestUnlock_InvalidKey(t *testing.T) {
@@ -59,7 +59,7 @@ func TestUnlock_Err(t *testing.T) {
 	backend := mocks.NewMockBackend()
 	When(backend.Unlock(matchers.AnyModelsProject(), AnyString())).ThenReturn(nil, expectedErr)
 	l := locking.NewClient(backend)
-	_, err := l.Unlock("owner/repo/path/env")
+	_, err := l.Unlock("github.com/owner/repo/path/env")
 	Equals(t, err, err)
 	backend.VerifyWasCalledOnce().Unlock(project, "env")
 }
@@ -69,7 +69,7 @@ func TestUnlock(t *testing.T) {
 	backend := mocks.NewMockBackend()
 	When(backend.Unlock(matchers.AnyModelsProject(), AnyString())).ThenReturn(&pl, nil)
 	l := locking.NewClient(backend)
-	lock, err := l.Unlock("owner/repo/path/env")
+	lock, err := l.Unlock("github.com/owner/repo/path/env")
 	Ok(t, err)
 	Equals(t, &pl, lock)
 }
@@ -91,16 +91,16 @@ func TestList(t *testing.T) {
 	list, err := l.List()
 	Ok(t, err)
 	Equals(t, map[string]models.ProjectLock{
-		"owner/repo/path/env": pl,
+		"github.com/owner/repo/path/env": pl,
 	}, list)
 }
 
 func TestUnlockByPull(t *testing.T) {
 	RegisterMockTestingT(t)
 	backend := mocks.NewMockBackend()
-	When(backend.UnlockByPull("owner/repo", 1)).ThenReturn(nil, expectedErr)
+	When(backend.UnlockByPull("github.com", "owner/repo", 1)).ThenReturn(nil, expectedErr)
 	l := locking.NewClient(backend)
-	_, err := l.UnlockByPull("owner/repo", 1)
+	_, err := l.UnlockByPull("github.com", "owner/repo", 1)
 	Equals(t, expectedErr, err)
 }
 
@@ -118,7 +118,7 @@ func TestGetLock_Err(t *testing.T) {
 	backend := mocks.NewMockBackend()
 	When(backend.GetLock(project, env)).ThenReturn(nil, expectedErr)
 	l := locking.NewClient(backend)
-	_, err := l.GetLock("owner/repo/path/env")
+	_, err := l.GetLock("github.com/owner/repo/path/env")
 	Equals(t, expectedErr, err)
 }
 
@@ -127,7 +127,7 @@ func TestGetLock(t *testing.T) {
 	backend := mocks.NewMockBackend()
 	When(backend.GetLock(project, env)).ThenReturn(&pl, nil)
 	l := locking.NewClient(backend)
-	lock, err := l.GetLock("owner/repo/path/env")
+	lock, err := l.GetLock("github.com/owner/repo/path/env")
 	Ok(t, err)
 	Equals(t, &pl, lock)
 }
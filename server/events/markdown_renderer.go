@@ -3,19 +3,58 @@ package events
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// The names of the templates that can be overridden. Each name is also the
+// name of its file, without the .tmpl extension, in the templates directory
+// given to NewMarkdownRenderer.
+const (
+	// HelpTemplate renders the response to `atlantis help`. It has no data.
+	HelpTemplate = "help"
+	// SingleProjectTemplate renders the results of a command that ran in
+	// one project. Its data is ResultData.
+	SingleProjectTemplate = "single-project"
+	// MultiProjectTemplate renders the results of a command that ran in
+	// multiple projects. Its data is ResultData.
+	MultiProjectTemplate = "multi-project"
+	// PlanSuccessTemplate renders the result of a successful plan. Its data
+	// is PlanSuccess.
+	PlanSuccessTemplate = "plan-success"
+	// ApplySuccessTemplate renders the result of a successful apply. Its
+	// data is ApplySuccessData.
+	ApplySuccessTemplate = "apply-success"
+	// ErrTemplate renders an error. Its data is ErrData.
+	ErrTemplate = "error"
+	// FailureTemplate renders a failure. Its data is FailureData.
+	FailureTemplate = "failure"
+	// LogTemplate renders the log for verbose commands. It's included by the
+	// other templates with {{ template "log" . }}. Its data is CommonData.
+	LogTemplate = "log"
 )
 
-var helpTmpl = template.Must(template.New("").Parse("```cmake\n" +
-	`atlantis - Terraform collaboration tool that enables you to collaborate on infrastructure
+// errWithLogTemplate and failureWithLogTemplate render errors and failures
+// that stopped the whole command. They can't be overridden.
+const errWithLogTemplate = "error-with-log"
+const failureWithLogTemplate = "failure-with-log"
+
+var defaultTemplates = map[string]string{
+	HelpTemplate: "```cmake\n" +
+		`atlantis - Terraform collaboration tool that enables you to collaborate on infrastructure
 safely and securely.
 
-Usage: atlantis <command> [environment] [--verbose]
+Usage: atlantis <command> [environment | -w workspace] [--verbose]
 
 Commands:
 plan           Runs 'terraform plan' on the files changed in the pull request
 apply          Runs 'terraform apply' using the plans generated by 'atlantis plan'
+cancel         Stops the plan or apply that's running for the environment
 help           Get help
 
 Examples:
@@ -23,6 +62,9 @@ Examples:
 # Generates a plan for staging environment
 atlantis plan staging
 
+# Generates a plan for the staging workspace (terraform >= 0.10)
+atlantis plan -w staging
+
 # Generates a plan for a standalone terraform project
 atlantis plan
 
@@ -31,10 +73,12 @@ atlantis apply staging
 
 # Applies a plan for a standalone terraform project
 atlantis apply
-`))
-var singleProjectTmpl = template.Must(template.New("").Parse("{{ range $result := .Results }}{{$result}}{{end}}\n" + logTmpl))
-var multiProjectTmpl = template.Must(template.New("").Parse(
-	"Ran {{.Command}} in {{ len .Results }} directories:\n" +
+
+# Stops the plan or apply that's running for staging environment
+atlantis cancel staging
+`,
+	SingleProjectTemplate: "{{ range $result := .Results }}{{$result}}{{end}}\n{{ template \"log\" . }}",
+	MultiProjectTemplate: "Ran {{.Command}} in {{ len .Results }} directories:\n" +
 		"{{ range $path, $result := .Results }}" +
 		" * `{{$path}}`\n" +
 		"{{end}}\n" +
@@ -42,29 +86,111 @@ var multiProjectTmpl = template.Must(template.New("").Parse(
 		"## {{$path}}/\n" +
 		"{{$result}}\n" +
 		"---\n{{end}}" +
-		logTmpl))
-var planSuccessTmpl = template.Must(template.New("").Parse(
-	"```diff\n" +
+		"{{ template \"log\" . }}",
+	PlanSuccessTemplate: "```diff\n" +
 		"{{.TerraformOutput}}\n" +
 		"```\n\n" +
-		"* To **discard** this plan click [here]({{.LockURL}})."))
-var applySuccessTmpl = template.Must(template.New("").Parse(
-	"```diff\n" +
+		"* To **discard** this plan click [here]({{.LockURL}}).",
+	ApplySuccessTemplate: "```diff\n" +
 		"{{.Output}}\n" +
-		"```"))
-var errTmplText = "**{{.Command}} Error**\n" +
-	"```\n" +
-	"{{.Error}}\n" +
-	"```\n"
-var errTmpl = template.Must(template.New("").Parse(errTmplText))
-var errWithLogTmpl = template.Must(template.New("").Parse(errTmplText + logTmpl))
-var failureTmplText = "**{{.Command}} Failed**: {{.Failure}}\n"
-var failureTmpl = template.Must(template.New("").Parse(failureTmplText))
-var failureWithLogTmpl = template.Must(template.New("").Parse(failureTmplText + logTmpl))
-var logTmpl = "{{if .Verbose}}\n<details><summary>Log</summary>\n  <p>\n\n```\n{{.Log}}```\n</p></details>{{end}}\n"
-
-// MarkdownRenderer renders responses as markdown
-type MarkdownRenderer struct{}
+		"```",
+	ErrTemplate: "**{{.Command}} Error**\n" +
+		"```\n" +
+		"{{.Error}}\n" +
+		"```\n",
+	FailureTemplate: "**{{.Command}} Failed**: {{.Failure}}\n",
+	LogTemplate:     "{{if .Verbose}}\n<details><summary>Log</summary>\n  <p>\n\n```\n{{.Log}}```\n</p></details>{{end}}\n",
+}
+
+// templateData is the data each template is rendered with. It's used to check
+// templates when they're loaded.
+var templateData = map[string]interface{}{
+	HelpTemplate:          nil,
+	SingleProjectTemplate: ResultData{},
+	MultiProjectTemplate:  ResultData{},
+	PlanSuccessTemplate:   PlanSuccess{},
+	ApplySuccessTemplate:  ApplySuccessData{},
+	ErrTemplate:           ErrData{},
+	FailureTemplate:       FailureData{},
+	LogTemplate:           CommonData{},
+}
+
+var defaultMarkdownTemplates = template.Must(parseTemplates(defaultTemplates))
+
+// MarkdownRenderer renders responses as markdown. Its zero value uses the
+// default templates.
+type MarkdownRenderer struct {
+	templates *template.Template
+}
+
+// NewMarkdownRenderer returns a renderer that uses the templates in
+// templatesDir, named {name}.tmpl where name is one of the template names
+// above, instead of the defaults. Templates that aren't in templatesDir use
+// the defaults. If templatesDir is empty, all the defaults are used.
+// An error is returned if a template can't be parsed or rendered.
+func NewMarkdownRenderer(templatesDir string) (*MarkdownRenderer, error) {
+	if templatesDir == "" {
+		return &MarkdownRenderer{}, nil
+	}
+	files, err := ioutil.ReadDir(templatesDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading templates dir")
+	}
+	texts := make(map[string]string)
+	for name, text := range defaultTemplates {
+		texts[name] = text
+	}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".tmpl" {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), ".tmpl")
+		if _, ok := defaultTemplates[name]; !ok {
+			return nil, fmt.Errorf("unknown template %s: not one of %s", f.Name(), strings.Join(templateNames(), ", "))
+		}
+		text, err := ioutil.ReadFile(filepath.Join(templatesDir, f.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading template %s", f.Name())
+		}
+		texts[name] = string(text)
+	}
+	templates, err := parseTemplates(texts)
+	if err != nil {
+		return nil, err
+	}
+	// Rendering each template catches references to fields that don't exist
+	// now rather than when a comment is rendered.
+	for name, data := range templateData {
+		if err := templates.ExecuteTemplate(ioutil.Discard, name, data); err != nil {
+			return nil, errors.Wrapf(err, "rendering template %s", name)
+		}
+	}
+	return &MarkdownRenderer{templates: templates}, nil
+}
+
+// parseTemplates parses texts, a map of template names to their text, into a
+// single set so templates can include each other.
+func parseTemplates(texts map[string]string) (*template.Template, error) {
+	templates := template.New("")
+	for _, name := range templateNames() {
+		if _, err := templates.New(name).Parse(texts[name]); err != nil {
+			return nil, errors.Wrapf(err, "parsing template %s", name)
+		}
+	}
+	templates = template.Must(templates.New(errWithLogTemplate).Parse(`{{ template "error" . }}{{ template "log" . }}`))
+	return templates.New(failureWithLogTemplate).Parse(`{{ template "failure" . }}{{ template "log" . }}`)
+}
+
+// templateNames returns the names of the templates that can be overridden in
+// alphabetical order.
+func templateNames() []string {
+	var names []string
+	for name := range defaultTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
 type CommonData struct {
 	Command string
@@ -87,19 +213,23 @@ type ResultData struct {
 	CommonData
 }
 
+type ApplySuccessData struct {
+	Output string
+}
+
 // Render formats the data into a string that can be commented back to GitHub.
 // nolint: interfacer
 func (g *MarkdownRenderer) Render(res CommandResponse, cmdName CommandName, log string, verbose bool) string {
 	if cmdName == Help {
-		return g.renderTemplate(helpTmpl, nil)
+		return g.renderTemplate(HelpTemplate, nil)
 	}
 	commandStr := strings.Title(cmdName.String())
 	common := CommonData{commandStr, verbose, log}
 	if res.Error != nil {
-		return g.renderTemplate(errWithLogTmpl, ErrData{res.Error.Error(), common})
+		return g.renderTemplate(errWithLogTemplate, ErrData{res.Error.Error(), common})
 	}
 	if res.Failure != "" {
-		return g.renderTemplate(failureWithLogTmpl, FailureData{res.Failure, common})
+		return g.renderTemplate(failureWithLogTemplate, FailureData{res.Failure, common})
 	}
 	return g.renderProjectResults(res.ProjectResults, common)
 }
@@ -108,42 +238,32 @@ func (g *MarkdownRenderer) renderProjectResults(pathResults []ProjectResult, com
 	results := make(map[string]string)
 	for _, result := range pathResults {
 		if result.Error != nil {
-			results[result.Path] = g.renderTemplate(errTmpl, struct {
-				Command string
-				Error   string
-			}{
-				Command: common.Command,
-				Error:   result.Error.Error(),
-			})
+			results[result.Path] = g.renderTemplate(ErrTemplate, ErrData{result.Error.Error(), common})
 		} else if result.Failure != "" {
-			results[result.Path] = g.renderTemplate(failureTmpl, struct {
-				Command string
-				Failure string
-			}{
-				Command: common.Command,
-				Failure: result.Failure,
-			})
+			results[result.Path] = g.renderTemplate(FailureTemplate, FailureData{result.Failure, common})
 		} else if result.PlanSuccess != nil {
-			results[result.Path] = g.renderTemplate(planSuccessTmpl, *result.PlanSuccess)
+			results[result.Path] = g.renderTemplate(PlanSuccessTemplate, *result.PlanSuccess)
 		} else if result.ApplySuccess != "" {
-			results[result.Path] = g.renderTemplate(applySuccessTmpl, struct{ Output string }{result.ApplySuccess})
+			results[result.Path] = g.renderTemplate(ApplySuccessTemplate, ApplySuccessData{result.ApplySuccess})
 		} else {
 			results[result.Path] = "Found no template. This is a bug!"
 		}
 	}
 
-	var tmpl *template.Template
+	tmpl := MultiProjectTemplate
 	if len(results) == 1 {
-		tmpl = singleProjectTmpl
-	} else {
-		tmpl = multiProjectTmpl
+		tmpl = SingleProjectTemplate
 	}
 	return g.renderTemplate(tmpl, ResultData{results, common})
 }
 
-func (g *MarkdownRenderer) renderTemplate(tmpl *template.Template, data interface{}) string {
+func (g *MarkdownRenderer) renderTemplate(name string, data interface{}) string {
+	templates := g.templates
+	if templates == nil {
+		templates = defaultMarkdownTemplates
+	}
 	buf := &bytes.Buffer{}
-	if err := tmpl.Execute(buf, data); err != nil {
+	if err := templates.ExecuteTemplate(buf, name, data); err != nil {
 		return fmt.Sprintf("Failed to render template, this is a bug: %v", err)
 	}
 	return buf.String()
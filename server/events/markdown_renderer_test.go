@@ -2,12 +2,85 @@ package events_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hootsuite/atlantis/server/events"
 	. "github.com/hootsuite/atlantis/testing"
 )
 
+func TestNewMarkdownRenderer_Overrides(t *testing.T) {
+	t.Log("templates in the templates dir should override the defaults and can include the log template")
+	dir := templatesDir(t, map[string]string{
+		"plan-success.tmpl": "{{.TerraformOutput}}\nSee the [runbook](https://runbook).",
+		"failure.tmpl":      "{{.Command}} failed, ask #oncall: {{.Failure}}\n",
+		"log.tmpl":          "{{if .Verbose}}log: {{.Log}}{{end}}",
+		"README.md":         "ignored",
+	})
+	defer os.RemoveAll(dir) // nolint: errcheck
+	r, err := events.NewMarkdownRenderer(dir)
+	Ok(t, err)
+
+	s := r.Render(events.CommandResponse{ProjectResults: []events.ProjectResult{{Path: "path", PlanSuccess: &events.PlanSuccess{TerraformOutput: "output"}}}}, events.Plan, "log", true)
+	Equals(t, "output\nSee the [runbook](https://runbook).\nlog: log", s)
+	s = r.Render(events.CommandResponse{Failure: "failure"}, events.Apply, "log", false)
+	Equals(t, "Apply failed, ask #oncall: failure\n", s)
+	s = r.Render(events.CommandResponse{ProjectResults: []events.ProjectResult{{Path: "path", ApplySuccess: "success"}}}, events.Apply, "log", false)
+	Equals(t, "```diff\nsuccess\n```\n", s)
+}
+
+func TestNewMarkdownRenderer_NoDir(t *testing.T) {
+	t.Log("without a templates dir the defaults should be used")
+	r, err := events.NewMarkdownRenderer("")
+	Ok(t, err)
+	Equals(t, (&events.MarkdownRenderer{}).Render(events.CommandResponse{}, events.Help, "", false), r.Render(events.CommandResponse{}, events.Help, "", false))
+}
+
+func TestNewMarkdownRenderer_Errors(t *testing.T) {
+	cases := []struct {
+		Description string
+		Files       map[string]string
+		ExpErr      string
+	}{
+		{
+			"unknown template",
+			map[string]string{"plan.tmpl": ""},
+			"unknown template plan.tmpl: not one of apply-success, error, failure, help, log, multi-project, plan-success, single-project",
+		},
+		{
+			"parse error",
+			map[string]string{"error.tmpl": "{{ .Error"},
+			"parsing template error: template: error:1: unclosed action",
+		},
+		{
+			"unknown field",
+			map[string]string{"apply-success.tmpl": "{{ .TerraformOutput }}"},
+			"rendering template apply-success: template: apply-success:1:3: executing \"apply-success\" at <.TerraformOutput>: can't evaluate field TerraformOutput in type events.ApplySuccessData",
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.Description)
+		dir := templatesDir(t, c.Files)
+		_, err := events.NewMarkdownRenderer(dir)
+		os.RemoveAll(dir) // nolint: errcheck
+		Assert(t, err != nil, "expected error")
+		Equals(t, c.ExpErr, err.Error())
+	}
+}
+
+// templatesDir returns a temp dir containing files, a map of file names to
+// their contents.
+func templatesDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	for name, contents := range files {
+		Ok(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600))
+	}
+	return dir
+}
+
 func TestRenderErr(t *testing.T) {
 	err := errors.New("err")
 	cases := []struct {
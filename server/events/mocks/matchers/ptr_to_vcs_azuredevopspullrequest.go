@@ -0,0 +1,20 @@
+package matchers
+
+import (
+	"reflect"
+
+	vcs "github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/petergtz/pegomock"
+)
+
+func AnyPtrToVcsAzureDevopsPullRequest() *vcs.AzureDevopsPullRequest {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(*vcs.AzureDevopsPullRequest))(nil)).Elem()))
+	var nullValue *vcs.AzureDevopsPullRequest
+	return nullValue
+}
+
+func EqPtrToVcsAzureDevopsPullRequest(value *vcs.AzureDevopsPullRequest) *vcs.AzureDevopsPullRequest {
+	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
+	var nullValue *vcs.AzureDevopsPullRequest
+	return nullValue
+}
@@ -0,0 +1,20 @@
+package matchers
+
+import (
+	"reflect"
+
+	vcs "github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/petergtz/pegomock"
+)
+
+func AnyPtrToVcsAzureDevopsRepository() *vcs.AzureDevopsRepository {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(*vcs.AzureDevopsRepository))(nil)).Elem()))
+	var nullValue *vcs.AzureDevopsRepository
+	return nullValue
+}
+
+func EqPtrToVcsAzureDevopsRepository(value *vcs.AzureDevopsRepository) *vcs.AzureDevopsRepository {
+	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
+	var nullValue *vcs.AzureDevopsRepository
+	return nullValue
+}
@@ -0,0 +1,20 @@
+package matchers
+
+import (
+	"reflect"
+
+	vcs "github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/petergtz/pegomock"
+)
+
+func AnyPtrToVcsGiteaPullRequest() *vcs.GiteaPullRequest {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(*vcs.GiteaPullRequest))(nil)).Elem()))
+	var nullValue *vcs.GiteaPullRequest
+	return nullValue
+}
+
+func EqPtrToVcsGiteaPullRequest(value *vcs.GiteaPullRequest) *vcs.GiteaPullRequest {
+	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
+	var nullValue *vcs.GiteaPullRequest
+	return nullValue
+}
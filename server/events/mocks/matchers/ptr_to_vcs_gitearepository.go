@@ -0,0 +1,20 @@
+package matchers
+
+import (
+	"reflect"
+
+	vcs "github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/petergtz/pegomock"
+)
+
+func AnyPtrToVcsGiteaRepository() *vcs.GiteaRepository {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(*vcs.GiteaRepository))(nil)).Elem()))
+	var nullValue *vcs.GiteaRepository
+	return nullValue
+}
+
+func EqPtrToVcsGiteaRepository(value *vcs.GiteaRepository) *vcs.GiteaRepository {
+	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
+	var nullValue *vcs.GiteaRepository
+	return nullValue
+}
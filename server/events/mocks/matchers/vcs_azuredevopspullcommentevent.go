@@ -0,0 +1,20 @@
+package matchers
+
+import (
+	"reflect"
+
+	vcs "github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/petergtz/pegomock"
+)
+
+func AnyVcsAzureDevopsPullCommentEvent() vcs.AzureDevopsPullCommentEvent {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(vcs.AzureDevopsPullCommentEvent))(nil)).Elem()))
+	var nullValue vcs.AzureDevopsPullCommentEvent
+	return nullValue
+}
+
+func EqVcsAzureDevopsPullCommentEvent(value vcs.AzureDevopsPullCommentEvent) vcs.AzureDevopsPullCommentEvent {
+	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
+	var nullValue vcs.AzureDevopsPullCommentEvent
+	return nullValue
+}
@@ -0,0 +1,20 @@
+package matchers
+
+import (
+	"reflect"
+
+	vcs "github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/petergtz/pegomock"
+)
+
+func AnyVcsGiteaIssueCommentEvent() vcs.GiteaIssueCommentEvent {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(vcs.GiteaIssueCommentEvent))(nil)).Elem()))
+	var nullValue vcs.GiteaIssueCommentEvent
+	return nullValue
+}
+
+func EqVcsGiteaIssueCommentEvent(value vcs.GiteaIssueCommentEvent) vcs.GiteaIssueCommentEvent {
+	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
+	var nullValue vcs.GiteaIssueCommentEvent
+	return nullValue
+}
@@ -17,8 +17,8 @@ func NewMockEnvLocker() *MockEnvLocker {
 	return &MockEnvLocker{fail: pegomock.GlobalFailHandler}
 }
 
-func (mock *MockEnvLocker) TryLock(repoFullName string, env string, pullNum int) bool {
-	params := []pegomock.Param{repoFullName, env, pullNum}
+func (mock *MockEnvLocker) TryLock(repoHostname string, repoFullName string, env string, pullNum int) bool {
+	params := []pegomock.Param{repoHostname, repoFullName, env, pullNum}
 	result := pegomock.GetGenericMockFrom(mock).Invoke("TryLock", params, []reflect.Type{reflect.TypeOf((*bool)(nil)).Elem()})
 	var ret0 bool
 	if len(result) != 0 {
@@ -29,8 +29,8 @@ func (mock *MockEnvLocker) TryLock(repoFullName string, env string, pullNum int)
 	return ret0
 }
 
-func (mock *MockEnvLocker) Unlock(repoFullName string, env string, pullNum int) {
-	params := []pegomock.Param{repoFullName, env, pullNum}
+func (mock *MockEnvLocker) Unlock(repoHostname string, repoFullName string, env string, pullNum int) {
+	params := []pegomock.Param{repoHostname, repoFullName, env, pullNum}
 	pegomock.GetGenericMockFrom(mock).Invoke("Unlock", params, []reflect.Type{})
 }
 
@@ -52,8 +52,8 @@ type VerifierEnvLocker struct {
 	inOrderContext         *pegomock.InOrderContext
 }
 
-func (verifier *VerifierEnvLocker) TryLock(repoFullName string, env string, pullNum int) *EnvLocker_TryLock_OngoingVerification {
-	params := []pegomock.Param{repoFullName, env, pullNum}
+func (verifier *VerifierEnvLocker) TryLock(repoHostname string, repoFullName string, env string, pullNum int) *EnvLocker_TryLock_OngoingVerification {
+	params := []pegomock.Param{repoHostname, repoFullName, env, pullNum}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "TryLock", params)
 	return &EnvLocker_TryLock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -63,12 +63,12 @@ type EnvLocker_TryLock_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *EnvLocker_TryLock_OngoingVerification) GetCapturedArguments() (string, string, int) {
-	repoFullName, env, pullNum := c.GetAllCapturedArguments()
-	return repoFullName[len(repoFullName)-1], env[len(env)-1], pullNum[len(pullNum)-1]
+func (c *EnvLocker_TryLock_OngoingVerification) GetCapturedArguments() (string, string, string, int) {
+	repoHostname, repoFullName, env, pullNum := c.GetAllCapturedArguments()
+	return repoHostname[len(repoHostname)-1], repoFullName[len(repoFullName)-1], env[len(env)-1], pullNum[len(pullNum)-1]
 }
 
-func (c *EnvLocker_TryLock_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []int) {
+func (c *EnvLocker_TryLock_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string, _param3 []int) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]string, len(params[0]))
@@ -79,16 +79,20 @@ func (c *EnvLocker_TryLock_OngoingVerification) GetAllCapturedArguments() (_para
 		for u, param := range params[1] {
 			_param1[u] = param.(string)
 		}
-		_param2 = make([]int, len(params[2]))
+		_param2 = make([]string, len(params[2]))
 		for u, param := range params[2] {
-			_param2[u] = param.(int)
+			_param2[u] = param.(string)
+		}
+		_param3 = make([]int, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(int)
 		}
 	}
 	return
 }
 
-func (verifier *VerifierEnvLocker) Unlock(repoFullName string, env string, pullNum int) *EnvLocker_Unlock_OngoingVerification {
-	params := []pegomock.Param{repoFullName, env, pullNum}
+func (verifier *VerifierEnvLocker) Unlock(repoHostname string, repoFullName string, env string, pullNum int) *EnvLocker_Unlock_OngoingVerification {
+	params := []pegomock.Param{repoHostname, repoFullName, env, pullNum}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Unlock", params)
 	return &EnvLocker_Unlock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -98,12 +102,12 @@ type EnvLocker_Unlock_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *EnvLocker_Unlock_OngoingVerification) GetCapturedArguments() (string, string, int) {
-	repoFullName, env, pullNum := c.GetAllCapturedArguments()
-	return repoFullName[len(repoFullName)-1], env[len(env)-1], pullNum[len(pullNum)-1]
+func (c *EnvLocker_Unlock_OngoingVerification) GetCapturedArguments() (string, string, string, int) {
+	repoHostname, repoFullName, env, pullNum := c.GetAllCapturedArguments()
+	return repoHostname[len(repoHostname)-1], repoFullName[len(repoFullName)-1], env[len(env)-1], pullNum[len(pullNum)-1]
 }
 
-func (c *EnvLocker_Unlock_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []int) {
+func (c *EnvLocker_Unlock_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string, _param3 []int) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]string, len(params[0]))
@@ -114,9 +118,13 @@ func (c *EnvLocker_Unlock_OngoingVerification) GetAllCapturedArguments() (_param
 		for u, param := range params[1] {
 			_param1[u] = param.(string)
 		}
-		_param2 = make([]int, len(params[2]))
+		_param2 = make([]string, len(params[2]))
 		for u, param := range params[2] {
-			_param2[u] = param.(int)
+			_param2[u] = param.(string)
+		}
+		_param3 = make([]int, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(int)
 		}
 	}
 	return
@@ -146,6 +146,126 @@ func (mock *MockEventParsing) ParseGitlabMergeRequest(mr *go_gitlab.MergeRequest
 	return ret0
 }
 
+func (mock *MockEventParsing) ParseGiteaIssueCommentEvent(event vcs.GiteaIssueCommentEvent) (models.Repo, models.User, int, error) {
+	params := []pegomock.Param{event}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseGiteaIssueCommentEvent", params, []reflect.Type{reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*models.User)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.Repo
+	var ret1 models.User
+	var ret2 int
+	var ret3 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.Repo)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(models.User)
+		}
+		if result[2] != nil {
+			ret2 = result[2].(int)
+		}
+		if result[3] != nil {
+			ret3 = result[3].(error)
+		}
+	}
+	return ret0, ret1, ret2, ret3
+}
+
+func (mock *MockEventParsing) ParseGiteaPull(pull *vcs.GiteaPullRequest) (models.PullRequest, models.Repo, error) {
+	params := []pegomock.Param{pull}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseGiteaPull", params, []reflect.Type{reflect.TypeOf((*models.PullRequest)(nil)).Elem(), reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.PullRequest
+	var ret1 models.Repo
+	var ret2 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.PullRequest)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(models.Repo)
+		}
+		if result[2] != nil {
+			ret2 = result[2].(error)
+		}
+	}
+	return ret0, ret1, ret2
+}
+
+func (mock *MockEventParsing) ParseGiteaRepo(repo *vcs.GiteaRepository) (models.Repo, error) {
+	params := []pegomock.Param{repo}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseGiteaRepo", params, []reflect.Type{reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.Repo
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.Repo)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockEventParsing) ParseAzureDevopsPullCommentEvent(event vcs.AzureDevopsPullCommentEvent) (models.Repo, models.User, int, error) {
+	params := []pegomock.Param{event}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseAzureDevopsPullCommentEvent", params, []reflect.Type{reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*models.User)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.Repo
+	var ret1 models.User
+	var ret2 int
+	var ret3 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.Repo)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(models.User)
+		}
+		if result[2] != nil {
+			ret2 = result[2].(int)
+		}
+		if result[3] != nil {
+			ret3 = result[3].(error)
+		}
+	}
+	return ret0, ret1, ret2, ret3
+}
+
+func (mock *MockEventParsing) ParseAzureDevopsPull(pull *vcs.AzureDevopsPullRequest) (models.PullRequest, models.Repo, error) {
+	params := []pegomock.Param{pull}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseAzureDevopsPull", params, []reflect.Type{reflect.TypeOf((*models.PullRequest)(nil)).Elem(), reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.PullRequest
+	var ret1 models.Repo
+	var ret2 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.PullRequest)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(models.Repo)
+		}
+		if result[2] != nil {
+			ret2 = result[2].(error)
+		}
+	}
+	return ret0, ret1, ret2
+}
+
+func (mock *MockEventParsing) ParseAzureDevopsRepo(repo *vcs.AzureDevopsRepository) (models.Repo, error) {
+	params := []pegomock.Param{repo}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseAzureDevopsRepo", params, []reflect.Type{reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.Repo
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.Repo)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockEventParsing) VerifyWasCalledOnce() *VerifierEventParsing {
 	return &VerifierEventParsing{mock, pegomock.Times(1), nil}
 }
@@ -356,3 +476,165 @@ func (c *EventParsing_ParseGitlabMergeRequest_OngoingVerification) GetAllCapture
 	}
 	return
 }
+
+func (verifier *VerifierEventParsing) ParseGiteaIssueCommentEvent(event vcs.GiteaIssueCommentEvent) *EventParsing_ParseGiteaIssueCommentEvent_OngoingVerification {
+	params := []pegomock.Param{event}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGiteaIssueCommentEvent", params)
+	return &EventParsing_ParseGiteaIssueCommentEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type EventParsing_ParseGiteaIssueCommentEvent_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *EventParsing_ParseGiteaIssueCommentEvent_OngoingVerification) GetCapturedArguments() vcs.GiteaIssueCommentEvent {
+	event := c.GetAllCapturedArguments()
+	return event[len(event)-1]
+}
+
+func (c *EventParsing_ParseGiteaIssueCommentEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []vcs.GiteaIssueCommentEvent) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]vcs.GiteaIssueCommentEvent, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(vcs.GiteaIssueCommentEvent)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierEventParsing) ParseGiteaPull(pull *vcs.GiteaPullRequest) *EventParsing_ParseGiteaPull_OngoingVerification {
+	params := []pegomock.Param{pull}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGiteaPull", params)
+	return &EventParsing_ParseGiteaPull_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type EventParsing_ParseGiteaPull_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *EventParsing_ParseGiteaPull_OngoingVerification) GetCapturedArguments() *vcs.GiteaPullRequest {
+	pull := c.GetAllCapturedArguments()
+	return pull[len(pull)-1]
+}
+
+func (c *EventParsing_ParseGiteaPull_OngoingVerification) GetAllCapturedArguments() (_param0 []*vcs.GiteaPullRequest) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]*vcs.GiteaPullRequest, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(*vcs.GiteaPullRequest)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierEventParsing) ParseGiteaRepo(repo *vcs.GiteaRepository) *EventParsing_ParseGiteaRepo_OngoingVerification {
+	params := []pegomock.Param{repo}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGiteaRepo", params)
+	return &EventParsing_ParseGiteaRepo_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type EventParsing_ParseGiteaRepo_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *EventParsing_ParseGiteaRepo_OngoingVerification) GetCapturedArguments() *vcs.GiteaRepository {
+	repo := c.GetAllCapturedArguments()
+	return repo[len(repo)-1]
+}
+
+func (c *EventParsing_ParseGiteaRepo_OngoingVerification) GetAllCapturedArguments() (_param0 []*vcs.GiteaRepository) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]*vcs.GiteaRepository, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(*vcs.GiteaRepository)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierEventParsing) ParseAzureDevopsPullCommentEvent(event vcs.AzureDevopsPullCommentEvent) *EventParsing_ParseAzureDevopsPullCommentEvent_OngoingVerification {
+	params := []pegomock.Param{event}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseAzureDevopsPullCommentEvent", params)
+	return &EventParsing_ParseAzureDevopsPullCommentEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type EventParsing_ParseAzureDevopsPullCommentEvent_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *EventParsing_ParseAzureDevopsPullCommentEvent_OngoingVerification) GetCapturedArguments() vcs.AzureDevopsPullCommentEvent {
+	event := c.GetAllCapturedArguments()
+	return event[len(event)-1]
+}
+
+func (c *EventParsing_ParseAzureDevopsPullCommentEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []vcs.AzureDevopsPullCommentEvent) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]vcs.AzureDevopsPullCommentEvent, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(vcs.AzureDevopsPullCommentEvent)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierEventParsing) ParseAzureDevopsPull(pull *vcs.AzureDevopsPullRequest) *EventParsing_ParseAzureDevopsPull_OngoingVerification {
+	params := []pegomock.Param{pull}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseAzureDevopsPull", params)
+	return &EventParsing_ParseAzureDevopsPull_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type EventParsing_ParseAzureDevopsPull_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *EventParsing_ParseAzureDevopsPull_OngoingVerification) GetCapturedArguments() *vcs.AzureDevopsPullRequest {
+	pull := c.GetAllCapturedArguments()
+	return pull[len(pull)-1]
+}
+
+func (c *EventParsing_ParseAzureDevopsPull_OngoingVerification) GetAllCapturedArguments() (_param0 []*vcs.AzureDevopsPullRequest) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]*vcs.AzureDevopsPullRequest, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(*vcs.AzureDevopsPullRequest)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierEventParsing) ParseAzureDevopsRepo(repo *vcs.AzureDevopsRepository) *EventParsing_ParseAzureDevopsRepo_OngoingVerification {
+	params := []pegomock.Param{repo}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseAzureDevopsRepo", params)
+	return &EventParsing_ParseAzureDevopsRepo_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type EventParsing_ParseAzureDevopsRepo_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *EventParsing_ParseAzureDevopsRepo_OngoingVerification) GetCapturedArguments() *vcs.AzureDevopsRepository {
+	repo := c.GetAllCapturedArguments()
+	return repo[len(repo)-1]
+}
+
+func (c *EventParsing_ParseAzureDevopsRepo_OngoingVerification) GetAllCapturedArguments() (_param0 []*vcs.AzureDevopsRepository) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]*vcs.AzureDevopsRepository, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(*vcs.AzureDevopsRepository)
+		}
+	}
+	return
+}
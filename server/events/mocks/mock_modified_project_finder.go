@@ -19,8 +19,8 @@ func NewMockModifiedProjectFinder() *MockModifiedProjectFinder {
 	return &MockModifiedProjectFinder{fail: pegomock.GlobalFailHandler}
 }
 
-func (mock *MockModifiedProjectFinder) FindModified(log *logging.SimpleLogger, modifiedFiles []string, repoFullName string) []models.Project {
-	params := []pegomock.Param{log, modifiedFiles, repoFullName}
+func (mock *MockModifiedProjectFinder) FindModified(log *logging.SimpleLogger, repoDir string, modifiedFiles []string, repoHostname string, repoFullName string) []models.Project {
+	params := []pegomock.Param{log, repoDir, modifiedFiles, repoHostname, repoFullName}
 	result := pegomock.GetGenericMockFrom(mock).Invoke("FindModified", params, []reflect.Type{reflect.TypeOf((*[]models.Project)(nil)).Elem()})
 	var ret0 []models.Project
 	if len(result) != 0 {
@@ -49,8 +49,8 @@ type VerifierModifiedProjectFinder struct {
 	inOrderContext         *pegomock.InOrderContext
 }
 
-func (verifier *VerifierModifiedProjectFinder) FindModified(log *logging.SimpleLogger, modifiedFiles []string, repoFullName string) *ModifiedProjectFinder_FindModified_OngoingVerification {
-	params := []pegomock.Param{log, modifiedFiles, repoFullName}
+func (verifier *VerifierModifiedProjectFinder) FindModified(log *logging.SimpleLogger, repoDir string, modifiedFiles []string, repoHostname string, repoFullName string) *ModifiedProjectFinder_FindModified_OngoingVerification {
+	params := []pegomock.Param{log, repoDir, modifiedFiles, repoHostname, repoFullName}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "FindModified", params)
 	return &ModifiedProjectFinder_FindModified_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -60,25 +60,33 @@ type ModifiedProjectFinder_FindModified_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *ModifiedProjectFinder_FindModified_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, []string, string) {
-	log, modifiedFiles, repoFullName := c.GetAllCapturedArguments()
-	return log[len(log)-1], modifiedFiles[len(modifiedFiles)-1], repoFullName[len(repoFullName)-1]
+func (c *ModifiedProjectFinder_FindModified_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, string, []string, string, string) {
+	log, repoDir, modifiedFiles, repoHostname, repoFullName := c.GetAllCapturedArguments()
+	return log[len(log)-1], repoDir[len(repoDir)-1], modifiedFiles[len(modifiedFiles)-1], repoHostname[len(repoHostname)-1], repoFullName[len(repoFullName)-1]
 }
 
-func (c *ModifiedProjectFinder_FindModified_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 [][]string, _param2 []string) {
+func (c *ModifiedProjectFinder_FindModified_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 []string, _param2 [][]string, _param3 []string, _param4 []string) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]*logging.SimpleLogger, len(params[0]))
 		for u, param := range params[0] {
 			_param0[u] = param.(*logging.SimpleLogger)
 		}
-		_param1 = make([][]string, len(params[1]))
+		_param1 = make([]string, len(params[1]))
 		for u, param := range params[1] {
-			_param1[u] = param.([]string)
+			_param1[u] = param.(string)
 		}
-		_param2 = make([]string, len(params[2]))
+		_param2 = make([][]string, len(params[2]))
 		for u, param := range params[2] {
-			_param2[u] = param.(string)
+			_param2[u] = param.([]string)
+		}
+		_param3 = make([]string, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(string)
+		}
+		_param4 = make([]string, len(params[4]))
+		for u, param := range params[4] {
+			_param4[u] = param.(string)
 		}
 	}
 	return
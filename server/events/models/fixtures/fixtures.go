@@ -6,6 +6,7 @@ var Pull = models.PullRequest{
 	Num:        1,
 	HeadCommit: "16ca62f65c18ff456c6ef4cacc8d4826e264bb17",
 	Branch:     "branch",
+	BaseBranch: "master",
 	Author:     "lkysow",
 	URL:        "url",
 }
@@ -23,6 +23,9 @@ type Repo struct {
 	// SanitizedCloneURL is the full HTTPS url for cloning without the username and password.
 	// ex. "https://github.com/atlantis/atlantis.git".
 	SanitizedCloneURL string
+	// Hostname is the hostname of the VCS host this repo lives on,
+	// ex. "github.com" or "gitlab.mycompany.com".
+	Hostname string
 }
 
 // PullRequest is a VCS pull request.
@@ -38,6 +41,9 @@ type PullRequest struct {
 	URL string
 	// Branch is the name of the head branch (not the base).
 	Branch string
+	// BaseBranch is the name of the branch the pull request is into,
+	// ex. "master".
+	BaseBranch string
 	// Author is the username of the pull request author.
 	Author string
 	// State will be one of Open or Closed.
@@ -79,6 +85,10 @@ type ProjectLock struct {
 // Terraform projects in a single repo we also include Path to the project
 // root relative to the repo root.
 type Project struct {
+	// RepoHostname is the hostname of the VCS host the repo lives on, ex.
+	// "github.com". It's needed to tell apart repos with the same name on
+	// different hosts.
+	RepoHostname string
 	// RepoFullName is the owner and repo name, ex. "hootsuite/atlantis"
 	RepoFullName string
 	// Path to project root in the repo.
@@ -99,12 +109,13 @@ type Plan struct {
 
 // NewProject constructs a Project. Use this constructor because it
 // sets Path correctly.
-func NewProject(repoFullName string, path string) Project {
+func NewProject(repoHostname string, repoFullName string, path string) Project {
 	path = paths.Clean(path)
 	if path == "/" {
 		path = "."
 	}
 	return Project{
+		RepoHostname: repoHostname,
 		RepoFullName: repoFullName,
 		Path:         path,
 	}
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -27,8 +28,10 @@ const atlantisUserTFVar = "atlantis_user"
 
 // PlanExecutor handles everything related to running terraform plan.
 type PlanExecutor struct {
-	VCSClient         vcs.ClientProxy
-	Terraform         terraform.Runner
+	VCSClient vcs.ClientProxy
+	Terraform terraform.Runner
+	// Terragrunt runs the commands of projects that use terragrunt.
+	Terragrunt        terraform.Runner
 	Locker            locking.Locker
 	LockURL           func(id string) (url string)
 	Run               run.Runner
@@ -53,21 +56,39 @@ func (p *PlanExecutor) Execute(ctx *CommandContext) CommandResponse {
 		return CommandResponse{Error: errors.Wrap(err, "getting modified files")}
 	}
 	ctx.Log.Info("found %d files modified in this pull request", len(modifiedFiles))
-	projects := p.ProjectFinder.FindModified(ctx.Log, modifiedFiles, ctx.BaseRepo.FullName)
-	if len(projects) == 0 {
+	if len(filterToTerraform(modifiedFiles)) == 0 {
 		return CommandResponse{Failure: "No Terraform files were modified."}
 	}
 
+	// the repo is cloned before finding the projects so their config files
+	// can be read to find which projects modified var files belong to
 	cloneDir, err := p.Workspace.Clone(ctx.Log, ctx.BaseRepo, ctx.HeadRepo, ctx.Pull, ctx.Command.Environment)
 	if err != nil {
 		return CommandResponse{Error: err}
 	}
+	projects := p.ProjectFinder.FindModified(ctx.Log, cloneDir, modifiedFiles, ctx.BaseRepo.Hostname, ctx.BaseRepo.FullName)
+	if len(projects) == 0 {
+		return CommandResponse{Failure: "No Terraform files were modified."}
+	}
 
-	results := []ProjectResult{}
+	// Terragrunt projects are planned after the projects they depend on.
+	var paths []string
+	byPath := make(map[string]models.Project)
 	for _, project := range projects {
+		paths = append(paths, project.Path)
+		byPath[project.Path] = project
+	}
+	results := []ProjectResult{}
+	for _, path := range sortByDependencies(ctx.Log, cloneDir, paths) {
+		project := byPath[path]
+		if ctx.Canceled() {
+			results = append(results, ProjectResult{Path: project.Path, ProjectPath: project.Path, Failure: "The plan was canceled before it ran for this project."})
+			continue
+		}
 		ctx.Log.Info("running plan for project at path %q", project.Path)
 		result := p.plan(ctx, cloneDir, project)
 		result.Path = project.Path
+		result.ProjectPath = project.Path
 		results = append(results, result)
 	}
 	return CommandResponse{ProjectResults: results}
@@ -78,37 +99,92 @@ func (p *PlanExecutor) Execute(ctx *CommandContext) CommandResponse {
 func (p *PlanExecutor) plan(ctx *CommandContext, repoDir string, project models.Project) ProjectResult {
 	preExecute := p.ProjectPreExecute.Execute(ctx, repoDir, project)
 	if preExecute.ProjectResult != (ProjectResult{}) {
+		// If the plan was canceled there's nothing to apply so don't hold
+		// the lock.
+		if ctx.Canceled() && preExecute.LockResponse.LockKey != "" {
+			if _, unlockErr := p.Locker.Unlock(preExecute.LockResponse.LockKey); unlockErr != nil {
+				ctx.Log.Err("error unlocking state after plan was canceled: %v", unlockErr)
+			}
+		}
 		return preExecute.ProjectResult
 	}
 	config := preExecute.ProjectConfig
 	terraformVersion := preExecute.TerraformVersion
 	tfEnv := ctx.Command.Environment
+	absolutePath := filepath.Join(repoDir, project.Path)
+	tf, err := terraformRunner(config, p.Terraform, p.Terragrunt)
+	if err != nil {
+		return p.unlockAfterErr(ctx, preExecute, ProjectResult{Error: err})
+	}
 
 	// Run terraform plan
-	planFile := filepath.Join(repoDir, project.Path, fmt.Sprintf("%s.tfplan", tfEnv))
+	planFile := planFilePath(absolutePath, tfEnv)
 	userVar := fmt.Sprintf("%s=%s", atlantisUserTFVar, ctx.User.Username)
 	planExtraArgs := config.GetExtraArguments(ctx.Command.Name.String())
 	tfPlanCmd := append(append([]string{"plan", "-refresh", "-no-color", "-out", planFile, "-var", userVar}, planExtraArgs...), ctx.Command.Flags...)
 
-	// check if env/{environment}.tfvars exist
-	tfEnvFileName := filepath.Join("env", tfEnv+".tfvars")
-	if _, err := os.Stat(filepath.Join(repoDir, project.Path, tfEnvFileName)); err == nil {
-		tfPlanCmd = append(tfPlanCmd, "-var-file", tfEnvFileName)
-	}
-	output, err := p.Terraform.RunCommandWithVersion(ctx.Log, filepath.Join(repoDir, project.Path), tfPlanCmd, terraformVersion, tfEnv)
+	// add the var files for the environment, by default env/{environment}.tfvars
+	varFiles, err := config.GetVarFiles(absolutePath, tfEnv)
 	if err != nil {
-		// plan failed so unlock the state
-		if _, unlockErr := p.Locker.Unlock(preExecute.LockResponse.LockKey); unlockErr != nil {
-			ctx.Log.Err("error unlocking state after plan error: %v", unlockErr)
+		return p.unlockAfterErr(ctx, preExecute, ProjectResult{Error: err})
+	}
+	for _, varFile := range varFiles {
+		// terragrunt runs terraform in a copy of the project so relative
+		// paths won't be found
+		if config.Terragrunt {
+			varFile = filepath.Join(absolutePath, varFile)
 		}
-		return ProjectResult{Error: fmt.Errorf("%s\n%s", err.Error(), output)}
+		tfPlanCmd = append(tfPlanCmd, "-var-file", varFile)
+	}
+	if len(varFiles) > 0 {
+		ctx.Log.Info("planning with var files %v", varFiles)
+	}
+	runPlan := func(cmdCtx context.Context) (string, error) {
+		return tf.RunCommandWithVersion(cmdCtx, ctx.Log, ctx.Output, absolutePath, tfPlanCmd, terraformVersion, tfEnv)
+	}
+
+	var output string
+	var res ProjectResult
+	steps := config.Workflow.Steps(ctx.Command.Name)
+	if steps != nil {
+		w := &workflowRun{
+			ctx:              ctx,
+			terraform:        tf,
+			run:              p.Run,
+			config:           config,
+			path:             absolutePath,
+			terraformVersion: terraformVersion,
+			command:          runPlan,
+		}
+		output, res = w.runSteps(steps)
+	} else {
+		planCtx, cancel := run.WithTimeout(config.commandContext(ctx.Context), config.Timeouts.Plan)
+		var err error
+		output, err = runPlan(planCtx)
+		cancel()
+		if timeoutRes, ok := timeoutResult(err, "`terraform plan`"); ok {
+			res = timeoutRes
+		} else if err != nil {
+			res = ProjectResult{Error: fmt.Errorf("%s\n%s", err.Error(), output)}
+		}
+	}
+	if res != (ProjectResult{}) {
+		// plan failed so unlock the state and make sure a partially written
+		// plan can't be applied
+		if removeErr := os.Remove(planFile); removeErr != nil && !os.IsNotExist(removeErr) {
+			ctx.Log.Err("error removing plan file after plan error: %v", removeErr)
+		}
+		return p.unlockAfterErr(ctx, preExecute, res)
 	}
 	ctx.Log.Info("plan succeeded")
 
-	// if there are post plan commands then run them
-	if len(config.PostPlan) > 0 {
-		absolutePath := filepath.Join(repoDir, project.Path)
-		_, err := p.Run.Execute(ctx.Log, config.PostPlan, absolutePath, tfEnv, terraformVersion, "post_plan")
+	// if there are post plan commands then run them, unless a custom
+	// workflow ran its own
+	if steps == nil && len(config.PostPlan) > 0 {
+		err := runHooks(ctx, p.Run, config, config.PostPlan, absolutePath, terraformVersion, "post_plan")
+		if res, ok := timeoutResult(err, "the post_plan commands"); ok {
+			return res
+		}
 		if err != nil {
 			return ProjectResult{Error: errors.Wrap(err, "running post plan commands")}
 		}
@@ -121,3 +197,12 @@ func (p *PlanExecutor) plan(ctx *CommandContext, repoDir string, project models.
 		},
 	}
 }
+
+// unlockAfterErr unlocks the state of a project whose plan failed with res
+// and returns res.
+func (p *PlanExecutor) unlockAfterErr(ctx *CommandContext, preExecute PreExecuteResult, res ProjectResult) ProjectResult {
+	if _, unlockErr := p.Locker.Unlock(preExecute.LockResponse.LockKey); unlockErr != nil {
+		ctx.Log.Err("error unlocking state after plan error: %v", unlockErr)
+	}
+	return res
+}
@@ -1,16 +1,24 @@
 package events_test
 
 import (
+	"context"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/hashicorp/go-version"
 	"github.com/hootsuite/atlantis/server/events"
 	"github.com/hootsuite/atlantis/server/events/locking"
 	lmocks "github.com/hootsuite/atlantis/server/events/locking/mocks"
 	"github.com/hootsuite/atlantis/server/events/mocks"
 	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/run"
 	rmocks "github.com/hootsuite/atlantis/server/events/run/mocks"
+	rmatchers "github.com/hootsuite/atlantis/server/events/run/mocks/matchers"
 	tmocks "github.com/hootsuite/atlantis/server/events/terraform/mocks"
+	tmatchers "github.com/hootsuite/atlantis/server/events/terraform/mocks/matchers"
 	vcsmocks "github.com/hootsuite/atlantis/server/events/vcs/mocks"
 	"github.com/hootsuite/atlantis/server/events/vcs/mocks/matchers"
 	"github.com/hootsuite/atlantis/server/logging"
@@ -32,6 +40,14 @@ var planCtx = events.CommandContext{
 	},
 }
 
+// planHook is what the hooks of the project at . are run for with planCtx.
+var planHook = run.HookContext{
+	Environment: "env",
+	ProjectDir:  "/tmp/clone-repo",
+	User:        "anubhavmishra",
+	PlanFile:    "/tmp/clone-repo/env.tfplan",
+}
+
 func TestExecute_ModifiedFilesErr(t *testing.T) {
 	t.Log("If GetModifiedFiles returns an error we return an error")
 	p, _, _ := setupPlanExecutorTest(t)
@@ -50,6 +66,12 @@ func TestExecute_NoModifiedProjects(t *testing.T) {
 	r := p.Execute(&planCtx)
 
 	Equals(t, "No Terraform files were modified.", r.Failure)
+
+	t.Log("the repo shouldn't be cloned if no terraform files were modified")
+	When(p.VCSClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())).ThenReturn([]string{"README.md"}, nil)
+	r = p.Execute(&planCtx)
+	Equals(t, "No Terraform files were modified.", r.Failure)
+	p.Workspace.(*mocks.MockWorkspace).VerifyWasCalled(Never()).Clone(planCtx.Log, planCtx.BaseRepo, planCtx.HeadRepo, planCtx.Pull, "env")
 }
 
 func TestExecute_CloneErr(t *testing.T) {
@@ -79,7 +101,9 @@ func TestExecute_Success(t *testing.T) {
 	r := p.Execute(&planCtx)
 
 	runner.VerifyWasCalledOnce().RunCommandWithVersion(
+		nil,
 		planCtx.Log,
+		nil,
 		"/tmp/clone-repo",
 		[]string{"plan", "-refresh", "-no-color", "-out", "/tmp/clone-repo/env.tfplan", "-var", "atlantis_user=anubhavmishra"},
 		nil,
@@ -126,7 +150,9 @@ func TestExecute_MultiProjectFailure(t *testing.T) {
 
 	// The first project will fail when running plan
 	When(runner.RunCommandWithVersion(
+		nil,
 		planCtx.Log,
+		nil,
 		"/tmp/clone-repo/path1",
 		[]string{"plan", "-refresh", "-no-color", "-out", "/tmp/clone-repo/path1/env.tfplan", "-var", "atlantis_user=anubhavmishra"},
 		nil,
@@ -161,7 +187,7 @@ func TestExecute_PostPlanCommands(t *testing.T) {
 		ThenReturn(events.PreExecuteResult{
 			ProjectConfig: events.ProjectConfig{PostPlan: []string{"post-plan"}},
 		})
-	When(p.Run.Execute(planCtx.Log, []string{"post-plan"}, "/tmp/clone-repo", "env", nil, "post_plan")).
+	When(p.Run.Execute(nil, planCtx.Log, nil, []string{"post-plan"}, planHook, "post_plan")).
 		ThenReturn("", errors.New("err"))
 
 	r := p.Execute(&planCtx)
@@ -172,6 +198,197 @@ func TestExecute_PostPlanCommands(t *testing.T) {
 	Equals(t, "running post plan commands: err", result.Error.Error())
 }
 
+func TestExecute_Canceled(t *testing.T) {
+	t.Log("If the plan is canceled, the project's lock should be released and the remaining projects skipped")
+	p, _, locker := setupPlanExecutorTest(t)
+	cmdCtx, cancel := context.WithCancel(context.Background())
+	ctx := planCtx
+	ctx.Context = cmdCtx
+	When(p.VCSClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())).ThenReturn([]string{"path1/file.tf", "path2/file.tf"}, nil)
+	When(p.Workspace.Clone(ctx.Log, ctx.BaseRepo, ctx.HeadRepo, ctx.Pull, "env")).
+		ThenReturn("/tmp/clone-repo", nil)
+	When(p.ProjectPreExecute.Execute(&ctx, "/tmp/clone-repo", models.Project{RepoFullName: "", Path: "path1"})).
+		Then(func(params []Param) ReturnValues {
+			cancel()
+			return []ReturnValue{events.PreExecuteResult{
+				ProjectResult: events.ProjectResult{Error: errors.New("canceled")},
+				LockResponse:  locking.TryLockResponse{LockKey: "key1"},
+			}}
+		})
+
+	r := p.Execute(&ctx)
+
+	locker.VerifyWasCalledOnce().Unlock("key1")
+	p.ProjectPreExecute.(*mocks.MockProjectPreExecutor).VerifyWasCalled(Never()).Execute(&ctx, "/tmp/clone-repo", models.Project{RepoFullName: "", Path: "path2"})
+	Assert(t, len(r.ProjectResults) == 2, "exp two project results")
+	Equals(t, "canceled", r.ProjectResults[0].Error.Error())
+	Equals(t, "path2", r.ProjectResults[1].Path)
+	Equals(t, "The plan was canceled before it ran for this project.", r.ProjectResults[1].Failure)
+}
+
+func TestExecute_Workflow(t *testing.T) {
+	t.Log("a custom workflow's steps should be run in order with its env and their output returned")
+	p, runner, _ := setupPlanExecutorTest(t)
+	When(p.VCSClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())).ThenReturn([]string{"file.tf"}, nil)
+	When(p.Workspace.Clone(planCtx.Log, planCtx.BaseRepo, planCtx.HeadRepo, planCtx.Pull, "env")).
+		ThenReturn("/tmp/clone-repo", nil)
+	tfVersion, _ := version.NewVersion("0.11.0")
+	When(p.ProjectPreExecute.Execute(&planCtx, "/tmp/clone-repo", models.Project{RepoFullName: "", Path: "."})).
+		ThenReturn(events.PreExecuteResult{
+			TerraformVersion: tfVersion,
+			ProjectConfig: events.ProjectConfig{
+				PostPlan: []string{"ignored"},
+				Workflow: events.Workflow{Plan: []events.Step{
+					{Name: "env", EnvName: "FOO", EnvValue: "bar"},
+					{Name: "init"},
+					{Name: "run", Command: "make"},
+					{Name: "plan"},
+				}},
+			},
+		})
+	var runEnv map[string]string
+	var runCommands []string
+	var runHook run.HookContext
+	var runStage string
+	When(p.Run.Execute(rmatchers.AnyContextContext(), rmatchers.AnyPtrToLoggingSimpleLogger(), rmatchers.AnyIoWriter(), rmatchers.AnySliceOfString(), rmatchers.AnyRunHookContext(), AnyString())).
+		Then(func(params []Param) ReturnValues {
+			runEnv = run.Env(params[0].(context.Context))
+			runCommands = params[3].([]string)
+			runHook = params[4].(run.HookContext)
+			runStage = params[5].(string)
+			return []ReturnValue{"make output", nil}
+		})
+	When(runner.RunCommandWithVersion(tmatchers.AnyContextContext(), tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyIoWriter(), AnyString(), tmatchers.AnySliceOfString(), tmatchers.AnyPtrToGoVersionVersion(), AnyString())).
+		ThenReturn("plan output", nil)
+
+	r := p.Execute(&planCtx)
+
+	Assert(t, len(r.ProjectResults) == 1, "exp one project result")
+	Equals(t, "make output\nplan output", r.ProjectResults[0].PlanSuccess.TerraformOutput)
+	Equals(t, map[string]string{"FOO": "bar"}, runEnv)
+	runner.VerifyWasCalledOnce().RunInitAndEnv(tmatchers.AnyContextContext(), tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyIoWriter(), EqString("/tmp/clone-repo"), EqString("env"), tmatchers.AnySliceOfString(), tmatchers.EqPtrToGoVersionVersion(tfVersion))
+	Equals(t, []string{"make"}, runCommands)
+	expHook := planHook
+	expHook.TerraformVersion = tfVersion
+	Equals(t, expHook, runHook)
+	Equals(t, "run", runStage)
+}
+
+func TestExecute_WorkflowStepErr(t *testing.T) {
+	t.Log("if a custom workflow's step fails the steps after it shouldn't run and the project should be unlocked")
+	p, runner, locker := setupPlanExecutorTest(t)
+	When(p.VCSClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())).ThenReturn([]string{"file.tf"}, nil)
+	When(p.Workspace.Clone(planCtx.Log, planCtx.BaseRepo, planCtx.HeadRepo, planCtx.Pull, "env")).
+		ThenReturn("/tmp/clone-repo", nil)
+	When(p.ProjectPreExecute.Execute(&planCtx, "/tmp/clone-repo", models.Project{RepoFullName: "", Path: "."})).
+		ThenReturn(events.PreExecuteResult{
+			LockResponse: locking.TryLockResponse{LockKey: "key"},
+			ProjectConfig: events.ProjectConfig{
+				Workflow: events.Workflow{Plan: []events.Step{
+					{Name: "run", Command: "make"},
+					{Name: "plan"},
+				}},
+			},
+		})
+	When(p.Run.Execute(nil, planCtx.Log, nil, []string{"make"}, planHook, "run")).
+		ThenReturn("", errors.New("err"))
+
+	r := p.Execute(&planCtx)
+
+	Assert(t, len(r.ProjectResults) == 1, "exp one project result")
+	Equals(t, "running step 1, `make`: err", r.ProjectResults[0].Error.Error())
+	locker.VerifyWasCalledOnce().Unlock("key")
+	runner.VerifyWasCalled(Never()).RunCommandWithVersion(tmatchers.AnyContextContext(), tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyIoWriter(), AnyString(), tmatchers.AnySliceOfString(), tmatchers.AnyPtrToGoVersionVersion(), AnyString())
+}
+
+func TestExecute_VarFiles(t *testing.T) {
+	t.Log("the project's var files for the environment should be passed to plan")
+	p, runner, _ := setupPlanExecutorTest(t)
+	cloneDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(cloneDir) // nolint: errcheck
+	for _, file := range []string{"common.tfvars", "vars/env/a.tfvars", "vars/other/a.tfvars"} {
+		Ok(t, os.MkdirAll(filepath.Join(cloneDir, filepath.Dir(file)), 0755))
+		Ok(t, ioutil.WriteFile(filepath.Join(cloneDir, file), nil, 0644))
+	}
+	When(p.VCSClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())).ThenReturn([]string{"file.tf"}, nil)
+	When(p.Workspace.Clone(planCtx.Log, planCtx.BaseRepo, planCtx.HeadRepo, planCtx.Pull, "env")).
+		ThenReturn(cloneDir, nil)
+	When(p.ProjectPreExecute.Execute(&planCtx, cloneDir, models.Project{RepoFullName: "", Path: "."})).
+		ThenReturn(events.PreExecuteResult{
+			ProjectConfig: events.ProjectConfig{VarFiles: []string{"common.tfvars", "vars/{{workspace}}/*.tfvars"}},
+		})
+
+	p.Execute(&planCtx)
+
+	runner.VerifyWasCalledOnce().RunCommandWithVersion(
+		nil,
+		planCtx.Log,
+		nil,
+		cloneDir,
+		[]string{"plan", "-refresh", "-no-color", "-out", filepath.Join(cloneDir, "env.tfplan"), "-var", "atlantis_user=anubhavmishra", "-var-file", "common.tfvars", "-var-file", "vars/env/a.tfvars"},
+		nil,
+		"env",
+	)
+}
+
+func TestExecute_Terragrunt(t *testing.T) {
+	t.Log("terragrunt projects should be planned with terragrunt after the projects they depend on")
+	p, runner, _ := setupPlanExecutorTest(t)
+	terragrunt := tmocks.NewMockRunner()
+	p.Terragrunt = terragrunt
+	cloneDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(cloneDir) // nolint: errcheck
+	for dir, config := range map[string]string{
+		"app": "dependency \"vpc\" {\n  config_path = \"../vpc\"\n}\n",
+		"vpc": "dependencies {\n  paths = [\"../account\"]\n}\n",
+	} {
+		Ok(t, os.MkdirAll(filepath.Join(cloneDir, dir), 0755))
+		Ok(t, ioutil.WriteFile(filepath.Join(cloneDir, dir, "terragrunt.hcl"), []byte(config), 0644))
+	}
+	When(p.VCSClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())).ThenReturn([]string{"app/terragrunt.hcl", "vpc/terragrunt.hcl"}, nil)
+	When(p.Workspace.Clone(planCtx.Log, planCtx.BaseRepo, planCtx.HeadRepo, planCtx.Pull, "env")).
+		ThenReturn(cloneDir, nil)
+	for _, dir := range []string{"app", "vpc"} {
+		When(p.ProjectPreExecute.Execute(&planCtx, cloneDir, models.Project{RepoFullName: "", Path: dir})).
+			ThenReturn(events.PreExecuteResult{ProjectConfig: events.ProjectConfig{Terragrunt: true}})
+	}
+	var planned []string
+	When(terragrunt.RunCommandWithVersion(tmatchers.AnyContextContext(), tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyIoWriter(), AnyString(), tmatchers.AnySliceOfString(), tmatchers.AnyPtrToGoVersionVersion(), AnyString())).
+		Then(func(params []Param) ReturnValues {
+			planned = append(planned, params[3].(string))
+			return []ReturnValue{"", nil}
+		})
+
+	r := p.Execute(&planCtx)
+
+	Assert(t, len(r.ProjectResults) == 2, "exp two project results")
+	Equals(t, "vpc", r.ProjectResults[0].Path)
+	Equals(t, "app", r.ProjectResults[1].Path)
+	Equals(t, []string{filepath.Join(cloneDir, "vpc"), filepath.Join(cloneDir, "app")}, planned)
+	runner.VerifyWasCalled(Never()).RunCommandWithVersion(tmatchers.AnyContextContext(), tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyIoWriter(), AnyString(), tmatchers.AnySliceOfString(), tmatchers.AnyPtrToGoVersionVersion(), AnyString())
+}
+
+func TestExecute_TerragruntNotConfigured(t *testing.T) {
+	t.Log("if a project uses terragrunt but there's no terragrunt runner it should be an error")
+	p, _, locker := setupPlanExecutorTest(t)
+	When(p.VCSClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())).ThenReturn([]string{"file.tf"}, nil)
+	When(p.Workspace.Clone(planCtx.Log, planCtx.BaseRepo, planCtx.HeadRepo, planCtx.Pull, "env")).
+		ThenReturn("/tmp/clone-repo", nil)
+	When(p.ProjectPreExecute.Execute(&planCtx, "/tmp/clone-repo", models.Project{RepoFullName: "", Path: "."})).
+		ThenReturn(events.PreExecuteResult{
+			LockResponse:  locking.TryLockResponse{LockKey: "key"},
+			ProjectConfig: events.ProjectConfig{Terragrunt: true},
+		})
+
+	r := p.Execute(&planCtx)
+
+	Assert(t, len(r.ProjectResults) == 1, "exp one project result")
+	Equals(t, "project uses terragrunt but terragrunt isn't configured", r.ProjectResults[0].Error.Error())
+	locker.VerifyWasCalledOnce().Unlock("key")
+}
+
 func setupPlanExecutorTest(t *testing.T) (*events.PlanExecutor, *tmocks.MockRunner, *lmocks.MockLocker) {
 	RegisterMockTestingT(t)
 	vcsProxy := vcsmocks.NewMockClientProxy()
@@ -1,9 +1,13 @@
 package events
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
@@ -12,6 +16,14 @@ import (
 
 const ProjectConfigFile = "atlantis.yaml"
 
+// WorkspaceVarFileToken is replaced with the workspace, or environment, that
+// a command is run for in var_files patterns.
+const WorkspaceVarFileToken = "{{workspace}}"
+
+// DefaultVarFiles are the var file patterns for projects that don't set
+// var_files.
+var DefaultVarFiles = []string{"env/" + WorkspaceVarFileToken + ".tfvars"}
+
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_project_config_reader.go ProjectConfigReader
 
 // ProjectConfigReader implements reading project config.
@@ -39,6 +51,52 @@ type projectConfigYAML struct {
 	PostApply        Hook                    `yaml:"post_apply"`
 	TerraformVersion string                  `yaml:"terraform_version"`
 	ExtraArguments   []commandExtraArguments `yaml:"extra_arguments"`
+	Timeouts         timeoutsYAML            `yaml:"timeouts"`
+	Workflow         string                  `yaml:"workflow"`
+	Workflows        map[string]workflowYAML `yaml:"workflows"`
+	Terragrunt       *bool                   `yaml:"terragrunt"`
+	VarFiles         []string                `yaml:"var_files"`
+	Env              []EnvVar                `yaml:"env"`
+}
+
+// timeoutsYAML is used to parse the timeouts. They're parsed as strings so
+// that a number without a unit is an error rather than nanoseconds.
+type timeoutsYAML struct {
+	Init  string `yaml:"init"`
+	Plan  string `yaml:"plan"`
+	Apply string `yaml:"apply"`
+	Hooks string `yaml:"hooks"`
+}
+
+// Timeouts are how long each stage of a command can run for before it's
+// stopped. A zero timeout means there's no limit.
+type Timeouts struct {
+	// Init is the timeout for terraform init and selecting the environment,
+	// or terraform get for terraform < 0.9.0.
+	Init time.Duration
+	// Plan is the timeout for terraform plan.
+	Plan time.Duration
+	// Apply is the timeout for terraform apply.
+	Apply time.Duration
+	// Hooks is the timeout for each stage's commands, ex. pre_plan.
+	Hooks time.Duration
+}
+
+// WithDefaults returns t with its zero timeouts set to those in defaults.
+func (t Timeouts) WithDefaults(defaults Timeouts) Timeouts {
+	if t.Init == 0 {
+		t.Init = defaults.Init
+	}
+	if t.Plan == 0 {
+		t.Plan = defaults.Plan
+	}
+	if t.Apply == 0 {
+		t.Apply = defaults.Apply
+	}
+	if t.Hooks == 0 {
+		t.Hooks = defaults.Hooks
+	}
+	return t
 }
 
 // ProjectConfig is a more usable version of projectConfigYAML that we can
@@ -57,8 +115,34 @@ type ProjectConfig struct {
 	// PostApply is a slice of command strings to run after terraform apply.
 	PostApply []string
 	// TerraformVersion is the version specified in the config file or nil
-	// if version wasn't specified.
+	// if version wasn't specified or is constraints.
 	TerraformVersion *version.Version
+	// TerraformVersionConstraints are the constraints specified in the config
+	// file instead of an exact version, ex. "~> 0.11.0", or nil.
+	TerraformVersionConstraints version.Constraints
+	// Timeouts are the timeouts specified in the config file. Unspecified
+	// timeouts are zero.
+	Timeouts Timeouts
+	// WorkflowName is the name of the workflow the project uses.
+	WorkflowName string
+	// Workflow is the workflow the project uses. Its stages are nil if the
+	// project uses the built-in workflow.
+	Workflow Workflow
+	// Terragrunt is true if the project's commands are run with terragrunt
+	// instead of terraform. If it isn't set in the config file, it's true
+	// when the project has a terragrunt.hcl file.
+	Terragrunt bool
+	// VarFiles are the patterns of the var files to plan with, relative to
+	// the project, ex. vars/{{workspace}}/*.tfvars. If nil, DefaultVarFiles
+	// are used. Callers should use GetVarFiles to find the files.
+	VarFiles []string
+	// EnvVars are the environment variables the config file sets for the
+	// project's commands.
+	EnvVars []EnvVar
+	// Env is the values of the environment variables that are set for the
+	// project's commands, from EnvVars and the server's config. It's set by
+	// ProjectPreExecute.
+	Env map[string]string
 	// extraArguments is the extra args that we should tack on to certain
 	// terraform commands. It shouldn't be used directly and instead callers
 	// should use the GetExtraArguments method on ProjectConfig.
@@ -100,22 +184,98 @@ func (c *ProjectConfigManager) Read(execPath string) (ProjectConfig, error) {
 		return pc, errors.Wrapf(err, "parsing %s", ProjectConfigFile)
 	}
 
+	// terraform_version is either an exact version or constraints like
+	// "~> 0.11.0" that are resolved when the project is run.
 	var v *version.Version
+	var constraints version.Constraints
 	if pcYaml.TerraformVersion != "" {
 		v, err = version.NewVersion(pcYaml.TerraformVersion)
 		if err != nil {
-			return pc, errors.Wrap(err, "parsing terraform_version")
+			constraints, err = version.NewConstraint(pcYaml.TerraformVersion)
+			if err != nil {
+				return pc, errors.Wrap(err, "parsing terraform_version")
+			}
+		}
+	}
+	var timeouts Timeouts
+	for _, t := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"init", pcYaml.Timeouts.Init, &timeouts.Init},
+		{"plan", pcYaml.Timeouts.Plan, &timeouts.Plan},
+		{"apply", pcYaml.Timeouts.Apply, &timeouts.Apply},
+		{"hooks", pcYaml.Timeouts.Hooks, &timeouts.Hooks},
+	} {
+		if t.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(t.value)
+		if err != nil {
+			return pc, errors.Wrapf(err, "parsing timeouts.%s", t.name)
+		}
+		if d <= 0 {
+			return pc, errors.Errorf("parsing timeouts.%s: must be greater than 0", t.name)
+		}
+		*t.dest = d
+	}
+
+	workflowName := pcYaml.Workflow
+	if workflowName == "" {
+		workflowName = DefaultWorkflowName
+	}
+	// Parse all the workflows, not just the one that's used, so mistakes in
+	// the others aren't missed.
+	var names []string
+	for name := range pcYaml.Workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	workflows := make(map[string]Workflow)
+	for _, name := range names {
+		if workflows[name], err = parseWorkflow(name, pcYaml.Workflows[name]); err != nil {
+			return pc, err
+		}
+	}
+	workflow, ok := workflows[workflowName]
+	if !ok && workflowName != DefaultWorkflowName {
+		return pc, fmt.Errorf("workflow %q isn't defined in workflows", workflowName)
+	}
+	for _, pattern := range pcYaml.VarFiles {
+		if filepath.IsAbs(pattern) {
+			return pc, fmt.Errorf("parsing var_files: %q must be relative to the project", pattern)
 		}
+		if _, err := filepath.Match(varFileGlob(pattern), ""); err != nil {
+			return pc, errors.Wrapf(err, "parsing var_files: %q", pattern)
+		}
+	}
+	for _, e := range pcYaml.Env {
+		if err := e.Validate(); err != nil {
+			return pc, errors.Wrap(err, "parsing env")
+		}
+	}
+	terragrunt := usesTerragrunt(execPath)
+	if pcYaml.Terragrunt != nil {
+		terragrunt = *pcYaml.Terragrunt
 	}
+
 	return ProjectConfig{
-		TerraformVersion: v,
-		extraArguments:   pcYaml.ExtraArguments,
-		PreInit:          pcYaml.PreInit.Commands,
-		PreGet:           pcYaml.PreGet.Commands,
-		PostApply:        pcYaml.PostApply.Commands,
-		PreApply:         pcYaml.PreApply.Commands,
-		PrePlan:          pcYaml.PrePlan.Commands,
-		PostPlan:         pcYaml.PostPlan.Commands,
+		TerraformVersion:            v,
+		TerraformVersionConstraints: constraints,
+		Timeouts:                    timeouts,
+		WorkflowName:                workflowName,
+		Workflow:                    workflow,
+		Terragrunt:                  terragrunt,
+		VarFiles:                    pcYaml.VarFiles,
+		EnvVars:                     pcYaml.Env,
+		extraArguments:              pcYaml.ExtraArguments,
+		PreInit:                     pcYaml.PreInit.Commands,
+		PreGet:                      pcYaml.PreGet.Commands,
+		PostApply:                   pcYaml.PostApply.Commands,
+		PreApply:                    pcYaml.PreApply.Commands,
+		PrePlan:                     pcYaml.PrePlan.Commands,
+		PostPlan:                    pcYaml.PostPlan.Commands,
 	}, nil
 }
 
@@ -129,3 +289,77 @@ func (c *ProjectConfig) GetExtraArguments(command string) []string {
 	}
 	return nil
 }
+
+// GetVarFiles returns the var files in the project at projectPath that match
+// the project's var file patterns for workspace. They're relative to
+// projectPath and in the order of the patterns. The files that match each
+// pattern are sorted and patterns that match nothing are skipped. workspace
+// comes from the comment so it's matched literally and can't be a path.
+func (c *ProjectConfig) GetVarFiles(projectPath string, workspace string) ([]string, error) {
+	if strings.ContainsRune(workspace, filepath.Separator) || workspace == "." || workspace == ".." {
+		return nil, fmt.Errorf("invalid workspace %q for var files", workspace)
+	}
+	var varFiles []string
+	seen := make(map[string]bool)
+	for _, pattern := range c.varFilePatterns() {
+		pattern = strings.Replace(pattern, WorkspaceVarFileToken, escapeGlob(workspace), -1)
+		matches, err := filepath.Glob(filepath.Join(projectPath, pattern))
+		if err != nil {
+			return nil, errors.Wrapf(err, "finding var files for %q", pattern)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			rel, err := filepath.Rel(projectPath, match)
+			if err != nil {
+				return nil, err
+			}
+			if info, err := os.Stat(match); err != nil || info.IsDir() || seen[rel] {
+				continue
+			}
+			seen[rel] = true
+			varFiles = append(varFiles, rel)
+		}
+	}
+	return varFiles, nil
+}
+
+// IsVarFile returns true if file, relative to the project, matches one of
+// the project's var file patterns for any workspace.
+func (c *ProjectConfig) IsVarFile(file string) bool {
+	for _, pattern := range c.varFilePatterns() {
+		if ok, _ := filepath.Match(varFileGlob(pattern), filepath.Clean(file)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ProjectConfig) varFilePatterns() []string {
+	if c.VarFiles == nil {
+		return DefaultVarFiles
+	}
+	return c.VarFiles
+}
+
+// escapeGlob returns s with the characters that are special in
+// filepath.Match patterns quoted so the pattern matches them literally.
+func escapeGlob(s string) string {
+	var escaped []string
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[':
+			escaped = append(escaped, "["+string(r)+"]")
+		case '\\':
+			escaped = append(escaped, `[\\]`)
+		default:
+			escaped = append(escaped, string(r))
+		}
+	}
+	return strings.Join(escaped, "")
+}
+
+// varFileGlob returns the var file pattern with the workspace replaced by a
+// wildcard so it matches the var files of every workspace.
+func varFileGlob(pattern string) string {
+	return filepath.Clean(strings.Replace(pattern, WorkspaceVarFileToken, "*", -1))
+}
@@ -3,7 +3,10 @@ package events_test
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hootsuite/atlantis/server/events"
 	. "github.com/hootsuite/atlantis/testing"
@@ -90,6 +93,244 @@ func TestRead_ValidConfig(t *testing.T) {
 	Equals(t, 0, len(config.GetExtraArguments("not-specified")))
 }
 
+func TestRead_TerraformVersionConstraints(t *testing.T) {
+	t.Log("when terraform_version isn't an exact version it should be parsed as constraints")
+	writeAtlantisConfigFile(t, []byte(`terraform_version: "~> 0.11.0"`))
+	defer os.Remove(tempConfigFile) // nolint: errcheck
+	config, err := c.Read("/tmp")
+	Ok(t, err)
+	Assert(t, config.TerraformVersion == nil, "exp no exact version")
+	Equals(t, "~> 0.11.0", config.TerraformVersionConstraints.String())
+}
+
+func TestRead_InvalidTerraformVersion(t *testing.T) {
+	t.Log("when terraform_version isn't a version or constraints it should be an error")
+	writeAtlantisConfigFile(t, []byte(`terraform_version: "latest"`))
+	defer os.Remove(tempConfigFile) // nolint: errcheck
+	_, err := c.Read("/tmp")
+	Assert(t, err != nil, "exp an error")
+	Assert(t, strings.HasPrefix(err.Error(), "parsing terraform_version"), "exp terraform_version error, got %q", err.Error())
+}
+
+func TestRead_Timeouts(t *testing.T) {
+	t.Log("timeouts should be parsed as durations and unset timeouts left at zero")
+	writeAtlantisConfigFile(t, []byte(`
+timeouts:
+  plan: 30m
+  hooks: 90s
+`))
+	defer os.Remove(tempConfigFile) // nolint: errcheck
+	config, err := c.Read("/tmp")
+	Ok(t, err)
+	Equals(t, events.Timeouts{Plan: 30 * time.Minute, Hooks: 90 * time.Second}, config.Timeouts)
+	Equals(t, events.Timeouts{Init: time.Minute, Plan: 30 * time.Minute, Apply: time.Hour, Hooks: 90 * time.Second},
+		config.Timeouts.WithDefaults(events.Timeouts{Init: time.Minute, Plan: time.Minute, Apply: time.Hour}))
+}
+
+func TestRead_InvalidTimeouts(t *testing.T) {
+	t.Log("timeouts that aren't positive durations should be an error")
+	cases := map[string]string{
+		"timeouts:\n  apply: 10":    "parsing timeouts.apply: time: missing unit",
+		"timeouts:\n  init: -1m":    "parsing timeouts.init: must be greater than 0",
+		"timeouts:\n  hooks: never": "parsing timeouts.hooks: time: invalid duration",
+	}
+	for config, expErr := range cases {
+		writeAtlantisConfigFile(t, []byte(config))
+		_, err := c.Read("/tmp")
+		os.Remove(tempConfigFile) // nolint: errcheck
+		Assert(t, err != nil, "exp an error for %q", config)
+		Assert(t, strings.HasPrefix(err.Error(), expErr), "exp %q to start with %q", err.Error(), expErr)
+	}
+}
+
+func TestRead_Workflow(t *testing.T) {
+	t.Log("the project's workflow should be parsed")
+	writeAtlantisConfigFile(t, []byte(`
+workflow: custom
+workflows:
+  custom:
+    plan:
+      steps:
+      - env: TF_LOG=DEBUG
+      - init
+      - run: make generate
+      - plan
+  unused:
+    apply:
+      steps:
+      - apply
+`))
+	defer os.Remove(tempConfigFile) // nolint: errcheck
+	config, err := c.Read("/tmp")
+	Ok(t, err)
+	Equals(t, "custom", config.WorkflowName)
+	Equals(t, events.Workflow{
+		Plan: []events.Step{
+			{Name: "env", EnvName: "TF_LOG", EnvValue: "DEBUG"},
+			{Name: "init"},
+			{Name: "run", Command: "make generate"},
+			{Name: "plan"},
+		},
+	}, config.Workflow)
+	Assert(t, config.Workflow.Steps(events.Apply) == nil, "exp apply to use the built-in steps")
+}
+
+func TestRead_DefaultWorkflow(t *testing.T) {
+	t.Log("without a workflow the default should be used, which can be overridden")
+	writeAtlantisConfigFile(t, []byte("terraform_version: 0.11.0"))
+	config, err := c.Read("/tmp")
+	os.Remove(tempConfigFile) // nolint: errcheck
+	Ok(t, err)
+	Equals(t, "default", config.WorkflowName)
+	Equals(t, events.Workflow{}, config.Workflow)
+
+	writeAtlantisConfigFile(t, []byte("workflows:\n  default:\n    apply:\n      steps: [run: echo hi, apply]"))
+	config, err = c.Read("/tmp")
+	os.Remove(tempConfigFile) // nolint: errcheck
+	Ok(t, err)
+	Equals(t, "default", config.WorkflowName)
+	Equals(t, []events.Step{{Name: "run", Command: "echo hi"}, {Name: "apply"}}, config.Workflow.Apply)
+}
+
+func TestRead_InvalidWorkflow(t *testing.T) {
+	t.Log("invalid workflows should be an error")
+	cases := map[string]string{
+		"workflow: missing": `workflow "missing" isn't defined in workflows`,
+		"workflows:\n  w:\n    plan:\n      steps: [apply]":                           `parsing workflows.w.plan: step 1: unknown step "apply", expected init, plan, run or env`,
+		"workflows:\n  w:\n    apply:\n      steps: [init, plan]":                     `parsing workflows.w.apply: step 2: unknown step "plan", expected init, apply, run or env`,
+		"workflows:\n  w:\n    plan:\n      steps: [run: '']":                         "parsing workflows.w.plan: step 1: run needs a command",
+		"workflows:\n  w:\n    plan:\n      steps: [env: FOO]":                        `parsing workflows.w.plan: step 1: env must be NAME=value, found "FOO"`,
+		"workflows:\n  w:\n    plan:\n      steps: [env: 1A=b]":                       `parsing workflows.w.plan: step 1: env must be NAME=value, found "1A=b"`,
+		"workflows:\n  w:\n    plan:\n      steps: [env: WORKSPACE=b]":                "parsing workflows.w.plan: step 1: WORKSPACE is set by Atlantis and can't be overridden",
+		"workflows:\n  w:\n    plan:\n      steps: [env: TF_PLUGIN_CACHE_DIR=/tmp/p]": "parsing workflows.w.plan: step 1: TF_PLUGIN_CACHE_DIR is set by Atlantis and can't be overridden",
+		"workflows:\n  w:\n    plan:\n      steps: [{init: x}]":                       "parsing workflows.w.plan: step 1: init doesn't take a value",
+		"workflows:\n  w:\n    plan:\n      steps: [{run: a, env: b}]":                "parsing atlantis.yaml",
+		"workflows:\n  w:\n    plan:\n      steps: [init, run: make]":                 "parsing workflows.w.plan: needs exactly one plan step, found 0",
+		"workflows:\n  w:\n    plan:\n      steps: [plan, plan]":                      "parsing workflows.w.plan: needs exactly one plan step, found 2",
+		"workflows:\n  w:\n    apply:\n      steps: [init]":                           "parsing workflows.w.apply: needs exactly one apply step, found 0",
+		"workflows:\n  w:\n    apply:\n      steps: [apply, apply]":                   "parsing workflows.w.apply: needs exactly one apply step, found 2",
+	}
+	for config, expErr := range cases {
+		writeAtlantisConfigFile(t, []byte(config))
+		_, err := c.Read("/tmp")
+		os.Remove(tempConfigFile) // nolint: errcheck
+		Assert(t, err != nil, "exp an error for %q", config)
+		Assert(t, strings.HasPrefix(err.Error(), expErr), "exp %q to start with %q", err.Error(), expErr)
+	}
+}
+
+func TestRead_Terragrunt(t *testing.T) {
+	t.Log("projects should use terragrunt if they have a terragrunt.hcl unless the config file says otherwise")
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	configFile := filepath.Join(dir, events.ProjectConfigFile)
+	cases := []struct {
+		config        string
+		terragruntHCL bool
+		exp           bool
+	}{
+		{"terraform_version: 0.11.0", false, false},
+		{"terraform_version: 0.11.0", true, true},
+		{"terragrunt: true", false, true},
+		{"terragrunt: false", true, false},
+	}
+	for _, c := range cases {
+		Ok(t, ioutil.WriteFile(configFile, []byte(c.config), 0644))
+		os.Remove(filepath.Join(dir, "terragrunt.hcl")) // nolint: errcheck
+		if c.terragruntHCL {
+			Ok(t, ioutil.WriteFile(filepath.Join(dir, "terragrunt.hcl"), nil, 0644))
+		}
+		config, err := (&events.ProjectConfigManager{}).Read(dir)
+		Ok(t, err)
+		Equals(t, c.exp, config.Terragrunt)
+	}
+}
+
+func TestRead_VarFiles(t *testing.T) {
+	t.Log("var_files should be parsed and invalid patterns should be an error")
+	writeAtlantisConfigFile(t, []byte("var_files: [common.tfvars, 'vars/{{workspace}}/*.tfvars']"))
+	config, err := c.Read("/tmp")
+	os.Remove(tempConfigFile) // nolint: errcheck
+	Ok(t, err)
+	Equals(t, []string{"common.tfvars", "vars/{{workspace}}/*.tfvars"}, config.VarFiles)
+
+	cases := map[string]string{
+		"var_files: [/etc/common.tfvars]": `parsing var_files: "/etc/common.tfvars" must be relative to the project`,
+		"var_files: ['vars/[.tfvars']":    `parsing var_files: "vars/[.tfvars": syntax error in pattern`,
+	}
+	for config, expErr := range cases {
+		writeAtlantisConfigFile(t, []byte(config))
+		_, err := c.Read("/tmp")
+		os.Remove(tempConfigFile) // nolint: errcheck
+		Assert(t, err != nil, "exp an error for %q", config)
+		Equals(t, expErr, err.Error())
+	}
+}
+
+func TestRead_Env(t *testing.T) {
+	t.Log("env should be parsed and invalid variables should be an error")
+	writeAtlantisConfigFile(t, []byte("env:\n- {name: REGION, value: us-east-1}\n- {name: DB_PASSWORD, secret: 'secret/db#password', workspaces: [production]}"))
+	config, err := c.Read("/tmp")
+	os.Remove(tempConfigFile) // nolint: errcheck
+	Ok(t, err)
+	Equals(t, []events.EnvVar{
+		{Name: "REGION", Value: "us-east-1"},
+		{Name: "DB_PASSWORD", Secret: "secret/db#password", Workspaces: []string{"production"}},
+	}, config.EnvVars)
+
+	writeAtlantisConfigFile(t, []byte("env:\n- {name: A, file: a.txt, secret: 'secret/a#key'}"))
+	_, err = c.Read("/tmp")
+	os.Remove(tempConfigFile) // nolint: errcheck
+	Equals(t, "parsing env: A must set only one of value, file or secret", err.Error())
+
+	writeAtlantisConfigFile(t, []byte("env:\n- {name: PLANFILE, value: /tmp/other.tfplan}"))
+	_, err = c.Read("/tmp")
+	os.Remove(tempConfigFile) // nolint: errcheck
+	Equals(t, "parsing env: PLANFILE is set by Atlantis and can't be overridden", err.Error())
+}
+
+func TestGetVarFiles(t *testing.T) {
+	t.Log("the var files that match the patterns for the workspace should be returned in order")
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	for _, file := range []string{"common.tfvars", "env/prod.tfvars", "env/p*.tfvars", "vars/prod/b.tfvars", "vars/prod/a.tfvars", "vars/staging/a.tfvars"} {
+		Ok(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0755))
+		Ok(t, ioutil.WriteFile(filepath.Join(dir, file), nil, 0644))
+	}
+
+	cases := []struct {
+		varFiles  []string
+		workspace string
+		exp       []string
+	}{
+		{nil, "prod", []string{"env/prod.tfvars"}},
+		{nil, "staging", nil},
+		{[]string{}, "prod", nil},
+		{[]string{"common.tfvars", "vars/{{workspace}}/*.tfvars", "missing.tfvars"}, "prod", []string{"common.tfvars", "vars/prod/a.tfvars", "vars/prod/b.tfvars"}},
+		{[]string{"vars/{{workspace}}/*.tfvars", "vars/*/a.tfvars"}, "staging", []string{"vars/staging/a.tfvars", "vars/prod/a.tfvars"}},
+		// The workspace comes from the comment so it's matched literally.
+		{nil, "*", nil},
+		{nil, "?rod", nil},
+		{nil, "[p]rod", nil},
+		{nil, "p*", []string{"env/p*.tfvars"}},
+	}
+	for _, c := range cases {
+		config := events.ProjectConfig{VarFiles: c.varFiles}
+		varFiles, err := config.GetVarFiles(dir, c.workspace)
+		Ok(t, err)
+		Equals(t, c.exp, varFiles)
+	}
+
+	t.Log("workspaces that are paths should be an error")
+	config := events.ProjectConfig{VarFiles: []string{"{{workspace}}/common.tfvars"}}
+	for _, workspace := range []string{"..", ".", "../vars", "a/b"} {
+		_, err := config.GetVarFiles(filepath.Join(dir, "vars"), workspace)
+		Assert(t, err != nil, "exp an error for %q", workspace)
+	}
+}
+
 func writeAtlantisConfigFile(t *testing.T, s []byte) {
 	err := ioutil.WriteFile(tempConfigFile, s, 0644)
 	Ok(t, err)
@@ -1,10 +1,14 @@
 package events
 
 import (
+	"os"
 	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/terraform"
 	"github.com/hootsuite/atlantis/server/logging"
 )
 
@@ -12,51 +16,62 @@ import (
 
 type ModifiedProjectFinder interface {
 	// FindModified returns the list of projects that were modified based on
-	// the modifiedFiles. The list will be de-duplicated.
-	FindModified(log *logging.SimpleLogger, modifiedFiles []string, repoFullName string) []models.Project
+	// the modifiedFiles. repoDir is the repo cloned on disk, used to read the
+	// projects' config files. The list will be de-duplicated.
+	FindModified(log *logging.SimpleLogger, repoDir string, modifiedFiles []string, repoHostname string, repoFullName string) []models.Project
 }
 
 // ProjectFinder identifies projects in a repo.
-type ProjectFinder struct{}
+type ProjectFinder struct {
+	// ConfigReader reads the config files of the projects in the repo so
+	// modified var files can be mapped to the projects that use them. If
+	// nil, every project is assumed to use DefaultVarFiles.
+	ConfigReader ProjectConfigReader
+}
 
 var excludeList = []string{"terraform.tfstate", "terraform.tfstate.backup", "_modules", "modules"}
 
 // FindModified returns the list of projects that were modified based on
 // the modifiedFiles. The list will be de-duplicated.
-func (p *ProjectFinder) FindModified(log *logging.SimpleLogger, modifiedFiles []string, repoFullName string) []models.Project {
+func (p *ProjectFinder) FindModified(log *logging.SimpleLogger, repoDir string, modifiedFiles []string, repoHostname string, repoFullName string) []models.Project {
 	var projects []models.Project
 
-	modifiedTerraformFiles := p.filterToTerraform(modifiedFiles)
+	modifiedTerraformFiles := filterToTerraform(modifiedFiles)
 	if len(modifiedTerraformFiles) == 0 {
 		return projects
 	}
-	log.Info("filtered modified files to %d non-module .tf files: %v",
+	log.Info("filtered modified files to %d non-module .tf and terragrunt.hcl files: %v",
 		len(modifiedTerraformFiles), modifiedTerraformFiles)
 
+	configs := p.readConfigs(log, repoDir)
 	var paths []string
 	for _, modifiedFile := range modifiedTerraformFiles {
-		paths = append(paths, p.getProjectPath(modifiedFile))
+		paths = append(paths, p.getProjectPaths(configs, modifiedFile)...)
 	}
 	uniquePaths := p.unique(paths)
 	for _, uniquePath := range uniquePaths {
-		projects = append(projects, models.NewProject(repoFullName, uniquePath))
+		projects = append(projects, models.NewProject(repoHostname, repoFullName, uniquePath))
 	}
 	log.Info("there are %d modified project(s) at path(s): %v",
 		len(projects), strings.Join(uniquePaths, ", "))
 	return projects
 }
 
-func (p *ProjectFinder) filterToTerraform(files []string) []string {
+// filterToTerraform returns the files in files that can belong to a project.
+func filterToTerraform(files []string) []string {
 	var filtered []string
 	for _, fileName := range files {
-		if !p.isInExcludeList(fileName) && strings.Contains(fileName, ".tf") {
+		if isInExcludeList(fileName) {
+			continue
+		}
+		if strings.Contains(fileName, ".tf") || path.Base(fileName) == terraform.TerragruntConfigFile {
 			filtered = append(filtered, fileName)
 		}
 	}
 	return filtered
 }
 
-func (p *ProjectFinder) isInExcludeList(fileName string) bool {
+func isInExcludeList(fileName string) bool {
 	for _, s := range excludeList {
 		if strings.Contains(fileName, s) {
 			return true
@@ -65,16 +80,75 @@ func (p *ProjectFinder) isInExcludeList(fileName string) bool {
 	return false
 }
 
-// getProjectPath returns the path to the project relative to the repo root
-// if the project is at the root returns "."
-func (p *ProjectFinder) getProjectPath(modifiedFilePath string) string {
-	dir := path.Dir(modifiedFilePath)
-	if path.Base(dir) == "env" {
-		// if the modified file was inside an env/ directory, we treat this specially and
-		// run plan one level up
-		return path.Dir(dir)
+// getProjectPaths returns the paths to the projects relative to the repo
+// root that modifiedFilePath belongs to. If it's a var file, they're the
+// projects whose var file patterns match it, otherwise it's the directory the
+// file is in. If the project is at the root returns ".". configs are the
+// config files of the projects in the repo by their paths.
+func (p *ProjectFinder) getProjectPaths(configs map[string]ProjectConfig, modifiedFilePath string) []string {
+	// The file could be a var file of any of its parent directories with the
+	// default var files, or of any project whose config file sets var_files.
+	candidates := make(map[string]bool)
+	for dir := path.Dir(modifiedFilePath); ; dir = path.Dir(dir) {
+		candidates[dir] = true
+		if dir == "." || dir == "/" {
+			break
+		}
+	}
+	for dir := range configs {
+		candidates[dir] = true
+	}
+
+	var projectPaths []string
+	for dir := range candidates {
+		config := configs[dir]
+		rel, err := filepath.Rel(dir, modifiedFilePath)
+		if err == nil && config.IsVarFile(rel) {
+			projectPaths = append(projectPaths, dir)
+		}
+	}
+	if len(projectPaths) == 0 {
+		return []string{path.Dir(modifiedFilePath)}
+	}
+	sort.Strings(projectPaths)
+	return projectPaths
+}
+
+// readConfigs returns the config files of the projects in repoDir by the
+// projects' paths relative to repoDir. Config files that can't be read are
+// skipped.
+func (p *ProjectFinder) readConfigs(log *logging.SimpleLogger, repoDir string) map[string]ProjectConfig {
+	configs := make(map[string]ProjectConfig)
+	if p.ConfigReader == nil || repoDir == "" {
+		return configs
+	}
+	err := filepath.Walk(repoDir, func(absPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == ".terraform" || info.Name() == terragruntCacheDir) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || info.Name() != ProjectConfigFile {
+			return nil
+		}
+		dir := filepath.Dir(absPath)
+		rel, err := filepath.Rel(repoDir, dir)
+		if err != nil {
+			return err
+		}
+		config, err := p.ConfigReader.Read(dir)
+		if err != nil {
+			log.Warn("reading config file in %q to find its var files: %s", rel, err)
+			return nil
+		}
+		configs[filepath.ToSlash(rel)] = config
+		return nil
+	})
+	if err != nil {
+		log.Warn("finding config files in repo, modified var files may not be planned: %s", err)
 	}
-	return dir
+	return configs
 }
 
 func (p *ProjectFinder) unique(strs []string) []string {
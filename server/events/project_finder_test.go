@@ -1,6 +1,9 @@
 package events_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hootsuite/atlantis/server/events"
@@ -53,6 +56,11 @@ func TestGetModified_NoFiles(t *testing.T) {
 			[]string{"parent/a.tf"},
 			[]string{"parent"},
 		},
+		{
+			"Should return directory when terragrunt.hcl is changed",
+			[]string{"live/vpc/terragrunt.hcl", "live/other.hcl"},
+			[]string{"live/vpc"},
+		},
 		{
 			"Should return parent dir when changed file is in an env/ dir",
 			[]string{"env/a.tfvars"},
@@ -66,7 +74,7 @@ func TestGetModified_NoFiles(t *testing.T) {
 	}
 	for _, c := range cases {
 		t.Log(c.description)
-		projects := m.FindModified(noopLogger, c.files, modifiedRepo)
+		projects := m.FindModified(noopLogger, "", c.files, "github.com", modifiedRepo)
 
 		// Extract the paths from the projects. We use a slice here instead of a
 		// map so we can test whether there are duplicates returned.
@@ -75,6 +83,7 @@ func TestGetModified_NoFiles(t *testing.T) {
 			paths = append(paths, project.Path)
 			// Check that the project object has the repo set properly.
 			Equals(t, modifiedRepo, project.RepoFullName)
+			Equals(t, "github.com", project.RepoHostname)
 		}
 		Assert(t, len(c.expProjectPaths) == len(paths),
 			"exp %d paths but found %d. They were %v", len(c.expProjectPaths), len(paths), paths)
@@ -93,3 +102,54 @@ func TestGetModified_NoFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestGetModified_VarFiles(t *testing.T) {
+	t.Log("modified var files should plan the projects whose var_files match them")
+	repoDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(repoDir) // nolint: errcheck
+	for dir, config := range map[string]string{
+		"live/app": "var_files: [../common.tfvars, 'vars/{{workspace}}/*.tfvars']",
+		"live/db":  "var_files: [../common.tfvars]",
+		"other":    "terraform_version: 0.11.0",
+	} {
+		Ok(t, os.MkdirAll(filepath.Join(repoDir, dir), 0755))
+		Ok(t, ioutil.WriteFile(filepath.Join(repoDir, dir, events.ProjectConfigFile), []byte(config), 0644))
+	}
+	finder := events.ProjectFinder{ConfigReader: &events.ProjectConfigManager{}}
+
+	cases := []struct {
+		description     string
+		files           []string
+		expProjectPaths []string
+	}{
+		{
+			"A var file matching a workspace pattern should plan its project",
+			[]string{"live/app/vars/prod/a.tfvars"},
+			[]string{"live/app"},
+		},
+		{
+			"A shared var file should plan every project that uses it",
+			[]string{"live/common.tfvars"},
+			[]string{"live/app", "live/db"},
+		},
+		{
+			"Projects without var_files should use the default var files",
+			[]string{"other/env/prod.tfvars"},
+			[]string{"other"},
+		},
+		{
+			"Projects with var_files shouldn't use the default var files",
+			[]string{"live/app/env/prod.tfvars"},
+			[]string{"live/app/env"},
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.description)
+		var paths []string
+		for _, project := range finder.FindModified(noopLogger, repoDir, c.files, "github.com", modifiedRepo) {
+			paths = append(paths, project.Path)
+		}
+		Equals(t, c.expProjectPaths, paths)
+	}
+}
@@ -2,12 +2,14 @@ package events
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"strings"
 
 	"github.com/hashicorp/go-version"
 	"github.com/hootsuite/atlantis/server/events/locking"
 	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/redaction"
 	"github.com/hootsuite/atlantis/server/events/run"
 	"github.com/hootsuite/atlantis/server/events/terraform"
 	"github.com/pkg/errors"
@@ -23,7 +25,16 @@ type ProjectPreExecute struct {
 	Locker       locking.Locker
 	ConfigReader ProjectConfigReader
 	Terraform    terraform.Runner
-	Run          run.Runner
+	// Terragrunt runs the commands of projects that use terragrunt.
+	Terragrunt terraform.Runner
+	Run        run.Runner
+	// EnvVars finds the environment variables to set for projects' commands.
+	// If nil, only the variables without secrets in projects' config files
+	// are set.
+	EnvVars *EnvVarResolver
+	// Timeouts are used for the timeouts that projects don't set in their
+	// config file.
+	Timeouts Timeouts
 }
 
 type PreExecuteResult struct {
@@ -52,42 +63,94 @@ func (p *ProjectPreExecute) Execute(ctx *CommandContext, repoDir string, project
 	if p.ConfigReader.Exists(absolutePath) {
 		config, err = p.ConfigReader.Read(absolutePath)
 		if err != nil {
-			return PreExecuteResult{ProjectResult: ProjectResult{Error: err}}
+			return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: ProjectResult{Error: err}}
 		}
 		ctx.Log.Info("parsed atlantis config file in %q", absolutePath)
+	} else {
+		config.Terragrunt = usesTerragrunt(absolutePath)
+	}
+	if config.Terragrunt {
+		ctx.Log.Info("running terragrunt for project at %q", absolutePath)
+	}
+	tf, err := terraformRunner(config, p.Terraform, p.Terragrunt)
+	if err != nil {
+		return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: ProjectResult{Error: err}}
+	}
+	config.Timeouts = config.Timeouts.WithDefaults(p.Timeouts)
+
+	// Find the values of sensitive variables before running anything that
+	// could print them.
+	varFiles, err := config.GetVarFiles(absolutePath, tfEnv)
+	if err != nil {
+		ctx.Log.Warn("finding var files, the values of sensitive variables in them won't be redacted: %s", err)
 	}
+	sensitiveValues, err := redaction.SensitiveValues(absolutePath, varFiles)
+	if err != nil {
+		ctx.Log.Warn("finding values of sensitive variables, they may not be redacted: %s", err)
+	}
+	ctx.SensitiveValues = append(ctx.SensitiveValues, sensitiveValues...)
 
-	// check if terraform version is >= 0.9.0
-	terraformVersion := p.Terraform.Version()
+	// The environment variables are only set for the commands Atlantis runs,
+	// not Atlantis itself, and the secret ones are redacted.
+	env, secretValues, err := p.EnvVars.Resolve(ctx.BaseRepo.Hostname, ctx.BaseRepo.FullName, project.Path, tfEnv, absolutePath, config.EnvVars)
+	ctx.SensitiveValues = append(ctx.SensitiveValues, secretValues...)
+	if err != nil {
+		return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: ProjectResult{Error: err}}
+	}
+	config.Env = env
+
+	terraformVersion := tf.Version()
 	if config.TerraformVersion != nil {
 		terraformVersion = config.TerraformVersion
+	} else if config.TerraformVersionConstraints != nil {
+		terraformVersion, err = resolveVersion(ctx, tf, config.TerraformVersionConstraints, absolutePath)
+		if err != nil {
+			return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: ProjectResult{Error: err}}
+		}
+	} else {
+		// Without a terraform_version, use the version the project itself
+		// requires, if any.
+		requiredVersion, err := terraform.RequiredVersion(absolutePath)
+		if err != nil {
+			ctx.Log.Warn("finding required_version in %q, using terraform %s: %s", absolutePath, terraformVersion, err)
+		} else if requiredVersion != nil {
+			ctx.Log.Info("no terraform_version is set so resolving the required_version %q in %q", requiredVersion, absolutePath)
+			terraformVersion, err = resolveVersion(ctx, tf, requiredVersion, absolutePath)
+			if err != nil {
+				return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: ProjectResult{Error: errors.Wrap(err, "resolving required_version")}}
+			}
+		}
+	}
+	// Custom workflows run their own steps, including init, so there's
+	// nothing left to do.
+	if steps := config.Workflow.Steps(ctx.Command.Name); steps != nil {
+		ctx.Log.Info("running terraform %s with the %q workflow", terraformVersion, config.WorkflowName)
+		return PreExecuteResult{ProjectConfig: config, TerraformVersion: terraformVersion, LockResponse: lockAttempt}
 	}
-	constraints, _ := version.NewConstraint(">= 0.9.0")
-	if constraints.Check(terraformVersion) {
+
+	if initConstraint.Check(terraformVersion) {
 		ctx.Log.Info("determined that we are running terraform with version >= 0.9.0. Running version %s", terraformVersion)
 		if len(config.PreInit) > 0 {
-			_, err := p.Run.Execute(ctx.Log, config.PreInit, absolutePath, tfEnv, terraformVersion, "pre_init")
-			if err != nil {
-				return PreExecuteResult{ProjectResult: ProjectResult{Error: errors.Wrapf(err, "running %s commands", "pre_init")}}
+			if err := runHooks(ctx, p.Run, config, config.PreInit, absolutePath, terraformVersion, "pre_init"); err != nil {
+				return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: hooksErrResult(err, "pre_init")}
 			}
 		}
-		_, err := p.Terraform.RunInitAndEnv(ctx.Log, absolutePath, tfEnv, config.GetExtraArguments("init"), terraformVersion)
-		if err != nil {
-			return PreExecuteResult{ProjectResult: ProjectResult{Error: err}}
-		}
 	} else {
 		ctx.Log.Info("determined that we are running terraform with version < 0.9.0. Running version %s", terraformVersion)
 		if len(config.PreGet) > 0 {
-			_, err := p.Run.Execute(ctx.Log, config.PreGet, absolutePath, tfEnv, terraformVersion, "pre_get")
-			if err != nil {
-				return PreExecuteResult{ProjectResult: ProjectResult{Error: errors.Wrapf(err, "running %s commands", "pre_get")}}
+			if err := runHooks(ctx, p.Run, config, config.PreGet, absolutePath, terraformVersion, "pre_get"); err != nil {
+				return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: hooksErrResult(err, "pre_get")}
 			}
 		}
-		terraformGetCmd := append([]string{"get", "-no-color"}, config.GetExtraArguments("get")...)
-		_, err := p.Terraform.RunCommandWithVersion(ctx.Log, absolutePath, terraformGetCmd, terraformVersion, tfEnv)
-		if err != nil {
-			return PreExecuteResult{ProjectResult: ProjectResult{Error: err}}
-		}
+	}
+	initCtx, cancel := run.WithTimeout(config.commandContext(ctx.Context), config.Timeouts.Init)
+	what, err := runInit(initCtx, ctx, tf, config, absolutePath, terraformVersion)
+	cancel()
+	if res, ok := timeoutResult(err, what); ok {
+		return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: res}
+	}
+	if err != nil {
+		return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: ProjectResult{Error: err}}
 	}
 
 	stage := fmt.Sprintf("pre_%s", strings.ToLower(ctx.Command.Name.String()))
@@ -98,10 +161,79 @@ func (p *ProjectPreExecute) Execute(ctx *CommandContext, repoDir string, project
 		commands = config.PreApply
 	}
 	if len(commands) > 0 {
-		_, err := p.Run.Execute(ctx.Log, commands, absolutePath, tfEnv, terraformVersion, stage)
-		if err != nil {
-			return PreExecuteResult{ProjectResult: ProjectResult{Error: errors.Wrapf(err, "running %s commands", stage)}}
+		if err := runHooks(ctx, p.Run, config, commands, absolutePath, terraformVersion, stage); err != nil {
+			return PreExecuteResult{LockResponse: lockAttempt, ProjectResult: hooksErrResult(err, stage)}
 		}
 	}
 	return PreExecuteResult{ProjectConfig: config, TerraformVersion: terraformVersion, LockResponse: lockAttempt}
 }
+
+// resolveVersion returns the version of terraform that constraints resolve
+// to for the project at path. Plans save the version next to their plan file
+// and applies use it, as long as it still matches, so a plan is applied with
+// the version it was made with even if a newer one was released since.
+func resolveVersion(ctx *CommandContext, tf terraform.Runner, constraints version.Constraints, path string) (*version.Version, error) {
+	versionFile := planVersionFilePath(path, ctx.Command.Environment)
+	if ctx.Command.Name == Apply {
+		if contents, err := ioutil.ReadFile(versionFile); err == nil {
+			if v, err := version.NewVersion(strings.TrimSpace(string(contents))); err == nil && constraints.Check(v) {
+				ctx.Log.Info("using terraform %s, the version the plan was made with", v)
+				return v, nil
+			}
+		}
+	}
+	v, err := tf.ResolveVersion(ctx.Log, constraints)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Command.Name == Plan {
+		if err := ioutil.WriteFile(versionFile, []byte(v.String()+"\n"), 0600); err != nil {
+			ctx.Log.Warn("saving the terraform version for apply, it will be resolved again: %s", err)
+		}
+	}
+	return v, nil
+}
+
+// runHooks runs the commands for stage in the project at path and stops them
+// if they run for longer than the project's hook timeout.
+func runHooks(ctx *CommandContext, runner run.Runner, config ProjectConfig, commands []string, path string, terraformVersion *version.Version, stage string) error {
+	cmdCtx, cancel := run.WithTimeout(config.commandContext(ctx.Context), config.Timeouts.Hooks)
+	defer cancel()
+	_, err := runner.Execute(cmdCtx, ctx.Log, ctx.Output, commands, hookContext(ctx, path, terraformVersion), stage)
+	return err
+}
+
+// hookContext returns what the hooks of the project at path are run for.
+func hookContext(ctx *CommandContext, path string, terraformVersion *version.Version) run.HookContext {
+	return run.HookContext{
+		Environment:      ctx.Command.Environment,
+		ProjectDir:       path,
+		TerraformVersion: terraformVersion,
+		PullNum:          ctx.Pull.Num,
+		HeadCommit:       ctx.Pull.HeadCommit,
+		BaseBranch:       ctx.Pull.BaseBranch,
+		User:             ctx.User.Username,
+		PlanFile:         planFilePath(path, ctx.Command.Environment),
+	}
+}
+
+// planFilePath returns the path of the plan file for environment in the
+// project at path.
+func planFilePath(path string, environment string) string {
+	return filepath.Join(path, environment+".tfplan")
+}
+
+// planVersionFilePath returns the path of the file the terraform version
+// used for the plan for environment in the project at path is saved to.
+func planVersionFilePath(path string, environment string) string {
+	return planFilePath(path, environment) + ".version"
+}
+
+// hooksErrResult returns the result for a project whose commands for stage
+// failed with err.
+func hooksErrResult(err error, stage string) ProjectResult {
+	if res, ok := timeoutResult(err, fmt.Sprintf("the %s commands", stage)); ok {
+		return res
+	}
+	return ProjectResult{Error: errors.Wrapf(err, "running %s commands", stage)}
+}
@@ -1,8 +1,13 @@
 package events_test
 
 import (
+	"context"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/hootsuite/atlantis/server/events"
@@ -10,8 +15,12 @@ import (
 	lmocks "github.com/hootsuite/atlantis/server/events/locking/mocks"
 	"github.com/hootsuite/atlantis/server/events/mocks"
 	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/run"
 	rmocks "github.com/hootsuite/atlantis/server/events/run/mocks"
+	rmatchers "github.com/hootsuite/atlantis/server/events/run/mocks/matchers"
+	"github.com/hootsuite/atlantis/server/events/secrets"
 	tmocks "github.com/hootsuite/atlantis/server/events/terraform/mocks"
+	tmatchers "github.com/hootsuite/atlantis/server/events/terraform/mocks/matchers"
 	"github.com/hootsuite/atlantis/server/logging"
 	. "github.com/hootsuite/atlantis/testing"
 	"github.com/mohae/deepcopy"
@@ -74,12 +83,41 @@ func TestExecute_PreInitErr(t *testing.T) {
 	}, nil)
 	tfVersion, _ := version.NewVersion("0.9.0")
 	When(tm.Version()).ThenReturn(tfVersion)
-	When(r.Execute(ctx.Log, []string{"pre-init"}, "", "", tfVersion, "pre_init")).ThenReturn("", errors.New("err"))
+	When(r.Execute(nil, ctx.Log, nil, []string{"pre-init"}, run.HookContext{TerraformVersion: tfVersion, PlanFile: ".tfplan"}, "pre_init")).ThenReturn("", errors.New("err"))
 
 	res := p.Execute(&ctx, "", project)
 	Equals(t, "running pre_init commands: err", res.ProjectResult.Error.Error())
 }
 
+func TestExecute_PreInitTimeout(t *testing.T) {
+	t.Log("when a `pre_init` runs for longer than the default hook timeout we return a timed out failure")
+	p, l, tm, r := setupPreExecuteTest(t)
+	p.Timeouts = events.Timeouts{Hooks: time.Minute}
+	When(l.TryLock(project, "", ctx.Pull, ctx.User)).ThenReturn(locking.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "key",
+	}, nil)
+	When(p.ConfigReader.Exists("")).ThenReturn(true)
+	When(p.ConfigReader.Read("")).ThenReturn(events.ProjectConfig{
+		PreInit: []string{"pre-init"},
+	}, nil)
+	tfVersion, _ := version.NewVersion("0.9.0")
+	When(tm.Version()).ThenReturn(tfVersion)
+	When(r.Execute(rmatchers.AnyContextContext(), rmatchers.AnyPtrToLoggingSimpleLogger(), rmatchers.AnyIoWriter(), rmatchers.AnySliceOfString(), rmatchers.AnyRunHookContext(), AnyString())).
+		Then(func(params []Param) ReturnValues {
+			_, ok := params[0].(context.Context).Deadline()
+			Assert(t, ok, "exp the hook's context to have a deadline")
+			return []ReturnValue{"", run.TimeoutError{Timeout: time.Minute}}
+		})
+
+	res := p.Execute(&ctx, "", project)
+	Equals(t, events.ProjectResult{
+		TimedOut: true,
+		Failure:  "Timed out after 1m0s running the pre_init commands.",
+	}, res.ProjectResult)
+	Equals(t, "key", res.LockResponse.LockKey)
+}
+
 func TestExecute_InitErr(t *testing.T) {
 	t.Log("when the project is on tf >= 0.9 and we run `init` that returns an error we return it")
 	p, l, tm, _ := setupPreExecuteTest(t)
@@ -90,7 +128,7 @@ func TestExecute_InitErr(t *testing.T) {
 	When(p.ConfigReader.Read("")).ThenReturn(events.ProjectConfig{}, nil)
 	tfVersion, _ := version.NewVersion("0.9.0")
 	When(tm.Version()).ThenReturn(tfVersion)
-	When(tm.RunInitAndEnv(ctx.Log, "", "", nil, tfVersion)).ThenReturn(nil, errors.New("err"))
+	When(tm.RunInitAndEnv(nil, ctx.Log, nil, "", "", nil, tfVersion)).ThenReturn(nil, errors.New("err"))
 
 	res := p.Execute(&ctx, "", project)
 	Equals(t, "err", res.ProjectResult.Error.Error())
@@ -108,7 +146,7 @@ func TestExecute_PreGetErr(t *testing.T) {
 	}, nil)
 	tfVersion, _ := version.NewVersion("0.8")
 	When(tm.Version()).ThenReturn(tfVersion)
-	When(r.Execute(ctx.Log, []string{"pre-get"}, "", "", tfVersion, "pre_get")).ThenReturn("", errors.New("err"))
+	When(r.Execute(nil, ctx.Log, nil, []string{"pre-get"}, run.HookContext{TerraformVersion: tfVersion, PlanFile: ".tfplan"}, "pre_get")).ThenReturn("", errors.New("err"))
 
 	res := p.Execute(&ctx, "", project)
 	Equals(t, "running pre_get commands: err", res.ProjectResult.Error.Error())
@@ -124,7 +162,7 @@ func TestExecute_GetErr(t *testing.T) {
 	When(p.ConfigReader.Read("")).ThenReturn(events.ProjectConfig{}, nil)
 	tfVersion, _ := version.NewVersion("0.8")
 	When(tm.Version()).ThenReturn(tfVersion)
-	When(tm.RunCommandWithVersion(ctx.Log, "", []string{"get", "-no-color"}, tfVersion, "")).ThenReturn("", errors.New("err"))
+	When(tm.RunCommandWithVersion(nil, ctx.Log, nil, "", []string{"get", "-no-color"}, tfVersion, "")).ThenReturn("", errors.New("err"))
 
 	res := p.Execute(&ctx, "", project)
 	Equals(t, "err", res.ProjectResult.Error.Error())
@@ -142,13 +180,159 @@ func TestExecute_PreCommandErr(t *testing.T) {
 	}, nil)
 	tfVersion, _ := version.NewVersion("0.9")
 	When(tm.Version()).ThenReturn(tfVersion)
-	When(tm.RunInitAndEnv(ctx.Log, "", "", nil, tfVersion)).ThenReturn(nil, nil)
-	When(r.Execute(ctx.Log, []string{"command"}, "", "", tfVersion, "pre_plan")).ThenReturn("", errors.New("err"))
+	When(tm.RunInitAndEnv(nil, ctx.Log, nil, "", "", nil, tfVersion)).ThenReturn(nil, nil)
+	When(r.Execute(nil, ctx.Log, nil, []string{"command"}, run.HookContext{TerraformVersion: tfVersion, PlanFile: ".tfplan"}, "pre_plan")).ThenReturn("", errors.New("err"))
 
 	res := p.Execute(&ctx, "", project)
 	Equals(t, "running pre_plan commands: err", res.ProjectResult.Error.Error())
 }
 
+func TestExecute_TerraformVersionConstraints(t *testing.T) {
+	t.Log("when the project's terraform_version is constraints, the version they resolve to should be used and saved for apply")
+	p, l, tm, _ := setupPreExecuteTest(t)
+	repoDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(repoDir) // nolint: errcheck
+	When(l.TryLock(project, "", ctx.Pull, ctx.User)).ThenReturn(locking.TryLockResponse{LockAcquired: true}, nil)
+	When(p.ConfigReader.Exists(repoDir)).ThenReturn(true)
+	constraints, _ := version.NewConstraint("~> 0.11.0")
+	When(p.ConfigReader.Read(repoDir)).ThenReturn(events.ProjectConfig{TerraformVersionConstraints: constraints}, nil)
+	defaultVersion, _ := version.NewVersion("0.10.0")
+	tfVersion, _ := version.NewVersion("0.11.7")
+	When(tm.Version()).ThenReturn(defaultVersion)
+	When(tm.ResolveVersion(ctx.Log, constraints)).ThenReturn(tfVersion, nil)
+
+	res := p.Execute(&ctx, repoDir, project)
+	Equals(t, tfVersion, res.TerraformVersion)
+	tm.VerifyWasCalledOnce().RunInitAndEnv(nil, ctx.Log, nil, repoDir, "", nil, tfVersion)
+	saved, err := ioutil.ReadFile(filepath.Join(repoDir, ".tfplan.version"))
+	Ok(t, err)
+	Equals(t, "0.11.7\n", string(saved))
+}
+
+func TestExecute_TerraformVersionFromPlan(t *testing.T) {
+	t.Log("apply should use the version the plan was made with if it still matches the constraints")
+	p, l, tm, _ := setupPreExecuteTest(t)
+	repoDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(repoDir) // nolint: errcheck
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, ".tfplan.version"), []byte("0.11.0\n"), 0600))
+	applyCtx := ctx
+	applyCtx.Command = &events.Command{Name: events.Apply}
+	When(l.TryLock(project, "", ctx.Pull, ctx.User)).ThenReturn(locking.TryLockResponse{LockAcquired: true}, nil)
+	When(p.ConfigReader.Exists(repoDir)).ThenReturn(true)
+	constraints, _ := version.NewConstraint("~> 0.11.0")
+	When(p.ConfigReader.Read(repoDir)).ThenReturn(events.ProjectConfig{TerraformVersionConstraints: constraints}, nil)
+	defaultVersion, _ := version.NewVersion("0.10.0")
+	When(tm.Version()).ThenReturn(defaultVersion)
+
+	res := p.Execute(&applyCtx, repoDir, project)
+	planVersion, _ := version.NewVersion("0.11.0")
+	Equals(t, planVersion, res.TerraformVersion)
+	tm.VerifyWasCalled(Never()).ResolveVersion(tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyGoVersionConstraints())
+
+	t.Log("if the constraints changed so it no longer matches, they should be resolved again")
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, ".tfplan.version"), []byte("0.10.8\n"), 0600))
+	tfVersion, _ := version.NewVersion("0.11.7")
+	When(tm.ResolveVersion(ctx.Log, constraints)).ThenReturn(tfVersion, nil)
+	res = p.Execute(&applyCtx, repoDir, project)
+	Equals(t, tfVersion, res.TerraformVersion)
+}
+
+func TestExecute_TerraformVersionConstraintsErr(t *testing.T) {
+	t.Log("when the project's terraform_version constraints can't be resolved we return the error")
+	p, l, tm, _ := setupPreExecuteTest(t)
+	When(l.TryLock(project, "", ctx.Pull, ctx.User)).ThenReturn(locking.TryLockResponse{LockAcquired: true}, nil)
+	When(p.ConfigReader.Exists("")).ThenReturn(true)
+	constraints, _ := version.NewConstraint("~> 0.11.0")
+	When(p.ConfigReader.Read("")).ThenReturn(events.ProjectConfig{TerraformVersionConstraints: constraints}, nil)
+	When(tm.ResolveVersion(ctx.Log, constraints)).ThenReturn(nil, errors.New("err"))
+
+	res := p.Execute(&ctx, "", project)
+	Equals(t, "err", res.ProjectResult.Error.Error())
+}
+
+func TestExecute_RequiredVersion(t *testing.T) {
+	t.Log("when there's no terraform_version, the version the project's required_version resolves to should be used")
+	p, l, tm, _ := setupPreExecuteTest(t)
+	repoDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(repoDir) // nolint: errcheck
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, "main.tf"), []byte("terraform {\n  required_version = \"= 0.10.8\"\n}\n"), 0600))
+	When(l.TryLock(project, "", ctx.Pull, ctx.User)).ThenReturn(locking.TryLockResponse{LockAcquired: true}, nil)
+	defaultVersion, _ := version.NewVersion("0.11.0")
+	tfVersion, _ := version.NewVersion("0.10.8")
+	When(tm.Version()).ThenReturn(defaultVersion)
+	When(tm.ResolveVersion(tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyGoVersionConstraints())).ThenReturn(tfVersion, nil)
+
+	res := p.Execute(&ctx, repoDir, project)
+	Equals(t, tfVersion, res.TerraformVersion)
+	_, constraints := tm.VerifyWasCalledOnce().ResolveVersion(tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyGoVersionConstraints()).GetCapturedArguments()
+	Equals(t, "= 0.10.8", constraints.String())
+	tm.VerifyWasCalledOnce().RunInitAndEnv(nil, ctx.Log, nil, repoDir, "", nil, tfVersion)
+}
+
+func TestExecute_RequiredVersionFromPlan(t *testing.T) {
+	t.Log("apply should use the version the plan was made with if it still matches the required_version")
+	p, l, tm, _ := setupPreExecuteTest(t)
+	repoDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(repoDir) // nolint: errcheck
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, "main.tf"), []byte("terraform {\n  required_version = \"~> 0.10.0\"\n}\n"), 0600))
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, ".tfplan.version"), []byte("0.10.8\n"), 0600))
+	applyCtx := ctx
+	applyCtx.Command = &events.Command{Name: events.Apply}
+	When(l.TryLock(project, "", ctx.Pull, ctx.User)).ThenReturn(locking.TryLockResponse{LockAcquired: true}, nil)
+	defaultVersion, _ := version.NewVersion("0.11.0")
+	When(tm.Version()).ThenReturn(defaultVersion)
+
+	res := p.Execute(&applyCtx, repoDir, project)
+	planVersion, _ := version.NewVersion("0.10.8")
+	Equals(t, planVersion, res.TerraformVersion)
+	tm.VerifyWasCalled(Never()).ResolveVersion(tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyGoVersionConstraints())
+}
+
+func TestExecute_RequiredVersionErr(t *testing.T) {
+	t.Log("when the project's required_version can't be resolved we return the error")
+	p, l, tm, _ := setupPreExecuteTest(t)
+	repoDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(repoDir) // nolint: errcheck
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, "main.tf"), []byte("terraform {\n  required_version = \"= 0.10.8\"\n}\n"), 0600))
+	When(l.TryLock(project, "", ctx.Pull, ctx.User)).ThenReturn(locking.TryLockResponse{LockAcquired: true}, nil)
+	When(tm.ResolveVersion(tmatchers.AnyPtrToLoggingSimpleLogger(), tmatchers.AnyGoVersionConstraints())).ThenReturn(nil, errors.New("err"))
+
+	res := p.Execute(&ctx, repoDir, project)
+	Equals(t, "resolving required_version: err", res.ProjectResult.Error.Error())
+}
+
+func TestExecute_WorkflowSkipsInit(t *testing.T) {
+	t.Log("when the project's workflow has steps for the command, init and the hooks should be left to them")
+	p, l, tm, r := setupPreExecuteTest(t)
+	lockResponse := locking.TryLockResponse{LockAcquired: true}
+	When(l.TryLock(project, "", ctx.Pull, ctx.User)).ThenReturn(lockResponse, nil)
+	When(p.ConfigReader.Exists("")).ThenReturn(true)
+	config := events.ProjectConfig{
+		PreInit:      []string{"pre-init"},
+		PrePlan:      []string{"pre-plan"},
+		WorkflowName: "custom",
+		Workflow:     events.Workflow{Plan: []events.Step{{Name: "plan"}}},
+	}
+	When(p.ConfigReader.Read("")).ThenReturn(config, nil)
+	tfVersion, _ := version.NewVersion("0.11.0")
+	When(tm.Version()).ThenReturn(tfVersion)
+
+	res := p.Execute(&ctx, "", project)
+	Equals(t, events.PreExecuteResult{
+		ProjectConfig:    config,
+		TerraformVersion: tfVersion,
+		LockResponse:     lockResponse,
+	}, res)
+	tm.VerifyWasCalled(Never()).RunInitAndEnv(nil, ctx.Log, nil, "", "", nil, tfVersion)
+	r.VerifyWasCalled(Never()).Execute(nil, ctx.Log, nil, []string{"pre-init"}, run.HookContext{TerraformVersion: tfVersion, PlanFile: ".tfplan"}, "pre_init")
+	r.VerifyWasCalled(Never()).Execute(nil, ctx.Log, nil, []string{"pre-plan"}, run.HookContext{TerraformVersion: tfVersion, PlanFile: ".tfplan"}, "pre_plan")
+}
+
 func TestExecute_SuccessTF9(t *testing.T) {
 	t.Log("when the project is on tf >= 0.9 it should be successful")
 	p, l, tm, r := setupPreExecuteTest(t)
@@ -163,7 +347,7 @@ func TestExecute_SuccessTF9(t *testing.T) {
 	When(p.ConfigReader.Read("")).ThenReturn(config, nil)
 	tfVersion, _ := version.NewVersion("0.9")
 	When(tm.Version()).ThenReturn(tfVersion)
-	When(tm.RunInitAndEnv(ctx.Log, "", "", nil, tfVersion)).ThenReturn(nil, nil)
+	When(tm.RunInitAndEnv(nil, ctx.Log, nil, "", "", nil, tfVersion)).ThenReturn(nil, nil)
 
 	res := p.Execute(&ctx, "", project)
 	Equals(t, events.PreExecuteResult{
@@ -171,8 +355,8 @@ func TestExecute_SuccessTF9(t *testing.T) {
 		TerraformVersion: tfVersion,
 		LockResponse:     lockResponse,
 	}, res)
-	tm.VerifyWasCalledOnce().RunInitAndEnv(ctx.Log, "", "", nil, tfVersion)
-	r.VerifyWasCalledOnce().Execute(ctx.Log, []string{"pre-init"}, "", "", tfVersion, "pre_init")
+	tm.VerifyWasCalledOnce().RunInitAndEnv(nil, ctx.Log, nil, "", "", nil, tfVersion)
+	r.VerifyWasCalledOnce().Execute(nil, ctx.Log, nil, []string{"pre-init"}, run.HookContext{TerraformVersion: tfVersion, PlanFile: ".tfplan"}, "pre_init")
 }
 
 func TestExecute_SuccessTF8(t *testing.T) {
@@ -196,8 +380,8 @@ func TestExecute_SuccessTF8(t *testing.T) {
 		TerraformVersion: tfVersion,
 		LockResponse:     lockResponse,
 	}, res)
-	tm.VerifyWasCalledOnce().RunCommandWithVersion(ctx.Log, "", []string{"get", "-no-color"}, tfVersion, "")
-	r.VerifyWasCalledOnce().Execute(ctx.Log, []string{"pre-get"}, "", "", tfVersion, "pre_get")
+	tm.VerifyWasCalledOnce().RunCommandWithVersion(nil, ctx.Log, nil, "", []string{"get", "-no-color"}, tfVersion, "")
+	r.VerifyWasCalledOnce().Execute(nil, ctx.Log, nil, []string{"pre-get"}, run.HookContext{TerraformVersion: tfVersion, PlanFile: ".tfplan"}, "pre_get")
 }
 
 func TestExecute_SuccessPrePlan(t *testing.T) {
@@ -221,7 +405,7 @@ func TestExecute_SuccessPrePlan(t *testing.T) {
 		TerraformVersion: tfVersion,
 		LockResponse:     lockResponse,
 	}, res)
-	r.VerifyWasCalledOnce().Execute(ctx.Log, []string{"command"}, "", "", tfVersion, "pre_plan")
+	r.VerifyWasCalledOnce().Execute(nil, ctx.Log, nil, []string{"command"}, run.HookContext{TerraformVersion: tfVersion, PlanFile: ".tfplan"}, "pre_plan")
 }
 
 func TestExecute_SuccessPreApply(t *testing.T) {
@@ -251,7 +435,50 @@ func TestExecute_SuccessPreApply(t *testing.T) {
 		TerraformVersion: tfVersion,
 		LockResponse:     lockResponse,
 	}, res)
-	r.VerifyWasCalledOnce().Execute(cpCtx.Log, []string{"command"}, "", "", tfVersion, "pre_apply")
+	r.VerifyWasCalledOnce().Execute(nil, cpCtx.Log, nil, []string{"command"}, run.HookContext{TerraformVersion: tfVersion, PlanFile: ".tfplan"}, "pre_apply")
+}
+
+func TestExecute_EnvVars(t *testing.T) {
+	t.Log("the project's environment variables should be set for its commands and the secret ones redacted")
+	p, l, tm, r := setupPreExecuteTest(t)
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	Ok(t, ioutil.WriteFile(filepath.Join(dir, "db.txt"), []byte("file-secret\n"), 0600))
+	Ok(t, ioutil.WriteFile(filepath.Join(dir, "secrets.yaml"), []byte("secret/atlantis: {token: store-secret}"), 0600))
+	p.EnvVars = &events.EnvVarResolver{
+		ServerEnvVars: []events.ServerEnvVar{{EnvVar: events.EnvVar{Name: "TOKEN", Secret: "secret/atlantis#token"}}},
+		Secrets:       &secrets.LocalStore{Path: filepath.Join(dir, "secrets.yaml")},
+	}
+	cmdCtx := ctx
+	When(l.TryLock(project, "", cmdCtx.Pull, cmdCtx.User)).ThenReturn(locking.TryLockResponse{LockAcquired: true}, nil)
+	When(p.ConfigReader.Exists(dir)).ThenReturn(true)
+	When(p.ConfigReader.Read(dir)).ThenReturn(events.ProjectConfig{
+		PrePlan: []string{"command"},
+		EnvVars: []events.EnvVar{
+			{Name: "REGION", Value: "us-east-1"},
+			{Name: "DB_PASSWORD", File: "db.txt"},
+			{Name: "ONLY_PROD", Value: "prod", Workspaces: []string{"production"}},
+		},
+	}, nil)
+	tfVersion, _ := version.NewVersion("0.9")
+	When(tm.Version()).ThenReturn(tfVersion)
+	var hookEnv map[string]string
+	When(r.Execute(rmatchers.AnyContextContext(), rmatchers.AnyPtrToLoggingSimpleLogger(), rmatchers.AnyIoWriter(), rmatchers.AnySliceOfString(), rmatchers.AnyRunHookContext(), AnyString())).
+		Then(func(params []Param) ReturnValues {
+			hookEnv = run.Env(params[0].(context.Context))
+			return []ReturnValue{"", nil}
+		})
+
+	res := p.Execute(&cmdCtx, dir, project)
+
+	Equals(t, events.ProjectResult{}, res.ProjectResult)
+	exp := map[string]string{"TOKEN": "store-secret", "REGION": "us-east-1", "DB_PASSWORD": "file-secret"}
+	Equals(t, exp, hookEnv)
+	Equals(t, exp, res.ProjectConfig.Env)
+	Equals(t, []string{"store-secret", "file-secret"}, cmdCtx.SensitiveValues)
+	_, hasEnv := os.LookupEnv("DB_PASSWORD")
+	Assert(t, !hasEnv, "exp the variables not to be set for Atlantis")
 }
 
 func setupPreExecuteTest(t *testing.T) (*events.ProjectPreExecute, *lmocks.MockLocker, *tmocks.MockRunner, *rmocks.MockRunner) {
@@ -1,13 +1,25 @@
 package events
 
-import "github.com/hootsuite/atlantis/server/events/vcs"
+import (
+	"fmt"
+
+	"github.com/hootsuite/atlantis/server/events/run"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/pkg/errors"
+)
 
 type ProjectResult struct {
-	Path         string
+	Path string
+	// ProjectPath is the path of the project relative to the repo root. It
+	// names the project's commit status, check run and comment.
+	ProjectPath  string
 	Error        error
 	Failure      string
 	PlanSuccess  *PlanSuccess
 	ApplySuccess string
+	// TimedOut is true if one of the project's commands was stopped because
+	// it ran for longer than its timeout. Failure says which one.
+	TimedOut bool
 }
 
 func (p ProjectResult) Status() vcs.CommitStatus {
@@ -19,3 +31,17 @@ func (p ProjectResult) Status() vcs.CommitStatus {
 	}
 	return vcs.Success
 }
+
+// timeoutResult returns the result for a project whose command failed with
+// err if it's because the command timed out. what describes the command, ex.
+// "`terraform plan`".
+func timeoutResult(err error, what string) (ProjectResult, bool) {
+	timeoutErr, ok := errors.Cause(err).(run.TimeoutError)
+	if !ok {
+		return ProjectResult{}, false
+	}
+	return ProjectResult{
+		TimedOut: true,
+		Failure:  fmt.Sprintf("Timed out after %s running %s.", timeoutErr.Timeout, what),
+	}, true
+}
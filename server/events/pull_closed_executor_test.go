@@ -39,7 +39,7 @@ func TestCleanUpPullUnlockErr(t *testing.T) {
 		Workspace: w,
 	}
 	err := errors.New("err")
-	When(l.UnlockByPull(fixtures.Repo.FullName, fixtures.Pull.Num)).ThenReturn(nil, err)
+	When(l.UnlockByPull(fixtures.Repo.Hostname, fixtures.Repo.FullName, fixtures.Pull.Num)).ThenReturn(nil, err)
 	actualErr := pce.CleanUpPull(fixtures.Repo, fixtures.Pull, vcs.Github)
 	Equals(t, "cleaning up locks: err", actualErr.Error())
 }
@@ -55,7 +55,7 @@ func TestCleanUpPullNoLocks(t *testing.T) {
 		VCSClient: cp,
 		Workspace: w,
 	}
-	When(l.UnlockByPull(fixtures.Repo.FullName, fixtures.Pull.Num)).ThenReturn(nil, nil)
+	When(l.UnlockByPull(fixtures.Repo.Hostname, fixtures.Repo.FullName, fixtures.Pull.Num)).ThenReturn(nil, nil)
 	err := pce.CleanUpPull(fixtures.Repo, fixtures.Pull, vcs.Github)
 	Ok(t, err)
 	cp.VerifyWasCalled(Never()).CreateComment(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString(), matchers.AnyVcsHost())
@@ -73,7 +73,7 @@ func TestCleanUpPullComments(t *testing.T) {
 			"single lock, empty path",
 			[]models.ProjectLock{
 				{
-					Project: models.NewProject("owner/repo", ""),
+					Project: models.NewProject("github.com", "owner/repo", ""),
 					Env:     "default",
 				},
 			},
@@ -83,7 +83,7 @@ func TestCleanUpPullComments(t *testing.T) {
 			"single lock, non-empty path",
 			[]models.ProjectLock{
 				{
-					Project: models.NewProject("owner/repo", "path"),
+					Project: models.NewProject("github.com", "owner/repo", "path"),
 					Env:     "default",
 				},
 			},
@@ -93,11 +93,11 @@ func TestCleanUpPullComments(t *testing.T) {
 			"single path, multiple environments",
 			[]models.ProjectLock{
 				{
-					Project: models.NewProject("owner/repo", "path"),
+					Project: models.NewProject("github.com", "owner/repo", "path"),
 					Env:     "env1",
 				},
 				{
-					Project: models.NewProject("owner/repo", "path"),
+					Project: models.NewProject("github.com", "owner/repo", "path"),
 					Env:     "env2",
 				},
 			},
@@ -107,19 +107,19 @@ func TestCleanUpPullComments(t *testing.T) {
 			"multiple paths, multiple environments",
 			[]models.ProjectLock{
 				{
-					Project: models.NewProject("owner/repo", "path"),
+					Project: models.NewProject("github.com", "owner/repo", "path"),
 					Env:     "env1",
 				},
 				{
-					Project: models.NewProject("owner/repo", "path"),
+					Project: models.NewProject("github.com", "owner/repo", "path"),
 					Env:     "env2",
 				},
 				{
-					Project: models.NewProject("owner/repo", "path2"),
+					Project: models.NewProject("github.com", "owner/repo", "path2"),
 					Env:     "env1",
 				},
 				{
-					Project: models.NewProject("owner/repo", "path2"),
+					Project: models.NewProject("github.com", "owner/repo", "path2"),
 					Env:     "env2",
 				},
 			},
@@ -136,7 +136,7 @@ func TestCleanUpPullComments(t *testing.T) {
 			Workspace: w,
 		}
 		t.Log("testing: " + c.Description)
-		When(l.UnlockByPull(fixtures.Repo.FullName, fixtures.Pull.Num)).ThenReturn(c.Locks, nil)
+		When(l.UnlockByPull(fixtures.Repo.Hostname, fixtures.Repo.FullName, fixtures.Pull.Num)).ThenReturn(c.Locks, nil)
 		err := pce.CleanUpPull(fixtures.Repo, fixtures.Pull, vcs.Github)
 		Ok(t, err)
 		_, _, comment, _ := cp.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString(), matchers.AnyVcsHost()).GetCapturedArguments()
@@ -0,0 +1,96 @@
+// Package redaction masks secrets in the output Atlantis posts to pull
+// requests and webhooks.
+package redaction
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Mask replaces the secrets that are redacted.
+const Mask = "[redacted]"
+
+// minValueLength is the length of the shortest value we redact. Masking
+// shorter values, ex. "1" or "yes", would mangle the output without hiding
+// anything worth hiding.
+const minValueLength = 4
+
+// Redactor masks secrets in text. A nil Redactor doesn't mask anything.
+type Redactor struct {
+	// Patterns are masked wherever they match. If a pattern has capturing
+	// groups only the groups are masked, ex. `password=(\S+)` keeps
+	// "password=" in the output.
+	Patterns []*regexp.Regexp
+	// Values are masked wherever they appear.
+	Values []string
+}
+
+// NewRedactor returns a redactor that masks matches of patterns and
+// occurrences of values.
+func NewRedactor(patterns []string, values []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "compiling redact regex %q", p)
+		}
+		r.Patterns = append(r.Patterns, re)
+	}
+	r.Values = values
+	return r, nil
+}
+
+// Redact returns s with the redactor's patterns and values masked, as well
+// as extraValues, which are usually secrets only known for the current
+// command, ex. the values of sensitive variables.
+func (r *Redactor) Redact(s string, extraValues ...string) string {
+	var values []string
+	if r != nil {
+		values = append(values, r.Values...)
+	}
+	values = append(values, extraValues...)
+	// Longer values go first so a value that contains another one is
+	// masked in full.
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		if len(v) >= minValueLength {
+			s = strings.Replace(s, v, Mask, -1)
+		}
+	}
+	if r == nil {
+		return s
+	}
+	for _, p := range r.Patterns {
+		s = maskMatches(p, s)
+	}
+	return s
+}
+
+// maskMatches masks the matches of p in s, or only their capturing groups
+// if p has any.
+func maskMatches(p *regexp.Regexp, s string) string {
+	if p.NumSubexp() == 0 {
+		return p.ReplaceAllLiteralString(s, Mask)
+	}
+	var b bytes.Buffer
+	last := 0
+	for _, match := range p.FindAllStringSubmatchIndex(s, -1) {
+		// match[0:2] is the whole match and the rest are the groups'
+		// start and end indexes, which are -1 if a group didn't match.
+		for i := 2; i < len(match); i += 2 {
+			start, end := match[i], match[i+1]
+			if start < last || start == end {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Mask)
+			last = end
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package redaction
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/pkg/errors"
+)
+
+// variableBlockRegex matches the start of a variable block, ex.
+// `variable "password" {`.
+var variableBlockRegex = regexp.MustCompile(`(?m)^\s*variable\s+"([^"]+)"\s*\{`)
+
+// sensitiveRegex matches `sensitive = true` in the body of a variable block.
+var sensitiveRegex = regexp.MustCompile(`(?m)^\s*sensitive\s*=\s*true\s*$`)
+
+// SensitiveValues returns the values of the variables marked sensitive in
+// the Terraform files in projectDir. Values are read from the tfvars files
+// Terraform loads automatically, the project's varFiles, which are relative to
+// projectDir, and TF_VAR_ environment variables.
+//
+// Variables can only be marked sensitive in terraform >= 0.14 so for projects
+// using older versions this returns nothing.
+//
+// The .tf files aren't fully parsed since they may use syntax the vendored
+// HCL parser doesn't support. If a tfvars file can't be parsed the values
+// from the other files are still returned along with an error.
+func SensitiveValues(projectDir string, varFiles []string) ([]string, error) {
+	names, err := sensitiveVariables(projectDir)
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+
+	var values []string
+	for name := range names {
+		if v, ok := os.LookupEnv("TF_VAR_" + name); ok {
+			values = append(values, v)
+		}
+	}
+
+	autoVarFiles, err := filepath.Glob(filepath.Join(projectDir, "*.auto.tfvars"))
+	if err != nil {
+		return values, err
+	}
+	files := append([]string{filepath.Join(projectDir, "terraform.tfvars")}, autoVarFiles...)
+	for _, f := range varFiles {
+		files = append(files, filepath.Join(projectDir, f))
+	}
+	var parseErrs []string
+	for _, f := range files {
+		contents, err := ioutil.ReadFile(f)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return values, err
+		}
+		var vars map[string]interface{}
+		if err := hcl.Unmarshal(contents, &vars); err != nil {
+			parseErrs = append(parseErrs, fmt.Sprintf("%s: %s", filepath.Base(f), err))
+			continue
+		}
+		for name, v := range vars {
+			if names[name] {
+				values = append(values, scalars(v)...)
+			}
+		}
+	}
+	if len(parseErrs) > 0 {
+		return values, fmt.Errorf("parsing tfvars files: %s", strings.Join(parseErrs, ", "))
+	}
+	return values, nil
+}
+
+// sensitiveVariables returns the names of the variables marked sensitive in
+// the .tf files in dir.
+func sensitiveVariables(dir string) (map[string]bool, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	names := make(map[string]bool)
+	for _, f := range files {
+		contents, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", filepath.Base(f))
+		}
+		text := string(contents)
+		for _, match := range variableBlockRegex.FindAllStringSubmatchIndex(text, -1) {
+			name := text[match[2]:match[3]]
+			if sensitiveRegex.MatchString(blockBody(text[match[1]:])) {
+				names[name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+// blockBody returns the text of s up to the brace that closes the block whose
+// opening brace came just before s. Braces in strings aren't handled since
+// they're rare in variable blocks.
+func blockBody(s string) string {
+	depth := 1
+	for i, c := range s {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[:i]
+			}
+		}
+	}
+	return s
+}
+
+// scalars returns the string form of v if it's a scalar, or of the scalars
+// it contains if it's a list or map.
+func scalars(v interface{}) []string {
+	switch t := v.(type) {
+	case []interface{}:
+		var values []string
+		for _, e := range t {
+			values = append(values, scalars(e)...)
+		}
+		return values
+	case []map[string]interface{}:
+		var values []string
+		for _, m := range t {
+			values = append(values, scalars(m)...)
+		}
+		return values
+	case map[string]interface{}:
+		var values []string
+		for _, e := range t {
+			values = append(values, scalars(e)...)
+		}
+		return values
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprint(t)}
+	}
+}
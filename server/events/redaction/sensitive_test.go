@@ -0,0 +1,92 @@
+package redaction_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events/redaction"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+const variablesTF = `
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+
+variable "api_keys" {
+  type = list(string)
+  validation {
+    condition = length(var.api_keys) > 0
+  }
+  sensitive = true
+}
+
+variable "region" {
+  default = "us-east-1"
+}
+`
+
+func TestSensitiveValues(t *testing.T) {
+	t.Log("should return the values of sensitive variables from tfvars files and TF_VAR_ environment variables")
+	dir := projectDir(t, map[string]string{
+		"variables.tf":        variablesTF,
+		"terraform.tfvars":    `region = "us-west-2"`,
+		"keys.auto.tfvars":    `api_keys = ["key-one", "key-two"]`,
+		"env/staging.tfvars":  `db_password = "staging-password"`,
+		"env/prod.tfvars":     `db_password = "prod-password"`,
+		"modules/vpc/main.tf": `variable "ignored" { sensitive = true }`,
+	})
+	defer os.RemoveAll(dir)                         // nolint: errcheck
+	os.Setenv("TF_VAR_db_password", "env-password") // nolint: errcheck
+	defer os.Unsetenv("TF_VAR_db_password")         // nolint: errcheck
+
+	values, err := redaction.SensitiveValues(dir, []string{"env/staging.tfvars"})
+	Ok(t, err)
+	sort.Strings(values)
+	Equals(t, []string{"env-password", "key-one", "key-two", "staging-password"}, values)
+}
+
+func TestSensitiveValues_NoSensitiveVariables(t *testing.T) {
+	t.Log("should not read tfvars files if no variables are sensitive")
+	dir := projectDir(t, map[string]string{
+		"main.tf":          `variable "region" {}`,
+		"terraform.tfvars": `this isn't valid`,
+	})
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	values, err := redaction.SensitiveValues(dir, nil)
+	Ok(t, err)
+	Equals(t, 0, len(values))
+}
+
+func TestSensitiveValues_ParseError(t *testing.T) {
+	t.Log("should return the values from the other tfvars files if one can't be parsed")
+	dir := projectDir(t, map[string]string{
+		"variables.tf":       variablesTF,
+		"terraform.tfvars":   `db_password = "unterminated`,
+		"env/default.tfvars": `db_password = "default-password"`,
+	})
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	values, err := redaction.SensitiveValues(dir, []string{"env/default.tfvars"})
+	Assert(t, err != nil, "expected error")
+	Assert(t, strings.HasPrefix(err.Error(), "parsing tfvars files: terraform.tfvars:"), "unexpected error %q", err)
+	Equals(t, []string{"default-password"}, values)
+}
+
+// projectDir returns a temp dir containing files, a map of paths to their
+// contents.
+func projectDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	for path, contents := range files {
+		Ok(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0700))
+		Ok(t, ioutil.WriteFile(filepath.Join(dir, path), []byte(contents), 0600))
+	}
+	return dir
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Wildcard matches 0-n of all characters except commas.
+const Wildcard = "*"
+
+// RepoWhitelist implements checking if repos are whitelisted to be used with
+// this Atlantis.
+type RepoWhitelist struct {
+	// Whitelist is a comma separated list of rules with wildcards '*' allowed.
+	// Each rule is matched against "{hostname}/{owner}/{repo}",
+	// ex. "github.com/hootsuite/*".
+	Whitelist string
+}
+
+// IsWhitelisted returns true if this repo is in our whitelist and false
+// otherwise.
+func (r *RepoWhitelist) IsWhitelisted(repoFullName string, vcsHostname string) bool {
+	candidate := fmt.Sprintf("%s/%s", vcsHostname, repoFullName)
+	rules := strings.Split(r.Whitelist, ",")
+	for _, rule := range rules {
+		if r.matchesRule(rule, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RepoWhitelist) matchesRule(rule string, candidate string) bool {
+	// Case insensitive compare.
+	rule = strings.ToLower(strings.TrimSpace(rule))
+	candidate = strings.ToLower(candidate)
+	if rule == "" {
+		return false
+	}
+
+	parts := strings.Split(rule, Wildcard)
+	if len(parts) == 1 {
+		// No wildcard so can do a straight up match.
+		return candidate == rule
+	}
+
+	// The candidate must start with everything before the first wildcard
+	// and end with everything after the last wildcard. Everything in between
+	// must appear in order.
+	first, last := parts[0], parts[len(parts)-1]
+	if !strings.HasPrefix(candidate, first) {
+		return false
+	}
+	remaining := candidate[len(first):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(remaining, part)
+		if idx == -1 {
+			return false
+		}
+		remaining = remaining[idx+len(part):]
+	}
+	return strings.HasSuffix(remaining, last)
+}
@@ -0,0 +1,123 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestRepoWhitelist_IsWhitelisted(t *testing.T) {
+	cases := []struct {
+		Description  string
+		Whitelist    string
+		RepoFullName string
+		Hostname     string
+		Exp          bool
+	}{
+		{
+			"exact match",
+			"github.com/owner/repo",
+			"owner/repo",
+			"github.com",
+			true,
+		},
+		{
+			"exact match shouldn't match anything else",
+			"github.com/owner/repo",
+			"owner/rep",
+			"github.com",
+			false,
+		},
+		{
+			"* should match anything",
+			"*",
+			"owner/repo",
+			"github.com",
+			true,
+		},
+		{
+			"github.com* should match anything github",
+			"github.com*",
+			"owner/repo",
+			"github.com",
+			true,
+		},
+		{
+			"github.com* should not match gitlab",
+			"github.com*",
+			"owner/repo",
+			"gitlab.com",
+			false,
+		},
+		{
+			"github.com/o* should match",
+			"github.com/o*",
+			"owner/repo",
+			"github.com",
+			true,
+		},
+		{
+			"github.com/owner/* should not match a different owner",
+			"github.com/owner/*",
+			"other/repo",
+			"github.com",
+			false,
+		},
+		{
+			"wildcard in the middle should match",
+			"github.com/*/repo",
+			"owner/repo",
+			"github.com",
+			true,
+		},
+		{
+			"wildcard in the middle shouldn't match a different repo",
+			"github.com/*/repo",
+			"owner/other",
+			"github.com",
+			false,
+		},
+		{
+			"multiple wildcards should match",
+			"*.com/*/repo*",
+			"owner/repo-infra",
+			"github.com",
+			true,
+		},
+		{
+			"matching should be case insensitive",
+			"github.com/OWNER/*",
+			"Owner/Repo",
+			"GitHub.com",
+			true,
+		},
+		{
+			"comma separated rules should match any rule",
+			"github.com/owner/repo, gitlab.com/owner/*",
+			"owner/other",
+			"gitlab.com",
+			true,
+		},
+		{
+			"comma separated rules shouldn't match if no rule matches",
+			"github.com/owner/repo,gitlab.com/owner/*",
+			"owner/other",
+			"github.com",
+			false,
+		},
+		{
+			"empty whitelist should match nothing",
+			"",
+			"owner/repo",
+			"github.com",
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Log(c.Description)
+		w := events.RepoWhitelist{Whitelist: c.Whitelist}
+		Equals(t, c.Exp, w.IsWhitelisted(c.RepoFullName, c.Hostname))
+	}
+}
@@ -0,0 +1,20 @@
+package matchers
+
+import (
+	"reflect"
+
+	run "github.com/hootsuite/atlantis/server/events/run"
+	"github.com/petergtz/pegomock"
+)
+
+func AnyRunHookContext() run.HookContext {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(run.HookContext))(nil)).Elem()))
+	var nullValue run.HookContext
+	return nullValue
+}
+
+func EqRunHookContext(value run.HookContext) run.HookContext {
+	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
+	var nullValue run.HookContext
+	return nullValue
+}
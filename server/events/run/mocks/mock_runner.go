@@ -4,9 +4,11 @@
 package mocks
 
 import (
+	context "context"
+	io "io"
 	"reflect"
 
-	go_version "github.com/hashicorp/go-version"
+	run "github.com/hootsuite/atlantis/server/events/run"
 	logging "github.com/hootsuite/atlantis/server/logging"
 	pegomock "github.com/petergtz/pegomock"
 )
@@ -19,8 +21,8 @@ func NewMockRunner() *MockRunner {
 	return &MockRunner{fail: pegomock.GlobalFailHandler}
 }
 
-func (mock *MockRunner) Execute(log *logging.SimpleLogger, commands []string, path string, environment string, terraformVersion *go_version.Version, stage string) (string, error) {
-	params := []pegomock.Param{log, commands, path, environment, terraformVersion, stage}
+func (mock *MockRunner) Execute(ctx context.Context, log *logging.SimpleLogger, output io.Writer, commands []string, hook run.HookContext, stage string) (string, error) {
+	params := []pegomock.Param{ctx, log, output, commands, hook, stage}
 	result := pegomock.GetGenericMockFrom(mock).Invoke("Execute", params, []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
 	var ret0 string
 	var ret1 error
@@ -53,8 +55,8 @@ type VerifierRunner struct {
 	inOrderContext         *pegomock.InOrderContext
 }
 
-func (verifier *VerifierRunner) Execute(log *logging.SimpleLogger, commands []string, path string, environment string, terraformVersion *go_version.Version, stage string) *Runner_Execute_OngoingVerification {
-	params := []pegomock.Param{log, commands, path, environment, terraformVersion, stage}
+func (verifier *VerifierRunner) Execute(ctx context.Context, log *logging.SimpleLogger, output io.Writer, commands []string, hook run.HookContext, stage string) *Runner_Execute_OngoingVerification {
+	params := []pegomock.Param{ctx, log, output, commands, hook, stage}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Execute", params)
 	return &Runner_Execute_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -64,33 +66,33 @@ type Runner_Execute_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *Runner_Execute_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, []string, string, string, *go_version.Version, string) {
-	log, commands, path, environment, terraformVersion, stage := c.GetAllCapturedArguments()
-	return log[len(log)-1], commands[len(commands)-1], path[len(path)-1], environment[len(environment)-1], terraformVersion[len(terraformVersion)-1], stage[len(stage)-1]
+func (c *Runner_Execute_OngoingVerification) GetCapturedArguments() (context.Context, *logging.SimpleLogger, io.Writer, []string, run.HookContext, string) {
+	ctx, log, output, commands, hook, stage := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1], log[len(log)-1], output[len(output)-1], commands[len(commands)-1], hook[len(hook)-1], stage[len(stage)-1]
 }
 
-func (c *Runner_Execute_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 [][]string, _param2 []string, _param3 []string, _param4 []*go_version.Version, _param5 []string) {
+func (c *Runner_Execute_OngoingVerification) GetAllCapturedArguments() (_param0 []context.Context, _param1 []*logging.SimpleLogger, _param2 []io.Writer, _param3 [][]string, _param4 []run.HookContext, _param5 []string) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
-		_param0 = make([]*logging.SimpleLogger, len(params[0]))
+		_param0 = make([]context.Context, len(params[0]))
 		for u, param := range params[0] {
-			_param0[u] = param.(*logging.SimpleLogger)
+			_param0[u] = param.(context.Context)
 		}
-		_param1 = make([][]string, len(params[1]))
+		_param1 = make([]*logging.SimpleLogger, len(params[1]))
 		for u, param := range params[1] {
-			_param1[u] = param.([]string)
+			_param1[u] = param.(*logging.SimpleLogger)
 		}
-		_param2 = make([]string, len(params[2]))
+		_param2 = make([]io.Writer, len(params[2]))
 		for u, param := range params[2] {
-			_param2[u] = param.(string)
+			_param2[u] = param.(io.Writer)
 		}
-		_param3 = make([]string, len(params[3]))
+		_param3 = make([][]string, len(params[3]))
 		for u, param := range params[3] {
-			_param3[u] = param.(string)
+			_param3[u] = param.([]string)
 		}
-		_param4 = make([]*go_version.Version, len(params[4]))
+		_param4 = make([]run.HookContext, len(params[4]))
 		for u, param := range params[4] {
-			_param4[u] = param.(*go_version.Version)
+			_param4[u] = param.(run.HookContext)
 		}
 		_param5 = make([]string, len(params[5]))
 		for u, param := range params[5] {
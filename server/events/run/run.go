@@ -4,11 +4,17 @@ package run
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/hootsuite/atlantis/server/logging"
@@ -17,22 +23,114 @@ import (
 
 const inlineShebang = "#!/bin/sh -e"
 
+// pluginCacheDirEnv is the environment variable terraform reads its plugin
+// cache directory from.
+const pluginCacheDirEnv = "TF_PLUGIN_CACHE_DIR"
+
+// ErrCanceled is returned when a command is stopped because it was canceled.
+var ErrCanceled = errors.New("command was canceled")
+
+// TimeoutError is returned when a command is stopped because it ran for
+// longer than the timeout of the context from WithTimeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (t TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", t.Timeout)
+}
+
+// timeoutKey is the context key for the timeout set by WithTimeout.
+type timeoutKey struct{}
+
+// envKey is the context key for the environment variables set by WithEnv.
+type envKey struct{}
+
+// killGracePeriod is how long a canceled command has to exit after it's
+// interrupted before it's killed.
+var killGracePeriod = 30 * time.Second
+
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_runner.go Runner
 
 type Runner interface {
-	Execute(log *logging.SimpleLogger, commands []string, path string, environment string, terraformVersion *version.Version, stage string) (string, error)
+	Execute(ctx context.Context, log *logging.SimpleLogger, output io.Writer, commands []string, hook HookContext, stage string) (string, error)
+}
+
+// HookContext is what the commands are being run for. It's passed to them as
+// environment variables so scripts can use it.
+type HookContext struct {
+	// Environment is the terraform environment, or workspace, ex. "staging".
+	// It's set as ENVIRONMENT and WORKSPACE_NAME.
+	Environment string
+	// ProjectDir is the absolute path to the project the commands are run
+	// in. It's set as PROJECT_DIR and, for older scripts, WORKSPACE.
+	ProjectDir string
+	// TerraformVersion is the version of terraform the project uses. It's set
+	// as ATLANTIS_TERRAFORM_VERSION.
+	TerraformVersion *version.Version
+	// PullNum is the number of the pull request. It's set as PULL_NUM.
+	PullNum int
+	// HeadCommit is the sha of the pull request's head commit. It's set as
+	// HEAD_COMMIT.
+	HeadCommit string
+	// BaseBranch is the branch the pull request is into. It's set as
+	// BASE_BRANCH_NAME.
+	BaseBranch string
+	// User is the username of the user that ran the command. It's set as
+	// USER_NAME.
+	User string
+	// PlanFile is the absolute path to the plan file for the environment,
+	// which won't exist before it's planned. It's set as PLANFILE.
+	PlanFile string
+}
+
+// Env returns the environment variables for h in the form "NAME=value".
+func (h HookContext) Env() []string {
+	tfVersion := ""
+	if h.TerraformVersion != nil {
+		tfVersion = h.TerraformVersion.String()
+	}
+	return []string{
+		"ENVIRONMENT=" + h.Environment,
+		"WORKSPACE_NAME=" + h.Environment,
+		"ATLANTIS_TERRAFORM_VERSION=" + tfVersion,
+		"WORKSPACE=" + h.ProjectDir,
+		"PROJECT_DIR=" + h.ProjectDir,
+		fmt.Sprintf("PULL_NUM=%d", h.PullNum),
+		"HEAD_COMMIT=" + h.HeadCommit,
+		"BASE_BRANCH_NAME=" + h.BaseBranch,
+		"USER_NAME=" + h.User,
+		"PLANFILE=" + h.PlanFile,
+	}
+}
+
+// ReservedEnvVar returns true if name is one of the environment variables
+// Atlantis sets for commands, which can't be set with WithEnv.
+func ReservedEnvVar(name string) bool {
+	if name == pluginCacheDirEnv {
+		return true
+	}
+	for _, v := range (HookContext{}).Env() {
+		if strings.SplitN(v, "=", 2)[0] == name {
+			return true
+		}
+	}
+	return false
 }
 
 type Run struct{}
 
 // Execute runs the commands by writing them as a script to disk
-// and then executing the script.
+// and then executing the script. If output isn't nil, the script's output is
+// also written to it as it runs. The script is stopped if ctx is canceled.
+// The variables for hook are only set for the script so concurrent calls
+// don't see each other's.
 func (p *Run) Execute(
+	ctx context.Context,
 	log *logging.SimpleLogger,
+	output io.Writer,
 	commands []string,
-	path string,
-	environment string,
-	terraformVersion *version.Version,
+	hook HookContext,
 	stage string) (string, error) {
 	// we create a script from the commands provided
 	if len(commands) == 0 {
@@ -47,13 +145,40 @@ func (p *Run) Execute(
 
 	log.Info("running %s commands: %v", stage, commands)
 
-	// set environment variable for the run.
-	// this is to support scripts to use the ENVIRONMENT, ATLANTIS_TERRAFORM_VERSION
-	// and WORKSPACE variables in their scripts
-	os.Setenv("ENVIRONMENT", environment)                              // nolint: errcheck
-	os.Setenv("ATLANTIS_TERRAFORM_VERSION", terraformVersion.String()) // nolint: errcheck
-	os.Setenv("WORKSPACE", path)                                       // nolint: errcheck
-	return execute(s)
+	// The plugin cache is only safe to write to while it's locked, which the
+	// scripts can't do, so terraform in them doesn't use it.
+	env := withoutVars(Environ(hook.Env()...), []string{pluginCacheDirEnv})
+	return execute(ctx, s, env, output)
+}
+
+// Environ returns our environment variables with vars, which are in the form
+// "NAME=value", in place of any with the same names. exec only uses the last
+// value of a variable since Go 1.9, so appending them wouldn't be enough.
+func Environ(vars ...string) []string {
+	names := make([]string, len(vars))
+	for i, v := range vars {
+		names[i] = strings.SplitN(v, "=", 2)[0]
+	}
+	return append(withoutVars(os.Environ(), names), vars...)
+}
+
+// withoutVars returns the variables in env, which are in the form
+// "NAME=value", that aren't named in names.
+func withoutVars(env []string, names []string) []string {
+	var kept []string
+	for _, v := range env {
+		omit := false
+		for _, name := range names {
+			if strings.HasPrefix(v, name+"=") {
+				omit = true
+				break
+			}
+		}
+		if !omit {
+			kept = append(kept, v)
+		}
+	}
+	return kept
 }
 
 func createScript(cmds []string, stage string) (string, error) {
@@ -86,13 +211,127 @@ func createScript(cmds []string, stage string) (string, error) {
 	return scriptName, nil
 }
 
-func execute(script string) (string, error) {
+// execute runs script with the environment variables in env, or ours if env
+// is nil.
+func execute(ctx context.Context, script string, env []string, output io.Writer) (string, error) {
 	localCmd := exec.Command("sh", "-c", script)
-	out, err := localCmd.CombinedOutput()
-	output := string(out)
-	if err != nil {
-		return output, errors.Wrapf(err, "running script %s: %s", script, output)
+	localCmd.Env = env
+	var out bytes.Buffer
+	var cmdOutput io.Writer = &out
+	if output != nil {
+		cmdOutput = io.MultiWriter(&out, output)
 	}
+	localCmd.Stdout = cmdOutput
+	localCmd.Stderr = cmdOutput
+	if err := Command(ctx, localCmd); err != nil {
+		return out.String(), errors.Wrapf(err, "running script %s: %s", script, out.String())
+	}
+
+	return out.String(), nil
+}
+
+// WithTimeout returns a context that's canceled after timeout. Commands
+// stopped because of it return TimeoutError. If timeout is 0, ctx is returned
+// as is.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return ctx, func() {}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithTimeout(context.WithValue(ctx, timeoutKey{}, timeout), timeout)
+}
+
+// WithEnv returns a context that sets the environment variables in env for
+// the commands run with it, in addition to those set by ctx. If env is empty,
+// ctx is returned as is. Variables for which ReservedEnvVar is true are
+// ignored so Atlantis' own always reach the commands.
+func WithEnv(ctx context.Context, env map[string]string) context.Context {
+	if len(env) == 0 {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	merged := make(map[string]string)
+	for k, v := range Env(ctx) {
+		merged[k] = v
+	}
+	for k, v := range env {
+		if !ReservedEnvVar(k) {
+			merged[k] = v
+		}
+	}
+	return context.WithValue(ctx, envKey{}, merged)
+}
 
-	return output, nil
+// Env returns the environment variables set for ctx by WithEnv.
+func Env(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	env, _ := ctx.Value(envKey{}).(map[string]string)
+	return env
+}
+
+// Command runs cmd in its own process group so it can be stopped along with
+// any processes it started, ex. terraform run by a hook. If ctx is canceled,
+// the group is sent SIGINT, which terraform handles by stopping gracefully,
+// and then SIGKILL if it hasn't exited after killGracePeriod. If cmd was
+// stopped, TimeoutError is returned if ctx's timeout from WithTimeout expired
+// and ErrCanceled otherwise. A nil ctx is never canceled. The environment
+// variables set by WithEnv are added to cmd's, overriding any already set.
+func Command(ctx context.Context, cmd *exec.Cmd) error {
+	var canceled <-chan struct{}
+	if ctx != nil {
+		canceled = ctx.Done()
+	}
+	if env := Env(ctx); len(env) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		var names []string
+		for name := range env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		cmd.Env = withoutVars(cmd.Env, names)
+		for _, name := range names {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", name, env[name]))
+		}
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-canceled:
+	}
+
+	// A negative pid signals the whole process group.
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGINT) // nolint: errcheck
+	select {
+	case <-done:
+	case <-time.After(killGracePeriod):
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) // nolint: errcheck
+		<-done
+	}
+	return StoppedErr(ctx)
+}
+
+// StoppedErr returns the error for something stopped because ctx is done:
+// TimeoutError if ctx's timeout from WithTimeout expired and ErrCanceled
+// otherwise.
+func StoppedErr(ctx context.Context) error {
+	if timeout, ok := ctx.Value(timeoutKey{}).(time.Duration); ok && ctx.Err() == context.DeadlineExceeded {
+		return TimeoutError{Timeout: timeout}
+	}
+	return ErrCanceled
 }
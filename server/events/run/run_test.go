@@ -1,7 +1,15 @@
 package run
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	version "github.com/hashicorp/go-version"
 	"github.com/hootsuite/atlantis/server/logging"
@@ -21,14 +29,14 @@ func TestRunCreateScript_valid(t *testing.T) {
 func TestRunExecuteScript_invalid(t *testing.T) {
 	cmds := []string{"invalid", "command"}
 	scriptName, _ := createScript(cmds, "post_apply")
-	_, err := execute(scriptName)
+	_, err := execute(context.Background(), scriptName, nil, nil)
 	Assert(t, err != nil, "there should be an error")
 }
 
 func TestRunExecuteScript_valid(t *testing.T) {
 	cmds := []string{"echo", "date"}
 	scriptName, _ := createScript(cmds, "post_apply")
-	output, err := execute(scriptName)
+	output, err := execute(context.Background(), scriptName, nil, nil)
 	Assert(t, err == nil, "there should not be an error")
 	Assert(t, output != "", "there should be output")
 }
@@ -36,6 +44,189 @@ func TestRunExecuteScript_valid(t *testing.T) {
 func TestRun_valid(t *testing.T) {
 	cmds := []string{"echo", "date"}
 	version, _ := version.NewVersion("0.8.8")
-	_, err := run.Execute(logger, cmds, "/tmp/atlantis", "staging", version, "post_apply")
+	hook := HookContext{Environment: "staging", ProjectDir: "/tmp/atlantis", TerraformVersion: version}
+	_, err := run.Execute(context.Background(), logger, nil, cmds, hook, "post_apply")
 	Ok(t, err)
 }
+
+func TestRun_Output(t *testing.T) {
+	t.Log("the output of the commands should also be written to the output writer")
+	var out bytes.Buffer
+	cmds := []string{"echo hello", "echo world >&2"}
+	version, _ := version.NewVersion("0.8.8")
+	hook := HookContext{Environment: "staging", ProjectDir: "/tmp/atlantis", TerraformVersion: version}
+	output, err := run.Execute(context.Background(), logger, &out, cmds, hook, "post_apply")
+	Ok(t, err)
+	Equals(t, "hello\nworld\n", output)
+	Equals(t, "hello\nworld\n", out.String())
+}
+
+func TestRun_Env(t *testing.T) {
+	t.Log("the hook context should be set as environment variables for the commands only")
+	v, _ := version.NewVersion("0.10.0")
+	hook := HookContext{
+		Environment:      "staging",
+		ProjectDir:       "/tmp/atlantis/project",
+		TerraformVersion: v,
+		PullNum:          2,
+		HeadCommit:       "sha",
+		BaseBranch:       "master",
+		User:             "lkysow",
+		PlanFile:         "/tmp/atlantis/project/staging.tfplan",
+	}
+	r := &Run{}
+	cmds := []string{`echo "$ENVIRONMENT $WORKSPACE_NAME $ATLANTIS_TERRAFORM_VERSION $WORKSPACE $PROJECT_DIR"`, `echo "$PULL_NUM $HEAD_COMMIT $BASE_BRANCH_NAME $USER_NAME $PLANFILE"`}
+	output, err := r.Execute(context.Background(), logger, nil, cmds, hook, "post_plan")
+	Ok(t, err)
+	Equals(t, "staging staging 0.10.0 /tmp/atlantis/project /tmp/atlantis/project\n2 sha master lkysow /tmp/atlantis/project/staging.tfplan\n", output)
+	for _, name := range []string{"ENVIRONMENT", "WORKSPACE", "PULL_NUM"} {
+		_, set := os.LookupEnv(name)
+		Assert(t, !set, "exp %s not to be set for atlantis", name)
+	}
+}
+
+func TestRun_NoPluginCache(t *testing.T) {
+	t.Log("the commands shouldn't use the plugin cache since they can't lock it")
+	os.Setenv("TF_PLUGIN_CACHE_DIR", "/tmp/plugins") // nolint: errcheck
+	defer os.Unsetenv("TF_PLUGIN_CACHE_DIR")         // nolint: errcheck
+	output, err := (&Run{}).Execute(context.Background(), logger, nil, []string{`echo "cache: $TF_PLUGIN_CACHE_DIR"`}, HookContext{}, "pre_init")
+	Ok(t, err)
+	Equals(t, "cache: \n", output)
+}
+
+func TestRun_EnvReserved(t *testing.T) {
+	t.Log("the variables from WithEnv shouldn't override the ones for the hook or turn the plugin cache back on")
+	ctx := WithEnv(context.Background(), map[string]string{"PLANFILE": "/tmp/other.tfplan", "TF_PLUGIN_CACHE_DIR": "/tmp/plugins", "OTHER": "other"})
+	output, err := (&Run{}).Execute(ctx, logger, nil, []string{`echo "$PLANFILE $TF_PLUGIN_CACHE_DIR $OTHER"`}, HookContext{PlanFile: "/tmp/default.tfplan"}, "pre_plan")
+	Ok(t, err)
+	Equals(t, "/tmp/default.tfplan  other\n", output)
+}
+
+func TestRun_Concurrent(t *testing.T) {
+	t.Log("concurrent runs should each only see their own environment")
+	v, _ := version.NewVersion("0.10.0")
+	cmds := []string{"sleep 0.1", `echo "$ENVIRONMENT $WORKSPACE $PULL_NUM"`}
+	var wg sync.WaitGroup
+	outputs := make([]string, 10)
+	errs := make([]error, 10)
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hook := HookContext{
+				Environment:      fmt.Sprintf("env%d", i),
+				ProjectDir:       fmt.Sprintf("/tmp/atlantis/%d", i),
+				TerraformVersion: v,
+				PullNum:          i,
+			}
+			outputs[i], errs[i] = run.Execute(context.Background(), logger, nil, cmds, hook, "pre_plan")
+		}(i)
+	}
+	wg.Wait()
+	for i := range outputs {
+		Ok(t, errs[i])
+		Equals(t, fmt.Sprintf("env%d /tmp/atlantis/%d %d\n", i, i, i), outputs[i])
+	}
+}
+
+func TestCommand_Canceled(t *testing.T) {
+	t.Log("when the context is canceled the command should be interrupted")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Command(ctx, exec.Command("sleep", "10"))
+	Equals(t, ErrCanceled, err)
+}
+
+func TestCommand_Killed(t *testing.T) {
+	t.Log("when the command ignores the interrupt it should be killed")
+	defer func(d time.Duration) { killGracePeriod = d }(killGracePeriod)
+	killGracePeriod = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.Command("sh", "-c", "trap '' INT; sleep 10")
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	err := Command(ctx, cmd)
+	Equals(t, ErrCanceled, err)
+	Assert(t, time.Since(start) < 5*time.Second, "exp command to be killed")
+}
+
+func TestCommand_NilContext(t *testing.T) {
+	t.Log("a nil context should never cancel the command")
+	Ok(t, Command(nil, exec.Command("true")))
+}
+
+func TestCommand_TimedOut(t *testing.T) {
+	t.Log("when the timeout expires the command should be stopped with a timeout error")
+	ctx, cancel := WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := Command(ctx, exec.Command("sleep", "10"))
+	Equals(t, TimeoutError{Timeout: 10 * time.Millisecond}, err)
+	Equals(t, "timed out after 10ms", err.Error())
+}
+
+func TestCommand_TimeoutCanceled(t *testing.T) {
+	t.Log("when a command with a timeout is canceled it shouldn't be a timeout error")
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, cancelTimeout := WithTimeout(parent, time.Hour)
+	defer cancelTimeout()
+	cancel()
+	err := Command(ctx, exec.Command("sleep", "10"))
+	Equals(t, ErrCanceled, err)
+}
+
+func TestWithTimeout_Zero(t *testing.T) {
+	t.Log("a zero timeout should return the context as is")
+	ctx, cancel := WithTimeout(nil, 0)
+	cancel()
+	Assert(t, ctx == nil, "exp nil context")
+}
+
+func TestCommand_Env(t *testing.T) {
+	t.Log("the environment variables from WithEnv should be set, with later ones overriding earlier ones")
+	ctx := WithEnv(context.Background(), map[string]string{"FIRST": "1", "SECOND": "1"})
+	ctx = WithEnv(ctx, map[string]string{"SECOND": "2"})
+	cmd := exec.Command("sh", "-c", "echo $FIRST $SECOND $PATH")
+	cmd.Env = []string{"SECOND=0", "PATH=/bin"}
+	out, err := runCommand(ctx, cmd)
+	Ok(t, err)
+	Equals(t, "1 2 /bin\n", out)
+	Equals(t, []string{"PATH=/bin", "FIRST=1", "SECOND=2"}, cmd.Env)
+}
+
+func TestCommand_EnvInherited(t *testing.T) {
+	t.Log("if the command's environment isn't set it should still inherit ours")
+	ctx := WithEnv(context.Background(), map[string]string{"FIRST": "1"})
+	out, err := runCommand(ctx, exec.Command("sh", "-c", "echo $FIRST $HOME"))
+	Ok(t, err)
+	Equals(t, "1 "+os.Getenv("HOME")+"\n", out)
+}
+
+func TestEnviron(t *testing.T) {
+	t.Log("the variables should replace ours with the same names instead of being added after them")
+	os.Setenv("ATLANTIS_TEST_VAR", "ours") // nolint: errcheck
+	defer os.Unsetenv("ATLANTIS_TEST_VAR") // nolint: errcheck
+	env := Environ("ATLANTIS_TEST_VAR=theirs")
+	var values []string
+	for _, v := range env {
+		if strings.HasPrefix(v, "ATLANTIS_TEST_VAR=") {
+			values = append(values, v)
+		}
+	}
+	Equals(t, []string{"ATLANTIS_TEST_VAR=theirs"}, values)
+	Assert(t, len(env) == len(os.Environ()), "exp our other variables to be kept")
+}
+
+func TestWithEnv_Empty(t *testing.T) {
+	t.Log("no environment variables should return the context as is")
+	Assert(t, WithEnv(nil, nil) == nil, "exp nil context")
+}
+
+func runCommand(ctx context.Context, cmd *exec.Cmd) (string, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := Command(ctx, cmd)
+	return out.String(), err
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// runningCommands tracks the commands that are running so they can be
+// canceled. Like EnvLock, there's at most one command running for each repo,
+// pull request and environment. Repos are identified by their VCS host as
+// well as their name since repos on different hosts can have the same name.
+// The zero value is ready to use.
+type runningCommands struct {
+	mutex    sync.Mutex
+	commands map[string]*runningCommand
+}
+
+type runningCommand struct {
+	ctx    *CommandContext
+	cancel context.CancelFunc
+}
+
+// start sets up ctx so the command it's for can be canceled. The returned
+// function must be called once the command has finished.
+func (r *runningCommands) start(ctx *CommandContext) func() {
+	cmdCtx, cancel := context.WithCancel(context.Background())
+	ctx.Context = cmdCtx
+	key := r.key(ctx.BaseRepo.Hostname, ctx.BaseRepo.FullName, ctx.Pull.Num, ctx.Command.Environment)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.commands == nil {
+		r.commands = make(map[string]*runningCommand)
+	}
+	r.commands[key] = &runningCommand{ctx: ctx, cancel: cancel}
+	return func() {
+		cancel()
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		delete(r.commands, key)
+	}
+}
+
+// cancel cancels the command running for the repo, pull request and
+// environment and returns its context, or nil if there isn't one.
+func (r *runningCommands) cancel(repoHostname string, repoFullName string, pullNum int, env string) *CommandContext {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	cmd, ok := r.commands[r.key(repoHostname, repoFullName, pullNum, env)]
+	if !ok {
+		return nil
+	}
+	cmd.cancel()
+	return cmd.ctx
+}
+
+func (r *runningCommands) key(repoHostname string, repoFullName string, pullNum int, env string) string {
+	return fmt.Sprintf("%s/%s/%s/%d", repoHostname, repoFullName, env, pullNum)
+}
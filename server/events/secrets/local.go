@@ -0,0 +1,33 @@
+package secrets
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// LocalStore reads secrets from a YAML file that maps the paths of secrets to
+// their keys and values, ex. {secret/data/atlantis: {db_password: hunter2}}.
+// It stands in for Vault when developing or testing. The file is read on
+// every Get so changes are picked up without restarting.
+type LocalStore struct {
+	Path string
+}
+
+// Get returns the value of key in the secret at path.
+func (l *LocalStore) Get(path string, key string) (string, error) {
+	raw, err := ioutil.ReadFile(l.Path)
+	if err != nil {
+		return "", errors.Wrap(err, "reading local secrets file")
+	}
+	var secrets map[string]map[string]interface{}
+	if err := yaml.Unmarshal(raw, &secrets); err != nil {
+		return "", errors.Wrap(err, "parsing local secrets file")
+	}
+	data, ok := secrets[path]
+	if !ok {
+		return "", errors.Errorf("reading secret %q: it isn't in the local secrets file", path)
+	}
+	return value(path, key, data)
+}
@@ -0,0 +1,40 @@
+// Package secrets reads the secrets that are set as environment variables
+// for projects' commands.
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Store is where secrets are read from.
+type Store interface {
+	// Get returns the value of key in the secret at path.
+	Get(path string, key string) (string, error)
+}
+
+// ParseRef returns the path and key of a reference to a secret, which is
+// {path}#{key}, ex. secret/data/atlantis#db_password.
+func ParseRef(ref string) (string, string, error) {
+	i := strings.LastIndex(ref, "#")
+	if i <= 0 || i == len(ref)-1 {
+		return "", "", fmt.Errorf("secret %q must be {path}#{key}", ref)
+	}
+	return strings.Trim(ref[:i], "/"), ref[i+1:], nil
+}
+
+// value returns v, a value from a secret, as a string.
+func value(path string, key string, data map[string]interface{}) (string, error) {
+	v, ok := data[key]
+	if !ok || v == nil {
+		return "", fmt.Errorf("secret %q has no key %q", path, key)
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+		return "", fmt.Errorf("key %q of secret %q isn't a string", key, path)
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
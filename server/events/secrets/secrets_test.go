@@ -0,0 +1,92 @@
+package secrets_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events/secrets"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestParseRef(t *testing.T) {
+	t.Log("references should be split into the path and key")
+	path, key, err := secrets.ParseRef("/secret/data/atlantis#db_password")
+	Ok(t, err)
+	Equals(t, "secret/data/atlantis", path)
+	Equals(t, "db_password", key)
+
+	for _, ref := range []string{"secret/data/atlantis", "#key", "secret/data/atlantis#"} {
+		_, _, err := secrets.ParseRef(ref)
+		Assert(t, err != nil, "exp an error for %q", ref)
+	}
+}
+
+func TestVault(t *testing.T) {
+	t.Log("secrets should be read from both versions of the key/value engine with the token")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/atlantis":
+			fmt.Fprint(w, `{"data": {"db_password": "v1-password", "port": 5432}}`) // nolint: errcheck
+		case "/v1/secret/data/atlantis":
+			fmt.Fprint(w, `{"data": {"data": {"db_password": "v2-password"}, "metadata": {"version": 1}}}`) // nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	vault := &secrets.Vault{Address: server.URL, Token: "token"}
+
+	cases := []struct {
+		path   string
+		key    string
+		exp    string
+		expErr string
+	}{
+		{"kv/atlantis", "db_password", "v1-password", ""},
+		{"kv/atlantis", "port", "5432", ""},
+		{"secret/data/atlantis", "db_password", "v2-password", ""},
+		{"secret/data/atlantis", "missing", "", `secret "secret/data/atlantis" has no key "missing"`},
+		{"secret/data/missing", "db_password", "", `reading secret "secret/data/missing": unexpected status 404 Not Found`},
+	}
+	for _, c := range cases {
+		v, err := vault.Get(c.path, c.key)
+		if c.expErr != "" {
+			Assert(t, err != nil, "exp an error for %s#%s", c.path, c.key)
+			Equals(t, c.expErr, err.Error())
+			continue
+		}
+		Ok(t, err)
+		Equals(t, c.exp, v)
+	}
+
+	vault.Token = "wrong"
+	_, err := vault.Get("kv/atlantis", "db_password")
+	Assert(t, err != nil, "exp an error for the wrong token")
+}
+
+func TestLocalStore(t *testing.T) {
+	t.Log("secrets should be read from the local file")
+	f, err := ioutil.TempFile("", "")
+	Ok(t, err)
+	defer os.Remove(f.Name()) // nolint: errcheck
+	_, err = f.WriteString("secret/data/atlantis:\n  db_password: hunter2\n  nested: {a: b}\n")
+	Ok(t, err)
+	Ok(t, f.Close())
+	store := &secrets.LocalStore{Path: f.Name()}
+
+	v, err := store.Get("secret/data/atlantis", "db_password")
+	Ok(t, err)
+	Equals(t, "hunter2", v)
+	_, err = store.Get("secret/data/atlantis", "nested")
+	Equals(t, `key "nested" of secret "secret/data/atlantis" isn't a string`, err.Error())
+	_, err = store.Get("secret/data/missing", "db_password")
+	Equals(t, `reading secret "secret/data/missing": it isn't in the local secrets file`, err.Error())
+}
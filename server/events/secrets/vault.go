@@ -0,0 +1,66 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultVaultTimeout is how long a request to Vault can take if
+// Vault.HTTPClient isn't set.
+const defaultVaultTimeout = 30 * time.Second
+
+// Vault reads secrets from the HTTP API of Vault or anything compatible with
+// it. Both versions of the key/value secrets engine are supported. For
+// version 2, the path includes data, ex. secret/data/atlantis.
+type Vault struct {
+	// Address is the URL of the server, ex. https://vault.example.com:8200.
+	Address string
+	// Token authenticates with the server.
+	Token string
+	// HTTPClient is used to make requests. If nil, a client that times out
+	// after defaultVaultTimeout is used.
+	HTTPClient *http.Client
+}
+
+// Get returns the value of key in the secret at path.
+func (v *Vault) Get(path string, key string) (string, error) {
+	url := fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(v.Address, "/"), strings.Trim(path, "/"))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading secret %q", path)
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	client := v.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultVaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading secret %q", path)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("reading secret %q: unexpected status %s", path, resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", errors.Wrapf(err, "parsing secret %q", path)
+	}
+	data := secret.Data
+	// Version 2 of the key/value engine nests the secret in data along with
+	// its metadata.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	return value(path, key, data)
+}
@@ -0,0 +1,282 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hootsuite/atlantis/server/logging"
+	"github.com/pkg/errors"
+)
+
+// defaultDownloadTimeout is how long a request to the releases can take,
+// including downloading the body, if Downloader.HTTPClient isn't set.
+const defaultDownloadTimeout = 10 * time.Minute
+
+// indexCacheTTL is how long the list of releases is cached for. New releases
+// are rare so there's no need to get it for every command.
+const indexCacheTTL = 10 * time.Minute
+
+// Downloader downloads terraform releases from releases.hashicorp.com or a
+// mirror of it and caches them in BinDir.
+type Downloader struct {
+	// URL is the base URL of the releases. It must have the same layout as
+	// releases.hashicorp.com, ex. URL/terraform/0.11.0/terraform_0.11.0_SHA256SUMS.
+	URL string
+	// BinDir is the directory the binaries are cached in. Each is saved as
+	// terraformVERSION, ex. terraform0.11.0.
+	BinDir string
+	// HTTPClient is used to download the releases. If nil, a client that
+	// times out after defaultDownloadTimeout is used.
+	HTTPClient *http.Client
+
+	mutex     sync.Mutex
+	downloads map[string]*download
+	// releases and releasesFetched cache the versions in the release index.
+	releases        []*version.Version
+	releasesFetched time.Time
+}
+
+// download is a download that's in progress. done is closed once it's
+// finished and err is set.
+type download struct {
+	done chan struct{}
+	err  error
+}
+
+// Path returns the path to the terraform binary for v, downloading it first
+// if it isn't cached. Concurrent calls for the same version share a single
+// download. If ctx is canceled, Path returns without waiting for the download
+// but the download carries on so it's cached for the next call. A nil ctx is
+// never canceled.
+func (d *Downloader) Path(ctx context.Context, log *logging.SimpleLogger, v *version.Version) (string, error) {
+	binPath := filepath.Join(d.BinDir, "terraform"+v.String())
+	d.mutex.Lock()
+	dl, ok := d.downloads[v.String()]
+	if !ok {
+		// Downloads are renamed into place before they're removed from
+		// d.downloads so if the binary doesn't exist here, there's no
+		// download.
+		if _, err := os.Stat(binPath); err == nil {
+			d.mutex.Unlock()
+			return binPath, nil
+		}
+		dl = &download{done: make(chan struct{})}
+		if d.downloads == nil {
+			d.downloads = make(map[string]*download)
+		}
+		d.downloads[v.String()] = dl
+		go d.run(log, dl, v, binPath)
+	}
+	d.mutex.Unlock()
+
+	var canceled <-chan struct{}
+	if ctx != nil {
+		canceled = ctx.Done()
+	}
+	select {
+	case <-dl.done:
+		return binPath, dl.err
+	case <-canceled:
+		return "", errors.Wrapf(ctx.Err(), "waiting for terraform %s to download", v)
+	}
+}
+
+// run downloads v to binPath for dl and closes dl.done once it's finished.
+func (d *Downloader) run(log *logging.SimpleLogger, dl *download, v *version.Version, binPath string) {
+	log.Info("downloading terraform %s from %s", v, d.URL)
+	dl.err = d.download(v, binPath)
+	if dl.err == nil {
+		log.Info("downloaded terraform %s to %s", v, binPath)
+	}
+
+	d.mutex.Lock()
+	delete(d.downloads, v.String())
+	d.mutex.Unlock()
+	close(dl.done)
+}
+
+// LatestVersion returns the newest release that matches constraints.
+// Pre-releases are skipped.
+func (d *Downloader) LatestVersion(constraints version.Constraints) (*version.Version, error) {
+	releases, err := d.releaseVersions()
+	if err != nil {
+		return nil, err
+	}
+	var versions []*version.Version
+	for _, v := range releases {
+		if constraints.Check(v) {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no terraform release matches %q", constraints)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].GreaterThan(versions[j]) })
+	return versions[0], nil
+}
+
+// releaseVersions returns the versions in the release index, skipping
+// pre-releases. The index is cached for indexCacheTTL.
+func (d *Downloader) releaseVersions() ([]*version.Version, error) {
+	d.mutex.Lock()
+	if d.releases != nil && time.Since(d.releasesFetched) < indexCacheTTL {
+		releases := d.releases
+		d.mutex.Unlock()
+		return releases, nil
+	}
+	d.mutex.Unlock()
+
+	resp, err := d.get(fmt.Sprintf("%s/terraform/index.json", d.URL))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	var index struct {
+		Versions map[string]interface{} `json:"versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return nil, errors.Wrap(err, "parsing terraform release index")
+	}
+	releases := []*version.Version{}
+	for s := range index.Versions {
+		v, err := version.NewVersion(s)
+		if err != nil || v.Prerelease() != "" {
+			continue
+		}
+		releases = append(releases, v)
+	}
+
+	d.mutex.Lock()
+	d.releases = releases
+	d.releasesFetched = time.Now()
+	d.mutex.Unlock()
+	return releases, nil
+}
+
+// download downloads the release for v, verifies its checksum and extracts
+// the binary to binPath.
+func (d *Downloader) download(v *version.Version, binPath string) error {
+	releaseURL := fmt.Sprintf("%s/terraform/%s", d.URL, v)
+	zipName := fmt.Sprintf("terraform_%s_%s_%s.zip", v, runtime.GOOS, runtime.GOARCH)
+	expSum, err := d.checksum(fmt.Sprintf("%s/terraform_%s_SHA256SUMS", releaseURL, v), zipName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(d.BinDir, 0755); err != nil {
+		return errors.Wrapf(err, "creating %s", d.BinDir)
+	}
+	zipFile, err := ioutil.TempFile(d.BinDir, zipName)
+	if err != nil {
+		return errors.Wrap(err, "creating temporary file")
+	}
+	defer os.Remove(zipFile.Name()) // nolint: errcheck
+	defer zipFile.Close()           // nolint: errcheck
+
+	resp, err := d.get(fmt.Sprintf("%s/%s", releaseURL, zipName))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(zipFile, hash), resp.Body); err != nil {
+		return errors.Wrapf(err, "downloading %s", zipName)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != expSum {
+		return fmt.Errorf("checksum of %s was %s, expected %s", zipName, sum, expSum)
+	}
+	return d.extract(zipFile.Name(), binPath)
+}
+
+// checksum returns the SHA256 checksum of the file name in the SHA256SUMS file
+// at sumsURL.
+func (d *Downloader) checksum(sumsURL string, name string) (string, error) {
+	resp, err := d.get(sumsURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	// Each line is "<checksum>  <file name>".
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", errors.Wrapf(err, "reading %s", sumsURL)
+	}
+	return "", fmt.Errorf("no checksum for %s in %s", name, sumsURL)
+}
+
+// extract extracts the terraform binary in the zip file at zipPath to
+// binPath. It's written to a temporary file first so binPath is never a
+// partial binary.
+func (d *Downloader) extract(zipPath string, binPath string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return errors.Wrapf(err, "opening %s", zipPath)
+	}
+	defer r.Close() // nolint: errcheck
+	for _, f := range r.File {
+		if f.Name != "terraform" {
+			continue
+		}
+		src, err := f.Open()
+		if err != nil {
+			return errors.Wrapf(err, "extracting %s", f.Name)
+		}
+		defer src.Close() // nolint: errcheck
+		// The dot keeps it from looking like a cached binary.
+		dst, err := ioutil.TempFile(d.BinDir, "."+filepath.Base(binPath))
+		if err != nil {
+			return errors.Wrap(err, "creating temporary file")
+		}
+		defer os.Remove(dst.Name()) // nolint: errcheck
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close() // nolint: errcheck
+			return errors.Wrapf(err, "extracting %s", f.Name)
+		}
+		if err := dst.Close(); err != nil {
+			return errors.Wrapf(err, "writing %s", dst.Name())
+		}
+		if err := os.Chmod(dst.Name(), 0755); err != nil {
+			return errors.Wrapf(err, "making %s executable", dst.Name())
+		}
+		return errors.Wrapf(os.Rename(dst.Name(), binPath), "moving binary to %s", binPath)
+	}
+	return fmt.Errorf("no terraform binary in %s", filepath.Base(zipPath))
+}
+
+func (d *Downloader) get(url string) (*http.Response, error) {
+	client := d.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultDownloadTimeout}
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting %s", url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close() // nolint: errcheck
+		return nil, fmt.Errorf("getting %s: unexpected status %s", url, resp.Status)
+	}
+	return resp, nil
+}
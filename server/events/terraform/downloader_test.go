@@ -0,0 +1,222 @@
+package terraform_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hootsuite/atlantis/server/events/terraform"
+	"github.com/hootsuite/atlantis/server/logging"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+var binary = []byte("#!/bin/sh\necho terraform\n")
+
+func TestPath_Downloads(t *testing.T) {
+	t.Log("the binary should be downloaded, verified and cached")
+	mirror, downloads := newMirror(t, binary, "")
+	defer mirror.Close()
+	d, cleanup := newDownloader(t, mirror.URL)
+	defer cleanup()
+
+	v, _ := version.NewVersion("0.11.0")
+	path, err := d.Path(context.Background(), logging.NewNoopLogger(), v)
+	Ok(t, err)
+	Equals(t, filepath.Join(d.BinDir, "terraform0.11.0"), path)
+	contents, err := ioutil.ReadFile(path)
+	Ok(t, err)
+	Equals(t, binary, contents)
+	info, err := os.Stat(path)
+	Ok(t, err)
+	Assert(t, info.Mode()&0100 != 0, "exp binary to be executable")
+
+	_, err = d.Path(context.Background(), logging.NewNoopLogger(), v)
+	Ok(t, err)
+	Equals(t, 1, downloads())
+}
+
+func TestPath_Concurrent(t *testing.T) {
+	t.Log("concurrent calls for the same version should share a download")
+	mirror, downloads := newMirror(t, binary, "")
+	defer mirror.Close()
+	d, cleanup := newDownloader(t, mirror.URL)
+	defer cleanup()
+
+	v, _ := version.NewVersion("0.11.0")
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := d.Path(context.Background(), logging.NewNoopLogger(), v)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		Ok(t, err)
+	}
+	Equals(t, 1, downloads())
+}
+
+func TestPath_BadChecksum(t *testing.T) {
+	t.Log("if the checksum doesn't match, the binary shouldn't be saved")
+	mirror, _ := newMirror(t, binary, "0000")
+	defer mirror.Close()
+	d, cleanup := newDownloader(t, mirror.URL)
+	defer cleanup()
+
+	v, _ := version.NewVersion("0.11.0")
+	_, err := d.Path(context.Background(), logging.NewNoopLogger(), v)
+	Assert(t, err != nil, "exp an error")
+	Assert(t, strings.HasSuffix(err.Error(), "expected 0000"), "exp checksum error, got %q", err.Error())
+	files, _ := ioutil.ReadDir(d.BinDir)
+	Equals(t, 0, len(files))
+}
+
+func TestPath_NotFound(t *testing.T) {
+	t.Log("if the version doesn't exist we should get an error")
+	mirror, _ := newMirror(t, binary, "")
+	defer mirror.Close()
+	d, cleanup := newDownloader(t, mirror.URL)
+	defer cleanup()
+
+	v, _ := version.NewVersion("0.1.0")
+	_, err := d.Path(context.Background(), logging.NewNoopLogger(), v)
+	Assert(t, err != nil, "exp an error")
+}
+
+func TestPath_Canceled(t *testing.T) {
+	t.Log("a canceled caller should stop waiting but the download should finish for the next caller")
+	mirror, downloads := newMirror(t, binary, "")
+	defer mirror.Close()
+	d, cleanup := newDownloader(t, mirror.URL)
+	defer cleanup()
+
+	v, _ := version.NewVersion("0.11.0")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := d.Path(ctx, logging.NewNoopLogger(), v)
+	Assert(t, err != nil, "exp an error")
+	Assert(t, strings.Contains(err.Error(), "context canceled"), "exp cancellation error, got %q", err.Error())
+
+	path, err := d.Path(context.Background(), logging.NewNoopLogger(), v)
+	Ok(t, err)
+	contents, err := ioutil.ReadFile(path)
+	Ok(t, err)
+	Equals(t, binary, contents)
+	Equals(t, 1, downloads())
+}
+
+func TestLatestVersion(t *testing.T) {
+	t.Log("the newest release that matches should be returned, skipping pre-releases")
+	mirror, _ := newMirror(t, binary, "")
+	defer mirror.Close()
+	d, cleanup := newDownloader(t, mirror.URL)
+	defer cleanup()
+
+	cases := map[string]string{
+		"~> 0.11.0":   "0.11.7",
+		"~> 0.10":     "0.11.7",
+		"< 0.11":      "0.10.8",
+		"= 0.11.0":    "0.11.0",
+		">= 0.12.0":   "",
+		"0.10.8, < 1": "0.10.8",
+	}
+	for constraint, exp := range cases {
+		c, err := version.NewConstraint(constraint)
+		Ok(t, err)
+		v, err := d.LatestVersion(c)
+		if exp == "" {
+			Assert(t, err != nil, "exp an error for %q", constraint)
+			continue
+		}
+		Ok(t, err)
+		Equals(t, exp, v.String())
+	}
+}
+
+func TestLatestVersion_CachesIndex(t *testing.T) {
+	t.Log("the release index should only be downloaded once")
+	mirror, _ := newMirror(t, binary, "")
+	defer mirror.Close()
+	d, cleanup := newDownloader(t, mirror.URL)
+	defer cleanup()
+
+	c, err := version.NewConstraint("~> 0.11.0")
+	Ok(t, err)
+	_, err = d.LatestVersion(c)
+	Ok(t, err)
+	mirror.Close()
+	v, err := d.LatestVersion(c)
+	Ok(t, err)
+	Equals(t, "0.11.7", v.String())
+}
+
+// newMirror returns a server with the layout of releases.hashicorp.com that
+// serves bin as the terraform binary for 0.11.0. If checksum isn't empty,
+// it's served as the zip's checksum instead of the real one. The returned
+// function returns the number of times the zip was downloaded.
+func newMirror(t *testing.T, bin []byte, checksum string) (*httptest.Server, func() int) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("terraform")
+	Ok(t, err)
+	_, err = f.Write(bin)
+	Ok(t, err)
+	Ok(t, w.Close())
+	zipBytes := buf.Bytes()
+	if checksum == "" {
+		sum := sha256.Sum256(zipBytes)
+		checksum = hex.EncodeToString(sum[:])
+	}
+	zipName := fmt.Sprintf("terraform_0.11.0_%s_%s.zip", runtime.GOOS, runtime.GOARCH)
+
+	var mutex sync.Mutex
+	downloads := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/terraform/index.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "terraform", "versions": {"0.10.8": {}, "0.11.0": {}, "0.11.7": {}, "0.12.0-beta1": {}}}`) // nolint: errcheck
+	})
+	mux.HandleFunc("/terraform/0.11.0/terraform_0.11.0_SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  terraform_0.11.0_other_arch.zip\n%s  %s\n", checksum, checksum, zipName) // nolint: errcheck
+	})
+	mux.HandleFunc("/terraform/0.11.0/"+zipName, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		downloads++
+		mutex.Unlock()
+		// Give concurrent callers a chance to start while this is in progress.
+		time.Sleep(50 * time.Millisecond)
+		w.Write(zipBytes) // nolint: errcheck
+	})
+	return httptest.NewServer(mux), func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return downloads
+	}
+}
+
+func newDownloader(t *testing.T, url string) (*terraform.Downloader, func()) {
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	return &terraform.Downloader{
+		URL:    url,
+		BinDir: filepath.Join(dir, "bin"),
+	}, func() { os.RemoveAll(dir) } // nolint: errcheck
+}
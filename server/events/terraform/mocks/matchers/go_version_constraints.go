@@ -7,14 +7,14 @@ import (
 	"github.com/petergtz/pegomock"
 )
 
-func AnyPtrToGoVersionVersion() *go_version.Version {
-	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(*go_version.Version))(nil)).Elem()))
-	var nullValue *go_version.Version
+func AnyGoVersionConstraints() go_version.Constraints {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(go_version.Constraints))(nil)).Elem()))
+	var nullValue go_version.Constraints
 	return nullValue
 }
 
-func EqPtrToGoVersionVersion(value *go_version.Version) *go_version.Version {
+func EqGoVersionConstraints(value go_version.Constraints) go_version.Constraints {
 	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
-	var nullValue *go_version.Version
+	var nullValue go_version.Constraints
 	return nullValue
 }
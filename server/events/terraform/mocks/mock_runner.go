@@ -4,6 +4,8 @@
 package mocks
 
 import (
+	context "context"
+	io "io"
 	"reflect"
 
 	go_version "github.com/hashicorp/go-version"
@@ -31,8 +33,24 @@ func (mock *MockRunner) Version() *go_version.Version {
 	return ret0
 }
 
-func (mock *MockRunner) RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, v *go_version.Version, env string) (string, error) {
-	params := []pegomock.Param{log, path, args, v, env}
+func (mock *MockRunner) ResolveVersion(log *logging.SimpleLogger, constraints go_version.Constraints) (*go_version.Version, error) {
+	params := []pegomock.Param{log, constraints}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ResolveVersion", params, []reflect.Type{reflect.TypeOf((**go_version.Version)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *go_version.Version
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*go_version.Version)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockRunner) RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, args []string, v *go_version.Version, env string) (string, error) {
+	params := []pegomock.Param{ctx, log, output, path, args, v, env}
 	result := pegomock.GetGenericMockFrom(mock).Invoke("RunCommandWithVersion", params, []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
 	var ret0 string
 	var ret1 error
@@ -47,8 +65,8 @@ func (mock *MockRunner) RunCommandWithVersion(log *logging.SimpleLogger, path st
 	return ret0, ret1
 }
 
-func (mock *MockRunner) RunInitAndEnv(log *logging.SimpleLogger, path string, env string, extraInitArgs []string, version *go_version.Version) ([]string, error) {
-	params := []pegomock.Param{log, path, env, extraInitArgs, version}
+func (mock *MockRunner) RunInitAndEnv(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, env string, extraInitArgs []string, version *go_version.Version) ([]string, error) {
+	params := []pegomock.Param{ctx, log, output, path, env, extraInitArgs, version}
 	result := pegomock.GetGenericMockFrom(mock).Invoke("RunInitAndEnv", params, []reflect.Type{reflect.TypeOf((*[]string)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
 	var ret0 []string
 	var ret1 error
@@ -98,8 +116,39 @@ func (c *Runner_Version_OngoingVerification) GetCapturedArguments() {
 func (c *Runner_Version_OngoingVerification) GetAllCapturedArguments() {
 }
 
-func (verifier *VerifierRunner) RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, v *go_version.Version, env string) *Runner_RunCommandWithVersion_OngoingVerification {
-	params := []pegomock.Param{log, path, args, v, env}
+func (verifier *VerifierRunner) ResolveVersion(log *logging.SimpleLogger, constraints go_version.Constraints) *Runner_ResolveVersion_OngoingVerification {
+	params := []pegomock.Param{log, constraints}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ResolveVersion", params)
+	return &Runner_ResolveVersion_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type Runner_ResolveVersion_OngoingVerification struct {
+	mock              *MockRunner
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *Runner_ResolveVersion_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, go_version.Constraints) {
+	log, constraints := c.GetAllCapturedArguments()
+	return log[len(log)-1], constraints[len(constraints)-1]
+}
+
+func (c *Runner_ResolveVersion_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 []go_version.Constraints) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]*logging.SimpleLogger, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(*logging.SimpleLogger)
+		}
+		_param1 = make([]go_version.Constraints, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(go_version.Constraints)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierRunner) RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, args []string, v *go_version.Version, env string) *Runner_RunCommandWithVersion_OngoingVerification {
+	params := []pegomock.Param{ctx, log, output, path, args, v, env}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RunCommandWithVersion", params)
 	return &Runner_RunCommandWithVersion_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -109,40 +158,48 @@ type Runner_RunCommandWithVersion_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *Runner_RunCommandWithVersion_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, string, []string, *go_version.Version, string) {
-	log, path, args, v, env := c.GetAllCapturedArguments()
-	return log[len(log)-1], path[len(path)-1], args[len(args)-1], v[len(v)-1], env[len(env)-1]
+func (c *Runner_RunCommandWithVersion_OngoingVerification) GetCapturedArguments() (context.Context, *logging.SimpleLogger, io.Writer, string, []string, *go_version.Version, string) {
+	ctx, log, output, path, args, v, env := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1], log[len(log)-1], output[len(output)-1], path[len(path)-1], args[len(args)-1], v[len(v)-1], env[len(env)-1]
 }
 
-func (c *Runner_RunCommandWithVersion_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 []string, _param2 [][]string, _param3 []*go_version.Version, _param4 []string) {
+func (c *Runner_RunCommandWithVersion_OngoingVerification) GetAllCapturedArguments() (_param0 []context.Context, _param1 []*logging.SimpleLogger, _param2 []io.Writer, _param3 []string, _param4 [][]string, _param5 []*go_version.Version, _param6 []string) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
-		_param0 = make([]*logging.SimpleLogger, len(params[0]))
+		_param0 = make([]context.Context, len(params[0]))
 		for u, param := range params[0] {
-			_param0[u] = param.(*logging.SimpleLogger)
+			_param0[u] = param.(context.Context)
 		}
-		_param1 = make([]string, len(params[1]))
+		_param1 = make([]*logging.SimpleLogger, len(params[1]))
 		for u, param := range params[1] {
-			_param1[u] = param.(string)
+			_param1[u] = param.(*logging.SimpleLogger)
 		}
-		_param2 = make([][]string, len(params[2]))
+		_param2 = make([]io.Writer, len(params[2]))
 		for u, param := range params[2] {
-			_param2[u] = param.([]string)
+			_param2[u] = param.(io.Writer)
 		}
-		_param3 = make([]*go_version.Version, len(params[3]))
+		_param3 = make([]string, len(params[3]))
 		for u, param := range params[3] {
-			_param3[u] = param.(*go_version.Version)
+			_param3[u] = param.(string)
 		}
-		_param4 = make([]string, len(params[4]))
+		_param4 = make([][]string, len(params[4]))
 		for u, param := range params[4] {
-			_param4[u] = param.(string)
+			_param4[u] = param.([]string)
+		}
+		_param5 = make([]*go_version.Version, len(params[5]))
+		for u, param := range params[5] {
+			_param5[u] = param.(*go_version.Version)
+		}
+		_param6 = make([]string, len(params[6]))
+		for u, param := range params[6] {
+			_param6[u] = param.(string)
 		}
 	}
 	return
 }
 
-func (verifier *VerifierRunner) RunInitAndEnv(log *logging.SimpleLogger, path string, env string, extraInitArgs []string, version *go_version.Version) *Runner_RunInitAndEnv_OngoingVerification {
-	params := []pegomock.Param{log, path, env, extraInitArgs, version}
+func (verifier *VerifierRunner) RunInitAndEnv(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, env string, extraInitArgs []string, version *go_version.Version) *Runner_RunInitAndEnv_OngoingVerification {
+	params := []pegomock.Param{ctx, log, output, path, env, extraInitArgs, version}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RunInitAndEnv", params)
 	return &Runner_RunInitAndEnv_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -152,33 +209,41 @@ type Runner_RunInitAndEnv_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *Runner_RunInitAndEnv_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, string, string, []string, *go_version.Version) {
-	log, path, env, extraInitArgs, version := c.GetAllCapturedArguments()
-	return log[len(log)-1], path[len(path)-1], env[len(env)-1], extraInitArgs[len(extraInitArgs)-1], version[len(version)-1]
+func (c *Runner_RunInitAndEnv_OngoingVerification) GetCapturedArguments() (context.Context, *logging.SimpleLogger, io.Writer, string, string, []string, *go_version.Version) {
+	ctx, log, output, path, env, extraInitArgs, version := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1], log[len(log)-1], output[len(output)-1], path[len(path)-1], env[len(env)-1], extraInitArgs[len(extraInitArgs)-1], version[len(version)-1]
 }
 
-func (c *Runner_RunInitAndEnv_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 []string, _param2 []string, _param3 [][]string, _param4 []*go_version.Version) {
+func (c *Runner_RunInitAndEnv_OngoingVerification) GetAllCapturedArguments() (_param0 []context.Context, _param1 []*logging.SimpleLogger, _param2 []io.Writer, _param3 []string, _param4 []string, _param5 [][]string, _param6 []*go_version.Version) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
-		_param0 = make([]*logging.SimpleLogger, len(params[0]))
+		_param0 = make([]context.Context, len(params[0]))
 		for u, param := range params[0] {
-			_param0[u] = param.(*logging.SimpleLogger)
+			_param0[u] = param.(context.Context)
 		}
-		_param1 = make([]string, len(params[1]))
+		_param1 = make([]*logging.SimpleLogger, len(params[1]))
 		for u, param := range params[1] {
-			_param1[u] = param.(string)
+			_param1[u] = param.(*logging.SimpleLogger)
 		}
-		_param2 = make([]string, len(params[2]))
+		_param2 = make([]io.Writer, len(params[2]))
 		for u, param := range params[2] {
-			_param2[u] = param.(string)
+			_param2[u] = param.(io.Writer)
 		}
-		_param3 = make([][]string, len(params[3]))
+		_param3 = make([]string, len(params[3]))
 		for u, param := range params[3] {
-			_param3[u] = param.([]string)
+			_param3[u] = param.(string)
 		}
-		_param4 = make([]*go_version.Version, len(params[4]))
+		_param4 = make([]string, len(params[4]))
 		for u, param := range params[4] {
-			_param4[u] = param.(*go_version.Version)
+			_param4[u] = param.(string)
+		}
+		_param5 = make([][]string, len(params[5]))
+		for u, param := range params[5] {
+			_param5[u] = param.([]string)
+		}
+		_param6 = make([]*go_version.Version, len(params[6]))
+		for u, param := range params[6] {
+			_param6[u] = param.(*go_version.Version)
 		}
 	}
 	return
@@ -0,0 +1,180 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hootsuite/atlantis/server/events/run"
+	"github.com/pkg/errors"
+)
+
+// PluginCacheDirEnv is the environment variable terraform >= 0.10 reads the
+// plugin cache directory from.
+const PluginCacheDirEnv = "TF_PLUGIN_CACHE_DIR"
+
+// pluginPrefix is the start of the file name of every provider plugin, ex.
+// terraform-provider-aws_v1.6.0_x4.
+const pluginPrefix = "terraform-provider-"
+
+// lockPollInterval is how often Lock tries again while the cache is locked.
+const lockPollInterval = 100 * time.Millisecond
+
+// PluginCache is a directory that terraform caches provider plugins in so
+// each one is downloaded once instead of by every init. It's shared by all
+// the projects and environments we run terraform for.
+type PluginCache struct {
+	Dir string
+}
+
+// Plugin is a provider plugin in the cache.
+type Plugin struct {
+	Path string
+	// Name is the name of the provider, ex. aws.
+	Name string
+	// Version is nil if it couldn't be parsed from the file name.
+	Version *version.Version
+	Size    int64
+}
+
+// PluginCacheDir returns the plugin cache directory for the data directory
+// dataDir. It's $TF_PLUGIN_CACHE_DIR if that's set so we use the same cache
+// as terraform would, otherwise dataDir/plugin-cache.
+func PluginCacheDir(dataDir string) string {
+	if dir := os.Getenv(PluginCacheDirEnv); dir != "" {
+		return dir
+	}
+	return filepath.Join(dataDir, "plugin-cache")
+}
+
+// Env returns the environment variable that tells terraform to use the cache.
+func (p *PluginCache) Env() string {
+	return fmt.Sprintf("%s=%s", PluginCacheDirEnv, p.Dir)
+}
+
+// Lock locks the cache so it can be written to, waiting until it's unlocked
+// if it's locked already. Terraform doesn't lock the cache itself so two
+// inits could otherwise write the same plugin at once. It's a file lock so
+// it also keeps out other processes like `atlantis plugin-cache gc`. If ctx
+// is done before the cache is unlocked, Lock stops waiting and returns
+// run.StoppedErr(ctx). A nil ctx is never canceled. The returned function
+// unlocks it.
+func (p *PluginCache) Lock(ctx context.Context) (func(), error) {
+	var canceled <-chan struct{}
+	if ctx != nil {
+		canceled = ctx.Done()
+	}
+	if err := os.MkdirAll(p.Dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "creating %s", p.Dir)
+	}
+	f, err := os.OpenFile(filepath.Join(p.Dir, ".lock"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening plugin cache lock file")
+	}
+	// flock can't be interrupted so we poll instead of blocking.
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if err != syscall.EWOULDBLOCK {
+			f.Close() // nolint: errcheck
+			return nil, errors.Wrap(err, "locking plugin cache")
+		}
+		select {
+		case <-canceled:
+			f.Close() // nolint: errcheck
+			return nil, run.StoppedErr(ctx)
+		case <-time.After(lockPollInterval):
+		}
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN) // nolint: errcheck
+		f.Close()                                   // nolint: errcheck
+	}, nil
+}
+
+// Plugins returns the plugins in the cache, sorted by path.
+func (p *PluginCache) Plugins() ([]Plugin, error) {
+	var plugins []Plugin
+	err := filepath.Walk(p.Dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == p.Dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasPrefix(info.Name(), pluginPrefix) {
+			return nil
+		}
+		name, v := parsePluginName(info.Name())
+		plugins = append(plugins, Plugin{Path: path, Name: name, Version: v, Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", p.Dir)
+	}
+	return plugins, nil
+}
+
+// GC removes every version of each provider from the cache except the newest
+// and returns the plugins it removed. Older versions are downloaded again the
+// next time a project needs them. Plugins whose version can't be parsed are
+// left alone. It locks the cache so it waits for running inits, but
+// terraform links the plugins a project uses into its .terraform directory
+// so a plan or apply that's already past its init can still fail if one of
+// them is removed.
+func (p *PluginCache) GC() ([]Plugin, error) {
+	unlock, err := p.Lock(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	plugins, err := p.Plugins()
+	if err != nil {
+		return nil, err
+	}
+	// Plugins for each OS and architecture are in their own directory.
+	byProvider := make(map[string][]Plugin)
+	for _, plugin := range plugins {
+		if plugin.Version == nil {
+			continue
+		}
+		key := filepath.Join(filepath.Dir(plugin.Path), plugin.Name)
+		byProvider[key] = append(byProvider[key], plugin)
+	}
+	var removed []Plugin
+	for _, versions := range byProvider {
+		sort.Slice(versions, func(i, j int) bool { return versions[i].Version.GreaterThan(versions[j].Version) })
+		for _, plugin := range versions[1:] {
+			if err := os.Remove(plugin.Path); err != nil {
+				return removed, errors.Wrapf(err, "removing %s", plugin.Path)
+			}
+			removed = append(removed, plugin)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Path < removed[j].Path })
+	return removed, nil
+}
+
+// parsePluginName returns the provider name and version from the file name of
+// a plugin, ex. aws and 1.6.0 from terraform-provider-aws_v1.6.0_x4.
+func parsePluginName(fileName string) (string, *version.Version) {
+	parts := strings.SplitN(strings.TrimPrefix(fileName, pluginPrefix), "_v", 2)
+	if len(parts) != 2 {
+		return parts[0], nil
+	}
+	// Drop the plugin protocol version, ex. _x4.
+	v, err := version.NewVersion(strings.SplitN(parts[1], "_", 2)[0])
+	if err != nil {
+		return parts[0], nil
+	}
+	return parts[0], v
+}
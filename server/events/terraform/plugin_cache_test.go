@@ -0,0 +1,120 @@
+package terraform_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hootsuite/atlantis/server/events/run"
+	"github.com/hootsuite/atlantis/server/events/terraform"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestPlugins(t *testing.T) {
+	t.Log("the plugins in the cache should be listed with their names, versions and sizes")
+	cache, cleanup := newPluginCache(t, "linux_amd64/terraform-provider-aws_v1.6.0_x4", "linux_amd64/terraform-provider-null_v1.0.0_x4", "linux_amd64/other")
+	defer cleanup()
+
+	plugins, err := cache.Plugins()
+	Ok(t, err)
+	Equals(t, 2, len(plugins))
+	Equals(t, filepath.Join(cache.Dir, "linux_amd64/terraform-provider-aws_v1.6.0_x4"), plugins[0].Path)
+	Equals(t, "aws", plugins[0].Name)
+	Equals(t, "1.6.0", plugins[0].Version.String())
+	Equals(t, int64(len("linux_amd64/terraform-provider-aws_v1.6.0_x4")), plugins[0].Size)
+	Equals(t, "null", plugins[1].Name)
+}
+
+func TestPlugins_NoCache(t *testing.T) {
+	t.Log("if the cache doesn't exist yet there should be no plugins")
+	cache := &terraform.PluginCache{Dir: "/does/not/exist"}
+	plugins, err := cache.Plugins()
+	Ok(t, err)
+	Equals(t, 0, len(plugins))
+}
+
+func TestGC(t *testing.T) {
+	t.Log("all but the newest version of each provider should be removed")
+	cache, cleanup := newPluginCache(t,
+		"linux_amd64/terraform-provider-aws_v1.6.0_x4",
+		"linux_amd64/terraform-provider-aws_v1.10.0_x4",
+		"linux_amd64/terraform-provider-aws_v1.9.0_x4",
+		"linux_amd64/terraform-provider-null_v1.0.0_x4",
+		"darwin_amd64/terraform-provider-aws_v1.6.0_x4",
+		"linux_amd64/terraform-provider-custom",
+	)
+	defer cleanup()
+
+	removed, err := cache.GC()
+	Ok(t, err)
+	Equals(t, 2, len(removed))
+	Equals(t, filepath.Join(cache.Dir, "linux_amd64/terraform-provider-aws_v1.6.0_x4"), removed[0].Path)
+	Equals(t, filepath.Join(cache.Dir, "linux_amd64/terraform-provider-aws_v1.9.0_x4"), removed[1].Path)
+	plugins, err := cache.Plugins()
+	Ok(t, err)
+	Equals(t, 4, len(plugins))
+}
+
+func TestLock(t *testing.T) {
+	t.Log("the cache should only be locked by one caller at a time")
+	cache, cleanup := newPluginCache(t)
+	defer cleanup()
+
+	unlock, err := cache.Lock(nil)
+	Ok(t, err)
+	locked := make(chan struct{})
+	go func() {
+		unlock2, err := cache.Lock(nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		close(locked)
+		unlock2()
+	}()
+	select {
+	case <-locked:
+		t.Fatal("exp second lock to wait for the first to be unlocked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("exp second lock to succeed once the first was unlocked")
+	}
+}
+
+func TestLock_Canceled(t *testing.T) {
+	t.Log("waiting for the lock should stop when the context is done")
+	cache, cleanup := newPluginCache(t)
+	defer cleanup()
+
+	unlock, err := cache.Lock(nil)
+	Ok(t, err)
+	defer unlock()
+	ctx, cancel := run.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = cache.Lock(ctx)
+	Equals(t, run.TimeoutError{Timeout: 50 * time.Millisecond}, err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = cache.Lock(ctx)
+	Equals(t, run.ErrCanceled, err)
+}
+
+// newPluginCache returns a cache with a file at each path. Each file contains
+// its path.
+func newPluginCache(t *testing.T, paths ...string) (*terraform.PluginCache, func()) {
+	dir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	for _, p := range paths {
+		Ok(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(p)), 0700))
+		Ok(t, ioutil.WriteFile(filepath.Join(dir, p), []byte(p), 0700))
+	}
+	return &terraform.PluginCache{Dir: dir}, func() { os.RemoveAll(dir) } // nolint: errcheck
+}
@@ -0,0 +1,56 @@
+package terraform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl"
+	"github.com/pkg/errors"
+)
+
+// RequiredVersion returns the constraints set by required_version in the
+// terraform blocks of the .tf files in dir, ex.
+// terraform { required_version = "~> 0.10.0" }. If more than one file sets it, a version must match all of them like it
+// must for terraform. If none do, the constraints are nil.
+func RequiredVersion(dir string) (version.Constraints, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var constraints version.Constraints
+	for _, f := range files {
+		contents, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", filepath.Base(f))
+		}
+		// Only check files that might set it since the vendored HCL parser
+		// doesn't support all the syntax terraform does.
+		if !strings.Contains(string(contents), "required_version") {
+			continue
+		}
+		var config struct {
+			Terraform []struct {
+				RequiredVersion string `hcl:"required_version"`
+			} `hcl:"terraform"`
+		}
+		if err := hcl.Unmarshal(contents, &config); err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", filepath.Base(f))
+		}
+		for _, block := range config.Terraform {
+			if block.RequiredVersion == "" {
+				continue
+			}
+			c, err := version.NewConstraint(block.RequiredVersion)
+			if err != nil {
+				return nil, fmt.Errorf("parsing required_version %q in %s: %s", block.RequiredVersion, filepath.Base(f), err)
+			}
+			constraints = append(constraints, c...)
+		}
+	}
+	return constraints, nil
+}
@@ -0,0 +1,89 @@
+package terraform_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events/terraform"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestRequiredVersion(t *testing.T) {
+	cases := []struct {
+		description string
+		files       map[string]string
+		exp         string
+		expErr      bool
+	}{
+		{
+			"no files",
+			nil,
+			"",
+			false,
+		},
+		{
+			"not set",
+			map[string]string{"main.tf": `resource "null_resource" "a" {}`},
+			"",
+			false,
+		},
+		{
+			"set",
+			map[string]string{
+				"main.tf":     `resource "null_resource" "a" {}`,
+				"versions.tf": "terraform {\n  required_version = \"= 0.10.8\"\n}\n",
+			},
+			"= 0.10.8",
+			false,
+		},
+		{
+			"set in terraform block with a backend",
+			map[string]string{"main.tf": "terraform {\n  required_version = \"~> 0.11.0\"\n  backend \"s3\" {\n    bucket = \"b\"\n  }\n}\n"},
+			"~> 0.11.0",
+			false,
+		},
+		{
+			"set in more than one file",
+			map[string]string{
+				"a.tf": "terraform {\n  required_version = \">= 0.10\"\n}\n",
+				"b.tf": "terraform {\n  required_version = \"< 0.12\"\n}\n",
+			},
+			">= 0.10,< 0.12",
+			false,
+		},
+		{
+			"invalid constraint",
+			map[string]string{"main.tf": "terraform {\n  required_version = \"latest\"\n}\n"},
+			"",
+			true,
+		},
+		{
+			"invalid hcl",
+			map[string]string{"main.tf": "terraform {\n  required_version = \n"},
+			"",
+			true,
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.description)
+		dir, err := ioutil.TempDir("", "")
+		Ok(t, err)
+		for name, contents := range c.files {
+			Ok(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600))
+		}
+		constraints, err := terraform.RequiredVersion(dir)
+		os.RemoveAll(dir) // nolint: errcheck
+		if c.expErr {
+			Assert(t, err != nil, "exp an error")
+			continue
+		}
+		Ok(t, err)
+		if c.exp == "" {
+			Assert(t, constraints == nil, "exp no constraints, got %q", constraints)
+			continue
+		}
+		Equals(t, c.exp, constraints.String())
+	}
+}
@@ -2,14 +2,19 @@
 package terraform
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 
 	"strings"
 
 	version "github.com/hashicorp/go-version"
+	"github.com/hootsuite/atlantis/server/events/run"
 	"github.com/hootsuite/atlantis/server/logging"
 	"github.com/pkg/errors"
 )
@@ -18,17 +23,27 @@ import (
 
 type Runner interface {
 	Version() *version.Version
-	RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, v *version.Version, env string) (string, error)
-	RunInitAndEnv(log *logging.SimpleLogger, path string, env string, extraInitArgs []string, version *version.Version) ([]string, error)
+	ResolveVersion(log *logging.SimpleLogger, constraints version.Constraints) (*version.Version, error)
+	RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, args []string, v *version.Version, env string) (string, error)
+	RunInitAndEnv(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, env string, extraInitArgs []string, version *version.Version) ([]string, error)
 }
 
 type Client struct {
 	defaultVersion *version.Version
+	// downloader downloads the versions of terraform that aren't in our
+	// $PATH. If nil, they must be in our $PATH.
+	downloader *Downloader
+	// pluginCache is where terraform caches provider plugins. If nil, they
+	// aren't cached.
+	pluginCache *PluginCache
 }
 
 var versionRegex = regexp.MustCompile("Terraform v(.*)\n")
 
-func NewClient() (*Client, error) {
+// NewClient returns a client for the terraform in our $PATH. Other versions
+// are downloaded with downloader and plugins are cached in pluginCache. Both
+// can be nil.
+func NewClient(downloader *Downloader, pluginCache *PluginCache) (*Client, error) {
 	// may be use exec.LookPath?
 	versionCmdOutput, err := exec.Command("terraform", "version").CombinedOutput()
 	output := string(versionCmdOutput)
@@ -51,6 +66,8 @@ func NewClient() (*Client, error) {
 
 	return &Client{
 		defaultVersion: version,
+		downloader:     downloader,
+		pluginCache:    pluginCache,
 	}, nil
 }
 
@@ -62,33 +79,58 @@ func (c *Client) Version() *version.Version {
 // RunCommandWithVersion executes the provided version of terraform with
 // the provided args in path. The variable "v" is the version of terraform executable to use and the variable "env" is the
 // environment specified by the user commenting "atlantis plan/apply {env}" which is set to "default" by default.
-func (c *Client) RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, v *version.Version, env string) (string, error) {
-	tfExecutable := "terraform"
-	// if version is the same as the default, don't need to prepend the version name to the executable
-	if !v.Equal(c.defaultVersion) {
-		tfExecutable = fmt.Sprintf("%s%s", tfExecutable, v.String())
+// If output isn't nil, the command and its output are also written to it as
+// it runs. The command is stopped if ctx is canceled.
+func (c *Client) RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, args []string, v *version.Version, env string) (string, error) {
+	tfExecutable, err := c.executable(ctx, log, v)
+	if err != nil {
+		return "", err
 	}
+	return c.runCommand(ctx, log, output, path, tfExecutable, args, v, env, nil)
+}
 
+// runCommand runs executable with args in path for RunCommandWithVersion.
+// extraEnvVars are set in addition to the usual environment variables.
+func (c *Client) runCommand(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, executable string, args []string, v *version.Version, env string, extraEnvVars []string) (string, error) {
 	// set environment variables
-	// this is to support scripts to use the ENVIRONMENT, ATLANTIS_TERRAFORM_VERSION
-	// and WORKSPACE variables in their scripts
+	// this is to support scripts to use the ENVIRONMENT, WORKSPACE_NAME,
+	// ATLANTIS_TERRAFORM_VERSION and WORKSPACE variables in their scripts
 	// append current process's environment variables
 	// this is to prevent the $PATH variable being removed from the environment
 	envVars := []string{
 		fmt.Sprintf("ENVIRONMENT=%s", env),
+		fmt.Sprintf("WORKSPACE_NAME=%s", env),
 		fmt.Sprintf("ATLANTIS_TERRAFORM_VERSION=%s", v.String()),
 		fmt.Sprintf("WORKSPACE=%s", path),
 	}
-	envVars = append(envVars, os.Environ()...)
+	if c.pluginCache != nil {
+		envVars = append(envVars, c.pluginCache.Env())
+	}
+	envVars = run.Environ(append(envVars, extraEnvVars...)...)
 
 	// append terraform executable name with args
-	tfCmd := fmt.Sprintf("%s %s", tfExecutable, strings.Join(args, " "))
+	tfCmd := fmt.Sprintf("%s %s", executable, strings.Join(args, " "))
 
 	terraformCmd := exec.Command("sh", "-c", tfCmd)
 	terraformCmd.Dir = path
 	terraformCmd.Env = envVars
-	out, err := terraformCmd.CombinedOutput()
+	var outBuf bytes.Buffer
+	var cmdOutput io.Writer = &outBuf
+	if output != nil {
+		fmt.Fprintf(output, "$ %s\n", tfCmd) // nolint: errcheck
+		cmdOutput = io.MultiWriter(&outBuf, output)
+	}
+	terraformCmd.Stdout = cmdOutput
+	terraformCmd.Stderr = cmdOutput
+	err := run.Command(ctx, terraformCmd)
+	out := outBuf.Bytes()
 	commandStr := strings.Join(terraformCmd.Args, " ")
+	if _, ok := err.(run.TimeoutError); ok {
+		// Keep the timeout as the cause so callers can report it.
+		err = errors.Wrapf(err, "running %q in %q", commandStr, path)
+		log.Debug("error: %s", err)
+		return string(out), err
+	}
 	if err != nil {
 		err = fmt.Errorf("%s: running %q in %q: \n%s", err, commandStr, path, out)
 		log.Debug("error: %s", err)
@@ -98,29 +140,132 @@ func (c *Client) RunCommandWithVersion(log *logging.SimpleLogger, path string, a
 	return string(out), nil
 }
 
-// RunInitAndEnv executes "terraform init" and "terraform env select" in path.
-// env is the environment to select and extraInitArgs are additional arguments
-// applied to the init command.
-func (c *Client) RunInitAndEnv(log *logging.SimpleLogger, path string, env string, extraInitArgs []string, version *version.Version) ([]string, error) {
+// ResolveVersion returns the version of terraform to use for a project that
+// requires constraints. It's the newest version that matches, out of the
+// versions that are installed and the releases we can download.
+func (c *Client) ResolveVersion(log *logging.SimpleLogger, constraints version.Constraints) (*version.Version, error) {
+	var installed *version.Version
+	for _, v := range c.installedVersions() {
+		if constraints.Check(v) && (installed == nil || v.GreaterThan(installed)) {
+			installed = v
+		}
+	}
+	if c.downloader == nil {
+		if installed == nil {
+			return nil, fmt.Errorf("no installed terraform version matches %q", constraints)
+		}
+		log.Info("resolved terraform version %q to %s, the newest installed version that matches", constraints, installed)
+		return installed, nil
+	}
+	released, err := c.downloader.LatestVersion(constraints)
+	if err != nil {
+		if installed == nil {
+			return nil, errors.Wrapf(err, "finding terraform version matching %q", constraints)
+		}
+		log.Warn("finding terraform releases matching %q, only considering installed versions: %s", constraints, err)
+	}
+	if installed != nil && (released == nil || !released.GreaterThan(installed)) {
+		log.Info("resolved terraform version %q to %s, the newest installed version that matches", constraints, installed)
+		return installed, nil
+	}
+	log.Info("resolved terraform version %q to %s, the newest release that matches", constraints, released)
+	return released, nil
+}
+
+// installedVersions returns the versions of terraform we can run without
+// downloading them: the default version, the terraformVERSION executables in
+// our $PATH and the versions the downloader has cached.
+func (c *Client) installedVersions() []*version.Version {
+	versions := []*version.Version{c.defaultVersion}
+	dirs := filepath.SplitList(os.Getenv("PATH"))
+	if c.downloader != nil {
+		dirs = append(dirs, c.downloader.BinDir)
+	}
+	for _, dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "terraform*"))
+		if err != nil {
+			continue
+		}
+		for _, path := range paths {
+			v, err := version.NewVersion(strings.TrimPrefix(filepath.Base(path), "terraform"))
+			if err == nil {
+				versions = append(versions, v)
+			}
+		}
+	}
+	return versions
+}
+
+// executable returns the terraform executable to run for v. Versions other
+// than the default are run as terraformVERSION, ex. terraform0.11.0, if it's
+// in our $PATH and otherwise downloaded. It stops waiting for the download if
+// ctx is canceled.
+func (c *Client) executable(ctx context.Context, log *logging.SimpleLogger, v *version.Version) (string, error) {
+	if v.Equal(c.defaultVersion) {
+		return "terraform", nil
+	}
+	name := fmt.Sprintf("terraform%s", v)
+	if _, err := exec.LookPath(name); err == nil || c.downloader == nil {
+		return name, nil
+	}
+	path, err := c.downloader.Path(ctx, log, v)
+	if err != nil {
+		return "", errors.Wrapf(err, "downloading terraform %s", v)
+	}
+	return path, nil
+}
+
+// RunInitAndEnv executes "terraform init" and then selects env in path,
+// creating it if it doesn't exist. env is selected with "terraform workspace"
+// for terraform >= 0.10 and the deprecated "terraform env" before that.
+// extraInitArgs are additional arguments applied to the init command. The
+// output of the commands is written to output as they run if it isn't nil.
+func (c *Client) RunInitAndEnv(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, env string, extraInitArgs []string, version *version.Version) ([]string, error) {
+	return runInitAndEnv(ctx, c, c.pluginCache, log, output, path, env, extraInitArgs, version)
+}
+
+// runInitAndEnv implements RunInitAndEnv with r's RunCommandWithVersion.
+func runInitAndEnv(ctx context.Context, r Runner, pluginCache *PluginCache, log *logging.SimpleLogger, output io.Writer, path string, env string, extraInitArgs []string, version *version.Version) ([]string, error) {
 	var outputs []string
-	// run terraform init
-	output, err := c.RunCommandWithVersion(log, path, append([]string{"init", "-no-color"}, extraInitArgs...), version, env)
-	outputs = append(outputs, output)
+	// run terraform init, which is the only command that writes to the
+	// plugin cache
+	unlock := func() {}
+	if pluginCache != nil {
+		var err error
+		if unlock, err = pluginCache.Lock(ctx); err != nil {
+			return outputs, err
+		}
+	}
+	out, err := r.RunCommandWithVersion(ctx, log, output, path, append([]string{"init", "-no-color"}, extraInitArgs...), version, env)
+	unlock()
+	outputs = append(outputs, out)
 	if err != nil {
 		return outputs, err
 	}
 
-	// run terraform env new and select
-	output, err = c.RunCommandWithVersion(log, path, []string{"env", "select", "-no-color", env}, version, env)
-	outputs = append(outputs, output)
+	// run terraform workspace/env select and new
+	envCmd := EnvCommand(version)
+	out, err = r.RunCommandWithVersion(ctx, log, output, path, []string{envCmd, "select", "-no-color", env}, version, env)
+	outputs = append(outputs, out)
 	if err != nil {
-		// if terraform env select fails we will run terraform env new
+		// if terraform workspace/env select fails we will run new
 		// to create a new environment
-		output, err = c.RunCommandWithVersion(log, path, []string{"env", "new", "-no-color", env}, version, env)
-		outputs = append(outputs, output)
+		out, err = r.RunCommandWithVersion(ctx, log, output, path, []string{envCmd, "new", "-no-color", env}, version, env)
+		outputs = append(outputs, out)
 		if err != nil {
 			return outputs, err
 		}
 	}
 	return outputs, nil
 }
+
+// EnvCommand returns the terraform command that manages environments in v:
+// "workspace" for terraform >= 0.10 and "env" before that.
+func EnvCommand(v *version.Version) string {
+	// Compare segments since 0.10.0-beta1 is less than 0.10.0.
+	segments := v.Segments()
+	if segments[0] > 0 || segments[1] >= 10 {
+		return "workspace"
+	}
+	return "env"
+}
@@ -1,4 +1,23 @@
 package terraform_test
 
-// todo: actually test
-// purposefully empty to trigger coverage report
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hootsuite/atlantis/server/events/terraform"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestEnvCommand(t *testing.T) {
+	t.Log("terraform >= 0.10 should use workspace and older versions env")
+	cases := map[string]string{
+		"0.9.11":       "env",
+		"0.10.0-beta1": "workspace",
+		"0.10.0":       "workspace",
+		"0.11.7":       "workspace",
+		"1.0.0":        "workspace",
+	}
+	for v, exp := range cases {
+		Equals(t, exp, terraform.EnvCommand(version.Must(version.NewVersion(v))))
+	}
+}
@@ -0,0 +1,43 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hootsuite/atlantis/server/logging"
+)
+
+// TerragruntConfigFile is the name of the file terragrunt reads a project's
+// config from.
+const TerragruntConfigFile = "terragrunt.hcl"
+
+// Terragrunt runs commands with the terragrunt in our $PATH instead of
+// terraform. Terragrunt passes them on to the terraform executable for the
+// version they're run with, so versions work the same as they do for Client.
+type Terragrunt struct {
+	*Client
+}
+
+// NewTerragrunt returns a Terragrunt that finds the versions of terraform
+// to run like c.
+func NewTerragrunt(c *Client) *Terragrunt {
+	return &Terragrunt{Client: c}
+}
+
+// RunCommandWithVersion runs terragrunt with args in path. See
+// Client.RunCommandWithVersion.
+func (t *Terragrunt) RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, args []string, v *version.Version, env string) (string, error) {
+	tfExecutable, err := t.executable(ctx, log, v)
+	if err != nil {
+		return "", err
+	}
+	return t.runCommand(ctx, log, output, path, "terragrunt", args, v, env, []string{fmt.Sprintf("TERRAGRUNT_TFPATH=%s", tfExecutable)})
+}
+
+// RunInitAndEnv runs terragrunt init and selects env in path. See
+// Client.RunInitAndEnv.
+func (t *Terragrunt) RunInitAndEnv(ctx context.Context, log *logging.SimpleLogger, output io.Writer, path string, env string, extraInitArgs []string, version *version.Version) ([]string, error) {
+	return runInitAndEnv(ctx, t, t.pluginCache, log, output, path, env, extraInitArgs, version)
+}
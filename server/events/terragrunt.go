@@ -0,0 +1,139 @@
+package events
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hootsuite/atlantis/server/events/terraform"
+	"github.com/hootsuite/atlantis/server/logging"
+	"github.com/pkg/errors"
+)
+
+// terragruntCacheDir is the directory terragrunt copies a project's module
+// into and runs terraform in.
+const terragruntCacheDir = ".terragrunt-cache"
+
+var (
+	// dependencyRegex matches the config_path of a dependency block, ex.
+	// dependency "vpc" { config_path = "../vpc" }.
+	dependencyRegex = regexp.MustCompile(`config_path\s*=\s*"([^"]+)"`)
+	// dependenciesRegex matches the paths of a dependencies block, ex.
+	// dependencies { paths = ["../vpc", "../mysql"] }.
+	dependenciesRegex = regexp.MustCompile(`(?s)dependencies\s*\{[^}]*?paths\s*=\s*\[([^\]]*)\]`)
+	quotedRegex       = regexp.MustCompile(`"([^"]+)"`)
+)
+
+// usesTerragrunt returns true if the project at absolutePath has a
+// terragrunt config file.
+func usesTerragrunt(absolutePath string) bool {
+	_, err := os.Stat(filepath.Join(absolutePath, terraform.TerragruntConfigFile))
+	return err == nil
+}
+
+// terraformRunner returns the runner for a project with config: tg if it
+// uses terragrunt, otherwise tf.
+func terraformRunner(config ProjectConfig, tf terraform.Runner, tg terraform.Runner) (terraform.Runner, error) {
+	if !config.Terragrunt {
+		return tf, nil
+	}
+	if tg == nil {
+		return nil, errors.New("project uses terragrunt but terragrunt isn't configured")
+	}
+	return tg, nil
+}
+
+// terragruntDependencies returns the paths, relative to repoDir, of the
+// projects that the terragrunt project at projectPath depends on through its
+// dependency and dependencies blocks. Paths that use interpolation are
+// skipped since we can't resolve them. If the project doesn't have a
+// terragrunt config file, it has no dependencies.
+func terragruntDependencies(repoDir string, projectPath string) ([]string, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(repoDir, projectPath, terraform.TerragruntConfigFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var configPaths []string
+	for _, match := range dependencyRegex.FindAllStringSubmatch(string(raw), -1) {
+		configPaths = append(configPaths, match[1])
+	}
+	for _, match := range dependenciesRegex.FindAllStringSubmatch(string(raw), -1) {
+		for _, quoted := range quotedRegex.FindAllStringSubmatch(match[1], -1) {
+			configPaths = append(configPaths, quoted[1])
+		}
+	}
+
+	var deps []string
+	for _, configPath := range configPaths {
+		if strings.Contains(configPath, "${") {
+			continue
+		}
+		if !filepath.IsAbs(configPath) {
+			configPath = filepath.Join(repoDir, projectPath, configPath)
+		}
+		rel, err := filepath.Rel(repoDir, configPath)
+		if err != nil {
+			continue
+		}
+		deps = append(deps, rel)
+	}
+	return deps, nil
+}
+
+// sortByDependencies returns paths, the paths of projects relative to
+// repoDir, ordered so each terragrunt project comes after the projects in
+// paths that it depends on. Otherwise the order of paths is kept. If the
+// dependencies have a cycle, paths is returned as is.
+func sortByDependencies(log *logging.SimpleLogger, repoDir string, paths []string) []string {
+	inPaths := make(map[string]bool)
+	for _, p := range paths {
+		inPaths[filepath.Clean(p)] = true
+	}
+	deps := make(map[string][]string)
+	for _, p := range paths {
+		pathDeps, err := terragruntDependencies(repoDir, p)
+		if err != nil {
+			log.Warn("reading terragrunt dependencies of %q, it may run out of order: %s", p, err)
+			continue
+		}
+		for _, dep := range pathDeps {
+			if inPaths[dep] && dep != filepath.Clean(p) {
+				deps[filepath.Clean(p)] = append(deps[filepath.Clean(p)], dep)
+			}
+		}
+	}
+	if len(deps) == 0 {
+		return paths
+	}
+
+	var sorted []string
+	done := make(map[string]bool)
+	for len(sorted) < len(paths) {
+		added := false
+		for _, p := range paths {
+			if done[filepath.Clean(p)] {
+				continue
+			}
+			ready := true
+			for _, dep := range deps[filepath.Clean(p)] {
+				ready = ready && done[dep]
+			}
+			if ready {
+				sorted = append(sorted, p)
+				done[filepath.Clean(p)] = true
+				added = true
+				break
+			}
+		}
+		if !added {
+			log.Warn("terragrunt dependencies between %v have a cycle so they're not being ordered", paths)
+			return paths
+		}
+	}
+	return sorted
+}
@@ -0,0 +1,80 @@
+package vcs
+
+// The types below are the subset of Azure DevOps' API and service hook
+// payloads that Atlantis uses.
+// See https://docs.microsoft.com/en-us/azure/devops/service-hooks/events.
+
+// Azure DevOps service hook event types.
+const (
+	AzureDevopsPullCreatedEventType   = "git.pullrequest.created"
+	AzureDevopsPullUpdatedEventType   = "git.pullrequest.updated"
+	AzureDevopsPullCommentedEventType = "ms.vss-code.git-pullrequest-comment-event"
+)
+
+// AzureDevopsIdentity is an Azure DevOps user. UniqueName is usually their
+// email address.
+type AzureDevopsIdentity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+// AzureDevopsProject is an Azure DevOps project. Projects contain repos.
+type AzureDevopsProject struct {
+	Name string `json:"name"`
+}
+
+// AzureDevopsRepository is an Azure DevOps Git repo.
+type AzureDevopsRepository struct {
+	ID      string             `json:"id"`
+	Name    string             `json:"name"`
+	Project AzureDevopsProject `json:"project"`
+	// RemoteURL is the clone URL, ex.
+	// https://org@dev.azure.com/org/project/_git/repo.
+	RemoteURL string `json:"remoteUrl"`
+	// WebURL is the URL of the repo in the web UI, ex.
+	// https://dev.azure.com/org/project/_git/repo.
+	WebURL string `json:"webUrl"`
+}
+
+// AzureDevopsCommit is a reference to a commit.
+type AzureDevopsCommit struct {
+	CommitID string `json:"commitId"`
+}
+
+// AzureDevopsForkSource is set on pull requests from forks.
+type AzureDevopsForkSource struct {
+	Repository AzureDevopsRepository `json:"repository"`
+}
+
+// AzureDevopsPullRequest is an Azure DevOps pull request.
+type AzureDevopsPullRequest struct {
+	PullRequestID int                   `json:"pullRequestId"`
+	Status        string                `json:"status"`
+	CreatedBy     AzureDevopsIdentity   `json:"createdBy"`
+	SourceRefName string                `json:"sourceRefName"`
+	TargetRefName string                `json:"targetRefName"`
+	Repository    AzureDevopsRepository `json:"repository"`
+	// LastMergeSourceCommit is the head commit of the source branch.
+	LastMergeSourceCommit AzureDevopsCommit      `json:"lastMergeSourceCommit"`
+	ForkSource            *AzureDevopsForkSource `json:"forkSource"`
+}
+
+// AzureDevopsComment is a comment in a pull request thread.
+type AzureDevopsComment struct {
+	Content string              `json:"content"`
+	Author  AzureDevopsIdentity `json:"author"`
+}
+
+// AzureDevopsPullEvent is the payload of a git.pullrequest.created or
+// git.pullrequest.updated service hook.
+type AzureDevopsPullEvent struct {
+	EventType   string
+	PullRequest AzureDevopsPullRequest
+}
+
+// AzureDevopsPullCommentEvent is the payload of a
+// ms.vss-code.git-pullrequest-comment-event service hook.
+type AzureDevopsPullCommentEvent struct {
+	Comment     AzureDevopsComment     `json:"comment"`
+	PullRequest AzureDevopsPullRequest `json:"pullRequest"`
+}
@@ -0,0 +1,318 @@
+package vcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/pkg/errors"
+)
+
+// azureDevopsAPIVersion is the REST API version we use. It's supported by
+// dev.azure.com and Azure DevOps Server 2020 and later.
+const azureDevopsAPIVersion = "6.0"
+
+// azureDevopsPageSize is the number of changes we request per page.
+const azureDevopsPageSize = 100
+
+// AzureDevopsClient is used to perform Azure DevOps actions. There's no
+// vendored SDK so it calls the REST API directly.
+// See https://docs.microsoft.com/en-us/rest/api/azure/devops/git.
+//
+// Repos are identified by their owner, which is the organization (or
+// collection) and project, ex. "org/project", and their name.
+type AzureDevopsClient struct {
+	// baseURL is the URL of the installation, ex. https://dev.azure.com.
+	baseURL string
+	user    string
+	token   string
+	// HTTPClient makes the API requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// NewAzureDevopsClient returns a client for the Azure DevOps installation at
+// hostname that authenticates with user and a personal access token.
+func NewAzureDevopsClient(hostname string, user string, token string) *AzureDevopsClient {
+	return &AzureDevopsClient{
+		baseURL:    "https://" + hostname,
+		user:       user,
+		token:      token,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// GetModifiedFiles returns the names of files that were modified in the pull
+// request across all its iterations, ie. all the pushes to its source branch.
+// The names include the path to the file from the repo root, ex. parent/child/file.txt.
+func (a *AzureDevopsClient) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
+	var iterations struct {
+		Value []struct {
+			ID int `json:"id"`
+		} `json:"value"`
+	}
+	if err := a.do("GET", a.pullURL(repo, pull.Num, "iterations", nil), nil, &iterations); err != nil {
+		return nil, errors.Wrap(err, "getting iterations")
+	}
+	lastIteration := 0
+	for _, i := range iterations.Value {
+		if i.ID > lastIteration {
+			lastIteration = i.ID
+		}
+	}
+	if lastIteration == 0 {
+		return nil, nil
+	}
+
+	// Comparing the last iteration to iteration 0, ie. the target branch,
+	// gives us the changes from all iterations.
+	var files []string
+	skip := 0
+	for {
+		var changes struct {
+			ChangeEntries []struct {
+				Item struct {
+					Path     string `json:"path"`
+					IsFolder bool   `json:"isFolder"`
+				} `json:"item"`
+			} `json:"changeEntries"`
+			NextSkip int `json:"nextSkip"`
+		}
+		query := url.Values{
+			"$compareTo": {"0"},
+			"$top":       {fmt.Sprint(azureDevopsPageSize)},
+			"$skip":      {fmt.Sprint(skip)},
+		}
+		path := fmt.Sprintf("iterations/%d/changes", lastIteration)
+		if err := a.do("GET", a.pullURL(repo, pull.Num, path, query), nil, &changes); err != nil {
+			return files, errors.Wrap(err, "getting changes")
+		}
+		for _, c := range changes.ChangeEntries {
+			if !c.Item.IsFolder {
+				files = append(files, strings.TrimPrefix(c.Item.Path, "/"))
+			}
+		}
+		if changes.NextSkip == 0 {
+			break
+		}
+		skip = changes.NextSkip
+	}
+	return files, nil
+}
+
+// CreateComment creates a thread on the pull request containing comment.
+// The thread is created as closed so it doesn't block completing the pull
+// request if a policy requires all comments to be resolved.
+func (a *AzureDevopsClient) CreateComment(repo models.Repo, pull models.PullRequest, comment string) error {
+	thread := map[string]interface{}{
+		"comments": []map[string]interface{}{
+			{
+				"parentCommentId": 0,
+				"content":         comment,
+				"commentType":     "text",
+			},
+		},
+		"status": "closed",
+	}
+	return a.do("POST", a.pullURL(repo, pull.Num, "threads", nil), thread, nil)
+}
+
+// FindComment returns the most recent thread on the pull request whose first
+// comment was made by Atlantis and starts with marker or nil if there isn't one. The ID of the
+// returned comment is "{threadID}/{commentID}".
+func (a *AzureDevopsClient) FindComment(repo models.Repo, pull models.PullRequest, marker string) (*Comment, error) {
+	comments, err := a.matchingComments(repo, pull, marker)
+	if err != nil || len(comments) == 0 {
+		return nil, err
+	}
+	// Threads are returned oldest first so the last match is the most
+	// recent.
+	return &comments[len(comments)-1], nil
+}
+
+// HideComments marks the threads on the pull request whose first comment was
+// made by Atlantis and starts with marker as outdated. Azure DevOps can't collapse comments so we
+// edit them instead.
+func (a *AzureDevopsClient) HideComments(repo models.Repo, pull models.PullRequest, marker string) error {
+	comments, err := a.matchingComments(repo, pull, marker)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		if err := a.UpdateComment(repo, pull, c.ID, OutdatedComment(marker, c.Body)); err != nil {
+			return errors.Wrapf(err, "hiding comment %s", c.ID)
+		}
+	}
+	return nil
+}
+
+// matchingComments returns the first comment of each thread on the pull
+// request, oldest first, that was made by Atlantis and starts with marker.
+// Comment authors are identified by their unique name, ex. their email
+// address.
+func (a *AzureDevopsClient) matchingComments(repo models.Repo, pull models.PullRequest, marker string) ([]Comment, error) {
+	var threads struct {
+		Value []struct {
+			ID        int  `json:"id"`
+			IsDeleted bool `json:"isDeleted"`
+			Comments  []struct {
+				ID        int    `json:"id"`
+				Content   string `json:"content"`
+				IsDeleted bool   `json:"isDeleted"`
+				Author    struct {
+					UniqueName string `json:"uniqueName"`
+				} `json:"author"`
+			} `json:"comments"`
+		} `json:"value"`
+	}
+	if err := a.do("GET", a.pullURL(repo, pull.Num, "threads", nil), nil, &threads); err != nil {
+		return nil, errors.Wrap(err, "listing threads")
+	}
+	var matches []Comment
+	for _, t := range threads.Value {
+		if t.IsDeleted || len(t.Comments) == 0 {
+			continue
+		}
+		c := t.Comments[0]
+		if !c.IsDeleted && strings.EqualFold(c.Author.UniqueName, a.user) && strings.HasPrefix(c.Content, marker) {
+			matches = append(matches, Comment{ID: fmt.Sprintf("%d/%d", t.ID, c.ID), Body: c.Content})
+		}
+	}
+	return matches, nil
+}
+
+// UpdateComment replaces the content of the comment with id, which must be
+// in the format returned by FindComment.
+func (a *AzureDevopsClient) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string) error {
+	ids := strings.Split(id, "/")
+	if len(ids) != 2 {
+		return fmt.Errorf("invalid comment id %q", id)
+	}
+	path := fmt.Sprintf("threads/%s/comments/%s", url.PathEscape(ids[0]), url.PathEscape(ids[1]))
+	return a.do("PATCH", a.pullURL(repo, pull.Num, path, nil), map[string]string{"content": comment}, nil)
+}
+
+// PullIsApproved returns true if a reviewer approved the pull request, with
+// or without suggestions, and no reviewer rejected it.
+func (a *AzureDevopsClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
+	const approvedWithSuggestions = 5
+	const rejected = -10
+	var reviewers struct {
+		Value []struct {
+			Vote int `json:"vote"`
+		} `json:"value"`
+	}
+	if err := a.do("GET", a.pullURL(repo, pull.Num, "reviewers", nil), nil, &reviewers); err != nil {
+		return false, errors.Wrap(err, "getting reviewers")
+	}
+	approved := false
+	for _, r := range reviewers.Value {
+		if r.Vote <= rejected {
+			return false, nil
+		}
+		if r.Vote >= approvedWithSuggestions {
+			approved = true
+		}
+	}
+	return approved, nil
+}
+
+// UserHasWriteAccess isn't supported since Azure DevOps permissions are set
+// through security groups that can't be checked with a single API call. It
+// always returns an error so commands on pull requests from forks are
+// rejected if the fork PR policy is maintainers.
+func (a *AzureDevopsClient) UserHasWriteAccess(repo models.Repo, user models.User) (bool, error) {
+	return false, errors.New("checking if a user has write access isn't supported on Azure DevOps")
+}
+
+// UpdateStatus sets a status on the pull request. Statuses with different
+// contexts are shown separately.
+func (a *AzureDevopsClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, statusContext string, description string, targetURL string) error {
+	azureState := "error"
+	switch state {
+	case Pending:
+		azureState = "pending"
+	case Success:
+		azureState = "succeeded"
+	case Failed:
+		azureState = "failed"
+	}
+	status := map[string]interface{}{
+		"state":       azureState,
+		"description": description,
+		"context": map[string]string{
+			"name": statusContext,
+		},
+	}
+	if targetURL != "" {
+		status["targetUrl"] = targetURL
+	}
+	return a.do("POST", a.pullURL(repo, pull.Num, "statuses", nil), status, nil)
+}
+
+// GetPullRequest returns the pull request.
+func (a *AzureDevopsClient) GetPullRequest(repo models.Repo, num int) (*AzureDevopsPullRequest, error) {
+	var pull AzureDevopsPullRequest
+	if err := a.do("GET", a.pullURL(repo, num, "", nil), nil, &pull); err != nil {
+		return nil, err
+	}
+	return &pull, nil
+}
+
+// pullURL returns the API url for path under the pull request. If path is
+// empty it returns the url of the pull request.
+func (a *AzureDevopsClient) pullURL(repo models.Repo, num int, path string, query url.Values) string {
+	var owner []string
+	for _, segment := range strings.Split(repo.Owner, "/") {
+		owner = append(owner, url.PathEscape(segment))
+	}
+	u := fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullRequests/%d", a.baseURL, strings.Join(owner, "/"), url.PathEscape(repo.Name), num)
+	if path != "" {
+		u += "/" + path
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api-version", azureDevopsAPIVersion)
+	return u + "?" + query.Encode()
+}
+
+// do makes an API request to u. If body isn't nil it's sent as JSON. If out
+// isn't nil the JSON response is decoded into it.
+func (a *AzureDevopsClient) do(method string, u string, body interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(a.user, a.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %d %s", method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if out == nil {
+		return nil
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(out), "decoding response from %s %s", method, req.URL.Path)
+}
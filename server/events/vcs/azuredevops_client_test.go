@@ -0,0 +1,208 @@
+package vcs_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/hootsuite/atlantis/server/events/vcs/fixtures"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+var azureDevopsRepo = models.Repo{FullName: "org/project/repo", Owner: "org/project", Name: "repo"}
+var azureDevopsPull = models.PullRequest{Num: 1, HeadCommit: "sha"}
+
+// azureDevopsServer is a stand-in for the Azure DevOps API. It records the
+// requests it receives and responds with the body in responses for the
+// request's path and query.
+func azureDevopsServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]string) {
+	var requests []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, ok := r.BasicAuth()
+		Assert(t, ok, "expected basic auth")
+		Equals(t, "azuredevops-user", user)
+		Equals(t, "azuredevops-token", token)
+		body, err := ioutil.ReadAll(r.Body)
+		Ok(t, err)
+		key := r.URL.EscapedPath() + "?" + r.URL.Query().Encode()
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, key, body))
+		resp, ok := responses[key]
+		if !ok {
+			http.Error(w, `{"message": "not found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, resp) // nolint: errcheck
+	}))
+	return server, &requests
+}
+
+// newAzureDevopsClient returns a client that talks to server.
+func newAzureDevopsClient(t *testing.T, server *httptest.Server) *vcs.AzureDevopsClient {
+	client := vcs.NewAzureDevopsClient(strings.TrimPrefix(server.URL, "https://"), "azuredevops-user", "azuredevops-token")
+	client.HTTPClient = tlsClient(t, server)
+	return client
+}
+
+func TestAzureDevopsClient_GetModifiedFiles(t *testing.T) {
+	t.Log("should page through the changes of the last iteration")
+	prefix := "/org/project/_apis/git/repositories/repo/pullRequests/1/iterations"
+	server, requests := azureDevopsServer(t, map[string]string{
+		prefix + "?api-version=6.0": `{"count": 2, "value": [{"id": 1}, {"id": 2}]}`,
+		prefix + "/2/changes?%24compareTo=0&%24skip=0&%24top=100&api-version=6.0":   `{"changeEntries": [{"item": {"path": "/modules", "isFolder": true}}, {"item": {"path": "/modules/vpc/main.tf"}}], "nextSkip": 100, "nextTop": 100}`,
+		prefix + "/2/changes?%24compareTo=0&%24skip=100&%24top=100&api-version=6.0": `{"changeEntries": [{"item": {"path": "/main.tf"}}], "nextSkip": 0, "nextTop": 0}`,
+	})
+	defer server.Close()
+
+	files, err := newAzureDevopsClient(t, server).GetModifiedFiles(azureDevopsRepo, azureDevopsPull)
+	Ok(t, err)
+	Equals(t, []string{"modules/vpc/main.tf", "main.tf"}, files)
+	Equals(t, 3, len(*requests))
+}
+
+func TestAzureDevopsClient_CreateComment(t *testing.T) {
+	t.Log("should create a closed thread with the comment")
+	server, requests := azureDevopsServer(t, map[string]string{
+		"/org/project/_apis/git/repositories/repo/pullRequests/1/threads?api-version=6.0": `{"id": 1}`,
+	})
+	defer server.Close()
+
+	Ok(t, newAzureDevopsClient(t, server).CreateComment(azureDevopsRepo, azureDevopsPull, "comment"))
+	Equals(t, []string{`POST /org/project/_apis/git/repositories/repo/pullRequests/1/threads?api-version=6.0 {"comments":[{"commentType":"text","content":"comment","parentCommentId":0}],"status":"closed"}`}, *requests)
+}
+
+func TestAzureDevopsClient_FindComment(t *testing.T) {
+	t.Log("should return the first comment of the most recent thread by Atlantis that starts with the marker")
+	server, _ := azureDevopsServer(t, map[string]string{
+		"/org/project/_apis/git/repositories/repo/pullRequests/1/threads?api-version=6.0": `{"value": [
+			{"id": 1, "comments": [{"id": 1, "content": "<!-- marker -->\nfirst", "author": {"uniqueName": "azuredevops-user"}}]},
+			{"id": 2, "comments": [{"id": 1, "content": "reply", "author": {"uniqueName": "azuredevops-user"}}, {"id": 2, "content": "<!-- marker -->\nreply", "author": {"uniqueName": "azuredevops-user"}}]},
+			{"id": 3, "isDeleted": true, "comments": [{"id": 1, "content": "<!-- marker -->\ndeleted", "author": {"uniqueName": "azuredevops-user"}}]},
+			{"id": 4, "comments": []},
+			{"id": 5, "comments": [{"id": 1, "content": "<!-- marker -->\nquoted", "author": {"uniqueName": "someone@example.com"}}]}
+		]}`,
+	})
+	defer server.Close()
+	client := newAzureDevopsClient(t, server)
+
+	comment, err := client.FindComment(azureDevopsRepo, azureDevopsPull, "<!-- marker -->")
+	Ok(t, err)
+	Equals(t, &vcs.Comment{ID: "1/1", Body: "<!-- marker -->\nfirst"}, comment)
+
+	comment, err = client.FindComment(azureDevopsRepo, azureDevopsPull, "<!-- missing -->")
+	Ok(t, err)
+	Assert(t, comment == nil, "expected no comment")
+}
+
+func TestAzureDevopsClient_UpdateComment(t *testing.T) {
+	t.Log("should edit the content of the comment in the thread")
+	server, requests := azureDevopsServer(t, map[string]string{
+		"/org/project/_apis/git/repositories/repo/pullRequests/1/threads/1/comments/2?api-version=6.0": `{"id": 2}`,
+	})
+	defer server.Close()
+	client := newAzureDevopsClient(t, server)
+
+	Ok(t, client.UpdateComment(azureDevopsRepo, azureDevopsPull, "1/2", "comment"))
+	Equals(t, []string{`PATCH /org/project/_apis/git/repositories/repo/pullRequests/1/threads/1/comments/2?api-version=6.0 {"content":"comment"}`}, *requests)
+
+	err := client.UpdateComment(azureDevopsRepo, azureDevopsPull, "2", "comment")
+	Assert(t, err != nil, "expected error")
+	Equals(t, `invalid comment id "2"`, err.Error())
+}
+
+func TestAzureDevopsClient_HideComments(t *testing.T) {
+	t.Log("should edit the first comment of each thread by Atlantis that starts with the marker to show it's outdated")
+	server, requests := azureDevopsServer(t, map[string]string{
+		"/org/project/_apis/git/repositories/repo/pullRequests/1/threads?api-version=6.0":              `{"value": [{"id": 1, "comments": [{"id": 1, "content": "<!-- marker -->\nfirst", "author": {"uniqueName": "azuredevops-user"}}]}, {"id": 2, "comments": [{"id": 1, "content": "other", "author": {"uniqueName": "azuredevops-user"}}]}, {"id": 3, "comments": [{"id": 1, "content": "<!-- marker -->\nquoted", "author": {"uniqueName": "someone@example.com"}}]}]}`,
+		"/org/project/_apis/git/repositories/repo/pullRequests/1/threads/1/comments/1?api-version=6.0": `{"id": 1}`,
+	})
+	defer server.Close()
+
+	Ok(t, newAzureDevopsClient(t, server).HideComments(azureDevopsRepo, azureDevopsPull, "<!-- marker -->"))
+	body, err := json.Marshal(map[string]string{"content": vcs.OutdatedComment("<!-- marker -->", "<!-- marker -->\nfirst")})
+	Ok(t, err)
+	Equals(t, []string{
+		"GET /org/project/_apis/git/repositories/repo/pullRequests/1/threads?api-version=6.0 ",
+		"PATCH /org/project/_apis/git/repositories/repo/pullRequests/1/threads/1/comments/1?api-version=6.0 " + string(body),
+	}, *requests)
+}
+
+func TestAzureDevopsClient_PullIsApproved(t *testing.T) {
+	cases := []struct {
+		reviewers string
+		exp       bool
+	}{
+		{`{"value": []}`, false},
+		{`{"value": [{"vote": 0}, {"vote": -5}]}`, false},
+		{`{"value": [{"vote": 10}, {"vote": -10}]}`, false},
+		{`{"value": [{"vote": 0}, {"vote": 5}]}`, true},
+		{`{"value": [{"vote": 10}]}`, true},
+	}
+	for _, c := range cases {
+		t.Log("when the reviewers are " + c.reviewers)
+		server, _ := azureDevopsServer(t, map[string]string{
+			"/org/project/_apis/git/repositories/repo/pullRequests/1/reviewers?api-version=6.0": c.reviewers,
+		})
+		approved, err := newAzureDevopsClient(t, server).PullIsApproved(azureDevopsRepo, azureDevopsPull)
+		server.Close()
+		Ok(t, err)
+		Equals(t, c.exp, approved)
+	}
+}
+
+func TestAzureDevopsClient_UserHasWriteAccess(t *testing.T) {
+	t.Log("should error since checking permissions isn't supported")
+	client := vcs.NewAzureDevopsClient("dev.azure.com", "azuredevops-user", "azuredevops-token")
+	_, err := client.UserHasWriteAccess(azureDevopsRepo, models.User{Username: "user@example.com"})
+	Assert(t, err != nil, "expected error")
+}
+
+func TestAzureDevopsClient_UpdateStatus(t *testing.T) {
+	t.Log("should set a pull request status with the context, target URL and azure devops' name for the state")
+	path := "/org/project/_apis/git/repositories/repo/pullRequests/1/statuses?api-version=6.0"
+	server, requests := azureDevopsServer(t, map[string]string{
+		path: `{"id": 1}`,
+	})
+	defer server.Close()
+
+	Ok(t, newAzureDevopsClient(t, server).UpdateStatus(azureDevopsRepo, azureDevopsPull, vcs.Success, "atlantis/plan", "Plan Succeeded", "https://atlantis.example.com/jobs/1"))
+	Equals(t, 1, len(*requests))
+	var status map[string]interface{}
+	Ok(t, json.Unmarshal([]byte((*requests)[0][len("POST "+path+" "):]), &status))
+	Equals(t, map[string]interface{}{
+		"state":       "succeeded",
+		"description": "Plan Succeeded",
+		"context":     map[string]interface{}{"name": "atlantis/plan"},
+		"targetUrl":   "https://atlantis.example.com/jobs/1",
+	}, status)
+}
+
+func TestAzureDevopsClient_GetPullRequest(t *testing.T) {
+	t.Log("should return the pull request and escape the project name")
+	repo := models.Repo{FullName: "org/My Project/repo", Owner: "org/My Project", Name: "repo"}
+	server, _ := azureDevopsServer(t, map[string]string{
+		"/org/My%20Project/_apis/git/repositories/repo/pullRequests/1?api-version=6.0": fixtures.AzureDevopsPullRequestJSON,
+	})
+	defer server.Close()
+
+	pull, err := newAzureDevopsClient(t, server).GetPullRequest(repo, 1)
+	Ok(t, err)
+	Equals(t, 1, pull.PullRequestID)
+	Equals(t, "refs/heads/add-null-resource", pull.SourceRefName)
+	Equals(t, "53d54ac915144006c2c9e90d2c7d3880920db49c", pull.LastMergeSourceCommit.CommitID)
+}
+
+func TestAzureDevopsClient_ErrorResponse(t *testing.T) {
+	t.Log("should return an error with the response body if the api responds with an error")
+	server, _ := azureDevopsServer(t, nil)
+	defer server.Close()
+
+	_, err := newAzureDevopsClient(t, server).GetPullRequest(azureDevopsRepo, 1)
+	Assert(t, err != nil, "expected error")
+	Equals(t, `GET /org/project/_apis/git/repositories/repo/pullRequests/1: 404 {"message": "not found"}`, err.Error())
+}
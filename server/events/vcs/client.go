@@ -1,6 +1,8 @@
 package vcs
 
 import (
+	"strings"
+
 	"github.com/hootsuite/atlantis/server/events/models"
 )
 
@@ -11,5 +13,37 @@ type Client interface {
 	GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error)
 	CreateComment(repo models.Repo, pull models.PullRequest, comment string) error
 	PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error)
-	UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, description string) error
+	// UpdateStatus sets the status of the pull request's head commit.
+	// targetURL is linked from the status, ex. to the command's output, if
+	// it isn't empty.
+	UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, statusContext string, description string, targetURL string) error
+	// UserHasWriteAccess returns true if user can push to repo.
+	UserHasWriteAccess(repo models.Repo, user models.User) (bool, error)
+	// FindComment returns the most recent comment on the pull request whose
+	// body starts with marker or nil if there isn't one.
+	FindComment(repo models.Repo, pull models.PullRequest, marker string) (*Comment, error)
+	// UpdateComment replaces the body of the comment with id.
+	UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string) error
+	// HideComments marks every comment on the pull request whose body starts
+	// with marker as outdated. Comments that were already hidden are left
+	// alone.
+	HideComments(repo models.Repo, pull models.PullRequest, marker string) error
+}
+
+// Comment is a comment on a pull request.
+type Comment struct {
+	// ID identifies the comment to UpdateComment. Its format depends on the
+	// VCS host.
+	ID   string
+	Body string
+}
+
+// outdatedHeader is shown above comments that were hidden by editing them on
+// hosts that can't collapse comments.
+const outdatedHeader = "~~**Outdated**~~ This comment was replaced by a newer one.\n\n<details><summary>Show outdated comment</summary>\n\n"
+
+// OutdatedComment returns body, which starts with marker, edited to show it's
+// outdated. The marker is removed so the comment isn't found again.
+func OutdatedComment(marker string, body string) string {
+	return outdatedHeader + strings.TrimPrefix(strings.TrimPrefix(body, marker), "\n") + "\n</details>"
 }
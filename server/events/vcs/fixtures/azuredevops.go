@@ -0,0 +1,81 @@
+package fixtures
+
+// AzureDevopsPullCommentEventJSON is an
+// ms.vss-code.git-pullrequest-comment-event service hook payload sent by
+// Azure DevOps for a comment on a pull request.
+var AzureDevopsPullCommentEventJSON = `{
+  "id": "af07be1b-f3ad-44c8-a7f1-c4835f2df06b",
+  "eventType": "ms.vss-code.git-pullrequest-comment-event",
+  "publisherId": "tfs",
+  "message": {"text": "commenter has commented on a pull request"},
+  "resource": {
+    "comment": {
+      "id": 2,
+      "parentCommentId": 1,
+      "author": {
+        "displayName": "Commenter",
+        "uniqueName": "commenter@example.com"
+      },
+      "content": "atlantis plan",
+      "publishedDate": "2023-01-02T15:04:05Z",
+      "commentType": "text"
+    },
+    "pullRequest": ` + AzureDevopsPullRequestJSON + `
+  },
+  "resourceVersion": "2.0"
+}`
+
+// AzureDevopsPullRequestJSON is a pull request as returned by the Azure
+// DevOps API.
+var AzureDevopsPullRequestJSON = `{
+  "repository": {
+    "id": "3411ebc1-d5aa-464f-9615-0b527bc66719",
+    "name": "repo",
+    "url": "https://dev.azure.com/org/_apis/git/repositories/3411ebc1-d5aa-464f-9615-0b527bc66719",
+    "project": {"id": "a7573007-bbb3-4341-b726-0c4148a07853", "name": "project"},
+    "remoteUrl": "https://org@dev.azure.com/org/project/_git/repo",
+    "webUrl": "https://dev.azure.com/org/project/_git/repo"
+  },
+  "pullRequestId": 1,
+  "status": "active",
+  "createdBy": {
+    "displayName": "Author",
+    "uniqueName": "author@example.com"
+  },
+  "creationDate": "2023-01-02T15:04:05Z",
+  "title": "Add null resource",
+  "sourceRefName": "refs/heads/add-null-resource",
+  "targetRefName": "refs/heads/main",
+  "mergeStatus": "succeeded",
+  "lastMergeSourceCommit": {"commitId": "53d54ac915144006c2c9e90d2c7d3880920db49c"},
+  "lastMergeTargetCommit": {"commitId": "a511f535b1ea495ee0c903badb68fbc83772c882"},
+  "url": "https://dev.azure.com/org/_apis/git/repositories/3411ebc1-d5aa-464f-9615-0b527bc66719/pullRequests/1"
+}`
+
+// AzureDevopsPullUpdatedEventJSON is a git.pullrequest.updated service hook
+// payload sent by Azure DevOps when a pull request is completed.
+var AzureDevopsPullUpdatedEventJSON = `{
+  "id": "2ab4e3d3-b7a6-425e-92b1-5a9982c1269e",
+  "eventType": "git.pullrequest.updated",
+  "publisherId": "tfs",
+  "message": {"text": "Author marked the pull request as completed"},
+  "resource": {
+    "repository": {
+      "id": "3411ebc1-d5aa-464f-9615-0b527bc66719",
+      "name": "repo",
+      "project": {"id": "a7573007-bbb3-4341-b726-0c4148a07853", "name": "project"},
+      "remoteUrl": "https://org@dev.azure.com/org/project/_git/repo",
+      "webUrl": "https://dev.azure.com/org/project/_git/repo"
+    },
+    "pullRequestId": 1,
+    "status": "completed",
+    "createdBy": {
+      "displayName": "Author",
+      "uniqueName": "author@example.com"
+    },
+    "sourceRefName": "refs/heads/add-null-resource",
+    "targetRefName": "refs/heads/main",
+    "lastMergeSourceCommit": {"commitId": "53d54ac915144006c2c9e90d2c7d3880920db49c"}
+  },
+  "resourceVersion": "1.0"
+}`
@@ -0,0 +1,103 @@
+package fixtures
+
+// GiteaIssueCommentEventJSON is an issue_comment webhook payload sent by Gitea
+// for a comment on a pull request.
+var GiteaIssueCommentEventJSON = `{
+  "action": "created",
+  "issue": {
+    "id": 12,
+    "url": "https://gitea.example.com/api/v1/repos/owner/repo/issues/2",
+    "html_url": "https://gitea.example.com/owner/repo/pulls/2",
+    "number": 2,
+    "user": {"id": 2, "login": "author", "username": "author"},
+    "title": "Add null resource",
+    "body": "",
+    "state": "open",
+    "pull_request": {"merged": false, "merged_at": null}
+  },
+  "pull_request": null,
+  "comment": {
+    "id": 31,
+    "html_url": "https://gitea.example.com/owner/repo/pulls/2#issuecomment-31",
+    "pull_request_url": "https://gitea.example.com/owner/repo/pulls/2",
+    "issue_url": "",
+    "user": {"id": 3, "login": "commenter", "username": "commenter"},
+    "body": "atlantis plan",
+    "created_at": "2023-01-02T15:04:05Z",
+    "updated_at": "2023-01-02T15:04:05Z"
+  },
+  "repository": {
+    "id": 5,
+    "owner": {"id": 1, "login": "owner", "username": "owner"},
+    "name": "repo",
+    "full_name": "owner/repo",
+    "private": false,
+    "fork": false,
+    "html_url": "https://gitea.example.com/owner/repo",
+    "ssh_url": "git@gitea.example.com:owner/repo.git",
+    "clone_url": "https://gitea.example.com/owner/repo.git",
+    "default_branch": "main"
+  },
+  "sender": {"id": 3, "login": "commenter", "username": "commenter"},
+  "is_pull": true
+}`
+
+// GiteaPullRequestJSON is a pull request as returned by Gitea's API. The head
+// branch is in a fork.
+var GiteaPullRequestJSON = `{
+  "id": 7,
+  "url": "https://gitea.example.com/owner/repo/pulls/2",
+  "number": 2,
+  "user": {"id": 2, "login": "author", "username": "author"},
+  "title": "Add null resource",
+  "body": "",
+  "state": "open",
+  "html_url": "https://gitea.example.com/owner/repo/pulls/2",
+  "diff_url": "https://gitea.example.com/owner/repo/pulls/2.diff",
+  "mergeable": true,
+  "merged": false,
+  "base": {
+    "label": "main",
+    "ref": "main",
+    "sha": "0f7d8d3fe5e0d2a8d4a9b1c62c5e3a1b2c3d4e5f",
+    "repo_id": 5,
+    "repo": {
+      "id": 5,
+      "owner": {"id": 1, "login": "owner", "username": "owner"},
+      "name": "repo",
+      "full_name": "owner/repo",
+      "clone_url": "https://gitea.example.com/owner/repo.git"
+    }
+  },
+  "head": {
+    "label": "add-null-resource",
+    "ref": "add-null-resource",
+    "sha": "a5b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9",
+    "repo_id": 6,
+    "repo": {
+      "id": 6,
+      "owner": {"id": 2, "login": "author", "username": "author"},
+      "name": "repo",
+      "full_name": "author/repo",
+      "clone_url": "https://gitea.example.com/author/repo.git"
+    }
+  },
+  "merge_base": "0f7d8d3fe5e0d2a8d4a9b1c62c5e3a1b2c3d4e5f"
+}`
+
+// GiteaPullRequestEventJSON is a pull_request webhook payload sent by Gitea
+// when a pull request is closed.
+var GiteaPullRequestEventJSON = `{
+  "action": "closed",
+  "number": 2,
+  "pull_request": ` + GiteaPullRequestJSON + `,
+  "repository": {
+    "id": 5,
+    "owner": {"id": 1, "login": "owner", "username": "owner"},
+    "name": "repo",
+    "full_name": "owner/repo",
+    "html_url": "https://gitea.example.com/owner/repo",
+    "clone_url": "https://gitea.example.com/owner/repo.git"
+  },
+  "sender": {"id": 2, "login": "author", "username": "author"}
+}`
@@ -0,0 +1,64 @@
+package vcs
+
+// The types below are the subset of Gitea's API and webhook payloads that
+// Atlantis uses. Forgejo is a fork of Gitea and sends the same payloads.
+// See https://docs.gitea.com/usage/webhooks.
+
+// GiteaUser is a Gitea user.
+type GiteaUser struct {
+	Login string `json:"login"`
+}
+
+// GiteaRepository is a Gitea repository.
+type GiteaRepository struct {
+	FullName string    `json:"full_name"`
+	Name     string    `json:"name"`
+	Owner    GiteaUser `json:"owner"`
+	CloneURL string    `json:"clone_url"`
+}
+
+// GiteaPRBranch is the head or base branch of a Gitea pull request.
+type GiteaPRBranch struct {
+	Ref  string           `json:"ref"`
+	Sha  string           `json:"sha"`
+	Repo *GiteaRepository `json:"repo"`
+}
+
+// GiteaPullRequest is a Gitea pull request.
+type GiteaPullRequest struct {
+	Number  int           `json:"number"`
+	User    GiteaUser     `json:"user"`
+	State   string        `json:"state"`
+	HTMLURL string        `json:"html_url"`
+	Head    GiteaPRBranch `json:"head"`
+	Base    GiteaPRBranch `json:"base"`
+}
+
+// GiteaIssue is a Gitea issue. Pull requests are also issues.
+type GiteaIssue struct {
+	Number int `json:"number"`
+}
+
+// GiteaComment is a comment on a Gitea issue or pull request.
+type GiteaComment struct {
+	Body string    `json:"body"`
+	User GiteaUser `json:"user"`
+}
+
+// GiteaIssueCommentEvent is the payload of an issue_comment webhook.
+type GiteaIssueCommentEvent struct {
+	Action     string          `json:"action"`
+	Issue      GiteaIssue      `json:"issue"`
+	Comment    GiteaComment    `json:"comment"`
+	Repository GiteaRepository `json:"repository"`
+	// IsPull is true if the comment is on a pull request rather than an issue.
+	IsPull bool `json:"is_pull"`
+}
+
+// GiteaPullRequestEvent is the payload of a pull_request webhook.
+type GiteaPullRequestEvent struct {
+	Action      string           `json:"action"`
+	Number      int              `json:"number"`
+	PullRequest GiteaPullRequest `json:"pull_request"`
+	Repository  GiteaRepository  `json:"repository"`
+}
@@ -0,0 +1,235 @@
+package vcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/pkg/errors"
+)
+
+// giteaPageSize is the number of items we request per page. It's Gitea's
+// default maximum so larger values would be ignored.
+const giteaPageSize = 50
+
+// GiteaClient is used to perform Gitea and Forgejo actions. There's no
+// vendored SDK so it calls the REST API directly.
+// See https://docs.gitea.com/api.
+type GiteaClient struct {
+	// apiURL is the base URL of the API, ex. https://gitea.example.com/api/v1.
+	apiURL string
+	// user is the username Atlantis' comments are authored by.
+	user   string
+	token  string
+	client *http.Client
+}
+
+// NewGiteaClient returns a client for the Gitea installation at baseURL,
+// ex. https://gitea.example.com, that authenticates as user with token. http URLs are allowed so a local
+// installation can be used for testing.
+func NewGiteaClient(baseURL string, user string, token string) (*GiteaClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid gitea base url %q", baseURL)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid gitea base url %q: must be an http or https url, ex. https://gitea.example.com", baseURL)
+	}
+	return &GiteaClient{
+		apiURL: strings.TrimSuffix(baseURL, "/") + "/api/v1",
+		user:   user,
+		token:  token,
+		client: http.DefaultClient,
+	}, nil
+}
+
+// GetModifiedFiles returns the names of files that were modified in the pull request.
+// The names include the path to the file from the repo root, ex. parent/child/file.txt.
+func (g *GiteaClient) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
+	var files []string
+	for page := 1; ; page++ {
+		var pageFiles []struct {
+			Filename string `json:"filename"`
+		}
+		path := fmt.Sprintf("repos/%s/%s/pulls/%d/files?page=%d&limit=%d", repo.Owner, repo.Name, pull.Num, page, giteaPageSize)
+		if err := g.do("GET", path, nil, &pageFiles); err != nil {
+			return files, err
+		}
+		if len(pageFiles) == 0 {
+			break
+		}
+		for _, f := range pageFiles {
+			files = append(files, f.Filename)
+		}
+	}
+	return files, nil
+}
+
+// CreateComment creates a comment on the pull request.
+func (g *GiteaClient) CreateComment(repo models.Repo, pull models.PullRequest, comment string) error {
+	path := fmt.Sprintf("repos/%s/%s/issues/%d/comments", repo.Owner, repo.Name, pull.Num)
+	return g.do("POST", path, map[string]string{"body": comment}, nil)
+}
+
+// FindComment returns the most recent comment on the pull request that was
+// made by Atlantis and whose body starts with marker or nil if there isn't one.
+func (g *GiteaClient) FindComment(repo models.Repo, pull models.PullRequest, marker string) (*Comment, error) {
+	comments, err := g.matchingComments(repo, pull, marker)
+	if err != nil || len(comments) == 0 {
+		return nil, err
+	}
+	// Comments are returned oldest first so the last match is the most
+	// recent.
+	return &comments[len(comments)-1], nil
+}
+
+// HideComments marks the comments on the pull request that were made by
+// Atlantis and whose body starts with marker as outdated. Gitea can't collapse comments so we edit them instead.
+func (g *GiteaClient) HideComments(repo models.Repo, pull models.PullRequest, marker string) error {
+	comments, err := g.matchingComments(repo, pull, marker)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		if err := g.UpdateComment(repo, pull, c.ID, OutdatedComment(marker, c.Body)); err != nil {
+			return errors.Wrapf(err, "hiding comment %s", c.ID)
+		}
+	}
+	return nil
+}
+
+// matchingComments returns the comments on the pull request, oldest first,
+// that were made by Atlantis and whose body starts with marker.
+func (g *GiteaClient) matchingComments(repo models.Repo, pull models.PullRequest, marker string) ([]Comment, error) {
+	var comments []struct {
+		ID   int64     `json:"id"`
+		Body string    `json:"body"`
+		User GiteaUser `json:"user"`
+	}
+	path := fmt.Sprintf("repos/%s/%s/issues/%d/comments", repo.Owner, repo.Name, pull.Num)
+	if err := g.do("GET", path, nil, &comments); err != nil {
+		return nil, errors.Wrap(err, "listing comments")
+	}
+	var matches []Comment
+	for _, c := range comments {
+		if strings.EqualFold(c.User.Login, g.user) && strings.HasPrefix(c.Body, marker) {
+			matches = append(matches, Comment{ID: fmt.Sprint(c.ID), Body: c.Body})
+		}
+	}
+	return matches, nil
+}
+
+// UpdateComment replaces the body of the comment with id.
+func (g *GiteaClient) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string) error {
+	path := fmt.Sprintf("repos/%s/%s/issues/comments/%s", repo.Owner, repo.Name, id)
+	return g.do("PATCH", path, map[string]string{"body": comment}, nil)
+}
+
+// PullIsApproved returns true if the pull request has an approval that
+// hasn't been dismissed.
+func (g *GiteaClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
+	var reviews []struct {
+		State     string `json:"state"`
+		Dismissed bool   `json:"dismissed"`
+	}
+	path := fmt.Sprintf("repos/%s/%s/pulls/%d/reviews", repo.Owner, repo.Name, pull.Num)
+	if err := g.do("GET", path, nil, &reviews); err != nil {
+		return false, errors.Wrap(err, "getting reviews")
+	}
+	for _, review := range reviews {
+		if review.State == "APPROVED" && !review.Dismissed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UserHasWriteAccess returns true if user has owner, admin or write
+// permissions on repo.
+func (g *GiteaClient) UserHasWriteAccess(repo models.Repo, user models.User) (bool, error) {
+	var perm struct {
+		Permission string `json:"permission"`
+	}
+	path := fmt.Sprintf("repos/%s/%s/collaborators/%s/permission", repo.Owner, repo.Name, user.Username)
+	if err := g.do("GET", path, nil, &perm); err != nil {
+		return false, errors.Wrap(err, "getting permission level")
+	}
+	switch perm.Permission {
+	case "owner", "admin", "write":
+		return true, nil
+	}
+	return false, nil
+}
+
+// UpdateStatus updates the status of the head commit of the pull request.
+// Statuses with different contexts are shown separately.
+func (g *GiteaClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, statusContext string, description string, targetURL string) error {
+	giteaState := "error"
+	switch state {
+	case Pending:
+		giteaState = "pending"
+	case Success:
+		giteaState = "success"
+	case Failed:
+		giteaState = "failure"
+	}
+	path := fmt.Sprintf("repos/%s/%s/statuses/%s", repo.Owner, repo.Name, pull.HeadCommit)
+	return g.do("POST", path, map[string]string{
+		"state":       giteaState,
+		"context":     statusContext,
+		"description": description,
+		"target_url":  targetURL,
+	}, nil)
+}
+
+// GetPullRequest returns the pull request.
+func (g *GiteaClient) GetPullRequest(repo models.Repo, num int) (*GiteaPullRequest, error) {
+	var pull GiteaPullRequest
+	path := fmt.Sprintf("repos/%s/%s/pulls/%d", repo.Owner, repo.Name, num)
+	if err := g.do("GET", path, nil, &pull); err != nil {
+		return nil, err
+	}
+	return &pull, nil
+}
+
+// do makes an API request to path, which is relative to the API's base url.
+// If body isn't nil it's sent as JSON. If out isn't nil the JSON response
+// is decoded into it.
+func (g *GiteaClient) do(method string, path string, body interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, g.apiURL+"/"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "token "+g.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %d %s", method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if out == nil {
+		return nil
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(out), "decoding response from %s %s", method, req.URL.Path)
+}
@@ -0,0 +1,213 @@
+package vcs_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/hootsuite/atlantis/server/events/vcs/fixtures"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+var giteaRepo = models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+var giteaPull = models.PullRequest{Num: 2, HeadCommit: "sha"}
+
+// giteaServer is a stand-in for the Gitea API. It records the requests it
+// receives and responds with the body in responses for the request's path.
+func giteaServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]string) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "token gitea-token", r.Header.Get("Authorization"))
+		body, err := ioutil.ReadAll(r.Body)
+		Ok(t, err)
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), body))
+		resp, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			http.Error(w, `{"message": "not found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, resp) // nolint: errcheck
+	}))
+	return server, &requests
+}
+
+func TestNewGiteaClient_InvalidURL(t *testing.T) {
+	t.Log("should error if the base url isn't an http or https url")
+	for _, u := range []string{"gitea.example.com", "ftp://gitea.example.com", "https://"} {
+		_, err := vcs.NewGiteaClient(u, "gitea-user", "gitea-token")
+		Assert(t, err != nil, "expected error for %s", u)
+	}
+}
+
+func TestGiteaClient_GetModifiedFiles(t *testing.T) {
+	t.Log("should page through the modified files until a page is empty")
+	server, requests := giteaServer(t, map[string]string{
+		"/api/v1/repos/owner/repo/pulls/2/files?page=1&limit=50": `[{"filename": "main.tf", "status": "changed"}, {"filename": "modules/vpc/main.tf", "status": "added"}]`,
+		"/api/v1/repos/owner/repo/pulls/2/files?page=2&limit=50": `[{"filename": "README.md", "status": "changed"}]`,
+		"/api/v1/repos/owner/repo/pulls/2/files?page=3&limit=50": `[]`,
+	})
+	defer server.Close()
+	client, err := vcs.NewGiteaClient(server.URL+"/", "gitea-user", "gitea-token")
+	Ok(t, err)
+
+	files, err := client.GetModifiedFiles(giteaRepo, giteaPull)
+	Ok(t, err)
+	Equals(t, []string{"main.tf", "modules/vpc/main.tf", "README.md"}, files)
+	Equals(t, 3, len(*requests))
+}
+
+func TestGiteaClient_CreateComment(t *testing.T) {
+	t.Log("should post the comment to the pull request's issue")
+	server, requests := giteaServer(t, map[string]string{
+		"/api/v1/repos/owner/repo/issues/2/comments": `{"id": 1}`,
+	})
+	defer server.Close()
+	client, err := vcs.NewGiteaClient(server.URL, "gitea-user", "gitea-token")
+	Ok(t, err)
+
+	Ok(t, client.CreateComment(giteaRepo, giteaPull, "comment"))
+	Equals(t, []string{`POST /api/v1/repos/owner/repo/issues/2/comments {"body":"comment"}`}, *requests)
+}
+
+func TestGiteaClient_FindComment(t *testing.T) {
+	t.Log("should return the most recent comment by Atlantis that starts with the marker")
+	server, _ := giteaServer(t, map[string]string{
+		"/api/v1/repos/owner/repo/issues/2/comments": `[{"id": 1, "body": "<!-- marker -->\nfirst", "user": {"login": "gitea-user"}}, {"id": 2, "body": "other", "user": {"login": "gitea-user"}}, {"id": 3, "body": "<!-- marker -->\nsecond", "user": {"login": "gitea-user"}}, {"id": 4, "body": "<!-- marker -->\nquoted", "user": {"login": "someone"}}]`,
+	})
+	defer server.Close()
+	client, err := vcs.NewGiteaClient(server.URL, "gitea-user", "gitea-token")
+	Ok(t, err)
+
+	comment, err := client.FindComment(giteaRepo, giteaPull, "<!-- marker -->")
+	Ok(t, err)
+	Equals(t, &vcs.Comment{ID: "3", Body: "<!-- marker -->\nsecond"}, comment)
+
+	comment, err = client.FindComment(giteaRepo, giteaPull, "<!-- missing -->")
+	Ok(t, err)
+	Assert(t, comment == nil, "expected no comment")
+}
+
+func TestGiteaClient_UpdateComment(t *testing.T) {
+	t.Log("should edit the comment's body")
+	server, requests := giteaServer(t, map[string]string{
+		"/api/v1/repos/owner/repo/issues/comments/3": `{"id": 3}`,
+	})
+	defer server.Close()
+	client, err := vcs.NewGiteaClient(server.URL, "gitea-user", "gitea-token")
+	Ok(t, err)
+
+	Ok(t, client.UpdateComment(giteaRepo, giteaPull, "3", "comment"))
+	Equals(t, []string{`PATCH /api/v1/repos/owner/repo/issues/comments/3 {"body":"comment"}`}, *requests)
+}
+
+func TestGiteaClient_HideComments(t *testing.T) {
+	t.Log("should edit each comment by Atlantis that starts with the marker to show it's outdated")
+	server, requests := giteaServer(t, map[string]string{
+		"/api/v1/repos/owner/repo/issues/2/comments": `[{"id": 1, "body": "<!-- marker -->\nfirst", "user": {"login": "gitea-user"}}, {"id": 2, "body": "other", "user": {"login": "gitea-user"}}, {"id": 3, "body": "<!-- marker -->\nquoted", "user": {"login": "someone"}}]`,
+		"/api/v1/repos/owner/repo/issues/comments/1": `{"id": 1}`,
+	})
+	defer server.Close()
+	client, err := vcs.NewGiteaClient(server.URL, "gitea-user", "gitea-token")
+	Ok(t, err)
+
+	Ok(t, client.HideComments(giteaRepo, giteaPull, "<!-- marker -->"))
+	body, err := json.Marshal(map[string]string{"body": vcs.OutdatedComment("<!-- marker -->", "<!-- marker -->\nfirst")})
+	Ok(t, err)
+	Equals(t, []string{
+		"GET /api/v1/repos/owner/repo/issues/2/comments ",
+		"PATCH /api/v1/repos/owner/repo/issues/comments/1 " + string(body),
+	}, *requests)
+}
+
+func TestGiteaClient_PullIsApproved(t *testing.T) {
+	cases := []struct {
+		reviews string
+		exp     bool
+	}{
+		{`[]`, false},
+		{`[{"state": "COMMENT"}, {"state": "REQUEST_CHANGES"}]`, false},
+		{`[{"state": "APPROVED", "dismissed": true}]`, false},
+		{`[{"state": "COMMENT"}, {"state": "APPROVED", "dismissed": false}]`, true},
+	}
+	for _, c := range cases {
+		t.Log("when the reviews are " + c.reviews)
+		server, _ := giteaServer(t, map[string]string{
+			"/api/v1/repos/owner/repo/pulls/2/reviews": c.reviews,
+		})
+		client, err := vcs.NewGiteaClient(server.URL, "gitea-user", "gitea-token")
+		Ok(t, err)
+		approved, err := client.PullIsApproved(giteaRepo, giteaPull)
+		server.Close()
+		Ok(t, err)
+		Equals(t, c.exp, approved)
+	}
+}
+
+func TestGiteaClient_UserHasWriteAccess(t *testing.T) {
+	for perm, exp := range map[string]bool{"owner": true, "admin": true, "write": true, "read": false, "none": false} {
+		t.Log("when the user's permission is " + perm)
+		server, _ := giteaServer(t, map[string]string{
+			"/api/v1/repos/owner/repo/collaborators/user/permission": fmt.Sprintf(`{"permission": %q}`, perm),
+		})
+		client, err := vcs.NewGiteaClient(server.URL, "gitea-user", "gitea-token")
+		Ok(t, err)
+		hasAccess, err := client.UserHasWriteAccess(giteaRepo, models.User{Username: "user"})
+		server.Close()
+		Ok(t, err)
+		Equals(t, exp, hasAccess)
+	}
+}
+
+func TestGiteaClient_UpdateStatus(t *testing.T) {
+	t.Log("should set a status with the context, target URL and gitea's name for the state")
+	server, requests := giteaServer(t, map[string]string{
+		"/api/v1/repos/owner/repo/statuses/sha": `{"id": 1}`,
+	})
+	defer server.Close()
+	client, err := vcs.NewGiteaClient(server.URL, "gitea-user", "gitea-token")
+	Ok(t, err)
+
+	Ok(t, client.UpdateStatus(giteaRepo, giteaPull, vcs.Failed, "atlantis/plan", "Plan Failed", "https://atlantis.example.com/jobs/1"))
+	Equals(t, 1, len(*requests))
+	var status map[string]string
+	Ok(t, json.Unmarshal([]byte((*requests)[0][len("POST /api/v1/repos/owner/repo/statuses/sha "):]), &status))
+	Equals(t, map[string]string{
+		"state":       "failure",
+		"context":     "atlantis/plan",
+		"description": "Plan Failed",
+		"target_url":  "https://atlantis.example.com/jobs/1",
+	}, status)
+}
+
+func TestGiteaClient_GetPullRequest(t *testing.T) {
+	t.Log("should return the pull request")
+	server, _ := giteaServer(t, map[string]string{
+		"/api/v1/repos/owner/repo/pulls/2": fixtures.GiteaPullRequestJSON,
+	})
+	defer server.Close()
+	client, err := vcs.NewGiteaClient(server.URL, "gitea-user", "gitea-token")
+	Ok(t, err)
+
+	pull, err := client.GetPullRequest(giteaRepo, 2)
+	Ok(t, err)
+	Equals(t, 2, pull.Number)
+	Equals(t, "add-null-resource", pull.Head.Ref)
+	Equals(t, "author/repo", pull.Head.Repo.FullName)
+}
+
+func TestGiteaClient_ErrorResponse(t *testing.T) {
+	t.Log("should return an error with the response body if the api responds with an error")
+	server, _ := giteaServer(t, nil)
+	defer server.Close()
+	client, err := vcs.NewGiteaClient(server.URL, "gitea-user", "gitea-token")
+	Ok(t, err)
+
+	_, err = client.GetPullRequest(giteaRepo, 2)
+	Assert(t, err != nil, "expected error")
+	Equals(t, `GET /api/v1/repos/owner/repo/pulls/2: 404 {"message": "not found"}`, err.Error())
+}
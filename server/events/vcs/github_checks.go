@@ -0,0 +1,126 @@
+package vcs
+
+import (
+	"fmt"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+)
+
+// githubChecksAcceptHeader is required by the GitHub API for the Checks API
+// while it's in preview.
+const githubChecksAcceptHeader = "application/vnd.github.antiope-preview+json"
+
+// maxCheckRunTextLength is the maximum length GitHub accepts for the summary
+// and text of a check run.
+const maxCheckRunTextLength = 65535
+
+// maxCheckRunAnnotations is the maximum number of annotations GitHub accepts
+// per request.
+const maxCheckRunAnnotations = 50
+
+// CheckRun is a GitHub check run.
+// See https://developer.github.com/v3/checks/runs/.
+type CheckRun struct {
+	// Name identifies the check run, ex. "atlantis/plan: dir/default".
+	// Creating a check run with the same name as an existing one replaces it
+	// in the pull request UI.
+	Name string
+	// Status is Pending while the command is running and Success or Failed
+	// once it's completed.
+	Status CommitStatus
+	// Title is a short description shown next to the check run.
+	Title string
+	// Summary is markdown that's shown at the top of the check run.
+	Summary string
+	// Text is markdown that's shown below the summary, ex. the full output.
+	Text        string
+	Annotations []CheckRunAnnotation
+	// DetailsURL is linked from the check run, ex. to the command's output.
+	DetailsURL string
+}
+
+// CheckRunAnnotation is a message attached to a specific line of a file.
+type CheckRunAnnotation struct {
+	// Path is relative to the root of the repo.
+	Path    string
+	Line    int
+	Message string
+}
+
+// githubCheckRun is the request body for creating a check run.
+type githubCheckRun struct {
+	Name       string               `json:"name"`
+	HeadSHA    string               `json:"head_sha"`
+	Status     string               `json:"status"`
+	Conclusion string               `json:"conclusion,omitempty"`
+	DetailsURL string               `json:"details_url,omitempty"`
+	Output     githubCheckRunOutput `json:"output"`
+}
+
+type githubCheckRunOutput struct {
+	Title       string                     `json:"title"`
+	Summary     string                     `json:"summary"`
+	Text        string                     `json:"text,omitempty"`
+	Annotations []githubCheckRunAnnotation `json:"annotations,omitempty"`
+}
+
+type githubCheckRunAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Message         string `json:"message"`
+}
+
+// CreateCheckRun creates a check run on the head commit of the pull request.
+// Only GitHub Apps can create check runs.
+func (g *GithubClient) CreateCheckRun(repo models.Repo, pull models.PullRequest, run CheckRun) error {
+	body := githubCheckRun{
+		Name:       run.Name,
+		HeadSHA:    pull.HeadCommit,
+		Status:     "completed",
+		DetailsURL: run.DetailsURL,
+		Output: githubCheckRunOutput{
+			Title:   run.Title,
+			Summary: truncateCheckRunText(run.Summary),
+			Text:    truncateCheckRunText(run.Text),
+		},
+	}
+	switch run.Status {
+	case Pending:
+		body.Status = "in_progress"
+	case Success:
+		body.Conclusion = "success"
+	default:
+		body.Conclusion = "failure"
+	}
+	for i, a := range run.Annotations {
+		if i == maxCheckRunAnnotations {
+			break
+		}
+		body.Output.Annotations = append(body.Output.Annotations, githubCheckRunAnnotation{
+			Path:            a.Path,
+			StartLine:       a.Line,
+			EndLine:         a.Line,
+			AnnotationLevel: "failure",
+			Message:         a.Message,
+		})
+	}
+
+	req, err := g.client.NewRequest("POST", fmt.Sprintf("repos/%s/%s/check-runs", repo.Owner, repo.Name), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", githubChecksAcceptHeader)
+	_, err = g.client.Do(g.ctx, req, nil)
+	return err
+}
+
+// truncateCheckRunText truncates s so it fits in a check run.
+func truncateCheckRunText(s string) string {
+	const truncated = "\n\n**Warning**: Output truncated."
+	if len(s) <= maxCheckRunTextLength {
+		return s
+	}
+	return s[:maxCheckRunTextLength-len(truncated)] + truncated
+}
@@ -2,8 +2,8 @@ package vcs
 
 import (
 	"context"
-	"fmt"
-	"net/url"
+	"encoding/json"
+	"strconv"
 	"strings"
 
 	"github.com/google/go-github/github"
@@ -13,32 +13,33 @@ import (
 
 // GithubClient is used to perform GitHub actions.
 type GithubClient struct {
-	client *github.Client
-	ctx    context.Context
+	client      *github.Client
+	credentials GithubCredentials
+	ctx         context.Context
 }
 
 // NewGithubClient returns a valid GitHub client.
-func NewGithubClient(hostname string, user string, pass string) (*GithubClient, error) {
-	tp := github.BasicAuthTransport{
-		Username: strings.TrimSpace(user),
-		Password: strings.TrimSpace(pass),
+func NewGithubClient(hostname string, credentials GithubCredentials) (*GithubClient, error) {
+	httpClient, err := credentials.Client()
+	if err != nil {
+		return nil, errors.Wrap(err, "creating http client")
 	}
-	client := github.NewClient(tp.Client())
+	client := github.NewClient(httpClient)
 	// If we're using github.com then we don't need to do any additional configuration
 	// for the client. It we're using Github Enterprise, then we need to manually
 	// set the base url for the API.
 	if hostname != "github.com" {
-		baseURL := fmt.Sprintf("https://%s/api/v3/", hostname)
-		base, err := url.Parse(baseURL)
+		base, err := githubAPIURL(hostname)
 		if err != nil {
-			return nil, errors.Wrapf(err, "Invalid github hostname trying to parse %s", baseURL)
+			return nil, err
 		}
 		client.BaseURL = base
 	}
 
 	return &GithubClient{
-		client: client,
-		ctx:    context.Background(),
+		client:      client,
+		credentials: credentials,
+		ctx:         context.Background(),
 	}, nil
 }
 
@@ -75,6 +76,123 @@ func (g *GithubClient) CreateComment(repo models.Repo, pull models.PullRequest,
 	return err
 }
 
+// FindComment returns the most recent comment on the pull request that was
+// made by Atlantis and whose body starts with marker or nil if there isn't one.
+func (g *GithubClient) FindComment(repo models.Repo, pull models.PullRequest, marker string) (*Comment, error) {
+	login, err := g.credentials.GetLogin()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting login")
+	}
+	var found *Comment
+	nextPage := 0
+	for {
+		opts := github.IssueListCommentsOptions{
+			ListOptions: github.ListOptions{PerPage: 100, Page: nextPage},
+		}
+		comments, resp, err := g.client.Issues.ListComments(g.ctx, repo.Owner, repo.Name, pull.Num, &opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing comments")
+		}
+		// Comments are returned oldest first so the last match is the most
+		// recent.
+		for _, c := range comments {
+			if strings.EqualFold(c.User.GetLogin(), login) && strings.HasPrefix(c.GetBody(), marker) {
+				found = &Comment{ID: strconv.Itoa(c.GetID()), Body: c.GetBody()}
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+	return found, nil
+}
+
+// UpdateComment replaces the body of the comment with id.
+func (g *GithubClient) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string) error {
+	commentID, err := strconv.Atoi(id)
+	if err != nil {
+		return errors.Wrapf(err, "invalid comment id %q", id)
+	}
+	_, _, err = g.client.Issues.EditComment(g.ctx, repo.Owner, repo.Name, commentID, &github.IssueComment{Body: &comment})
+	return err
+}
+
+// HideComments minimizes the comments on the pull request that were made by
+// Atlantis and whose body starts with marker, using the "outdated" reason.
+// Minimizing is only available through the GraphQL API.
+func (g *GithubClient) HideComments(repo models.Repo, pull models.PullRequest, marker string) error {
+	const listQuery = `query($owner: String!, $name: String!, $number: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      comments(first: 100, after: $after) {
+        nodes { id body isMinimized author { __typename login } }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}`
+	const minimizeMutation = `mutation($id: ID!) {
+  minimizeComment(input: {subjectId: $id, classifier: OUTDATED}) { clientMutationId }
+}`
+	login, err := g.credentials.GetLogin()
+	if err != nil {
+		return errors.Wrap(err, "getting login")
+	}
+	var ids []string
+	var after *string
+	for {
+		var data struct {
+			Repository struct {
+				PullRequest struct {
+					Comments struct {
+						Nodes []struct {
+							ID          string `json:"id"`
+							Body        string `json:"body"`
+							IsMinimized bool   `json:"isMinimized"`
+							Author      struct {
+								Typename string `json:"__typename"`
+								Login    string `json:"login"`
+							} `json:"author"`
+						} `json:"nodes"`
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+					} `json:"comments"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		vars := map[string]interface{}{"owner": repo.Owner, "name": repo.Name, "number": pull.Num, "after": after}
+		if err := g.graphql(listQuery, vars, &data); err != nil {
+			return errors.Wrap(err, "listing comments")
+		}
+		comments := data.Repository.PullRequest.Comments
+		for _, c := range comments.Nodes {
+			// GraphQL leaves the "[bot]" suffix off the logins of bots
+			// that the REST API and GetLogin include.
+			author := c.Author.Login
+			if c.Author.Typename == "Bot" {
+				author += "[bot]"
+			}
+			if !c.IsMinimized && strings.EqualFold(author, login) && strings.HasPrefix(c.Body, marker) {
+				ids = append(ids, c.ID)
+			}
+		}
+		if !comments.PageInfo.HasNextPage {
+			break
+		}
+		cursor := comments.PageInfo.EndCursor
+		after = &cursor
+	}
+	for _, id := range ids {
+		if err := g.graphql(minimizeMutation, map[string]interface{}{"id": id}, nil); err != nil {
+			return errors.Wrapf(err, "minimizing comment %s", id)
+		}
+	}
+	return nil
+}
+
 // PullIsApproved returns true if the pull request was approved.
 func (g *GithubClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
 	reviews, _, err := g.client.PullRequests.ListReviews(g.ctx, repo.Owner, repo.Name, pull.Num, nil)
@@ -89,16 +207,30 @@ func (g *GithubClient) PullIsApproved(repo models.Repo, pull models.PullRequest)
 	return false, nil
 }
 
+// UserHasWriteAccess returns true if user has admin or write permissions
+// on repo.
+func (g *GithubClient) UserHasWriteAccess(repo models.Repo, user models.User) (bool, error) {
+	perm, _, err := g.client.Repositories.GetPermissionLevel(g.ctx, repo.Owner, repo.Name, user.Username)
+	if err != nil {
+		return false, errors.Wrap(err, "getting permission level")
+	}
+	switch perm.GetPermission() {
+	case "admin", "write":
+		return true, nil
+	}
+	return false, nil
+}
+
 // GetPullRequest returns the pull request.
 func (g *GithubClient) GetPullRequest(repo models.Repo, num int) (*github.PullRequest, error) {
 	pull, _, err := g.client.PullRequests.Get(g.ctx, repo.Owner, repo.Name, num)
 	return pull, err
 }
 
-// UpdateStatus updates the status badge on the pull request.
+// UpdateStatus updates the status badge on the pull request. Statuses with
+// different contexts are shown separately.
 // See https://github.com/blog/1227-commit-status-api.
-func (g *GithubClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, description string) error {
-	const statusContext = "Atlantis"
+func (g *GithubClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, statusContext string, description string, targetURL string) error {
 	ghState := "error"
 	switch state {
 	case Pending:
@@ -112,6 +244,39 @@ func (g *GithubClient) UpdateStatus(repo models.Repo, pull models.PullRequest, s
 		State:       github.String(ghState),
 		Description: github.String(description),
 		Context:     github.String(statusContext)}
+	if targetURL != "" {
+		status.TargetURL = github.String(targetURL)
+	}
 	_, _, err := g.client.Repositories.CreateStatus(g.ctx, repo.Owner, repo.Name, pull.HeadCommit, status)
 	return err
 }
+
+// graphql runs query against GitHub's GraphQL API and decodes its data into
+// out if out isn't nil.
+func (g *GithubClient) graphql(query string, variables map[string]interface{}, out interface{}) error {
+	// The GraphQL endpoint is /graphql on api.github.com and /api/graphql on
+	// GitHub Enterprise so it's always one level above the REST base url.
+	req, err := g.client.NewRequest("POST", "../graphql", map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := g.client.Do(g.ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return errors.New(resp.Errors[0].Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
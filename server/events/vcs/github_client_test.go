@@ -0,0 +1,129 @@
+package vcs_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+var githubRepo = models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+var githubPull = models.PullRequest{Num: 1, HeadCommit: "sha"}
+
+// serverCredentials are GithubCredentials that trust an httptest server.
+type serverCredentials struct {
+	client *http.Client
+}
+
+func (s serverCredentials) Client() (*http.Client, error) { return s.client, nil }
+func (s serverCredentials) GetUser() string               { return "user" }
+func (s serverCredentials) GetToken() (string, error)     { return "token", nil }
+func (s serverCredentials) GetLogin() (string, error)     { return "user", nil }
+
+func TestGithubClient_FindAndUpdateComment(t *testing.T) {
+	t.Log("should page through the comments for the most recent one by Atlantis that starts with the marker and update it")
+	var requests []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		Ok(t, err)
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), strings.TrimSpace(string(body))))
+		switch r.URL.RequestURI() {
+		case "/api/v3/repos/owner/repo/issues/1/comments?per_page=100":
+			w.Header().Set("Link", `<https://github.example.com/api/v3/repos/owner/repo/issues/1/comments?page=2&per_page=100>; rel="next"`)
+			fmt.Fprint(w, `[{"id": 1, "body": "<!-- marker -->\nfirst", "user": {"login": "user"}}, {"id": 2, "body": "other", "user": {"login": "user"}}]`) // nolint: errcheck
+		case "/api/v3/repos/owner/repo/issues/1/comments?page=2&per_page=100":
+			fmt.Fprint(w, `[{"id": 3, "body": "<!-- marker -->\nsecond", "user": {"login": "User"}}, {"id": 4, "body": "<!-- marker -->\nquoted", "user": {"login": "someone"}}]`) // nolint: errcheck
+		default:
+			fmt.Fprint(w, `{}`) // nolint: errcheck
+		}
+	}))
+	defer server.Close()
+	client, err := vcs.NewGithubClient(strings.TrimPrefix(server.URL, "https://"), serverCredentials{tlsClient(t, server)})
+	Ok(t, err)
+
+	comment, err := client.FindComment(githubRepo, githubPull, "<!-- marker -->")
+	Ok(t, err)
+	Equals(t, &vcs.Comment{ID: "3", Body: "<!-- marker -->\nsecond"}, comment)
+	Ok(t, client.UpdateComment(githubRepo, githubPull, comment.ID, "comment"))
+
+	Equals(t, []string{
+		"GET /api/v3/repos/owner/repo/issues/1/comments?per_page=100 ",
+		"GET /api/v3/repos/owner/repo/issues/1/comments?page=2&per_page=100 ",
+		`PATCH /api/v3/repos/owner/repo/issues/comments/3 {"body":"comment"}`,
+	}, requests)
+}
+
+func TestGithubClient_HideComments(t *testing.T) {
+	t.Log("should page through the comments with GraphQL and minimize the ones by Atlantis that start with the marker and aren't minimized")
+	var queries []string
+	var minimized []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "POST /api/graphql", r.Method+" "+r.URL.Path)
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		Ok(t, json.NewDecoder(r.Body).Decode(&req))
+		switch {
+		case strings.HasPrefix(req.Query, "mutation"):
+			minimized = append(minimized, req.Variables["id"].(string))
+			fmt.Fprint(w, `{"data": {"minimizeComment": {"clientMutationId": null}}}`) // nolint: errcheck
+		case req.Variables["after"] == nil:
+			queries = append(queries, fmt.Sprintf("%s/%s#%v", req.Variables["owner"], req.Variables["name"], req.Variables["number"]))
+			fmt.Fprint(w, `{"data": {"repository": {"pullRequest": {"comments": {
+				"nodes": [
+					{"id": "a", "body": "<!-- marker -->\nfirst", "isMinimized": true, "author": {"__typename": "User", "login": "user"}},
+					{"id": "b", "body": "<!-- marker -->\nsecond", "author": {"__typename": "User", "login": "user"}},
+					{"id": "c", "body": "other", "author": {"__typename": "User", "login": "user"}},
+					{"id": "e", "body": "<!-- marker -->\nquoted", "author": {"__typename": "User", "login": "someone"}},
+					{"id": "f", "body": "<!-- marker -->\nother app", "author": {"__typename": "Bot", "login": "user"}}
+				],
+				"pageInfo": {"hasNextPage": true, "endCursor": "cursor"}}}}}}`) // nolint: errcheck
+		default:
+			queries = append(queries, fmt.Sprintf("after %s", req.Variables["after"]))
+			fmt.Fprint(w, `{"data": {"repository": {"pullRequest": {"comments": {
+				"nodes": [{"id": "d", "body": "<!-- marker -->\nthird", "author": {"__typename": "User", "login": "user"}}],
+				"pageInfo": {"hasNextPage": false, "endCursor": "cursor2"}}}}}}`) // nolint: errcheck
+		}
+	}))
+	defer server.Close()
+	client, err := vcs.NewGithubClient(strings.TrimPrefix(server.URL, "https://"), serverCredentials{tlsClient(t, server)})
+	Ok(t, err)
+
+	Ok(t, client.HideComments(githubRepo, githubPull, "<!-- marker -->"))
+	Equals(t, []string{"owner/repo#1", "after cursor"}, queries)
+	Equals(t, []string{"b", "d"}, minimized)
+}
+
+func TestGithubClient_HideCommentsGraphQLError(t *testing.T) {
+	t.Log("should return GraphQL errors")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": null, "errors": [{"message": "Could not resolve to a Repository"}]}`) // nolint: errcheck
+	}))
+	defer server.Close()
+	client, err := vcs.NewGithubClient(strings.TrimPrefix(server.URL, "https://"), serverCredentials{tlsClient(t, server)})
+	Ok(t, err)
+
+	err = client.HideComments(githubRepo, githubPull, "<!-- marker -->")
+	Assert(t, err != nil, "expected error")
+	Equals(t, "listing comments: Could not resolve to a Repository", err.Error())
+}
+
+func TestGithubClient_UpdateCommentInvalidID(t *testing.T) {
+	t.Log("should error if the comment id isn't a number")
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	client, err := vcs.NewGithubClient(strings.TrimPrefix(server.URL, "https://"), serverCredentials{tlsClient(t, server)})
+	Ok(t, err)
+
+	err = client.UpdateComment(githubRepo, githubPull, "1/2", "comment")
+	Assert(t, err != nil, "expected error")
+	Assert(t, strings.HasPrefix(err.Error(), `invalid comment id "1/2"`), "unexpected error %q", err)
+}
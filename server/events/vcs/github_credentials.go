@@ -0,0 +1,308 @@
+package vcs
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// githubAppAcceptHeader is required by the GitHub API for GitHub App
+// endpoints while they're in preview.
+const githubAppAcceptHeader = "application/vnd.github.machine-man-preview+json"
+
+// githubAppTokenUser is the username that must be used alongside an
+// installation token when cloning over HTTPS.
+const githubAppTokenUser = "x-access-token"
+
+// tokenRefreshBuffer is how long before an installation token expires that
+// we'll mint a new one. This ensures a token we hand out can be used for
+// at least this long, ex. for a git clone.
+const tokenRefreshBuffer = 5 * time.Minute
+
+// GithubCredentials handles authenticating API calls and git clones to GitHub.
+type GithubCredentials interface {
+	// Client returns an http.Client that authenticates its requests with
+	// these credentials.
+	Client() (*http.Client, error)
+	// GetUser returns the username to use alongside GetToken when cloning.
+	GetUser() string
+	// GetToken returns a token that can be used to clone over HTTPS.
+	GetToken() (string, error)
+	// GetLogin returns the login that comments made with these credentials
+	// are authored by.
+	GetLogin() (string, error)
+}
+
+// GithubUserCredentials authenticates as a GitHub user with a personal access
+// token.
+type GithubUserCredentials struct {
+	User  string
+	Token string
+}
+
+// Client returns a client that uses basic auth.
+func (c *GithubUserCredentials) Client() (*http.Client, error) {
+	tr := &github.BasicAuthTransport{
+		Username: strings.TrimSpace(c.User),
+		Password: strings.TrimSpace(c.Token),
+	}
+	return tr.Client(), nil
+}
+
+// GetUser returns the GitHub user.
+func (c *GithubUserCredentials) GetUser() string {
+	return c.User
+}
+
+// GetToken returns the user's token.
+func (c *GithubUserCredentials) GetToken() (string, error) {
+	return c.Token, nil
+}
+
+// GetLogin returns the GitHub user.
+func (c *GithubUserCredentials) GetLogin() (string, error) {
+	return c.User, nil
+}
+
+// GithubAppCredentials authenticates as a GitHub App installation.
+// Installation tokens expire after an hour so they're minted lazily and
+// refreshed automatically before they expire.
+// See https://developer.github.com/apps/building-github-apps/authentication-options-for-github-apps/.
+type GithubAppCredentials struct {
+	// AppID is the ID of the GitHub App.
+	AppID int64
+	// Key is the PEM encoded private key generated for the GitHub App.
+	Key []byte
+	// Hostname is the hostname of the GitHub installation, ex. "github.com".
+	Hostname string
+	// Transport is the underlying transport used for requests. If nil,
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	mutex          sync.Mutex
+	installationID int64
+	token          string
+	expiresAt      time.Time
+	login          string
+}
+
+// installationToken is the response from the access_tokens endpoint.
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Client returns a client that authenticates with an installation token.
+func (c *GithubAppCredentials) Client() (*http.Client, error) {
+	return &http.Client{Transport: &githubAppTransport{credentials: c}}, nil
+}
+
+// GetUser returns the username GitHub requires when cloning with an
+// installation token.
+func (c *GithubAppCredentials) GetUser() string {
+	return githubAppTokenUser
+}
+
+// GetToken returns a valid installation token, minting a new one if the
+// current one is missing or about to expire.
+func (c *GithubAppCredentials) GetToken() (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.token != "" && time.Now().Add(tokenRefreshBuffer).Before(c.expiresAt) {
+		return c.token, nil
+	}
+	jwt, err := c.jwt()
+	if err != nil {
+		return "", err
+	}
+	if c.installationID == 0 {
+		id, err := c.getInstallationID(jwt)
+		if err != nil {
+			return "", err
+		}
+		c.installationID = id
+	}
+	var tok installationToken
+	if err := c.appRequest("POST", fmt.Sprintf("app/installations/%d/access_tokens", c.installationID), jwt, &tok); err != nil {
+		return "", errors.Wrap(err, "creating installation token")
+	}
+	c.token = tok.Token
+	c.expiresAt = tok.ExpiresAt
+	return c.token, nil
+}
+
+// GetLogin returns the login of the app's bot user, ex. "my-atlantis[bot]".
+// It's looked up from the app's slug the first time it's needed.
+func (c *GithubAppCredentials) GetLogin() (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.login != "" {
+		return c.login, nil
+	}
+	jwt, err := c.jwt()
+	if err != nil {
+		return "", err
+	}
+	var app struct {
+		Slug string `json:"slug"`
+	}
+	if err := c.appRequest("GET", "app", jwt, &app); err != nil {
+		return "", errors.Wrap(err, "getting app")
+	}
+	c.login = app.Slug + "[bot]"
+	return c.login, nil
+}
+
+// getInstallationID returns the ID of the single installation of this app.
+func (c *GithubAppCredentials) getInstallationID(jwt string) (int64, error) {
+	var installations []struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.appRequest("GET", "app/installations", jwt, &installations); err != nil {
+		return 0, errors.Wrap(err, "listing installations")
+	}
+	if len(installations) != 1 {
+		return 0, fmt.Errorf("expected GitHub App %d to have 1 installation but found %d", c.AppID, len(installations))
+	}
+	return installations[0].ID, nil
+}
+
+// appRequest makes a request to the GitHub API authenticated as the app itself
+// and decodes the JSON response into v.
+func (c *GithubAppCredentials) appRequest(method string, path string, jwt string, v interface{}) error {
+	baseURL, err := githubAPIURL(c.Hostname)
+	if err != nil {
+		return err
+	}
+	u, err := baseURL.Parse(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", githubAppAcceptHeader)
+	resp, err := c.transport().RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s returned %d", method, u.String(), resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// jwt returns a JSON Web Token signed with the app's private key. It's used to
+// authenticate as the app itself, ex. to create installation tokens.
+func (c *GithubAppCredentials) jwt() (string, error) {
+	block, _ := pem.Decode(c.Key)
+	if block == nil {
+		return "", errors.New("GitHub App key is not PEM encoded")
+	}
+	key, err := parseRSAPrivateKey(block.Bytes)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing GitHub App key")
+	}
+
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		// Backdate the issued at time to allow for clock drift.
+		"iat": now.Add(-1 * time.Minute).Unix(),
+		// GitHub allows a maximum of 10 minutes.
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": c.AppID,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hashed := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", errors.Wrap(err, "signing JWT")
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// parseRSAPrivateKey parses a PKCS1 key (which is what GitHub generates) or
+// a PKCS8 key (in case it's been converted).
+func parseRSAPrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+func (c *GithubAppCredentials) transport() http.RoundTripper {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	return http.DefaultTransport
+}
+
+// githubAppTransport adds the installation token to each request.
+type githubAppTransport struct {
+	credentials *GithubAppCredentials
+}
+
+func (t *githubAppTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.credentials.GetToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting GitHub App installation token")
+	}
+	// RoundTrippers must not modify the original request.
+	authed := new(http.Request)
+	*authed = *req
+	authed.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		authed.Header[k] = v
+	}
+	authed.Header.Set("Authorization", "token "+token)
+	return t.credentials.transport().RoundTrip(authed)
+}
+
+// githubAPIURL returns the base URL of the GitHub API for hostname.
+func githubAPIURL(hostname string) (*url.URL, error) {
+	// If we're using github.com then the API is at api.github.com. If we're
+	// using GitHub Enterprise then the API is under /api/v3/.
+	if hostname == "github.com" {
+		return url.Parse("https://api.github.com/")
+	}
+	baseURL := fmt.Sprintf("https://%s/api/v3/", hostname)
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid github hostname trying to parse %s", baseURL)
+	}
+	return base, nil
+}
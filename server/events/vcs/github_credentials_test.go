@@ -0,0 +1,140 @@
+package vcs_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestGithubAppCredentials_GetToken(t *testing.T) {
+	t.Log("should find the installation and mint an installation token")
+	tokenRequests := 0
+	server := githubAppServer(t, time.Hour, &tokenRequests)
+	defer server.Close()
+	creds := githubAppCredentials(t, server)
+
+	token, err := creds.GetToken()
+	Ok(t, err)
+	Equals(t, "token-1", token)
+	Equals(t, "x-access-token", creds.GetUser())
+
+	t.Log("should cache the token until it's about to expire")
+	token, err = creds.GetToken()
+	Ok(t, err)
+	Equals(t, "token-1", token)
+	Equals(t, 1, tokenRequests)
+}
+
+func TestGithubAppCredentials_GetTokenRefresh(t *testing.T) {
+	t.Log("should mint a new token if the current one is about to expire")
+	tokenRequests := 0
+	server := githubAppServer(t, time.Minute, &tokenRequests)
+	defer server.Close()
+	creds := githubAppCredentials(t, server)
+
+	token, err := creds.GetToken()
+	Ok(t, err)
+	Equals(t, "token-1", token)
+	token, err = creds.GetToken()
+	Ok(t, err)
+	Equals(t, "token-2", token)
+}
+
+func TestGithubAppCredentials_InvalidKey(t *testing.T) {
+	t.Log("should error if the key isn't PEM encoded")
+	creds := &vcs.GithubAppCredentials{
+		AppID:    1,
+		Key:      []byte("not a key"),
+		Hostname: "github.com",
+	}
+	_, err := creds.GetToken()
+	Assert(t, err != nil, "expected error")
+	Assert(t, strings.Contains(err.Error(), "not PEM encoded"), "unexpected error %q", err)
+}
+
+func TestGithubAppCredentials_GetLogin(t *testing.T) {
+	t.Log("should return the login of the app's bot user")
+	tokenRequests := 0
+	server := githubAppServer(t, time.Hour, &tokenRequests)
+	defer server.Close()
+	creds := githubAppCredentials(t, server)
+
+	login, err := creds.GetLogin()
+	Ok(t, err)
+	Equals(t, "my-atlantis[bot]", login)
+}
+
+func TestNewGithubClient_AppCredentials(t *testing.T) {
+	t.Log("API requests should be authenticated with the installation token")
+	tokenRequests := 0
+	server := githubAppServer(t, time.Hour, &tokenRequests)
+	defer server.Close()
+	creds := githubAppCredentials(t, server)
+
+	client, err := vcs.NewGithubClient(creds.Hostname, creds)
+	Ok(t, err)
+	Ok(t, client.CreateComment(models.Repo{Owner: "owner", Name: "repo"}, models.PullRequest{Num: 1}, "comment"))
+}
+
+// githubAppServer returns a stand-in for a GitHub Enterprise API with a single
+// app installation. Tokens it mints are valid for tokenTTL.
+func githubAppServer(t *testing.T, tokenTTL time.Duration, tokenRequests *int) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v3/app":
+			assertJWT(t, r)
+			fmt.Fprint(w, `{"id": 1, "slug": "my-atlantis"}`) // nolint: errcheck
+		case r.Method == "GET" && r.URL.Path == "/api/v3/app/installations":
+			assertJWT(t, r)
+			fmt.Fprint(w, `[{"id": 42}]`) // nolint: errcheck
+		case r.Method == "POST" && r.URL.Path == "/api/v3/app/installations/42/access_tokens":
+			assertJWT(t, r)
+			*tokenRequests++
+			fmt.Fprintf(w, `{"token": "token-%d", "expires_at": %q}`, *tokenRequests, time.Now().Add(tokenTTL).Format(time.RFC3339)) // nolint: errcheck
+		case r.Method == "POST" && r.URL.Path == "/api/v3/repos/owner/repo/issues/1/comments":
+			Equals(t, "token token-1", r.Header.Get("Authorization"))
+			fmt.Fprint(w, `{}`) // nolint: errcheck
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func githubAppCredentials(t *testing.T, server *httptest.Server) *vcs.GithubAppCredentials {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Ok(t, err)
+	return &vcs.GithubAppCredentials{
+		AppID:     1,
+		Key:       pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		Hostname:  strings.TrimPrefix(server.URL, "https://"),
+		Transport: tlsClient(t, server).Transport,
+	}
+}
+
+func assertJWT(t *testing.T, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	Assert(t, strings.HasPrefix(auth, "Bearer "), "expected bearer auth but got %q", auth)
+	Equals(t, 3, len(strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")))
+}
+
+// tlsClient returns an http client that trusts server's certificate.
+func tlsClient(t *testing.T, server *httptest.Server) *http.Client {
+	cert, err := x509.ParseCertificate(server.TLS.Certificates[0].Certificate[0])
+	Ok(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+}
@@ -1,15 +1,68 @@
 package vcs
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/hootsuite/atlantis/server/events/models"
 	"github.com/lkysow/go-gitlab"
+	"github.com/pkg/errors"
 )
 
 type GitlabClient struct {
 	Client *gitlab.Client
+	// user is the username Atlantis' notes are authored by.
+	user string
+}
+
+// NewGitlabClient returns a client for the GitLab installation at hostname
+// that authenticates as user with token. If caFile is set, the certificates in it are trusted in addition to the
+// system's certificates, ex. for installations using an internal CA.
+func NewGitlabClient(hostname string, user string, token string, caFile string) (*GitlabClient, error) {
+	httpClient, err := httpClientWithCA(caFile)
+	if err != nil {
+		return nil, err
+	}
+	client := gitlab.NewClient(httpClient, token)
+	// If we're using gitlab.com then the client's default base url is
+	// correct. Otherwise we need to point it at the self-hosted installation.
+	if hostname != "gitlab.com" {
+		baseURL := fmt.Sprintf("https://%s/api/v4/", hostname)
+		if err := client.SetBaseURL(baseURL); err != nil {
+			return nil, errors.Wrapf(err, "invalid gitlab hostname trying to parse %s", baseURL)
+		}
+	}
+	return &GitlabClient{Client: client, user: user}, nil
+}
+
+// httpClientWithCA returns an http client that trusts the certificates in
+// caFile as well as the system's certificates. If caFile is empty it returns
+// nil so the default client is used.
+func httpClientWithCA(caFile string) (*http.Client, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading CA file")
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM encoded certificates found in %s", caFile)
+	}
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}}, nil
 }
 
 // GetModifiedFiles returns the names of files that were modified in the merge request.
@@ -53,6 +106,78 @@ func (g *GitlabClient) CreateComment(repo models.Repo, pull models.PullRequest,
 	return err
 }
 
+// FindComment returns the most recent note on the merge request that was made
+// by Atlantis and whose body starts with marker or nil if there isn't one.
+func (g *GitlabClient) FindComment(repo models.Repo, pull models.PullRequest, marker string) (*Comment, error) {
+	notes, err := g.matchingNotes(repo, pull, marker)
+	if err != nil || len(notes) == 0 {
+		return nil, err
+	}
+	// Notes are returned newest first so the first match is the most recent.
+	return &Comment{ID: strconv.Itoa(notes[0].ID), Body: notes[0].Body}, nil
+}
+
+// HideComments marks the notes on the merge request that were made by
+// Atlantis and whose body starts with marker as outdated. GitLab can't collapse notes so we edit them instead.
+func (g *GitlabClient) HideComments(repo models.Repo, pull models.PullRequest, marker string) error {
+	notes, err := g.matchingNotes(repo, pull, marker)
+	if err != nil {
+		return err
+	}
+	for _, n := range notes {
+		if err := g.UpdateComment(repo, pull, strconv.Itoa(n.ID), OutdatedComment(marker, n.Body)); err != nil {
+			return errors.Wrapf(err, "hiding note %d", n.ID)
+		}
+	}
+	return nil
+}
+
+// matchingNotes returns the notes on the merge request, newest first, that
+// were made by Atlantis and whose body starts with marker. System notes, ex.
+// "added 1 commit", are skipped.
+func (g *GitlabClient) matchingNotes(repo models.Repo, pull models.PullRequest, marker string) ([]*gitlab.Note, error) {
+	const maxPerPage = 100
+	var matches []*gitlab.Note
+	nextPage := 1
+	// Constructing the api url by hand so we can do pagination.
+	apiURL := fmt.Sprintf("projects/%s/merge_requests/%d/notes", url.QueryEscape(repo.FullName), pull.Num)
+	for {
+		opts := gitlab.ListOptions{
+			Page:    nextPage,
+			PerPage: maxPerPage,
+		}
+		req, err := g.Client.NewRequest("GET", apiURL, opts, nil)
+		if err != nil {
+			return nil, err
+		}
+		var notes []*gitlab.Note
+		resp, err := g.Client.Do(req, &notes)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing notes")
+		}
+		for _, n := range notes {
+			if !n.System && strings.EqualFold(n.Author.Username, g.user) && strings.HasPrefix(n.Body, marker) {
+				matches = append(matches, n)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+	return matches, nil
+}
+
+// UpdateComment replaces the body of the note with id.
+func (g *GitlabClient) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string) error {
+	noteID, err := strconv.Atoi(id)
+	if err != nil {
+		return errors.Wrapf(err, "invalid note id %q", id)
+	}
+	_, _, err = g.Client.Notes.UpdateMergeRequestNote(repo.FullName, pull.Num, noteID, &gitlab.UpdateMergeRequestNoteOptions{Body: gitlab.String(comment)})
+	return err
+}
+
 // PullIsApproved returns true if the merge request was approved.
 func (g *GitlabClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
 	approvals, _, err := g.Client.MergeRequests.GetMergeRequestApprovals(repo.FullName, pull.Num)
@@ -65,10 +190,31 @@ func (g *GitlabClient) PullIsApproved(repo models.Repo, pull models.PullRequest)
 	return true, nil
 }
 
-// UpdateStatus updates the build status of a commit.
-func (g *GitlabClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, description string) error {
-	const statusContext = "Atlantis"
+// UserHasWriteAccess returns true if user is a member of the project with
+// at least Developer permissions, including through the project's groups.
+func (g *GitlabClient) UserHasWriteAccess(repo models.Repo, user models.User) (bool, error) {
+	// Constructing the api url by hand since the client only lists the
+	// project's direct members.
+	apiURL := fmt.Sprintf("projects/%s/members/all", url.QueryEscape(repo.FullName))
+	req, err := g.Client.NewRequest("GET", apiURL, &gitlab.ListProjectMembersOptions{Query: gitlab.String(user.Username)}, nil)
+	if err != nil {
+		return false, err
+	}
+	var members []*gitlab.ProjectMember
+	if _, err := g.Client.Do(req, &members); err != nil {
+		return false, err
+	}
+	for _, m := range members {
+		if m.Username == user.Username && m.AccessLevel >= gitlab.DeveloperPermissions {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
+// UpdateStatus updates the build status of a commit. Statuses with different
+// contexts are shown separately.
+func (g *GitlabClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, statusContext string, description string, targetURL string) error {
 	gitlabState := gitlab.Failed
 	switch state {
 	case Pending:
@@ -78,11 +224,15 @@ func (g *GitlabClient) UpdateStatus(repo models.Repo, pull models.PullRequest, s
 	case Success:
 		gitlabState = gitlab.Success
 	}
-	_, _, err := g.Client.Commits.SetCommitStatus(repo.FullName, pull.HeadCommit, &gitlab.SetCommitStatusOptions{
+	opts := &gitlab.SetCommitStatusOptions{
 		State:       gitlabState,
 		Context:     gitlab.String(statusContext),
 		Description: gitlab.String(description),
-	})
+	}
+	if targetURL != "" {
+		opts.TargetURL = gitlab.String(targetURL)
+	}
+	_, _, err := g.Client.Commits.SetCommitStatus(repo.FullName, pull.HeadCommit, opts)
 	return err
 }
 
@@ -0,0 +1,175 @@
+package vcs_test
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+var gitlabRepo = models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+var gitlabPull = models.PullRequest{Num: 1, HeadCommit: "sha"}
+
+func TestNewGitlabClient_SelfHosted(t *testing.T) {
+	t.Log("requests should go to the self-hosted installation and trust its CA")
+	var requests []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		Equals(t, "token", r.Header.Get("Private-Token"))
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/owner%2Frepo/merge_requests/1/changes":
+			fmt.Fprint(w, `{"changes": [{"new_path": "main.tf"}]}`) // nolint: errcheck
+		case "/api/v4/projects/owner%2Frepo/merge_requests/1/approvals":
+			fmt.Fprint(w, `{"approvals_missing": 0}`) // nolint: errcheck
+		default:
+			fmt.Fprint(w, `{}`) // nolint: errcheck
+		}
+	}))
+	defer server.Close()
+	caFile := writeCAFile(t, server)
+	defer os.Remove(caFile) // nolint: errcheck
+
+	client, err := vcs.NewGitlabClient(strings.TrimPrefix(server.URL, "https://"), "user", "token", caFile)
+	Ok(t, err)
+
+	files, err := client.GetModifiedFiles(gitlabRepo, gitlabPull)
+	Ok(t, err)
+	Equals(t, []string{"main.tf"}, files)
+	approved, err := client.PullIsApproved(gitlabRepo, gitlabPull)
+	Ok(t, err)
+	Equals(t, true, approved)
+	Ok(t, client.CreateComment(gitlabRepo, gitlabPull, "comment"))
+	Ok(t, client.UpdateStatus(gitlabRepo, gitlabPull, vcs.Success, "Atlantis", "Plan Success", ""))
+
+	Equals(t, []string{
+		"GET /api/v4/projects/owner%2Frepo/merge_requests/1/changes",
+		"GET /api/v4/projects/owner%2Frepo/merge_requests/1/approvals",
+		"POST /api/v4/projects/owner%2Frepo/merge_requests/1/notes",
+		"POST /api/v4/projects/owner%2Frepo/statuses/sha",
+	}, requests)
+}
+
+func TestGitlabClient_UserHasWriteAccess(t *testing.T) {
+	t.Log("members with access through the project's groups should have write access")
+	var requests []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath()+"?"+r.URL.RawQuery)
+		fmt.Fprint(w, `[{"username": "user2", "access_level": 40}, {"username": "user", "access_level": 30}]`) // nolint: errcheck
+	}))
+	defer server.Close()
+	caFile := writeCAFile(t, server)
+	defer os.Remove(caFile) // nolint: errcheck
+	client, err := vcs.NewGitlabClient(strings.TrimPrefix(server.URL, "https://"), "user", "token", caFile)
+	Ok(t, err)
+
+	hasAccess, err := client.UserHasWriteAccess(gitlabRepo, models.User{Username: "user"})
+	Ok(t, err)
+	Equals(t, true, hasAccess)
+	hasAccess, err = client.UserHasWriteAccess(gitlabRepo, models.User{Username: "reporter"})
+	Ok(t, err)
+	Equals(t, false, hasAccess)
+	Equals(t, "GET /api/v4/projects/owner%2Frepo/members/all?query=user", requests[0])
+}
+
+func TestGitlabClient_FindAndUpdateComment(t *testing.T) {
+	t.Log("should page through the notes for the most recent one by Atlantis that starts with the marker and update it")
+	var requests []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath()+"?"+r.URL.RawQuery)
+		switch r.URL.EscapedPath() + "?" + r.URL.RawQuery {
+		case "/api/v4/projects/owner%2Frepo/merge_requests/1/notes?page=1&per_page=100":
+			w.Header().Set("Link", `<https://gitlab.example.com/api/v4/projects/owner%2Frepo/merge_requests/1/notes?page=2&per_page=100>; rel="next"`)
+			fmt.Fprint(w, `[{"id": 5, "body": "<!-- marker -->\nquoted", "author": {"username": "someone"}}, {"id": 4, "body": "other", "author": {"username": "user"}}, {"id": 3, "body": "<!-- marker --> added a commit", "system": true, "author": {"username": "user"}}]`) // nolint: errcheck
+		case "/api/v4/projects/owner%2Frepo/merge_requests/1/notes?page=2&per_page=100":
+			fmt.Fprint(w, `[{"id": 2, "body": "<!-- marker -->\nsecond", "author": {"username": "user"}}, {"id": 1, "body": "<!-- marker -->\nfirst", "author": {"username": "user"}}]`) // nolint: errcheck
+		default:
+			fmt.Fprint(w, `{}`) // nolint: errcheck
+		}
+	}))
+	defer server.Close()
+	caFile := writeCAFile(t, server)
+	defer os.Remove(caFile) // nolint: errcheck
+	client, err := vcs.NewGitlabClient(strings.TrimPrefix(server.URL, "https://"), "user", "token", caFile)
+	Ok(t, err)
+
+	comment, err := client.FindComment(gitlabRepo, gitlabPull, "<!-- marker -->")
+	Ok(t, err)
+	Equals(t, &vcs.Comment{ID: "2", Body: "<!-- marker -->\nsecond"}, comment)
+	Ok(t, client.UpdateComment(gitlabRepo, gitlabPull, comment.ID, "comment"))
+
+	Equals(t, []string{
+		"GET /api/v4/projects/owner%2Frepo/merge_requests/1/notes?page=1&per_page=100",
+		"GET /api/v4/projects/owner%2Frepo/merge_requests/1/notes?page=2&per_page=100",
+		"PUT /api/v4/projects/owner%2Frepo/merge_requests/1/notes/2?",
+	}, requests)
+}
+
+func TestGitlabClient_HideComments(t *testing.T) {
+	t.Log("should edit each note by Atlantis that starts with the marker to show it's outdated")
+	var requests []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		Ok(t, err)
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.EscapedPath(), strings.TrimSpace(string(body))))
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/owner%2Frepo/merge_requests/1/notes":
+			fmt.Fprint(w, `[{"id": 3, "body": "<!-- marker -->\nquoted", "author": {"username": "someone"}}, {"id": 2, "body": "<!-- marker -->\nsecond", "author": {"username": "user"}}, {"id": 1, "body": "other", "author": {"username": "user"}}]`) // nolint: errcheck
+		default:
+			fmt.Fprint(w, `{}`) // nolint: errcheck
+		}
+	}))
+	defer server.Close()
+	caFile := writeCAFile(t, server)
+	defer os.Remove(caFile) // nolint: errcheck
+	client, err := vcs.NewGitlabClient(strings.TrimPrefix(server.URL, "https://"), "user", "token", caFile)
+	Ok(t, err)
+
+	Ok(t, client.HideComments(gitlabRepo, gitlabPull, "<!-- marker -->"))
+	body, err := json.Marshal(map[string]string{"body": vcs.OutdatedComment("<!-- marker -->", "<!-- marker -->\nsecond")})
+	Ok(t, err)
+	Equals(t, []string{
+		"GET /api/v4/projects/owner%2Frepo/merge_requests/1/notes ",
+		"PUT /api/v4/projects/owner%2Frepo/merge_requests/1/notes/2 " + string(body),
+	}, requests)
+}
+
+func TestNewGitlabClient_UntrustedCA(t *testing.T) {
+	t.Log("requests should fail if the installation's CA isn't trusted")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	client, err := vcs.NewGitlabClient(strings.TrimPrefix(server.URL, "https://"), "user", "token", "")
+	Ok(t, err)
+	err = client.CreateComment(gitlabRepo, gitlabPull, "comment")
+	Assert(t, err != nil, "expected certificate error")
+}
+
+func TestNewGitlabClient_InvalidCAFile(t *testing.T) {
+	t.Log("should error if the CA file doesn't contain any certificates")
+	f, err := ioutil.TempFile("", "")
+	Ok(t, err)
+	defer os.Remove(f.Name()) // nolint: errcheck
+	_, err = vcs.NewGitlabClient("gitlab.example.com", "user", "token", f.Name())
+	Assert(t, err != nil, "expected error")
+}
+
+// writeCAFile writes the certificate of the test server to a file and returns
+// its path.
+func writeCAFile(t *testing.T, server *httptest.Server) string {
+	f, err := ioutil.TempFile("", "")
+	Ok(t, err)
+	defer f.Close() // nolint: errcheck
+	Ok(t, pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: server.TLS.Certificates[0].Certificate[0]}))
+	return f.Name()
+}
@@ -63,8 +63,8 @@ func (mock *MockClient) PullIsApproved(repo models.Repo, pull models.PullRequest
 	return ret0, ret1
 }
 
-func (mock *MockClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state vcs.CommitStatus, description string) error {
-	params := []pegomock.Param{repo, pull, state, description}
+func (mock *MockClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state vcs.CommitStatus, statusContext string, description string, targetURL string) error {
+	params := []pegomock.Param{repo, pull, state, statusContext, description, targetURL}
 	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateStatus", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
 	var ret0 error
 	if len(result) != 0 {
@@ -75,6 +75,62 @@ func (mock *MockClient) UpdateStatus(repo models.Repo, pull models.PullRequest,
 	return ret0
 }
 
+func (mock *MockClient) UserHasWriteAccess(repo models.Repo, user models.User) (bool, error) {
+	params := []pegomock.Param{repo, user}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UserHasWriteAccess", params, []reflect.Type{reflect.TypeOf((*bool)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 bool
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(bool)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockClient) FindComment(repo models.Repo, pull models.PullRequest, marker string) (*vcs.Comment, error) {
+	params := []pegomock.Param{repo, pull, marker}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("FindComment", params, []reflect.Type{reflect.TypeOf((**vcs.Comment)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *vcs.Comment
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*vcs.Comment)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockClient) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string) error {
+	params := []pegomock.Param{repo, pull, id, comment}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateComment", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockClient) HideComments(repo models.Repo, pull models.PullRequest, marker string) error {
+	params := []pegomock.Param{repo, pull, marker}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("HideComments", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockClient) VerifyWasCalledOnce() *VerifierClient {
 	return &VerifierClient{mock, pegomock.Times(1), nil}
 }
@@ -190,8 +246,8 @@ func (c *Client_PullIsApproved_OngoingVerification) GetAllCapturedArguments() (_
 	return
 }
 
-func (verifier *VerifierClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state vcs.CommitStatus, description string) *Client_UpdateStatus_OngoingVerification {
-	params := []pegomock.Param{repo, pull, state, description}
+func (verifier *VerifierClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state vcs.CommitStatus, statusContext string, description string, targetURL string) *Client_UpdateStatus_OngoingVerification {
+	params := []pegomock.Param{repo, pull, state, statusContext, description, targetURL}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateStatus", params)
 	return &Client_UpdateStatus_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -201,12 +257,12 @@ type Client_UpdateStatus_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *Client_UpdateStatus_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, vcs.CommitStatus, string) {
-	repo, pull, state, description := c.GetAllCapturedArguments()
-	return repo[len(repo)-1], pull[len(pull)-1], state[len(state)-1], description[len(description)-1]
+func (c *Client_UpdateStatus_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, vcs.CommitStatus, string, string, string) {
+	repo, pull, state, statusContext, description, targetURL := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], pull[len(pull)-1], state[len(state)-1], statusContext[len(statusContext)-1], description[len(description)-1], targetURL[len(targetURL)-1]
 }
 
-func (c *Client_UpdateStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []vcs.CommitStatus, _param3 []string) {
+func (c *Client_UpdateStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []vcs.CommitStatus, _param3 []string, _param4 []string, _param5 []string) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]models.Repo, len(params[0]))
@@ -225,6 +281,154 @@ func (c *Client_UpdateStatus_OngoingVerification) GetAllCapturedArguments() (_pa
 		for u, param := range params[3] {
 			_param3[u] = param.(string)
 		}
+		_param4 = make([]string, len(params[4]))
+		for u, param := range params[4] {
+			_param4[u] = param.(string)
+		}
+		_param5 = make([]string, len(params[5]))
+		for u, param := range params[5] {
+			_param5[u] = param.(string)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierClient) UserHasWriteAccess(repo models.Repo, user models.User) *Client_UserHasWriteAccess_OngoingVerification {
+	params := []pegomock.Param{repo, user}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UserHasWriteAccess", params)
+	return &Client_UserHasWriteAccess_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type Client_UserHasWriteAccess_OngoingVerification struct {
+	mock              *MockClient
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *Client_UserHasWriteAccess_OngoingVerification) GetCapturedArguments() (models.Repo, models.User) {
+	repo, user := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], user[len(user)-1]
+}
+
+func (c *Client_UserHasWriteAccess_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.User) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.User, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.User)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierClient) FindComment(repo models.Repo, pull models.PullRequest, marker string) *Client_FindComment_OngoingVerification {
+	params := []pegomock.Param{repo, pull, marker}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "FindComment", params)
+	return &Client_FindComment_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type Client_FindComment_OngoingVerification struct {
+	mock              *MockClient
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *Client_FindComment_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, string) {
+	repo, pull, marker := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], pull[len(pull)-1], marker[len(marker)-1]
+}
+
+func (c *Client_FindComment_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.PullRequest, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.PullRequest)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierClient) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string) *Client_UpdateComment_OngoingVerification {
+	params := []pegomock.Param{repo, pull, id, comment}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateComment", params)
+	return &Client_UpdateComment_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type Client_UpdateComment_OngoingVerification struct {
+	mock              *MockClient
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *Client_UpdateComment_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, string, string) {
+	repo, pull, id, comment := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], pull[len(pull)-1], id[len(id)-1], comment[len(comment)-1]
+}
+
+func (c *Client_UpdateComment_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []string, _param3 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.PullRequest, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.PullRequest)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+		_param3 = make([]string, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(string)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierClient) HideComments(repo models.Repo, pull models.PullRequest, marker string) *Client_HideComments_OngoingVerification {
+	params := []pegomock.Param{repo, pull, marker}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "HideComments", params)
+	return &Client_HideComments_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type Client_HideComments_OngoingVerification struct {
+	mock              *MockClient
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *Client_HideComments_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, string) {
+	repo, pull, marker := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], pull[len(pull)-1], marker[len(marker)-1]
+}
+
+func (c *Client_HideComments_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.PullRequest, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.PullRequest)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
 	}
 	return
 }
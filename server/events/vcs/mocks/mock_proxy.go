@@ -63,8 +63,8 @@ func (mock *MockClientProxy) PullIsApproved(repo models.Repo, pull models.PullRe
 	return ret0, ret1
 }
 
-func (mock *MockClientProxy) UpdateStatus(repo models.Repo, pull models.PullRequest, state vcs.CommitStatus, description string, host vcs.Host) error {
-	params := []pegomock.Param{repo, pull, state, description, host}
+func (mock *MockClientProxy) UpdateStatus(repo models.Repo, pull models.PullRequest, state vcs.CommitStatus, statusContext string, description string, targetURL string, host vcs.Host) error {
+	params := []pegomock.Param{repo, pull, state, statusContext, description, targetURL, host}
 	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateStatus", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
 	var ret0 error
 	if len(result) != 0 {
@@ -75,6 +75,62 @@ func (mock *MockClientProxy) UpdateStatus(repo models.Repo, pull models.PullRequ
 	return ret0
 }
 
+func (mock *MockClientProxy) UserHasWriteAccess(repo models.Repo, user models.User, host vcs.Host) (bool, error) {
+	params := []pegomock.Param{repo, user, host}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UserHasWriteAccess", params, []reflect.Type{reflect.TypeOf((*bool)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 bool
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(bool)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockClientProxy) FindComment(repo models.Repo, pull models.PullRequest, marker string, host vcs.Host) (*vcs.Comment, error) {
+	params := []pegomock.Param{repo, pull, marker, host}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("FindComment", params, []reflect.Type{reflect.TypeOf((**vcs.Comment)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *vcs.Comment
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*vcs.Comment)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockClientProxy) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string, host vcs.Host) error {
+	params := []pegomock.Param{repo, pull, id, comment, host}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateComment", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockClientProxy) HideComments(repo models.Repo, pull models.PullRequest, marker string, host vcs.Host) error {
+	params := []pegomock.Param{repo, pull, marker, host}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("HideComments", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockClientProxy) VerifyWasCalledOnce() *VerifierClientProxy {
 	return &VerifierClientProxy{mock, pegomock.Times(1), nil}
 }
@@ -202,8 +258,8 @@ func (c *ClientProxy_PullIsApproved_OngoingVerification) GetAllCapturedArguments
 	return
 }
 
-func (verifier *VerifierClientProxy) UpdateStatus(repo models.Repo, pull models.PullRequest, state vcs.CommitStatus, description string, host vcs.Host) *ClientProxy_UpdateStatus_OngoingVerification {
-	params := []pegomock.Param{repo, pull, state, description, host}
+func (verifier *VerifierClientProxy) UpdateStatus(repo models.Repo, pull models.PullRequest, state vcs.CommitStatus, statusContext string, description string, targetURL string, host vcs.Host) *ClientProxy_UpdateStatus_OngoingVerification {
+	params := []pegomock.Param{repo, pull, state, statusContext, description, targetURL, host}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateStatus", params)
 	return &ClientProxy_UpdateStatus_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -213,12 +269,12 @@ type ClientProxy_UpdateStatus_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *ClientProxy_UpdateStatus_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, vcs.CommitStatus, string, vcs.Host) {
-	repo, pull, state, description, host := c.GetAllCapturedArguments()
-	return repo[len(repo)-1], pull[len(pull)-1], state[len(state)-1], description[len(description)-1], host[len(host)-1]
+func (c *ClientProxy_UpdateStatus_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, vcs.CommitStatus, string, string, string, vcs.Host) {
+	repo, pull, state, statusContext, description, targetURL, host := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], pull[len(pull)-1], state[len(state)-1], statusContext[len(statusContext)-1], description[len(description)-1], targetURL[len(targetURL)-1], host[len(host)-1]
 }
 
-func (c *ClientProxy_UpdateStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []vcs.CommitStatus, _param3 []string, _param4 []vcs.Host) {
+func (c *ClientProxy_UpdateStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []vcs.CommitStatus, _param3 []string, _param4 []string, _param5 []string, _param6 []vcs.Host) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]models.Repo, len(params[0]))
@@ -237,6 +293,131 @@ func (c *ClientProxy_UpdateStatus_OngoingVerification) GetAllCapturedArguments()
 		for u, param := range params[3] {
 			_param3[u] = param.(string)
 		}
+		_param4 = make([]string, len(params[4]))
+		for u, param := range params[4] {
+			_param4[u] = param.(string)
+		}
+		_param5 = make([]string, len(params[5]))
+		for u, param := range params[5] {
+			_param5[u] = param.(string)
+		}
+		_param6 = make([]vcs.Host, len(params[6]))
+		for u, param := range params[6] {
+			_param6[u] = param.(vcs.Host)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierClientProxy) UserHasWriteAccess(repo models.Repo, user models.User, host vcs.Host) *ClientProxy_UserHasWriteAccess_OngoingVerification {
+	params := []pegomock.Param{repo, user, host}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UserHasWriteAccess", params)
+	return &ClientProxy_UserHasWriteAccess_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type ClientProxy_UserHasWriteAccess_OngoingVerification struct {
+	mock              *MockClientProxy
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *ClientProxy_UserHasWriteAccess_OngoingVerification) GetCapturedArguments() (models.Repo, models.User, vcs.Host) {
+	repo, user, host := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], user[len(user)-1], host[len(host)-1]
+}
+
+func (c *ClientProxy_UserHasWriteAccess_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.User, _param2 []vcs.Host) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.User, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.User)
+		}
+		_param2 = make([]vcs.Host, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(vcs.Host)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierClientProxy) FindComment(repo models.Repo, pull models.PullRequest, marker string, host vcs.Host) *ClientProxy_FindComment_OngoingVerification {
+	params := []pegomock.Param{repo, pull, marker, host}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "FindComment", params)
+	return &ClientProxy_FindComment_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type ClientProxy_FindComment_OngoingVerification struct {
+	mock              *MockClientProxy
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *ClientProxy_FindComment_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, string, vcs.Host) {
+	repo, pull, marker, host := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], pull[len(pull)-1], marker[len(marker)-1], host[len(host)-1]
+}
+
+func (c *ClientProxy_FindComment_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []string, _param3 []vcs.Host) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.PullRequest, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.PullRequest)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+		_param3 = make([]vcs.Host, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(vcs.Host)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierClientProxy) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string, host vcs.Host) *ClientProxy_UpdateComment_OngoingVerification {
+	params := []pegomock.Param{repo, pull, id, comment, host}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateComment", params)
+	return &ClientProxy_UpdateComment_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type ClientProxy_UpdateComment_OngoingVerification struct {
+	mock              *MockClientProxy
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *ClientProxy_UpdateComment_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, string, string, vcs.Host) {
+	repo, pull, id, comment, host := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], pull[len(pull)-1], id[len(id)-1], comment[len(comment)-1], host[len(host)-1]
+}
+
+func (c *ClientProxy_UpdateComment_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []string, _param3 []string, _param4 []vcs.Host) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.PullRequest, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.PullRequest)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+		_param3 = make([]string, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(string)
+		}
 		_param4 = make([]vcs.Host, len(params[4]))
 		for u, param := range params[4] {
 			_param4[u] = param.(vcs.Host)
@@ -244,3 +425,42 @@ func (c *ClientProxy_UpdateStatus_OngoingVerification) GetAllCapturedArguments()
 	}
 	return
 }
+
+func (verifier *VerifierClientProxy) HideComments(repo models.Repo, pull models.PullRequest, marker string, host vcs.Host) *ClientProxy_HideComments_OngoingVerification {
+	params := []pegomock.Param{repo, pull, marker, host}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "HideComments", params)
+	return &ClientProxy_HideComments_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type ClientProxy_HideComments_OngoingVerification struct {
+	mock              *MockClientProxy
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *ClientProxy_HideComments_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest, string, vcs.Host) {
+	repo, pull, marker, host := c.GetAllCapturedArguments()
+	return repo[len(repo)-1], pull[len(pull)-1], marker[len(marker)-1], host[len(host)-1]
+}
+
+func (c *ClientProxy_HideComments_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest, _param2 []string, _param3 []vcs.Host) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.PullRequest, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.PullRequest)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+		_param3 = make([]vcs.Host, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(vcs.Host)
+		}
+	}
+	return
+}
@@ -0,0 +1,119 @@
+package vcs
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/github"
+	"github.com/hootsuite/atlantis/server/events/models"
+)
+
+// MultiGithubClient is used to perform GitHub actions when Atlantis is
+// configured for more than one GitHub host, ex. github.com and a GitHub
+// Enterprise installation. Each call is routed to the client for the host
+// the repo lives on.
+type MultiGithubClient struct {
+	// Clients maps GitHub hostnames, ex. "github.com", to the client for
+	// that host.
+	Clients map[string]*GithubClient
+}
+
+// GetModifiedFiles returns the names of files that were modified in the pull request.
+func (m *MultiGithubClient) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
+	client, err := m.client(repo)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetModifiedFiles(repo, pull)
+}
+
+// CreateComment creates a comment on the pull request.
+func (m *MultiGithubClient) CreateComment(repo models.Repo, pull models.PullRequest, comment string) error {
+	client, err := m.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.CreateComment(repo, pull, comment)
+}
+
+// FindComment returns the most recent comment on the pull request whose body
+// starts with marker or nil if there isn't one.
+func (m *MultiGithubClient) FindComment(repo models.Repo, pull models.PullRequest, marker string) (*Comment, error) {
+	client, err := m.client(repo)
+	if err != nil {
+		return nil, err
+	}
+	return client.FindComment(repo, pull, marker)
+}
+
+// UpdateComment replaces the body of the comment with id.
+func (m *MultiGithubClient) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string) error {
+	client, err := m.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.UpdateComment(repo, pull, id, comment)
+}
+
+// HideComments minimizes the comments on the pull request whose body starts
+// with marker.
+func (m *MultiGithubClient) HideComments(repo models.Repo, pull models.PullRequest, marker string) error {
+	client, err := m.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.HideComments(repo, pull, marker)
+}
+
+// PullIsApproved returns true if the pull request was approved.
+func (m *MultiGithubClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
+	client, err := m.client(repo)
+	if err != nil {
+		return false, err
+	}
+	return client.PullIsApproved(repo, pull)
+}
+
+// UpdateStatus updates the status badge on the pull request.
+func (m *MultiGithubClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, statusContext string, description string, targetURL string) error {
+	client, err := m.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.UpdateStatus(repo, pull, state, statusContext, description, targetURL)
+}
+
+// UserHasWriteAccess returns true if user has write or admin permissions on repo.
+func (m *MultiGithubClient) UserHasWriteAccess(repo models.Repo, user models.User) (bool, error) {
+	client, err := m.client(repo)
+	if err != nil {
+		return false, err
+	}
+	return client.UserHasWriteAccess(repo, user)
+}
+
+// GetPullRequest returns the pull request.
+func (m *MultiGithubClient) GetPullRequest(repo models.Repo, num int) (*github.PullRequest, error) {
+	client, err := m.client(repo)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetPullRequest(repo, num)
+}
+
+// CreateCheckRun creates a check run on the head commit of the pull request.
+func (m *MultiGithubClient) CreateCheckRun(repo models.Repo, pull models.PullRequest, run CheckRun) error {
+	client, err := m.client(repo)
+	if err != nil {
+		return err
+	}
+	return client.CreateCheckRun(repo, pull, run)
+}
+
+// client returns the client for the host repo lives on.
+func (m *MultiGithubClient) client(repo models.Repo) (*GithubClient, error) {
+	client, ok := m.Clients[repo.Hostname]
+	if !ok {
+		return nil, fmt.Errorf("no GitHub credentials configured for host %q", repo.Hostname)
+	}
+	return client, nil
+}
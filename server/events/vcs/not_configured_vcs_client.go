@@ -22,7 +22,19 @@ func (a *NotConfiguredVCSClient) CreateComment(repo models.Repo, pull models.Pul
 func (a *NotConfiguredVCSClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
 	return false, a.err()
 }
-func (a *NotConfiguredVCSClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, description string) error {
+func (a *NotConfiguredVCSClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, statusContext string, description string, targetURL string) error {
+	return a.err()
+}
+func (a *NotConfiguredVCSClient) UserHasWriteAccess(repo models.Repo, user models.User) (bool, error) {
+	return false, a.err()
+}
+func (a *NotConfiguredVCSClient) FindComment(repo models.Repo, pull models.PullRequest, marker string) (*Comment, error) {
+	return nil, a.err()
+}
+func (a *NotConfiguredVCSClient) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string) error {
+	return a.err()
+}
+func (a *NotConfiguredVCSClient) HideComments(repo models.Repo, pull models.PullRequest, marker string) error {
 	return a.err()
 }
 func (a *NotConfiguredVCSClient) err() error {
@@ -13,26 +13,40 @@ type ClientProxy interface {
 	GetModifiedFiles(repo models.Repo, pull models.PullRequest, host Host) ([]string, error)
 	CreateComment(repo models.Repo, pull models.PullRequest, comment string, host Host) error
 	PullIsApproved(repo models.Repo, pull models.PullRequest, host Host) (bool, error)
-	UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, description string, host Host) error
+	UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, statusContext string, description string, targetURL string, host Host) error
+	UserHasWriteAccess(repo models.Repo, user models.User, host Host) (bool, error)
+	FindComment(repo models.Repo, pull models.PullRequest, marker string, host Host) (*Comment, error)
+	UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string, host Host) error
+	HideComments(repo models.Repo, pull models.PullRequest, marker string, host Host) error
 }
 
 // DefaultClientProxy proxies calls to the correct VCS client depending on which
 // VCS host is required.
 type DefaultClientProxy struct {
-	GithubClient Client
-	GitlabClient Client
+	GithubClient      Client
+	GitlabClient      Client
+	GiteaClient       Client
+	AzureDevopsClient Client
 }
 
-func NewDefaultClientProxy(githubClient Client, gitlabClient Client) *DefaultClientProxy {
+func NewDefaultClientProxy(githubClient Client, gitlabClient Client, giteaClient Client, azureDevopsClient Client) *DefaultClientProxy {
 	if githubClient == nil {
-		githubClient = &NotConfiguredVCSClient{}
+		githubClient = &NotConfiguredVCSClient{Host: Github}
 	}
 	if gitlabClient == nil {
-		gitlabClient = &NotConfiguredVCSClient{}
+		gitlabClient = &NotConfiguredVCSClient{Host: Gitlab}
+	}
+	if giteaClient == nil {
+		giteaClient = &NotConfiguredVCSClient{Host: Gitea}
+	}
+	if azureDevopsClient == nil {
+		azureDevopsClient = &NotConfiguredVCSClient{Host: AzureDevops}
 	}
 	return &DefaultClientProxy{
-		GitlabClient: gitlabClient,
-		GithubClient: githubClient,
+		GitlabClient:      gitlabClient,
+		GithubClient:      githubClient,
+		GiteaClient:       giteaClient,
+		AzureDevopsClient: azureDevopsClient,
 	}
 }
 
@@ -44,6 +58,10 @@ func (d *DefaultClientProxy) GetModifiedFiles(repo models.Repo, pull models.Pull
 		return d.GithubClient.GetModifiedFiles(repo, pull)
 	case Gitlab:
 		return d.GitlabClient.GetModifiedFiles(repo, pull)
+	case Gitea:
+		return d.GiteaClient.GetModifiedFiles(repo, pull)
+	case AzureDevops:
+		return d.AzureDevopsClient.GetModifiedFiles(repo, pull)
 	}
 	return nil, invalidVCSErr
 }
@@ -54,6 +72,10 @@ func (d *DefaultClientProxy) CreateComment(repo models.Repo, pull models.PullReq
 		return d.GithubClient.CreateComment(repo, pull, comment)
 	case Gitlab:
 		return d.GitlabClient.CreateComment(repo, pull, comment)
+	case Gitea:
+		return d.GiteaClient.CreateComment(repo, pull, comment)
+	case AzureDevops:
+		return d.AzureDevopsClient.CreateComment(repo, pull, comment)
 	}
 	return invalidVCSErr
 }
@@ -64,16 +86,80 @@ func (d *DefaultClientProxy) PullIsApproved(repo models.Repo, pull models.PullRe
 		return d.GithubClient.PullIsApproved(repo, pull)
 	case Gitlab:
 		return d.GitlabClient.PullIsApproved(repo, pull)
+	case Gitea:
+		return d.GiteaClient.PullIsApproved(repo, pull)
+	case AzureDevops:
+		return d.AzureDevopsClient.PullIsApproved(repo, pull)
+	}
+	return false, invalidVCSErr
+}
+
+func (d *DefaultClientProxy) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, statusContext string, description string, targetURL string, host Host) error {
+	switch host {
+	case Github:
+		return d.GithubClient.UpdateStatus(repo, pull, state, statusContext, description, targetURL)
+	case Gitlab:
+		return d.GitlabClient.UpdateStatus(repo, pull, state, statusContext, description, targetURL)
+	case Gitea:
+		return d.GiteaClient.UpdateStatus(repo, pull, state, statusContext, description, targetURL)
+	case AzureDevops:
+		return d.AzureDevopsClient.UpdateStatus(repo, pull, state, statusContext, description, targetURL)
+	}
+	return invalidVCSErr
+}
+
+func (d *DefaultClientProxy) UserHasWriteAccess(repo models.Repo, user models.User, host Host) (bool, error) {
+	switch host {
+	case Github:
+		return d.GithubClient.UserHasWriteAccess(repo, user)
+	case Gitlab:
+		return d.GitlabClient.UserHasWriteAccess(repo, user)
+	case Gitea:
+		return d.GiteaClient.UserHasWriteAccess(repo, user)
+	case AzureDevops:
+		return d.AzureDevopsClient.UserHasWriteAccess(repo, user)
 	}
 	return false, invalidVCSErr
 }
 
-func (d *DefaultClientProxy) UpdateStatus(repo models.Repo, pull models.PullRequest, state CommitStatus, description string, host Host) error {
+func (d *DefaultClientProxy) FindComment(repo models.Repo, pull models.PullRequest, marker string, host Host) (*Comment, error) {
+	switch host {
+	case Github:
+		return d.GithubClient.FindComment(repo, pull, marker)
+	case Gitlab:
+		return d.GitlabClient.FindComment(repo, pull, marker)
+	case Gitea:
+		return d.GiteaClient.FindComment(repo, pull, marker)
+	case AzureDevops:
+		return d.AzureDevopsClient.FindComment(repo, pull, marker)
+	}
+	return nil, invalidVCSErr
+}
+
+func (d *DefaultClientProxy) UpdateComment(repo models.Repo, pull models.PullRequest, id string, comment string, host Host) error {
+	switch host {
+	case Github:
+		return d.GithubClient.UpdateComment(repo, pull, id, comment)
+	case Gitlab:
+		return d.GitlabClient.UpdateComment(repo, pull, id, comment)
+	case Gitea:
+		return d.GiteaClient.UpdateComment(repo, pull, id, comment)
+	case AzureDevops:
+		return d.AzureDevopsClient.UpdateComment(repo, pull, id, comment)
+	}
+	return invalidVCSErr
+}
+
+func (d *DefaultClientProxy) HideComments(repo models.Repo, pull models.PullRequest, marker string, host Host) error {
 	switch host {
 	case Github:
-		return d.GithubClient.UpdateStatus(repo, pull, state, description)
+		return d.GithubClient.HideComments(repo, pull, marker)
 	case Gitlab:
-		return d.GitlabClient.UpdateStatus(repo, pull, state, description)
+		return d.GitlabClient.HideComments(repo, pull, marker)
+	case Gitea:
+		return d.GiteaClient.HideComments(repo, pull, marker)
+	case AzureDevops:
+		return d.AzureDevopsClient.HideComments(repo, pull, marker)
 	}
 	return invalidVCSErr
 }
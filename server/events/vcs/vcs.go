@@ -5,6 +5,8 @@ type Host int
 const (
 	Github Host = iota
 	Gitlab
+	Gitea
+	AzureDevops
 )
 
 func (h Host) String() string {
@@ -13,6 +15,10 @@ func (h Host) String() string {
 		return "Github"
 	case Gitlab:
 		return "Gitlab"
+	case Gitea:
+		return "Gitea"
+	case AzureDevops:
+		return "AzureDevops"
 	}
 	return "<missing String() implementation>"
 }
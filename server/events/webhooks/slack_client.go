@@ -3,6 +3,7 @@ package webhooks
 import (
 	"fmt"
 
+	"github.com/hootsuite/atlantis/server/events/redaction"
 	"github.com/nlopes/slack"
 )
 
@@ -34,12 +35,16 @@ type UnderlyingSlackClient interface {
 type DefaultSlackClient struct {
 	Slack UnderlyingSlackClient
 	Token string
+	// Redactor masks secrets in the messages we post. If nil, nothing is
+	// masked.
+	Redactor *redaction.Redactor
 }
 
-func NewSlackClient(token string) SlackClient {
+func NewSlackClient(token string, redactor *redaction.Redactor) SlackClient {
 	return &DefaultSlackClient{
-		Slack: slack.New(token),
-		Token: token,
+		Slack:    slack.New(token),
+		Token:    token,
+		Redactor: redactor,
 	}
 }
 
@@ -67,7 +72,7 @@ func (d *DefaultSlackClient) ChannelExists(channelName string) (bool, error) {
 
 func (d *DefaultSlackClient) PostMessage(channel string, applyResult ApplyResult) error {
 	params := slack.NewPostMessageParameters()
-	params.Attachments = d.createAttachments(applyResult)
+	params.Attachments = d.redact(d.createAttachments(applyResult))
 	params.AsUser = true
 	params.EscapeText = false
 	_, _, err := d.Slack.PostMessage(channel, "", params)
@@ -104,3 +109,14 @@ func (d *DefaultSlackClient) createAttachments(applyResult ApplyResult) []slack.
 	}
 	return []slack.Attachment{attachment}
 }
+
+// redact masks secrets in the text of attachments.
+func (d *DefaultSlackClient) redact(attachments []slack.Attachment) []slack.Attachment {
+	for i := range attachments {
+		attachments[i].Text = d.Redactor.Redact(attachments[i].Text)
+		for j := range attachments[i].Fields {
+			attachments[i].Fields[j].Value = d.Redactor.Redact(attachments[i].Fields[j].Value)
+		}
+	}
+	return attachments
+}
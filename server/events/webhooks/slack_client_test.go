@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/hootsuite/atlantis/server/events/models"
+	"github.com/hootsuite/atlantis/server/events/redaction"
 	"github.com/hootsuite/atlantis/server/events/webhooks"
 	"github.com/hootsuite/atlantis/server/events/webhooks/mocks"
 	"github.com/nlopes/slack"
@@ -116,6 +117,37 @@ func TestPostMessage_Success(t *testing.T) {
 	underlying.VerifyWasCalledOnce().PostMessage(channel, "", expParams)
 }
 
+func TestPostMessage_Redacted(t *testing.T) {
+	t.Log("Secrets should be redacted from the message")
+	setup(t)
+	client.Redactor = &redaction.Redactor{Values: []string{"production", "lkysow"}}
+
+	expParams := slack.NewPostMessageParameters()
+	expParams.Attachments = []slack.Attachment{{
+		Color: "good",
+		Text:  "Apply succeeded for <url|hootsuite/atlantis>",
+		Fields: []slack.AttachmentField{
+			{
+				Title: "Workspace",
+				Value: redaction.Mask,
+				Short: true,
+			},
+			{
+				Title: "User",
+				Value: redaction.Mask,
+				Short: true,
+			},
+		},
+	}}
+	expParams.AsUser = true
+	expParams.EscapeText = false
+
+	channel := "somechannel"
+	err := client.PostMessage(channel, result)
+	Ok(t, err)
+	underlying.VerifyWasCalledOnce().PostMessage(channel, "", expParams)
+}
+
 func TestPostMessage_Error(t *testing.T) {
 	t.Log("When the underylying slack client errors, an error should be returned")
 	setup(t)
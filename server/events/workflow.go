@@ -0,0 +1,255 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hootsuite/atlantis/server/events/run"
+	"github.com/hootsuite/atlantis/server/events/terraform"
+	"github.com/pkg/errors"
+)
+
+// DefaultWorkflowName is the name of the workflow projects use if they don't
+// set one. Unless a project defines a workflow with this name, it's the
+// built-in workflow: the pre_* and post_* commands around terraform init and
+// plan or apply.
+const DefaultWorkflowName = "default"
+
+// The names of the steps a workflow can have.
+const (
+	InitStepName  = "init"
+	PlanStepName  = "plan"
+	ApplyStepName = "apply"
+	RunStepName   = "run"
+	EnvStepName   = "env"
+)
+
+// envNameRegex matches valid environment variable names.
+var envNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Workflow is the steps to run for each stage of a project's commands.
+type Workflow struct {
+	// Plan is the steps to run for plan. If nil, the built-in steps are run.
+	Plan []Step
+	// Apply is the steps to run for apply. If nil, the built-in steps are
+	// run.
+	Apply []Step
+}
+
+// Steps returns the steps of w to run for command, or nil if the built-in
+// steps should be run.
+func (w Workflow) Steps(command CommandName) []Step {
+	switch command {
+	case Plan:
+		return w.Plan
+	case Apply:
+		return w.Apply
+	}
+	return nil
+}
+
+// Step is one step of a workflow.
+type Step struct {
+	// Name is init, plan, apply, run or env.
+	Name string
+	// Command is the shell command a run step runs.
+	Command string
+	// EnvName and EnvValue are the environment variable an env step sets for
+	// the steps after it.
+	EnvName  string
+	EnvValue string
+}
+
+// String describes s for errors, ex. `terraform plan` or `make generate`.
+func (s Step) String() string {
+	switch s.Name {
+	case RunStepName:
+		return fmt.Sprintf("`%s`", s.Command)
+	case EnvStepName:
+		return fmt.Sprintf("`env %s`", s.EnvName)
+	}
+	return fmt.Sprintf("`terraform %s`", s.Name)
+}
+
+// workflowYAML is used to parse a workflow.
+type workflowYAML struct {
+	Plan  stageYAML `yaml:"plan"`
+	Apply stageYAML `yaml:"apply"`
+}
+
+type stageYAML struct {
+	Steps []stepYAML `yaml:"steps"`
+}
+
+// stepYAML is used to parse a step. Steps are either the name of a built-in
+// step, ex. init, or a map from run or env to its value, ex.
+// {run: make generate}.
+type stepYAML struct {
+	Name  string
+	Value string
+}
+
+func (s *stepYAML) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&s.Name); err == nil {
+		return nil
+	}
+	var m map[string]string
+	if err := unmarshal(&m); err != nil {
+		return errors.New("steps must be a step name or a map with one key, ex. run: make generate")
+	}
+	if len(m) != 1 {
+		return fmt.Errorf("steps must be a map with one key, ex. run: make generate, found %d keys", len(m))
+	}
+	for k, v := range m {
+		s.Name = k
+		s.Value = v
+	}
+	return nil
+}
+
+// parseWorkflow returns the workflow w describes. name is used in errors.
+func parseWorkflow(name string, w workflowYAML) (Workflow, error) {
+	plan, err := parseSteps(w.Plan.Steps, PlanStepName)
+	if err != nil {
+		return Workflow{}, errors.Wrapf(err, "parsing workflows.%s.plan", name)
+	}
+	apply, err := parseSteps(w.Apply.Steps, ApplyStepName)
+	if err != nil {
+		return Workflow{}, errors.Wrapf(err, "parsing workflows.%s.apply", name)
+	}
+	return Workflow{Plan: plan, Apply: apply}, nil
+}
+
+// parseSteps returns the steps for a stage. stage is the only terraform
+// command, plan or apply, that the stage can run besides init, and a stage
+// with steps must run it exactly once so there's one plan to apply.
+func parseSteps(steps []stepYAML, stage string) ([]Step, error) {
+	var parsed []Step
+	stageSteps := 0
+	for i, s := range steps {
+		var step Step
+		switch s.Name {
+		case InitStepName, stage:
+			if s.Value != "" {
+				return nil, fmt.Errorf("step %d: %s doesn't take a value", i+1, s.Name)
+			}
+			step = Step{Name: s.Name}
+			if s.Name == stage {
+				stageSteps++
+			}
+		case RunStepName:
+			if s.Value == "" {
+				return nil, fmt.Errorf("step %d: run needs a command", i+1)
+			}
+			step = Step{Name: RunStepName, Command: s.Value}
+		case EnvStepName:
+			parts := strings.SplitN(s.Value, "=", 2)
+			if len(parts) != 2 || !envNameRegex.MatchString(parts[0]) {
+				return nil, fmt.Errorf("step %d: env must be NAME=value, found %q", i+1, s.Value)
+			}
+			if run.ReservedEnvVar(parts[0]) {
+				return nil, fmt.Errorf("step %d: %s is set by Atlantis and can't be overridden", i+1, parts[0])
+			}
+			step = Step{Name: EnvStepName, EnvName: parts[0], EnvValue: parts[1]}
+		default:
+			return nil, fmt.Errorf("step %d: unknown step %q, expected %s, %s, %s or %s", i+1, s.Name, InitStepName, stage, RunStepName, EnvStepName)
+		}
+		parsed = append(parsed, step)
+	}
+	if len(parsed) > 0 && stageSteps != 1 {
+		return nil, fmt.Errorf("needs exactly one %s step, found %d", stage, stageSteps)
+	}
+	return parsed, nil
+}
+
+// workflowRun runs the steps of a custom workflow for a project.
+type workflowRun struct {
+	ctx              *CommandContext
+	terraform        terraform.Runner
+	run              run.Runner
+	config           ProjectConfig
+	path             string
+	terraformVersion *version.Version
+	// command runs terraform plan or apply, depending on the stage, with
+	// cmdCtx.
+	command func(cmdCtx context.Context) (string, error)
+}
+
+// runSteps runs steps in order and returns the output of the plan, apply and
+// run steps. If a step fails, the steps after it aren't run and the result
+// for the project is returned.
+func (w *workflowRun) runSteps(steps []Step) (string, ProjectResult) {
+	var outputs []string
+	env := make(map[string]string)
+	for i, step := range steps {
+		if w.ctx.Canceled() {
+			return strings.Join(outputs, "\n"), ProjectResult{Error: run.ErrCanceled}
+		}
+		w.ctx.Log.Info("running step %d of %d: %s", i+1, len(steps), step)
+		if step.Name == EnvStepName {
+			env[step.EnvName] = step.EnvValue
+			continue
+		}
+		out, err := w.runStep(run.WithEnv(w.config.commandContext(w.ctx.Context), env), step)
+		if out != "" {
+			outputs = append(outputs, out)
+		}
+		if err == nil {
+			continue
+		}
+		what := step.String()
+		if res, ok := timeoutResult(err, what); ok {
+			return strings.Join(outputs, "\n"), res
+		}
+		if step.Name == PlanStepName || step.Name == ApplyStepName {
+			return strings.Join(outputs, "\n"), ProjectResult{Error: fmt.Errorf("%s\n%s", err.Error(), out)}
+		}
+		return strings.Join(outputs, "\n"), ProjectResult{Error: errors.Wrapf(err, "running step %d, %s", i+1, what)}
+	}
+	return strings.Join(outputs, "\n"), ProjectResult{}
+}
+
+// runStep runs step with stepCtx and returns its output. The output of init
+// steps isn't returned since it isn't useful once it's succeeded.
+func (w *workflowRun) runStep(stepCtx context.Context, step Step) (string, error) {
+	timeouts := w.config.Timeouts
+	switch step.Name {
+	case InitStepName:
+		initCtx, cancel := run.WithTimeout(stepCtx, timeouts.Init)
+		defer cancel()
+		_, err := runInit(initCtx, w.ctx, w.terraform, w.config, w.path, w.terraformVersion)
+		return "", err
+	case PlanStepName:
+		planCtx, cancel := run.WithTimeout(stepCtx, timeouts.Plan)
+		defer cancel()
+		return w.command(planCtx)
+	case ApplyStepName:
+		applyCtx, cancel := run.WithTimeout(stepCtx, timeouts.Apply)
+		defer cancel()
+		return w.command(applyCtx)
+	case RunStepName:
+		runCtx, cancel := run.WithTimeout(stepCtx, timeouts.Hooks)
+		defer cancel()
+		return w.run.Execute(runCtx, w.ctx.Log, w.ctx.Output, []string{step.Command}, hookContext(w.ctx, w.path, w.terraformVersion), RunStepName)
+	}
+	return "", fmt.Errorf("unknown step %q", step.Name)
+}
+
+// runInit runs terraform init and selects the environment in path, or runs
+// terraform get if version is < 0.9.0 since it doesn't have init. It returns
+// a description of the command that was run for errors.
+func runInit(cmdCtx context.Context, ctx *CommandContext, tf terraform.Runner, config ProjectConfig, path string, v *version.Version) (string, error) {
+	if initConstraint.Check(v) {
+		_, err := tf.RunInitAndEnv(cmdCtx, ctx.Log, ctx.Output, path, ctx.Command.Environment, config.GetExtraArguments("init"), v)
+		return "`terraform init`", err
+	}
+	terraformGetCmd := append([]string{"get", "-no-color"}, config.GetExtraArguments("get")...)
+	_, err := tf.RunCommandWithVersion(cmdCtx, ctx.Log, ctx.Output, path, terraformGetCmd, v, ctx.Command.Environment)
+	return "`terraform get`", err
+}
+
+// initConstraint matches the versions of terraform that have init.
+var initConstraint, _ = version.NewConstraint(">= 0.9.0")
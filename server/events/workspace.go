@@ -26,6 +26,9 @@ type Workspace interface {
 
 type FileWorkspace struct {
 	DataDir string
+	// CAFiles maps VCS hostnames to CA bundles that git should trust when
+	// cloning from them, ex. for self-hosted installations using an internal CA.
+	CAFiles map[string]string
 }
 
 // Clone git clones headRepo, checks out the branch and then returns the absolute
@@ -36,6 +39,9 @@ func (w *FileWorkspace) Clone(
 	headRepo models.Repo,
 	p models.PullRequest,
 	env string) (string, error) {
+	if err := w.migrateLegacyDir(baseRepo, p); err != nil {
+		return "", err
+	}
 	cloneDir := w.cloneDir(baseRepo, p, env)
 
 	// this is safe to do because we lock runs on repo/pull/env so no one else is using this workspace
@@ -51,7 +57,11 @@ func (w *FileWorkspace) Clone(
 	}
 
 	log.Info("git cloning %q into %q", headRepo.SanitizedCloneURL, cloneDir)
-	cloneCmd := exec.Command("git", "clone", headRepo.CloneURL, cloneDir)
+	cloneArgs := []string{"clone", headRepo.CloneURL, cloneDir}
+	if caFile, ok := w.CAFiles[headRepo.Hostname]; ok {
+		cloneArgs = append([]string{"-c", "http.sslCAInfo=" + caFile}, cloneArgs...)
+	}
+	cloneCmd := exec.Command("git", cloneArgs...)
 	if output, err := cloneCmd.CombinedOutput(); err != nil {
 		return "", errors.Wrapf(err, "cloning %s: %s", headRepo.SanitizedCloneURL, string(output))
 	}
@@ -67,6 +77,9 @@ func (w *FileWorkspace) Clone(
 }
 
 func (w *FileWorkspace) GetWorkspace(r models.Repo, p models.PullRequest, env string) (string, error) {
+	if err := w.migrateLegacyDir(r, p); err != nil {
+		return "", err
+	}
 	repoDir := w.cloneDir(r, p, env)
 	if _, err := os.Stat(repoDir); err != nil {
 		return "", errors.Wrap(err, "checking if workspace exists")
@@ -76,10 +89,37 @@ func (w *FileWorkspace) GetWorkspace(r models.Repo, p models.PullRequest, env st
 
 // Delete deletes the workspace for this repo and pull
 func (w *FileWorkspace) Delete(r models.Repo, p models.PullRequest) error {
+	if err := os.RemoveAll(w.legacyRepoPullDir(r, p)); err != nil {
+		return err
+	}
 	return os.RemoveAll(w.repoPullDir(r, p))
 }
 
+// migrateLegacyDir moves the pull request's clones from where they were
+// before their directories included the repo's hostname so that plans made
+// before then can still be applied.
+func (w *FileWorkspace) migrateLegacyDir(r models.Repo, p models.PullRequest) error {
+	legacyDir := w.legacyRepoPullDir(r, p)
+	if _, err := os.Stat(legacyDir); err != nil {
+		return nil
+	}
+	dir := w.repoPullDir(r, p)
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return errors.Wrap(err, "creating workspace")
+	}
+	return errors.Wrapf(os.Rename(legacyDir, dir), "moving workspace from %q", legacyDir)
+}
+
 func (w *FileWorkspace) repoPullDir(r models.Repo, p models.PullRequest) string {
+	return filepath.Join(w.DataDir, workspacePrefix, r.Hostname, r.FullName, strconv.Itoa(p.Num))
+}
+
+// legacyRepoPullDir is where the pull request was cloned before directories
+// included the repo's hostname.
+func (w *FileWorkspace) legacyRepoPullDir(r models.Repo, p models.PullRequest) string {
 	return filepath.Join(w.DataDir, workspacePrefix, r.FullName, strconv.Itoa(p.Num))
 }
 
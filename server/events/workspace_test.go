@@ -0,0 +1,33 @@
+package events_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server/events"
+	"github.com/hootsuite/atlantis/server/events/models"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+func TestGetWorkspace_LegacyDir(t *testing.T) {
+	t.Log("clones from before directories included the hostname should be moved so their plans can be applied")
+	dataDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(dataDir) // nolint: errcheck
+	legacyDir := filepath.Join(dataDir, "repos", "owner", "repo", "1", "default")
+	Ok(t, os.MkdirAll(legacyDir, 0755))
+	Ok(t, ioutil.WriteFile(filepath.Join(legacyDir, "default.tfplan"), nil, 0644))
+	w := &events.FileWorkspace{DataDir: dataDir}
+	repo := models.Repo{FullName: "owner/repo", Hostname: "github.com"}
+	pull := models.PullRequest{Num: 1}
+
+	dir, err := w.GetWorkspace(repo, pull, "default")
+	Ok(t, err)
+	Equals(t, filepath.Join(dataDir, "repos", "github.com", "owner", "repo", "1", "default"), dir)
+	_, err = os.Stat(filepath.Join(dir, "default.tfplan"))
+	Ok(t, err)
+	_, err = os.Stat(legacyDir)
+	Assert(t, os.IsNotExist(err), "exp legacy dir to be moved")
+}
@@ -15,28 +15,63 @@ import (
 const githubHeader = "X-Github-Event"
 const gitlabHeader = "X-Gitlab-Event"
 
+// giteaHeader is set by Gitea to the event type. Forgejo sets it too for
+// compatibility.
+const giteaHeader = "X-Gitea-Event"
+
+// azureDevopsHeader is set by Azure DevOps on service hook requests. Other
+// hosts may set it too so we check it last.
+const azureDevopsHeader = "Request-Id"
+
+// githubEnterpriseHostHeader is set by GitHub Enterprise to its hostname.
+// It's not set by github.com.
+const githubEnterpriseHostHeader = "X-Github-Enterprise-Host"
+
 type EventsController struct {
 	CommandRunner events.CommandRunner
 	PullCleaner   events.PullCleaner
 	Logger        *logging.SimpleLogger
 	Parser        events.EventParsing
-	// GithubWebHookSecret is the secret added to this webhook via the GitHub
-	// UI that identifies this call as coming from GitHub. If empty, no
-	// request validation is done.
-	GithubWebHookSecret    []byte
+	// GithubWebHookSecrets maps each supported GitHub hostname to the secret
+	// added to the webhook via the GitHub UI that identifies calls as coming
+	// from that host. If a secret is empty, no request validation is done.
+	GithubWebHookSecrets   map[string][]byte
 	GithubRequestValidator GithubRequestValidator
 	GitlabRequestParser    GitlabRequestParser
 	// GitlabWebHookSecret is the secret added to this webhook via the GitLab
 	// UI that identifies this call as coming from GitLab. If empty, no
 	// request validation is done.
 	GitlabWebHookSecret []byte
+	GiteaRequestParser  GiteaRequestParser
+	// GiteaWebHookSecret is the secret added to this webhook via the Gitea
+	// UI that is used to sign calls from Gitea. If empty, no request
+	// validation is done.
+	GiteaWebHookSecret       []byte
+	AzureDevopsRequestParser AzureDevopsRequestParser
+	// AzureDevopsWebHookUser and AzureDevopsWebHookPassword are the basic
+	// auth credentials added to the service hook via the Azure DevOps UI
+	// that identify calls as coming from Azure DevOps. If both are empty, no
+	// request validation is done.
+	AzureDevopsWebHookUser     []byte
+	AzureDevopsWebHookPassword []byte
 	// SupportedVCSHosts is which VCS hosts Atlantis was configured upon
 	// startup to support.
 	SupportedVCSHosts []vcs.Host
+	// RepoWhitelist is used to ignore events from repos that Atlantis
+	// hasn't been configured to run commands for.
+	RepoWhitelist *events.RepoWhitelist
 }
 
 func (e *EventsController) Post(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get(githubHeader) != "" {
+	// Gitea and Forgejo also send the GitHub header so they're checked first.
+	if r.Header.Get(giteaHeader) != "" {
+		if !e.supportsHost(vcs.Gitea) {
+			e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request since not configured to support Gitea")
+			return
+		}
+		e.handleGiteaPost(w, r)
+		return
+	} else if r.Header.Get(githubHeader) != "" {
 		if !e.supportsHost(vcs.Github) {
 			e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request since not configured to support GitHub")
 			return
@@ -50,6 +85,13 @@ func (e *EventsController) Post(w http.ResponseWriter, r *http.Request) {
 		}
 		e.handleGitlabPost(w, r)
 		return
+	} else if r.Header.Get(azureDevopsHeader) != "" {
+		if !e.supportsHost(vcs.AzureDevops) {
+			e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request since not configured to support Azure DevOps")
+			return
+		}
+		e.handleAzureDevopsPost(w, r)
+		return
 	}
 	e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request")
 }
@@ -81,9 +123,51 @@ func (e *EventsController) handleGitlabPost(w http.ResponseWriter, r *http.Reque
 
 }
 
+func (e *EventsController) handleGiteaPost(w http.ResponseWriter, r *http.Request) {
+	event, err := e.GiteaRequestParser.Validate(r, e.GiteaWebHookSecret)
+	if err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "%s", err)
+		return
+	}
+	switch event := event.(type) {
+	case vcs.GiteaIssueCommentEvent:
+		e.HandleGiteaCommentEvent(w, event)
+	case vcs.GiteaPullRequestEvent:
+		e.HandleGiteaPullRequestEvent(w, event)
+	default:
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event")
+	}
+}
+
+func (e *EventsController) handleAzureDevopsPost(w http.ResponseWriter, r *http.Request) {
+	event, err := e.AzureDevopsRequestParser.Validate(r, e.AzureDevopsWebHookUser, e.AzureDevopsWebHookPassword)
+	if err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "%s", err)
+		return
+	}
+	switch event := event.(type) {
+	case vcs.AzureDevopsPullCommentEvent:
+		e.HandleAzureDevopsCommentEvent(w, event)
+	case vcs.AzureDevopsPullEvent:
+		e.HandleAzureDevopsPullEvent(w, event)
+	default:
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event")
+	}
+}
+
 func (e *EventsController) handleGithubPost(w http.ResponseWriter, r *http.Request) {
+	hostname := r.Header.Get(githubEnterpriseHostHeader)
+	if hostname == "" {
+		hostname = "github.com"
+	}
+	secret, ok := e.GithubWebHookSecrets[hostname]
+	if !ok {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Ignoring request since not configured to support GitHub host %s", hostname)
+		return
+	}
+
 	// Validate the request against the optional webhook secret.
-	payload, err := e.GithubRequestValidator.Validate(r, e.GithubWebHookSecret)
+	payload, err := e.GithubRequestValidator.Validate(r, secret)
 	if err != nil {
 		e.respond(w, logging.Warn, http.StatusBadRequest, err.Error())
 		return
@@ -93,15 +177,17 @@ func (e *EventsController) handleGithubPost(w http.ResponseWriter, r *http.Reque
 	event, _ := github.ParseWebHook(github.WebHookType(r), payload)
 	switch event := event.(type) {
 	case *github.IssueCommentEvent:
-		e.HandleGithubCommentEvent(w, event, githubReqID)
+		e.HandleGithubCommentEvent(w, event, githubReqID, hostname)
 	case *github.PullRequestEvent:
-		e.HandleGithubPullRequestEvent(w, event, githubReqID)
+		e.HandleGithubPullRequestEvent(w, event, githubReqID, hostname)
 	default:
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event %s", githubReqID)
 	}
 }
 
-func (e *EventsController) HandleGithubCommentEvent(w http.ResponseWriter, event *github.IssueCommentEvent, githubReqID string) {
+// HandleGithubCommentEvent runs the command in the comment, if any.
+// hostname is the GitHub host the webhook was validated for.
+func (e *EventsController) HandleGithubCommentEvent(w http.ResponseWriter, event *github.IssueCommentEvent, githubReqID string, hostname string) {
 	if event.GetAction() != "created" {
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring comment event since action was not created %s", githubReqID)
 		return
@@ -112,6 +198,10 @@ func (e *EventsController) HandleGithubCommentEvent(w http.ResponseWriter, event
 		e.respond(w, logging.Error, http.StatusBadRequest, "Failed parsing event: %v %s", err, githubReqID)
 		return
 	}
+	if baseRepo.Hostname != hostname {
+		e.respondWrongHost(w, baseRepo, hostname)
+		return
+	}
 
 	command, err := e.Parser.DetermineCommand(event.Comment.GetBody(), vcs.Github)
 	if err != nil {
@@ -119,6 +209,13 @@ func (e *EventsController) HandleGithubCommentEvent(w http.ResponseWriter, event
 		return
 	}
 
+	// At this point we know it's a command we're not supposed to ignore, so now
+	// we check if this repo is allowed to run commands in the first place.
+	if !e.RepoWhitelist.IsWhitelisted(baseRepo.FullName, baseRepo.Hostname) {
+		e.respondNotWhitelisted(w, baseRepo)
+		return
+	}
+
 	// Respond with success and then actually execute the command asynchronously.
 	// We use a goroutine so that this function returns and the connection is
 	// closed.
@@ -133,6 +230,10 @@ func (e *EventsController) HandleGitlabCommentEvent(w http.ResponseWriter, event
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring: %s", err)
 		return
 	}
+	if !e.RepoWhitelist.IsWhitelisted(baseRepo.FullName, baseRepo.Hostname) {
+		e.respondNotWhitelisted(w, baseRepo)
+		return
+	}
 
 	// Respond with success and then actually execute the command asynchronously.
 	// We use a goroutine so that this function returns and the connection is
@@ -148,6 +249,10 @@ func (e *EventsController) HandleGitlabMergeRequestEvent(w http.ResponseWriter,
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring opened merge request event")
 		return
 	}
+	if !e.RepoWhitelist.IsWhitelisted(repo.FullName, repo.Hostname) {
+		e.respondNotWhitelisted(w, repo)
+		return
+	}
 	if err := e.PullCleaner.CleanUpPull(repo, pull, vcs.Gitlab); err != nil {
 		e.respond(w, logging.Error, http.StatusInternalServerError, "Error cleaning pull request: %s", err)
 		return
@@ -156,8 +261,126 @@ func (e *EventsController) HandleGitlabMergeRequestEvent(w http.ResponseWriter,
 	fmt.Fprintln(w, "Merge request cleaned successfully")
 }
 
-// HandleGithubPullRequestEvent will delete any locks associated with the pull request
-func (e *EventsController) HandleGithubPullRequestEvent(w http.ResponseWriter, pullEvent *github.PullRequestEvent, githubReqID string) {
+// HandleGiteaCommentEvent runs the command in the comment, if any.
+func (e *EventsController) HandleGiteaCommentEvent(w http.ResponseWriter, event vcs.GiteaIssueCommentEvent) {
+	if event.Action != "created" {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring comment event since action was not created")
+		return
+	}
+	if !event.IsPull {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring comment event since it's not on a pull request")
+		return
+	}
+	baseRepo, user, pullNum, err := e.Parser.ParseGiteaIssueCommentEvent(event)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Failed parsing event: %v", err)
+		return
+	}
+	command, err := e.Parser.DetermineCommand(event.Comment.Body, vcs.Gitea)
+	if err != nil {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring: %s", err)
+		return
+	}
+	if !e.RepoWhitelist.IsWhitelisted(baseRepo.FullName, baseRepo.Hostname) {
+		e.respondNotWhitelisted(w, baseRepo)
+		return
+	}
+
+	// Respond with success and then actually execute the command asynchronously.
+	// We use a goroutine so that this function returns and the connection is
+	// closed.
+	fmt.Fprintln(w, "Processing...")
+	go e.CommandRunner.ExecuteCommand(baseRepo, models.Repo{}, user, pullNum, command, vcs.Gitea)
+}
+
+// HandleGiteaPullRequestEvent will delete any locks associated with the pull request.
+func (e *EventsController) HandleGiteaPullRequestEvent(w http.ResponseWriter, event vcs.GiteaPullRequestEvent) {
+	if event.Action != "closed" {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring pull request event since action was not closed")
+		return
+	}
+	pull, _, err := e.Parser.ParseGiteaPull(&event.PullRequest)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s", err)
+		return
+	}
+	repo, err := e.Parser.ParseGiteaRepo(&event.Repository)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing repo data: %s", err)
+		return
+	}
+	if !e.RepoWhitelist.IsWhitelisted(repo.FullName, repo.Hostname) {
+		e.respondNotWhitelisted(w, repo)
+		return
+	}
+	if err := e.PullCleaner.CleanUpPull(repo, pull, vcs.Gitea); err != nil {
+		e.respond(w, logging.Error, http.StatusInternalServerError, "Error cleaning pull request: %s", err)
+		return
+	}
+	e.Logger.Info("deleted locks and workspace for repo %s, pull %d", repo.FullName, pull.Num)
+	fmt.Fprintln(w, "Pull request cleaned successfully")
+}
+
+// HandleAzureDevopsCommentEvent runs the command in the comment, if any.
+func (e *EventsController) HandleAzureDevopsCommentEvent(w http.ResponseWriter, event vcs.AzureDevopsPullCommentEvent) {
+	baseRepo, user, pullNum, err := e.Parser.ParseAzureDevopsPullCommentEvent(event)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Failed parsing event: %v", err)
+		return
+	}
+	command, err := e.Parser.DetermineCommand(event.Comment.Content, vcs.AzureDevops)
+	if err != nil {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring: %s", err)
+		return
+	}
+	if !e.RepoWhitelist.IsWhitelisted(baseRepo.FullName, baseRepo.Hostname) {
+		e.respondNotWhitelisted(w, baseRepo)
+		return
+	}
+
+	// Respond with success and then actually execute the command asynchronously.
+	// We use a goroutine so that this function returns and the connection is
+	// closed.
+	fmt.Fprintln(w, "Processing...")
+	go e.CommandRunner.ExecuteCommand(baseRepo, models.Repo{}, user, pullNum, command, vcs.AzureDevops)
+}
+
+// HandleAzureDevopsPullEvent will delete any locks associated with the pull
+// request once it's completed or abandoned.
+func (e *EventsController) HandleAzureDevopsPullEvent(w http.ResponseWriter, event vcs.AzureDevopsPullEvent) {
+	if event.EventType != vcs.AzureDevopsPullUpdatedEventType {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring pull request event since it was not an update")
+		return
+	}
+	pull, _, err := e.Parser.ParseAzureDevopsPull(&event.PullRequest)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s", err)
+		return
+	}
+	if pull.State != models.Closed {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring pull request event since pull request is still active")
+		return
+	}
+	repo, err := e.Parser.ParseAzureDevopsRepo(&event.PullRequest.Repository)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing repo data: %s", err)
+		return
+	}
+	if !e.RepoWhitelist.IsWhitelisted(repo.FullName, repo.Hostname) {
+		e.respondNotWhitelisted(w, repo)
+		return
+	}
+	if err := e.PullCleaner.CleanUpPull(repo, pull, vcs.AzureDevops); err != nil {
+		e.respond(w, logging.Error, http.StatusInternalServerError, "Error cleaning pull request: %s", err)
+		return
+	}
+	e.Logger.Info("deleted locks and workspace for repo %s, pull %d", repo.FullName, pull.Num)
+	fmt.Fprintln(w, "Pull request cleaned successfully")
+}
+
+// HandleGithubPullRequestEvent will delete any locks associated with the pull request.
+// hostname is the GitHub host the webhook was validated for.
+func (e *EventsController) HandleGithubPullRequestEvent(w http.ResponseWriter, pullEvent *github.PullRequestEvent, githubReqID string, hostname string) {
 	if pullEvent.GetAction() != "closed" {
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring pull request event since action was not closed %s", githubReqID)
 		return
@@ -172,6 +395,14 @@ func (e *EventsController) HandleGithubPullRequestEvent(w http.ResponseWriter, p
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing repo data: %s", err)
 		return
 	}
+	if repo.Hostname != hostname {
+		e.respondWrongHost(w, repo, hostname)
+		return
+	}
+	if !e.RepoWhitelist.IsWhitelisted(repo.FullName, repo.Hostname) {
+		e.respondNotWhitelisted(w, repo)
+		return
+	}
 
 	if err := e.PullCleaner.CleanUpPull(repo, pull, vcs.Github); err != nil {
 		e.respond(w, logging.Error, http.StatusInternalServerError, "Error cleaning pull request: %s", err)
@@ -181,6 +412,19 @@ func (e *EventsController) HandleGithubPullRequestEvent(w http.ResponseWriter, p
 	fmt.Fprintln(w, "Pull request cleaned successfully")
 }
 
+// respondNotWhitelisted logs and responds to a request for a repo that isn't
+// in the repo whitelist.
+func (e *EventsController) respondNotWhitelisted(w http.ResponseWriter, repo models.Repo) {
+	e.respond(w, logging.Warn, http.StatusForbidden, "Ignoring request for repo %s/%s since it is not in the repo whitelist", repo.Hostname, repo.FullName)
+}
+
+// respondWrongHost responds to a GitHub webhook whose repo isn't on the
+// host whose secret was used to validate it. Otherwise anyone with the secret
+// for one host could run commands on repos from another.
+func (e *EventsController) respondWrongHost(w http.ResponseWriter, repo models.Repo, hostname string) {
+	e.respond(w, logging.Warn, http.StatusBadRequest, "Ignoring request for repo %s/%s since it was sent by GitHub host %s", repo.Hostname, repo.FullName, hostname)
+}
+
 func (e *EventsController) respond(w http.ResponseWriter, lvl logging.LogLevel, code int, format string, args ...interface{}) {
 	response := fmt.Sprintf(format, args...)
 	e.Logger.Log(lvl, response)
@@ -22,6 +22,8 @@ import (
 
 const githubHeader = "X-Github-Event"
 const gitlabHeader = "X-Gitlab-Event"
+const giteaHeader = "X-Gitea-Event"
+const azureDevopsHeader = "Request-Id"
 
 var secret = []byte("secret")
 var eventsReq *http.Request
@@ -54,6 +56,187 @@ func TestPost_UnsupportedVCSGitlab(t *testing.T) {
 	responseContains(t, w, http.StatusBadRequest, "Ignoring request since not configured to support GitLab")
 }
 
+func TestPost_UnsupportedVCSGitea(t *testing.T) {
+	t.Log("when the request is for an unsupported vcs a 400 is returned")
+	e, _, _, _, _, _ := setup(t)
+	e.SupportedVCSHosts = nil
+	eventsReq.Header.Set(giteaHeader, "value")
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusBadRequest, "Ignoring request since not configured to support Gitea")
+}
+
+func TestPost_InvalidGiteaSecret(t *testing.T) {
+	t.Log("when the gitea payload can't be validated a 400 is returned")
+	e, gt, _, _, _ := setupGitea(t)
+	eventsReq.Header.Set(giteaHeader, "issue_comment")
+	When(gt.Validate(eventsReq, secret)).ThenReturn(nil, errors.New("err"))
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusBadRequest, "err")
+}
+
+func TestPost_GiteaCommentNotPull(t *testing.T) {
+	t.Log("when the event is a gitea comment on an issue we ignore it")
+	e, gt, _, cr, _ := setupGitea(t)
+	eventsReq.Header.Set(giteaHeader, "issue_comment")
+	When(gt.Validate(eventsReq, secret)).ThenReturn(vcs.GiteaIssueCommentEvent{Action: "created", IsPull: false}, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Ignoring comment event since it's not on a pull request")
+	cr.VerifyWasCalled(Never()).ExecuteCommand(matchers.AnyModelsRepo(), matchers.AnyModelsRepo(), matchers.AnyModelsUser(), AnyInt(), matchers.AnyPtrToEventsCommand(), matchers.AnyVcsHost())
+}
+
+func TestPost_GiteaCommentSuccess(t *testing.T) {
+	t.Log("when the event is a gitea comment with a valid command we call the command handler")
+	e, gt, p, cr, _ := setupGitea(t)
+	eventsReq.Header.Set(giteaHeader, "issue_comment")
+	event := vcs.GiteaIssueCommentEvent{Action: "created", IsPull: true, Comment: vcs.GiteaComment{Body: "atlantis plan"}}
+	When(gt.Validate(eventsReq, secret)).ThenReturn(event, nil)
+	baseRepo := models.Repo{FullName: "owner/repo", Hostname: "gitea.example.com"}
+	user := models.User{Username: "user"}
+	cmd := events.Command{}
+	When(p.ParseGiteaIssueCommentEvent(matchers.AnyVcsGiteaIssueCommentEvent())).ThenReturn(baseRepo, user, 2, nil)
+	When(p.DetermineCommand("atlantis plan", vcs.Gitea)).ThenReturn(&cmd, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Processing...")
+
+	// wait for 200ms so goroutine is called
+	time.Sleep(200 * time.Millisecond)
+	cr.VerifyWasCalledOnce().ExecuteCommand(baseRepo, models.Repo{}, user, 2, &cmd, vcs.Gitea)
+}
+
+func TestPost_GiteaWithGithubHeader(t *testing.T) {
+	t.Log("gitea webhooks also have the github header but should still be handled as gitea")
+	e, gt, p, cr, _ := setupGitea(t)
+	eventsReq.Header.Set(giteaHeader, "issue_comment")
+	eventsReq.Header.Set(githubHeader, "issue_comment")
+	event := vcs.GiteaIssueCommentEvent{Action: "created", IsPull: true, Comment: vcs.GiteaComment{Body: "atlantis plan"}}
+	When(gt.Validate(eventsReq, secret)).ThenReturn(event, nil)
+	baseRepo := models.Repo{FullName: "owner/repo", Hostname: "gitea.example.com"}
+	user := models.User{Username: "user"}
+	cmd := events.Command{}
+	When(p.ParseGiteaIssueCommentEvent(matchers.AnyVcsGiteaIssueCommentEvent())).ThenReturn(baseRepo, user, 2, nil)
+	When(p.DetermineCommand("atlantis plan", vcs.Gitea)).ThenReturn(&cmd, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Processing...")
+
+	// wait for 200ms so goroutine is called
+	time.Sleep(200 * time.Millisecond)
+	cr.VerifyWasCalledOnce().ExecuteCommand(baseRepo, models.Repo{}, user, 2, &cmd, vcs.Gitea)
+}
+
+func TestPost_GiteaPullRequestSuccess(t *testing.T) {
+	t.Log("when the event is a closed gitea pull request we clean it up")
+	e, gt, p, _, c := setupGitea(t)
+	eventsReq.Header.Set(giteaHeader, "pull_request")
+	event := vcs.GiteaPullRequestEvent{Action: "closed"}
+	When(gt.Validate(eventsReq, secret)).ThenReturn(event, nil)
+	repo := models.Repo{FullName: "owner/repo", Hostname: "gitea.example.com"}
+	pull := models.PullRequest{Num: 2}
+	When(p.ParseGiteaPull(matchers.AnyPtrToVcsGiteaPullRequest())).ThenReturn(pull, repo, nil)
+	When(p.ParseGiteaRepo(matchers.AnyPtrToVcsGiteaRepository())).ThenReturn(repo, nil)
+	When(c.CleanUpPull(repo, pull, vcs.Gitea)).ThenReturn(nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Pull request cleaned successfully")
+	c.VerifyWasCalledOnce().CleanUpPull(repo, pull, vcs.Gitea)
+}
+
+func TestPost_GiteaPullRequestNotClosed(t *testing.T) {
+	t.Log("when the event is a gitea pull request but it's not a closed event we ignore it")
+	e, gt, _, _, c := setupGitea(t)
+	eventsReq.Header.Set(giteaHeader, "pull_request")
+	When(gt.Validate(eventsReq, secret)).ThenReturn(vcs.GiteaPullRequestEvent{Action: "opened"}, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Ignoring pull request event since action was not closed")
+	c.VerifyWasCalled(Never()).CleanUpPull(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())
+}
+
+func TestPost_UnsupportedVCSAzureDevops(t *testing.T) {
+	t.Log("when the request is for an unsupported vcs a 400 is returned")
+	e, _, _, _, _, _ := setup(t)
+	e.SupportedVCSHosts = nil
+	eventsReq.Header.Set(azureDevopsHeader, "value")
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusBadRequest, "Ignoring request since not configured to support Azure DevOps")
+}
+
+func TestPost_InvalidAzureDevopsCredentials(t *testing.T) {
+	t.Log("when the azure devops payload can't be validated a 400 is returned")
+	e, ad, _, _, _ := setupAzureDevops(t)
+	eventsReq.Header.Set(azureDevopsHeader, "value")
+	When(ad.Validate(eventsReq, []byte("user"), secret)).ThenReturn(nil, errors.New("err"))
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusBadRequest, "err")
+}
+
+func TestPost_AzureDevopsCommentSuccess(t *testing.T) {
+	t.Log("when the event is an azure devops comment with a valid command we call the command handler")
+	e, ad, p, cr, _ := setupAzureDevops(t)
+	eventsReq.Header.Set(azureDevopsHeader, "value")
+	event := vcs.AzureDevopsPullCommentEvent{Comment: vcs.AzureDevopsComment{Content: "atlantis plan"}}
+	When(ad.Validate(eventsReq, []byte("user"), secret)).ThenReturn(event, nil)
+	baseRepo := models.Repo{FullName: "org/project/repo", Hostname: "dev.azure.com"}
+	user := models.User{Username: "user@example.com"}
+	cmd := events.Command{}
+	When(p.ParseAzureDevopsPullCommentEvent(matchers.AnyVcsAzureDevopsPullCommentEvent())).ThenReturn(baseRepo, user, 1, nil)
+	When(p.DetermineCommand("atlantis plan", vcs.AzureDevops)).ThenReturn(&cmd, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Processing...")
+
+	// wait for 200ms so goroutine is called
+	time.Sleep(200 * time.Millisecond)
+	cr.VerifyWasCalledOnce().ExecuteCommand(baseRepo, models.Repo{}, user, 1, &cmd, vcs.AzureDevops)
+}
+
+func TestPost_AzureDevopsPullCompleted(t *testing.T) {
+	t.Log("when the event is an update to a completed azure devops pull request we clean it up")
+	e, ad, p, _, c := setupAzureDevops(t)
+	eventsReq.Header.Set(azureDevopsHeader, "value")
+	event := vcs.AzureDevopsPullEvent{EventType: vcs.AzureDevopsPullUpdatedEventType}
+	When(ad.Validate(eventsReq, []byte("user"), secret)).ThenReturn(event, nil)
+	repo := models.Repo{FullName: "org/project/repo", Hostname: "dev.azure.com"}
+	pull := models.PullRequest{Num: 1, State: models.Closed}
+	When(p.ParseAzureDevopsPull(matchers.AnyPtrToVcsAzureDevopsPullRequest())).ThenReturn(pull, repo, nil)
+	When(p.ParseAzureDevopsRepo(matchers.AnyPtrToVcsAzureDevopsRepository())).ThenReturn(repo, nil)
+	When(c.CleanUpPull(repo, pull, vcs.AzureDevops)).ThenReturn(nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Pull request cleaned successfully")
+	c.VerifyWasCalledOnce().CleanUpPull(repo, pull, vcs.AzureDevops)
+}
+
+func TestPost_AzureDevopsPullActive(t *testing.T) {
+	t.Log("when the event is an update to an active azure devops pull request we ignore it")
+	e, ad, p, _, c := setupAzureDevops(t)
+	eventsReq.Header.Set(azureDevopsHeader, "value")
+	event := vcs.AzureDevopsPullEvent{EventType: vcs.AzureDevopsPullUpdatedEventType}
+	When(ad.Validate(eventsReq, []byte("user"), secret)).ThenReturn(event, nil)
+	When(p.ParseAzureDevopsPull(matchers.AnyPtrToVcsAzureDevopsPullRequest())).ThenReturn(models.PullRequest{State: models.Open}, models.Repo{}, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Ignoring pull request event since pull request is still active")
+	c.VerifyWasCalled(Never()).CleanUpPull(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())
+}
+
+func TestPost_AzureDevopsPullCreated(t *testing.T) {
+	t.Log("when the event is a created azure devops pull request we ignore it")
+	e, ad, _, _, c := setupAzureDevops(t)
+	eventsReq.Header.Set(azureDevopsHeader, "value")
+	When(ad.Validate(eventsReq, []byte("user"), secret)).ThenReturn(vcs.AzureDevopsPullEvent{EventType: vcs.AzureDevopsPullCreatedEventType}, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Ignoring pull request event since it was not an update")
+	c.VerifyWasCalled(Never()).CleanUpPull(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())
+}
+
 func TestPost_InvalidGithubSecret(t *testing.T) {
 	t.Log("when the github payload can't be validated a 400 is returned")
 	e, v, _, _, _, _ := setup(t)
@@ -135,7 +318,7 @@ func TestPost_GithubCommentInvalidCommand(t *testing.T) {
 	eventsReq.Header.Set(githubHeader, "issue_comment")
 	event := `{"action": "created"}`
 	When(v.Validate(eventsReq, secret)).ThenReturn([]byte(event), nil)
-	When(p.ParseGithubIssueCommentEvent(matchers.AnyPtrToGithubIssueCommentEvent())).ThenReturn(models.Repo{}, models.User{}, 1, nil)
+	When(p.ParseGithubIssueCommentEvent(matchers.AnyPtrToGithubIssueCommentEvent())).ThenReturn(models.Repo{Hostname: "github.com"}, models.User{}, 1, nil)
 	When(p.DetermineCommand("", vcs.Github)).ThenReturn(nil, errors.New("err"))
 	w := httptest.NewRecorder()
 	e.Post(w, eventsReq)
@@ -162,7 +345,7 @@ func TestPost_GithubCommentSuccess(t *testing.T) {
 	eventsReq.Header.Set(githubHeader, "issue_comment")
 	event := `{"action": "created"}`
 	When(v.Validate(eventsReq, secret)).ThenReturn([]byte(event), nil)
-	baseRepo := models.Repo{}
+	baseRepo := models.Repo{Hostname: "github.com"}
 	user := models.User{}
 	cmd := events.Command{}
 	When(p.ParseGithubIssueCommentEvent(matchers.AnyPtrToGithubIssueCommentEvent())).ThenReturn(baseRepo, user, 1, nil)
@@ -173,7 +356,103 @@ func TestPost_GithubCommentSuccess(t *testing.T) {
 
 	// wait for 200ms so goroutine is called
 	time.Sleep(200 * time.Millisecond)
-	cr.VerifyWasCalledOnce().ExecuteCommand(baseRepo, baseRepo, user, 1, &cmd, vcs.Github)
+	cr.VerifyWasCalledOnce().ExecuteCommand(baseRepo, models.Repo{}, user, 1, &cmd, vcs.Github)
+}
+
+func TestPost_GitlabCommentNotWhitelisted(t *testing.T) {
+	t.Log("when the event is a gitlab comment from a repo that isn't whitelisted we ignore it")
+	e, _, gl, p, cr, _ := setup(t)
+	e.RepoWhitelist = &events.RepoWhitelist{Whitelist: "gitlab.com/owner/*"}
+	eventsReq.Header.Set(gitlabHeader, "value")
+	event := gitlab.MergeCommentEvent{}
+	cmd := events.Command{}
+	repo := models.Repo{FullName: "other/repo", Hostname: "gitlab.com"}
+	When(gl.Validate(eventsReq, secret)).ThenReturn(event, nil)
+	When(p.ParseGitlabMergeCommentEvent(event)).ThenReturn(repo, repo, models.User{})
+	When(p.DetermineCommand("", vcs.Gitlab)).ThenReturn(&cmd, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusForbidden, "Ignoring request for repo gitlab.com/other/repo since it is not in the repo whitelist")
+	cr.VerifyWasCalled(Never()).ExecuteCommand(matchers.AnyModelsRepo(), matchers.AnyModelsRepo(), matchers.AnyModelsUser(), AnyInt(), matchers.AnyPtrToEventsCommand(), matchers.AnyVcsHost())
+}
+
+func TestPost_GithubCommentNotWhitelisted(t *testing.T) {
+	t.Log("when the event is a github comment from a repo that isn't whitelisted we ignore it")
+	e, v, _, p, cr, _ := setup(t)
+	e.RepoWhitelist = &events.RepoWhitelist{Whitelist: "github.com/owner/*"}
+	eventsReq.Header.Set(githubHeader, "issue_comment")
+	event := `{"action": "created"}`
+	When(v.Validate(eventsReq, secret)).ThenReturn([]byte(event), nil)
+	cmd := events.Command{}
+	repo := models.Repo{FullName: "other/repo", Hostname: "github.com"}
+	When(p.ParseGithubIssueCommentEvent(matchers.AnyPtrToGithubIssueCommentEvent())).ThenReturn(repo, models.User{}, 1, nil)
+	When(p.DetermineCommand("", vcs.Github)).ThenReturn(&cmd, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusForbidden, "Ignoring request for repo github.com/other/repo since it is not in the repo whitelist")
+	cr.VerifyWasCalled(Never()).ExecuteCommand(matchers.AnyModelsRepo(), matchers.AnyModelsRepo(), matchers.AnyModelsUser(), AnyInt(), matchers.AnyPtrToEventsCommand(), matchers.AnyVcsHost())
+}
+
+func TestPost_GithubUnsupportedEnterpriseHost(t *testing.T) {
+	t.Log("when the request is from a GitHub Enterprise host we're not configured for a 400 is returned")
+	e, v, _, _, _, _ := setup(t)
+	eventsReq.Header.Set(githubHeader, "issue_comment")
+	eventsReq.Header.Set("X-Github-Enterprise-Host", "ghe.example.com")
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusBadRequest, "Ignoring request since not configured to support GitHub host ghe.example.com")
+	v.VerifyWasCalled(Never()).Validate(eventsReq, secret)
+}
+
+func TestPost_GithubEnterpriseHostSecret(t *testing.T) {
+	t.Log("when the request is from a GitHub Enterprise host we validate it with that host's secret")
+	e, v, _, p, cr, _ := setup(t)
+	gheSecret := []byte("ghe-secret")
+	e.GithubWebHookSecrets["ghe.example.com"] = gheSecret
+	eventsReq.Header.Set(githubHeader, "issue_comment")
+	eventsReq.Header.Set("X-Github-Enterprise-Host", "ghe.example.com")
+	event := `{"action": "created"}`
+	When(v.Validate(eventsReq, gheSecret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{FullName: "owner/repo", Hostname: "ghe.example.com"}
+	cmd := events.Command{}
+	When(p.ParseGithubIssueCommentEvent(matchers.AnyPtrToGithubIssueCommentEvent())).ThenReturn(baseRepo, models.User{}, 1, nil)
+	When(p.DetermineCommand("", vcs.Github)).ThenReturn(&cmd, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusOK, "Processing...")
+
+	// wait for 200ms so goroutine is called
+	time.Sleep(200 * time.Millisecond)
+	cr.VerifyWasCalledOnce().ExecuteCommand(baseRepo, models.Repo{}, models.User{}, 1, &cmd, vcs.Github)
+}
+
+func TestPost_GithubCommentWrongHost(t *testing.T) {
+	t.Log("when the repo in a github comment isn't on the host that sent it we ignore it")
+	e, v, _, p, cr, _ := setup(t)
+	eventsReq.Header.Set(githubHeader, "issue_comment")
+	event := `{"action": "created"}`
+	When(v.Validate(eventsReq, secret)).ThenReturn([]byte(event), nil)
+	repo := models.Repo{FullName: "owner/repo", Hostname: "ghe.example.com"}
+	When(p.ParseGithubIssueCommentEvent(matchers.AnyPtrToGithubIssueCommentEvent())).ThenReturn(repo, models.User{}, 1, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusBadRequest, "Ignoring request for repo ghe.example.com/owner/repo since it was sent by GitHub host github.com")
+	cr.VerifyWasCalled(Never()).ExecuteCommand(matchers.AnyModelsRepo(), matchers.AnyModelsRepo(), matchers.AnyModelsUser(), AnyInt(), matchers.AnyPtrToEventsCommand(), matchers.AnyVcsHost())
+}
+
+func TestPost_GithubPullRequestWrongHost(t *testing.T) {
+	t.Log("when the repo in a github pull request event isn't on the host that sent it we don't clean it up")
+	e, v, _, p, _, c := setup(t)
+	eventsReq.Header.Set(githubHeader, "pull_request")
+	event := `{"action": "closed"}`
+	When(v.Validate(eventsReq, secret)).ThenReturn([]byte(event), nil)
+	repo := models.Repo{FullName: "owner/repo", Hostname: "ghe.example.com"}
+	When(p.ParseGithubPull(matchers.AnyPtrToGithubPullRequest())).ThenReturn(models.PullRequest{}, repo, nil)
+	When(p.ParseGithubRepo(matchers.AnyPtrToGithubRepository())).ThenReturn(repo, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusBadRequest, "Ignoring request for repo ghe.example.com/owner/repo since it was sent by GitHub host github.com")
+	c.VerifyWasCalled(Never()).CleanUpPull(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())
 }
 
 func TestPost_GithubPullRequestNotClosed(t *testing.T) {
@@ -234,7 +513,7 @@ func TestPost_GithubPullRequestErrCleaningPull(t *testing.T) {
 
 	event := `{"action": "closed"}`
 	When(v.Validate(eventsReq, secret)).ThenReturn([]byte(event), nil)
-	repo := models.Repo{}
+	repo := models.Repo{Hostname: "github.com"}
 	pull := models.PullRequest{}
 	When(p.ParseGithubPull(matchers.AnyPtrToGithubPullRequest())).ThenReturn(pull, repo, nil)
 	When(p.ParseGithubRepo(matchers.AnyPtrToGithubRepository())).ThenReturn(repo, nil)
@@ -259,6 +538,38 @@ func TestPost_GitlabMergeRequestErrCleaningPull(t *testing.T) {
 	responseContains(t, w, http.StatusInternalServerError, "Error cleaning pull request: err")
 }
 
+func TestPost_GithubPullRequestNotWhitelisted(t *testing.T) {
+	t.Log("when the event is a github pull request from a repo that isn't whitelisted we don't clean it up")
+	e, v, _, p, _, c := setup(t)
+	e.RepoWhitelist = &events.RepoWhitelist{Whitelist: "github.com/owner/*"}
+	eventsReq.Header.Set(githubHeader, "pull_request")
+
+	event := `{"action": "closed"}`
+	When(v.Validate(eventsReq, secret)).ThenReturn([]byte(event), nil)
+	repo := models.Repo{FullName: "other/repo", Hostname: "github.com"}
+	When(p.ParseGithubPull(matchers.AnyPtrToGithubPullRequest())).ThenReturn(models.PullRequest{}, repo, nil)
+	When(p.ParseGithubRepo(matchers.AnyPtrToGithubRepository())).ThenReturn(repo, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusForbidden, "Ignoring request for repo github.com/other/repo since it is not in the repo whitelist")
+	c.VerifyWasCalled(Never()).CleanUpPull(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())
+}
+
+func TestPost_GitlabMergeRequestNotWhitelisted(t *testing.T) {
+	t.Log("when the event is a gitlab merge request from a repo that isn't whitelisted we don't clean it up")
+	e, _, gl, p, _, c := setup(t)
+	e.RepoWhitelist = &events.RepoWhitelist{Whitelist: "gitlab.com/owner/*"}
+	eventsReq.Header.Set(gitlabHeader, "value")
+	event := gitlab.MergeEvent{}
+	When(gl.Validate(eventsReq, secret)).ThenReturn(event, nil)
+	repo := models.Repo{FullName: "other/repo", Hostname: "gitlab.com"}
+	When(p.ParseGitlabMergeEvent(event)).ThenReturn(models.PullRequest{State: models.Closed}, repo)
+	w := httptest.NewRecorder()
+	e.Post(w, eventsReq)
+	responseContains(t, w, http.StatusForbidden, "Ignoring request for repo gitlab.com/other/repo since it is not in the repo whitelist")
+	c.VerifyWasCalled(Never()).CleanUpPull(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyVcsHost())
+}
+
 func TestPost_GithubPullRequestSuccess(t *testing.T) {
 	t.Log("when the event is a pull request and everything works we return a 200")
 	e, v, _, p, _, c := setup(t)
@@ -266,7 +577,7 @@ func TestPost_GithubPullRequestSuccess(t *testing.T) {
 
 	event := `{"action": "closed"}`
 	When(v.Validate(eventsReq, secret)).ThenReturn([]byte(event), nil)
-	repo := models.Repo{}
+	repo := models.Repo{Hostname: "github.com"}
 	pull := models.PullRequest{}
 	When(p.ParseGithubPull(matchers.AnyPtrToGithubPullRequest())).ThenReturn(pull, repo, nil)
 	When(p.ParseGithubRepo(matchers.AnyPtrToGithubRepository())).ThenReturn(repo, nil)
@@ -304,10 +615,30 @@ func setup(t *testing.T) (server.EventsController, *mocks.MockGithubRequestValid
 		Parser:                 p,
 		CommandRunner:          cr,
 		PullCleaner:            c,
-		GithubWebHookSecret:    secret,
-		SupportedVCSHosts:      []vcs.Host{vcs.Github, vcs.Gitlab},
+		GithubWebHookSecrets:   map[string][]byte{"github.com": secret},
+		SupportedVCSHosts:      []vcs.Host{vcs.Github, vcs.Gitlab, vcs.Gitea, vcs.AzureDevops},
 		GitlabWebHookSecret:    secret,
 		GitlabRequestParser:    gl,
+		RepoWhitelist:          &events.RepoWhitelist{Whitelist: "*"},
 	}
 	return e, v, gl, p, cr, c
 }
+
+// setupGitea is setup with a mock Gitea request parser.
+func setupGitea(t *testing.T) (server.EventsController, *mocks.MockGiteaRequestParser, *emocks.MockEventParsing, *emocks.MockCommandRunner, *emocks.MockPullCleaner) {
+	e, _, _, p, cr, c := setup(t)
+	gt := mocks.NewMockGiteaRequestParser()
+	e.GiteaRequestParser = gt
+	e.GiteaWebHookSecret = secret
+	return e, gt, p, cr, c
+}
+
+// setupAzureDevops is setup with a mock Azure DevOps request parser.
+func setupAzureDevops(t *testing.T) (server.EventsController, *mocks.MockAzureDevopsRequestParser, *emocks.MockEventParsing, *emocks.MockCommandRunner, *emocks.MockPullCleaner) {
+	e, _, _, p, cr, c := setup(t)
+	ad := mocks.NewMockAzureDevopsRequestParser()
+	e.AzureDevopsRequestParser = ad
+	e.AzureDevopsWebHookUser = []byte("user")
+	e.AzureDevopsWebHookPassword = secret
+	return e, ad, p, cr, c
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hootsuite/atlantis/server/events/vcs"
+)
+
+// giteaSignatureHeader is the hex encoded HMAC-SHA256 of the request body
+// using the webhook secret. Forgejo sends it too.
+const giteaSignatureHeader = "X-Gitea-Signature"
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_gitea_request_parser.go GiteaRequestParser
+
+// GiteaRequestParser parses and validates Gitea and Forgejo requests.
+type GiteaRequestParser interface {
+	// Validate validates that the request was signed with secret.
+	// If secret is empty it does not check the signature.
+	// It then parses the request as a vcs.GiteaIssueCommentEvent or
+	// vcs.GiteaPullRequestEvent depending on the header provided by Gitea
+	// identifying the webhook type. If the webhook type is not recognized it
+	// will return nil but will not return an error.
+	// Usage:
+	//	event, err := GiteaRequestParser.Validate(r, secret)
+	//	if err != nil {
+	//		return
+	//	}
+	//	switch event := event.(type) {
+	//	case vcs.GiteaIssueCommentEvent:
+	//		// handle
+	//	case vcs.GiteaPullRequestEvent:
+	//		// handle
+	//	default:
+	//		// unsupported event
+	//	}
+	Validate(r *http.Request, secret []byte) (interface{}, error)
+}
+
+// DefaultGiteaRequestParser parses Gitea requests.
+type DefaultGiteaRequestParser struct{}
+
+// Validate returns the parsed event.
+// If secret is not empty, it checks that the request was signed
+// by secret and returns an error if it was not.
+// If secret is empty, it does not check if the request was signed.
+func (d *DefaultGiteaRequestParser) Validate(r *http.Request, secret []byte) (interface{}, error) {
+	const issueCommentEventHeader = "issue_comment"
+	const pullRequestEventHeader = "pull_request"
+
+	bytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate signature if secret is specified.
+	if len(secret) != 0 {
+		signature := r.Header.Get(giteaSignatureHeader)
+		if signature == "" {
+			return nil, fmt.Errorf("missing %s header", giteaSignatureHeader)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(bytes) // nolint: errcheck
+		expected := hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil, errors.New("payload signature check failed")
+		}
+	}
+
+	switch r.Header.Get(giteaHeader) {
+	case issueCommentEventHeader:
+		var event vcs.GiteaIssueCommentEvent
+		if err := json.Unmarshal(bytes, &event); err != nil {
+			return nil, err
+		}
+		return event, nil
+	case pullRequestEventHeader:
+		var event vcs.GiteaPullRequestEvent
+		if err := json.Unmarshal(bytes, &event); err != nil {
+			return nil, err
+		}
+		return event, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,93 @@
+package server_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/hootsuite/atlantis/server"
+	"github.com/hootsuite/atlantis/server/events/vcs"
+	"github.com/hootsuite/atlantis/server/events/vcs/fixtures"
+	. "github.com/hootsuite/atlantis/testing"
+)
+
+var giteaParser = server.DefaultGiteaRequestParser{}
+
+func TestGiteaValidate_MissingSignature(t *testing.T) {
+	t.Log("If a secret is set and the signature header is missing an error is returned")
+	req := giteaRequest(t, "issue_comment", fixtures.GiteaIssueCommentEventJSON)
+	_, err := giteaParser.Validate(req, []byte("secret"))
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "missing X-Gitea-Signature header", err.Error())
+}
+
+func TestGiteaValidate_InvalidSignature(t *testing.T) {
+	t.Log("If the signature doesn't match the secret an error is returned")
+	req := giteaRequest(t, "issue_comment", fixtures.GiteaIssueCommentEventJSON)
+	req.Header.Set("X-Gitea-Signature", sign("other-secret", fixtures.GiteaIssueCommentEventJSON))
+	_, err := giteaParser.Validate(req, []byte("secret"))
+	Assert(t, err != nil, "should be an error")
+	Equals(t, "payload signature check failed", err.Error())
+}
+
+func TestGiteaValidate_ValidSignature(t *testing.T) {
+	t.Log("If the signature matches the secret then the event is returned")
+	req := giteaRequest(t, "issue_comment", fixtures.GiteaIssueCommentEventJSON)
+	req.Header.Set("X-Gitea-Signature", sign("secret", fixtures.GiteaIssueCommentEventJSON))
+	event, err := giteaParser.Validate(req, []byte("secret"))
+	Ok(t, err)
+	comment := event.(vcs.GiteaIssueCommentEvent)
+	Equals(t, "created", comment.Action)
+	Equals(t, 2, comment.Issue.Number)
+	Equals(t, "atlantis plan", comment.Comment.Body)
+	Equals(t, "commenter", comment.Comment.User.Login)
+	Equals(t, "owner/repo", comment.Repository.FullName)
+	Equals(t, true, comment.IsPull)
+}
+
+func TestGiteaValidate_NoSecret(t *testing.T) {
+	t.Log("If there is no secret then we ignore the signature and return the event")
+	req := giteaRequest(t, "pull_request", fixtures.GiteaPullRequestEventJSON)
+	req.Header.Set("X-Gitea-Signature", "random")
+	event, err := giteaParser.Validate(req, nil)
+	Ok(t, err)
+	pull := event.(vcs.GiteaPullRequestEvent)
+	Equals(t, "closed", pull.Action)
+	Equals(t, 2, pull.PullRequest.Number)
+	Equals(t, "owner/repo", pull.Repository.FullName)
+}
+
+func TestGiteaValidate_InvalidEvent(t *testing.T) {
+	t.Log("If the event is malformed there should be an error")
+	for _, eventType := range []string{"issue_comment", "pull_request"} {
+		req := giteaRequest(t, eventType, "{")
+		_, err := giteaParser.Validate(req, nil)
+		Assert(t, err != nil, "should be an error")
+		Equals(t, "unexpected end of JSON input", err.Error())
+	}
+}
+
+func TestGiteaValidate_UnrecognizedEvent(t *testing.T) {
+	t.Log("If the event is not one we care about we return nil")
+	req := giteaRequest(t, "push", "{}")
+	event, err := giteaParser.Validate(req, nil)
+	Ok(t, err)
+	Equals(t, nil, event)
+}
+
+func giteaRequest(t *testing.T, eventType string, body string) *http.Request {
+	req, err := http.NewRequest("POST", "http://localhost/event", bytes.NewBufferString(body))
+	Ok(t, err)
+	req.Header.Set("X-Gitea-Event", eventType)
+	return req
+}
+
+// sign returns the signature Gitea would send for body.
+func sign(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body)) // nolint: errcheck
+	return hex.EncodeToString(mac.Sum(nil))
+}
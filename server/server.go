@@ -4,26 +4,32 @@ package server
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"flag"
 
 	"github.com/elazarl/go-bindata-assetfs"
 	"github.com/gorilla/mux"
 	"github.com/hootsuite/atlantis/server/events"
+	"github.com/hootsuite/atlantis/server/events/jobs"
 	"github.com/hootsuite/atlantis/server/events/locking"
 	"github.com/hootsuite/atlantis/server/events/locking/boltdb"
+	"github.com/hootsuite/atlantis/server/events/redaction"
 	"github.com/hootsuite/atlantis/server/events/run"
+	"github.com/hootsuite/atlantis/server/events/secrets"
 	"github.com/hootsuite/atlantis/server/events/terraform"
 	"github.com/hootsuite/atlantis/server/events/vcs"
 	"github.com/hootsuite/atlantis/server/events/webhooks"
 	"github.com/hootsuite/atlantis/server/logging"
 	"github.com/hootsuite/atlantis/server/static"
-	"github.com/lkysow/go-gitlab"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
 	"github.com/urfave/negroni"
@@ -31,6 +37,10 @@ import (
 
 const LockRouteName = "lock-detail"
 
+// JobRouteName is the name of the route for the page that shows a job's
+// output.
+const JobRouteName = "job-detail"
+
 // Server runs the Atlantis web server. It's used for webhook requests and the
 // Atlantis UI.
 type Server struct {
@@ -43,27 +53,112 @@ type Server struct {
 	EventsController   *EventsController
 	IndexTemplate      TemplateWriter
 	LockDetailTemplate TemplateWriter
+	Jobs               *jobs.Store
+	JobsTemplate       TemplateWriter
+	JobDetailTemplate  TemplateWriter
+	// AllowUICancel is true if running commands can be canceled from their
+	// page in the UI.
+	AllowUICancel bool
 }
 
 // Config configures Server.
 // The mapstructure tags correspond to flags in cmd/server.go and are used when
 // the config is parsed from a YAML file.
 type Config struct {
-	AtlantisURL         string          `mapstructure:"atlantis-url"`
-	DataDir             string          `mapstructure:"data-dir"`
-	GithubHostname      string          `mapstructure:"gh-hostname"`
-	GithubToken         string          `mapstructure:"gh-token"`
-	GithubUser          string          `mapstructure:"gh-user"`
-	GithubWebHookSecret string          `mapstructure:"gh-webhook-secret"`
-	GitlabHostname      string          `mapstructure:"gitlab-hostname"`
-	GitlabToken         string          `mapstructure:"gitlab-token"`
-	GitlabUser          string          `mapstructure:"gitlab-user"`
-	GitlabWebHookSecret string          `mapstructure:"gitlab-webhook-secret"`
-	LogLevel            string          `mapstructure:"log-level"`
-	Port                int             `mapstructure:"port"`
-	RequireApproval     bool            `mapstructure:"require-approval"`
-	SlackToken          string          `mapstructure:"slack-token"`
-	Webhooks            []WebhookConfig `mapstructure:"webhooks"`
+	AllowUICancel              bool                   `mapstructure:"allow-ui-cancel"`
+	ApplyTimeout               time.Duration          `mapstructure:"apply-timeout"`
+	AtlantisURL                string                 `mapstructure:"atlantis-url"`
+	AzureDevopsHostname        string                 `mapstructure:"azuredevops-hostname"`
+	AzureDevopsToken           string                 `mapstructure:"azuredevops-token"`
+	AzureDevopsUser            string                 `mapstructure:"azuredevops-user"`
+	AzureDevopsWebHookPassword string                 `mapstructure:"azuredevops-webhook-password"`
+	AzureDevopsWebHookUser     string                 `mapstructure:"azuredevops-webhook-user"`
+	CommentMode                string                 `mapstructure:"comment-mode"`
+	CommentTemplatesDir        string                 `mapstructure:"comment-templates-dir"`
+	DataDir                    string                 `mapstructure:"data-dir"`
+	ForkPRPolicy               string                 `mapstructure:"fork-pr-policy"`
+	GithubAppID                int64                  `mapstructure:"gh-app-id"`
+	GithubAppKeyFile           string                 `mapstructure:"gh-app-key-file"`
+	GithubChecks               bool                   `mapstructure:"gh-checks"`
+	GithubHostname             string                 `mapstructure:"gh-hostname"`
+	GithubHosts                []GithubHostConfig     `mapstructure:"github-hosts"`
+	GithubToken                string                 `mapstructure:"gh-token"`
+	GithubUser                 string                 `mapstructure:"gh-user"`
+	GithubWebHookSecret        string                 `mapstructure:"gh-webhook-secret"`
+	GiteaBaseURL               string                 `mapstructure:"gitea-base-url"`
+	GiteaToken                 string                 `mapstructure:"gitea-token"`
+	GiteaUser                  string                 `mapstructure:"gitea-user"`
+	GiteaWebHookSecret         string                 `mapstructure:"gitea-webhook-secret"`
+	GitlabCAFile               string                 `mapstructure:"gitlab-ca-file"`
+	GitlabHostname             string                 `mapstructure:"gitlab-hostname"`
+	GitlabToken                string                 `mapstructure:"gitlab-token"`
+	GitlabUser                 string                 `mapstructure:"gitlab-user"`
+	GitlabWebHookSecret        string                 `mapstructure:"gitlab-webhook-secret"`
+	HidePrevPlanComments       bool                   `mapstructure:"hide-prev-plan-comments"`
+	HookTimeout                time.Duration          `mapstructure:"hook-timeout"`
+	InitTimeout                time.Duration          `mapstructure:"init-timeout"`
+	LogLevel                   string                 `mapstructure:"log-level"`
+	LocalSecretsFile           string                 `mapstructure:"local-secrets-file"`
+	MaxFinishedJobs            int                    `mapstructure:"max-finished-jobs"`
+	PlanTimeout                time.Duration          `mapstructure:"plan-timeout"`
+	Port                       int                    `mapstructure:"port"`
+	ProjectEnv                 []ProjectEnvConfig     `mapstructure:"project-env"`
+	ProjectSecrets             []ProjectSecretsConfig `mapstructure:"project-secrets"`
+	RedactEnvVars              string                 `mapstructure:"redact-env-vars"`
+	RedactRegexes              []string               `mapstructure:"redact-regexes"`
+	RepoWhitelist              string                 `mapstructure:"repo-whitelist"`
+	RequireApproval            bool                   `mapstructure:"require-approval"`
+	SlackToken                 string                 `mapstructure:"slack-token"`
+	TFDownloadURL              string                 `mapstructure:"tf-download-url"`
+	VaultAddr                  string                 `mapstructure:"vault-addr"`
+	VaultToken                 string                 `mapstructure:"vault-token"`
+	Webhooks                   []WebhookConfig        `mapstructure:"webhooks"`
+}
+
+// ProjectEnvConfig is an environment variable that's set for the commands of
+// the projects it matches. Its value is either Value, the contents of File or
+// the value of the secret Secret, ex. secret/data/atlantis#db_password.
+// Repo, Path and Workspaces limit which projects and workspaces it's set
+// for. See events.ServerEnvVar.
+type ProjectEnvConfig struct {
+	Repo       string   `mapstructure:"repo"`
+	Path       string   `mapstructure:"path"`
+	Workspaces []string `mapstructure:"workspaces"`
+	Name       string   `mapstructure:"name"`
+	Value      string   `mapstructure:"value"`
+	File       string   `mapstructure:"file"`
+	Secret     string   `mapstructure:"secret"`
+}
+
+// ProjectSecretsConfig lets the projects in the repos Repo matches use the
+// secrets at the paths Path matches for their own environment variables.
+// See events.AllowedSecret.
+type ProjectSecretsConfig struct {
+	Repo string `mapstructure:"repo"`
+	Path string `mapstructure:"path"`
+}
+
+// EnvVar returns the environment variable p sets.
+func (p ProjectEnvConfig) EnvVar() events.EnvVar {
+	return events.EnvVar{
+		Name:       p.Name,
+		Value:      p.Value,
+		File:       p.File,
+		Secret:     p.Secret,
+		Workspaces: p.Workspaces,
+	}
+}
+
+// GithubHostConfig configures an additional GitHub host, ex. a GitHub
+// Enterprise installation, alongside the one configured with the gh-* flags.
+// Either User and Token or AppID and AppKeyFile must be set.
+type GithubHostConfig struct {
+	Hostname      string `mapstructure:"hostname"`
+	User          string `mapstructure:"user"`
+	Token         string `mapstructure:"token"`
+	AppID         int64  `mapstructure:"app-id"`
+	AppKeyFile    string `mapstructure:"app-key-file"`
+	WebHookSecret string `mapstructure:"webhook-secret"`
 }
 
 type WebhookConfig struct {
@@ -76,22 +171,65 @@ type WebhookConfig struct {
 
 func NewServer(config Config) (*Server, error) {
 	var supportedVCSHosts []vcs.Host
-	var githubClient *vcs.GithubClient
+	var githubClient *vcs.MultiGithubClient
 	var gitlabClient *vcs.GitlabClient
-	if config.GithubUser != "" {
+	var giteaClient *vcs.GiteaClient
+	var azureDevopsClient *vcs.AzureDevopsClient
+	githubCredentials := make(map[string]vcs.GithubCredentials)
+	githubWebHookSecrets := make(map[string][]byte)
+	githubHosts := config.GithubHosts
+	if config.GithubUser != "" || config.GithubAppID != 0 {
+		primary := GithubHostConfig{
+			Hostname:      config.GithubHostname,
+			User:          config.GithubUser,
+			Token:         config.GithubToken,
+			AppID:         config.GithubAppID,
+			AppKeyFile:    config.GithubAppKeyFile,
+			WebHookSecret: config.GithubWebHookSecret,
+		}
+		githubHosts = append([]GithubHostConfig{primary}, githubHosts...)
+	}
+	if len(githubHosts) > 0 {
 		supportedVCSHosts = append(supportedVCSHosts, vcs.Github)
+		githubClient = &vcs.MultiGithubClient{Clients: make(map[string]*vcs.GithubClient)}
+		for _, host := range githubHosts {
+			credentials, err := newGithubCredentials(host)
+			if err != nil {
+				return nil, errors.Wrapf(err, "configuring GitHub host %s", host.Hostname)
+			}
+			client, err := vcs.NewGithubClient(host.Hostname, credentials)
+			if err != nil {
+				return nil, err
+			}
+			githubCredentials[host.Hostname] = credentials
+			githubWebHookSecrets[host.Hostname] = []byte(host.WebHookSecret)
+			githubClient.Clients[host.Hostname] = client
+		}
+	}
+	if config.GitlabUser != "" {
+		supportedVCSHosts = append(supportedVCSHosts, vcs.Gitlab)
 		var err error
-		githubClient, err = vcs.NewGithubClient(config.GithubHostname, config.GithubUser, config.GithubToken)
+		gitlabClient, err = vcs.NewGitlabClient(config.GitlabHostname, config.GitlabUser, config.GitlabToken, config.GitlabCAFile)
 		if err != nil {
 			return nil, err
 		}
 	}
-	if config.GitlabUser != "" {
-		supportedVCSHosts = append(supportedVCSHosts, vcs.Gitlab)
-		gitlabClient = &vcs.GitlabClient{
-			Client: gitlab.NewClient(nil, config.GitlabToken),
+	if config.GiteaUser != "" {
+		supportedVCSHosts = append(supportedVCSHosts, vcs.Gitea)
+		var err error
+		giteaClient, err = vcs.NewGiteaClient(config.GiteaBaseURL, config.GiteaUser, config.GiteaToken)
+		if err != nil {
+			return nil, err
 		}
 	}
+	if config.AzureDevopsUser != "" {
+		supportedVCSHosts = append(supportedVCSHosts, vcs.AzureDevops)
+		azureDevopsClient = vcs.NewAzureDevopsClient(config.AzureDevopsHostname, config.AzureDevopsUser, config.AzureDevopsToken)
+	}
+	redactor, err := newRedactor(config, githubHosts)
+	if err != nil {
+		return nil, err
+	}
 	var webhooksConfig []webhooks.Config
 	for _, c := range config.Webhooks {
 		config := webhooks.Config{
@@ -102,20 +240,33 @@ func NewServer(config Config) (*Server, error) {
 		}
 		webhooksConfig = append(webhooksConfig, config)
 	}
-	webhooksManager, err := webhooks.NewMultiWebhookSender(webhooksConfig, webhooks.NewSlackClient(config.SlackToken))
+	webhooksManager, err := webhooks.NewMultiWebhookSender(webhooksConfig, webhooks.NewSlackClient(config.SlackToken, redactor))
 	if err != nil {
 		return nil, errors.Wrap(err, "initializing webhooks")
 	}
-	vcsClient := vcs.NewDefaultClientProxy(githubClient, gitlabClient)
-	commitStatusUpdater := &events.DefaultCommitStatusUpdater{Client: vcsClient}
-	terraformClient, err := terraform.NewClient()
+	vcsClient := vcs.NewDefaultClientProxy(githubClient, gitlabClient, giteaClient, azureDevopsClient)
+	var commitStatusUpdater events.CommitStatusUpdater = &events.DefaultCommitStatusUpdater{Client: vcsClient}
+	if config.GithubChecks {
+		commitStatusUpdater = &events.GithubChecksUpdater{
+			Client:   githubClient,
+			Fallback: commitStatusUpdater,
+		}
+	}
+	pluginCache := &terraform.PluginCache{Dir: terraform.PluginCacheDir(config.DataDir)}
+	terraformClient, err := terraform.NewClient(&terraform.Downloader{
+		URL:    strings.TrimSuffix(config.TFDownloadURL, "/"),
+		BinDir: filepath.Join(config.DataDir, "bin"),
+	}, pluginCache)
 	// The flag.Lookup call is to detect if we're running in a unit test. If we
 	// are, then we don't error out because we don't have/want terraform
 	// installed on our CI system where the unit tests run.
 	if err != nil && flag.Lookup("test.v") == nil {
 		return nil, errors.Wrap(err, "initializing terraform")
 	}
-	markdownRenderer := &events.MarkdownRenderer{}
+	markdownRenderer, err := events.NewMarkdownRenderer(config.CommentTemplatesDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading comment templates")
+	}
 	boltdb, err := boltdb.New(config.DataDir)
 	if err != nil {
 		return nil, err
@@ -127,15 +278,40 @@ func NewServer(config Config) (*Server, error) {
 	workspace := &events.FileWorkspace{
 		DataDir: config.DataDir,
 	}
+	if config.GitlabCAFile != "" {
+		workspace.CAFiles = map[string]string{config.GitlabHostname: config.GitlabCAFile}
+	}
+	terragrunt := terraform.NewTerragrunt(terraformClient)
+	envVars := &events.EnvVarResolver{}
+	for _, e := range config.ProjectEnv {
+		envVars.ServerEnvVars = append(envVars.ServerEnvVars, events.ServerEnvVar{EnvVar: e.EnvVar(), Repo: e.Repo, Path: e.Path})
+	}
+	for _, s := range config.ProjectSecrets {
+		envVars.AllowedSecrets = append(envVars.AllowedSecrets, events.AllowedSecret{Repo: s.Repo, Path: s.Path})
+	}
+	if config.VaultAddr != "" {
+		envVars.Secrets = &secrets.Vault{Address: config.VaultAddr, Token: config.VaultToken}
+	} else if config.LocalSecretsFile != "" {
+		envVars.Secrets = &secrets.LocalStore{Path: config.LocalSecretsFile}
+	}
 	projectPreExecute := &events.ProjectPreExecute{
 		Locker:       lockingClient,
 		Run:          run,
 		ConfigReader: configReader,
 		Terraform:    terraformClient,
+		Terragrunt:   terragrunt,
+		EnvVars:      envVars,
+		Timeouts: events.Timeouts{
+			Init:  config.InitTimeout,
+			Plan:  config.PlanTimeout,
+			Apply: config.ApplyTimeout,
+			Hooks: config.HookTimeout,
+		},
 	}
 	applyExecutor := &events.ApplyExecutor{
 		VCSClient:         vcsClient,
 		Terraform:         terraformClient,
+		Terragrunt:        terragrunt,
 		RequireApproval:   config.RequireApproval,
 		Run:               run,
 		Workspace:         workspace,
@@ -145,11 +321,12 @@ func NewServer(config Config) (*Server, error) {
 	planExecutor := &events.PlanExecutor{
 		VCSClient:         vcsClient,
 		Terraform:         terraformClient,
+		Terragrunt:        terragrunt,
 		Run:               run,
 		Workspace:         workspace,
 		ProjectPreExecute: projectPreExecute,
 		Locker:            lockingClient,
-		ProjectFinder:     &events.ProjectFinder{},
+		ProjectFinder:     &events.ProjectFinder{ConfigReader: configReader},
 	}
 	helpExecutor := &events.HelpExecutor{}
 	pullClosedExecutor := &events.PullClosedExecutor{
@@ -158,11 +335,16 @@ func NewServer(config Config) (*Server, error) {
 		Workspace: workspace,
 	}
 	logger := logging.NewSimpleLogger("server", nil, false, logging.ToLogLevel(config.LogLevel))
+	jobStore := jobs.NewStore(config.MaxFinishedJobs)
 	eventParser := &events.EventParser{
-		GithubUser:  config.GithubUser,
-		GithubToken: config.GithubToken,
-		GitlabUser:  config.GitlabUser,
-		GitlabToken: config.GitlabToken,
+		GithubUser:        config.GithubUser,
+		GithubCredentials: githubCredentials,
+		GitlabUser:        config.GitlabUser,
+		GitlabToken:       config.GitlabToken,
+		GiteaUser:         config.GiteaUser,
+		GiteaToken:        config.GiteaToken,
+		AzureDevopsUser:   config.AzureDevopsUser,
+		AzureDevopsToken:  config.AzureDevopsToken,
 	}
 	commandHandler := &events.CommandHandler{
 		ApplyExecutor:            applyExecutor,
@@ -173,21 +355,35 @@ func NewServer(config Config) (*Server, error) {
 		VCSClient:                vcsClient,
 		GithubPullGetter:         githubClient,
 		GitlabMergeRequestGetter: gitlabClient,
+		GiteaPullGetter:          giteaClient,
+		AzureDevopsPullGetter:    azureDevopsClient,
 		CommitStatusUpdater:      commitStatusUpdater,
 		EnvLocker:                concurrentRunLocker,
 		MarkdownRenderer:         markdownRenderer,
 		Logger:                   logger,
+		ForkPRPolicy:             config.ForkPRPolicy,
+		CommentMode:              config.CommentMode,
+		HidePrevPlanComments:     config.HidePrevPlanComments,
+		CommentUpdater:           &events.CommentUpdater{VCSClient: vcsClient},
+		Redactor:                 redactor,
+		Jobs:                     jobStore,
 	}
 	eventsController := &EventsController{
-		CommandRunner:          commandHandler,
-		PullCleaner:            pullClosedExecutor,
-		Parser:                 eventParser,
-		Logger:                 logger,
-		GithubWebHookSecret:    []byte(config.GithubWebHookSecret),
-		GithubRequestValidator: &DefaultGithubRequestValidator{},
-		GitlabRequestParser:    &DefaultGitlabRequestParser{},
-		GitlabWebHookSecret:    []byte(config.GitlabWebHookSecret),
-		SupportedVCSHosts:      supportedVCSHosts,
+		CommandRunner:              commandHandler,
+		PullCleaner:                pullClosedExecutor,
+		Parser:                     eventParser,
+		Logger:                     logger,
+		GithubWebHookSecrets:       githubWebHookSecrets,
+		GithubRequestValidator:     &DefaultGithubRequestValidator{},
+		GitlabRequestParser:        &DefaultGitlabRequestParser{},
+		GitlabWebHookSecret:        []byte(config.GitlabWebHookSecret),
+		GiteaRequestParser:         &DefaultGiteaRequestParser{},
+		GiteaWebHookSecret:         []byte(config.GiteaWebHookSecret),
+		AzureDevopsRequestParser:   &DefaultAzureDevopsRequestParser{},
+		AzureDevopsWebHookUser:     []byte(config.AzureDevopsWebHookUser),
+		AzureDevopsWebHookPassword: []byte(config.AzureDevopsWebHookPassword),
+		SupportedVCSHosts:          supportedVCSHosts,
+		RepoWhitelist:              &events.RepoWhitelist{Whitelist: config.RepoWhitelist},
 	}
 	router := mux.NewRouter()
 	return &Server{
@@ -200,6 +396,55 @@ func NewServer(config Config) (*Server, error) {
 		EventsController:   eventsController,
 		IndexTemplate:      indexTemplate,
 		LockDetailTemplate: lockTemplate,
+		Jobs:               jobStore,
+		JobsTemplate:       jobsTemplate,
+		JobDetailTemplate:  jobTemplate,
+		AllowUICancel:      config.AllowUICancel,
+	}, nil
+}
+
+// newRedactor returns the redactor for the output we post. Along with the
+// configured regexes and environment variables, it redacts the tokens and
+// secrets Atlantis was started with since they can end up in error messages,
+// ex. in the clone URL of a failed git clone.
+func newRedactor(config Config, githubHosts []GithubHostConfig) (*redaction.Redactor, error) {
+	values := []string{
+		config.AzureDevopsToken,
+		config.AzureDevopsWebHookPassword,
+		config.GiteaToken,
+		config.GiteaWebHookSecret,
+		config.GitlabToken,
+		config.GitlabWebHookSecret,
+		config.SlackToken,
+		config.VaultToken,
+	}
+	for _, host := range githubHosts {
+		values = append(values, host.Token, host.WebHookSecret)
+	}
+	for _, name := range strings.Split(config.RedactEnvVars, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			values = append(values, os.Getenv(name))
+		}
+	}
+	return redaction.NewRedactor(config.RedactRegexes, values)
+}
+
+// newGithubCredentials returns the credentials used to authenticate with host.
+func newGithubCredentials(host GithubHostConfig) (vcs.GithubCredentials, error) {
+	if host.AppID == 0 {
+		return &vcs.GithubUserCredentials{
+			User:  host.User,
+			Token: host.Token,
+		}, nil
+	}
+	key, err := ioutil.ReadFile(host.AppKeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading GitHub App key file")
+	}
+	return &vcs.GithubAppCredentials{
+		AppID:    host.AppID,
+		Key:      key,
+		Hostname: host.Hostname,
 	}, nil
 }
 
@@ -218,6 +463,17 @@ func (s *Server) Start() error {
 		u, _ := lockRoute.URL("id", url.QueryEscape(lockID))
 		return s.AtlantisURL + u.RequestURI()
 	})
+	s.Router.HandleFunc("/jobs", s.GetJobs).Methods("GET")
+	jobRoute := s.Router.HandleFunc("/jobs/{id}", s.GetJobRoute).Methods("GET").Name(JobRouteName)
+	s.Router.HandleFunc("/jobs/{id}/stream", s.StreamJobRoute).Methods("GET")
+	if s.AllowUICancel {
+		s.Router.HandleFunc("/jobs/{id}/cancel", s.CancelJobRoute).Methods("POST")
+	}
+	s.CommandHandler.JobURL = func(id string) string {
+		// ignoring error since guaranteed to succeed if "id" is specified
+		u, _ := jobRoute.URL("id", id)
+		return s.AtlantisURL + u.RequestURI()
+	}
 	n := negroni.New(&negroni.Recovery{
 		Logger:     log.New(os.Stdout, "", log.LstdFlags),
 		PrintStack: false,
@@ -327,6 +583,140 @@ func (s *Server) DeleteLock(w http.ResponseWriter, _ *http.Request, id string) {
 	s.respond(w, logging.Info, http.StatusOK, "Deleted lock id %s", idUnencoded)
 }
 
+// GetJobs renders the jobs page, which lists the running jobs and the most
+// recently finished ones.
+func (s *Server) GetJobs(w http.ResponseWriter, _ *http.Request) {
+	var results []JobIndexData
+	for _, job := range s.Jobs.List() {
+		jobURL, _ := s.Router.Get(JobRouteName).URL("id", job.ID)
+		results = append(results, JobIndexData{
+			JobURL:       jobURL.String(),
+			RepoFullName: job.RepoFullName,
+			PullNum:      job.PullNum,
+			Command:      job.Command,
+			Environment:  job.Environment,
+			Running:      job.Finished().IsZero(),
+			Time:         job.Started,
+		})
+	}
+	s.JobsTemplate.Execute(w, results) // nolint: errcheck
+}
+
+func (s *Server) GetJobRoute(w http.ResponseWriter, r *http.Request) {
+	s.GetJob(w, r, mux.Vars(r)["id"])
+}
+
+// GetJob renders the page for the job with id. The output of finished jobs is
+// rendered in the page and running jobs stream their output from
+// StreamJob.
+func (s *Server) GetJob(w http.ResponseWriter, _ *http.Request, id string) {
+	job := s.Jobs.Get(id)
+	if job == nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "No job found at that id")
+		return
+	}
+	data := JobDetailData{
+		ID:              job.ID,
+		RepoFullName:    job.RepoFullName,
+		PullNum:         job.PullNum,
+		PullRequestLink: job.PullURL,
+		Command:         job.Command,
+		Environment:     job.Environment,
+		Running:         job.Finished().IsZero(),
+		CanCancel:       s.AllowUICancel,
+		Started:         job.Started,
+		Finished:        job.Finished(),
+	}
+	if !data.Running {
+		data.Output = strings.Join(job.Lines(), "\n")
+	}
+	s.JobDetailTemplate.Execute(w, data) // nolint: errcheck
+}
+
+func (s *Server) StreamJobRoute(w http.ResponseWriter, r *http.Request) {
+	s.StreamJob(w, r, mux.Vars(r)["id"])
+}
+
+// StreamJob streams the output of the job with id as server-sent events. The
+// output so far is sent first and then each line as it's written. A "done"
+// event is sent once the job finishes.
+func (s *Server) StreamJob(w http.ResponseWriter, r *http.Request, id string) {
+	job := s.Jobs.Get(id)
+	if job == nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "No job found at that id")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.respond(w, logging.Error, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	lines, ch := job.Subscribe()
+	defer job.Unsubscribe(ch)
+	for _, line := range lines {
+		writeEvent(w, "", line)
+	}
+	flusher.Flush()
+	for {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				// The channel is also closed if we fell behind, in which
+				// case the browser reconnects and gets the output again.
+				if !job.Finished().IsZero() {
+					writeEvent(w, "done", "")
+				}
+				flusher.Flush()
+				return
+			}
+			writeEvent(w, "", line)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) CancelJobRoute(w http.ResponseWriter, r *http.Request) {
+	s.CancelJob(w, r, mux.Vars(r)["id"])
+}
+
+// CancelJob cancels the command that the job with id is for. Requests must
+// set the X-Requested-With header, which the UI's requests do, since browsers
+// don't let other sites set it. This stops other sites from canceling
+// commands through the browsers of people who can reach the UI.
+func (s *Server) CancelJob(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Header.Get("X-Requested-With") != "XMLHttpRequest" {
+		s.respond(w, logging.Warn, http.StatusForbidden, "Canceling a job requires the X-Requested-With header")
+		return
+	}
+	job := s.Jobs.Get(id)
+	if job == nil {
+		s.respond(w, logging.Warn, http.StatusNotFound, "No job found at that id")
+		return
+	}
+	if !job.Finished().IsZero() || !s.CommandHandler.CancelCommand(job.RepoHostname, job.RepoFullName, job.PullNum, job.Environment, "The Atlantis UI") {
+		s.respond(w, logging.Warn, http.StatusConflict, "Job %s isn't running", id)
+		return
+	}
+	s.respond(w, logging.Info, http.StatusOK, "Canceled job %s", id)
+}
+
+// writeEvent writes a server-sent event. If event is empty, it's a message
+// event.
+func writeEvent(w io.Writer, event string, data string) {
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event) // nolint: errcheck
+	}
+	// Carriage returns end the data in an event so they're dropped.
+	fmt.Fprintf(w, "data: %s\n\n", strings.Replace(data, "\r", "", -1)) // nolint: errcheck
+}
+
 // postEvents handles POST requests to our /events endpoint. These should be
 // VCS webhook requests.
 func (s *Server) postEvents(w http.ResponseWriter, r *http.Request) {
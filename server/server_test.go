@@ -1,17 +1,22 @@
 package server_test
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hootsuite/atlantis/server"
+	"github.com/hootsuite/atlantis/server/events"
+	"github.com/hootsuite/atlantis/server/events/jobs"
 	"github.com/hootsuite/atlantis/server/events/locking/mocks"
 	"github.com/hootsuite/atlantis/server/events/models"
 	"github.com/hootsuite/atlantis/server/logging"
@@ -30,6 +35,20 @@ func TestNewServer(t *testing.T) {
 	Ok(t, err)
 }
 
+func TestNewServer_InvalidCommentTemplate(t *testing.T) {
+	t.Log("NewServer should fail if a comment template can't be parsed")
+	tmpDir, err := ioutil.TempDir("", "")
+	Ok(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, "help.tmpl"), []byte("{{ .Unclosed"), 0600))
+	_, err = server.NewServer(server.Config{
+		DataDir:             tmpDir,
+		CommentTemplatesDir: tmpDir,
+	})
+	Assert(t, err != nil, "expected error")
+	Assert(t, strings.HasPrefix(err.Error(), "loading comment templates: parsing template help"), "unexpected error %q", err)
+}
+
 func TestIndex_LockErr(t *testing.T) {
 	t.Log("index should return a 503 if unable to list locks")
 	RegisterMockTestingT(t)
@@ -223,6 +242,177 @@ func TestDeleteLock_Success(t *testing.T) {
 	responseContains(t, w, http.StatusOK, "Deleted lock id id")
 }
 
+func TestGetJobs_Success(t *testing.T) {
+	t.Log("GetJobs should render the jobs template with the running and finished jobs")
+	RegisterMockTestingT(t)
+	store := jobs.NewStore(10)
+	finished := store.Start(&jobs.Job{RepoFullName: "owner/repo", PullNum: 1, Command: "plan", Environment: "default"})
+	store.Finish(finished)
+	running := store.Start(&jobs.Job{RepoFullName: "owner/repo", PullNum: 2, Command: "apply", Environment: "staging"})
+	tmpl := sMocks.NewMockTemplateWriter()
+	r := mux.NewRouter()
+	r.NewRoute().Path("/jobs/{id}").Name(server.JobRouteName)
+	s := server.Server{
+		Jobs:         store,
+		JobsTemplate: tmpl,
+		Router:       r,
+	}
+	eventsReq, _ = http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	s.GetJobs(w, eventsReq)
+	tmpl.VerifyWasCalledOnce().Execute(w, []server.JobIndexData{
+		{
+			JobURL:       "/jobs/" + running.ID,
+			RepoFullName: "owner/repo",
+			PullNum:      2,
+			Command:      "apply",
+			Environment:  "staging",
+			Running:      true,
+			Time:         running.Started,
+		},
+		{
+			JobURL:       "/jobs/" + finished.ID,
+			RepoFullName: "owner/repo",
+			PullNum:      1,
+			Command:      "plan",
+			Environment:  "default",
+			Running:      false,
+			Time:         finished.Started,
+		},
+	})
+}
+
+func TestGetJob_None(t *testing.T) {
+	t.Log("If the job doesn't exist we should get a 404")
+	s := server.Server{
+		Jobs: jobs.NewStore(10),
+	}
+	eventsReq, _ = http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	s.GetJob(w, eventsReq, "id")
+	responseContains(t, w, http.StatusNotFound, "No job found at that id")
+}
+
+func TestGetJob_Finished(t *testing.T) {
+	t.Log("The output of a finished job should be rendered in the page")
+	RegisterMockTestingT(t)
+	store := jobs.NewStore(10)
+	job := store.Start(&jobs.Job{RepoFullName: "owner/repo", PullNum: 1, PullURL: "url", Command: "plan", Environment: "default"})
+	_, err := job.Write([]byte("line1\nline2\n"))
+	Ok(t, err)
+	store.Finish(job)
+	tmpl := sMocks.NewMockTemplateWriter()
+	s := server.Server{
+		Jobs:              store,
+		JobDetailTemplate: tmpl,
+	}
+	eventsReq, _ = http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	s.GetJob(w, eventsReq, job.ID)
+	tmpl.VerifyWasCalledOnce().Execute(w, server.JobDetailData{
+		ID:              job.ID,
+		RepoFullName:    "owner/repo",
+		PullNum:         1,
+		PullRequestLink: "url",
+		Command:         "plan",
+		Environment:     "default",
+		Running:         false,
+		Started:         job.Started,
+		Finished:        job.Finished(),
+		Output:          "line1\nline2",
+	})
+}
+
+func TestStreamJob(t *testing.T) {
+	t.Log("StreamJob should send the output as server-sent events and then a done event once the job finishes")
+	store := jobs.NewStore(10)
+	job := store.Start(&jobs.Job{})
+	_, err := job.Write([]byte("line1\n"))
+	Ok(t, err)
+	s := server.Server{
+		Jobs: store,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.StreamJob(w, r, job.ID)
+	}))
+	defer ts.Close()
+	resp, err := http.Get(ts.URL)
+	Ok(t, err)
+	defer resp.Body.Close() // nolint: errcheck
+	Equals(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Read the first event before writing the second line so it has to be
+	// streamed.
+	body := bufio.NewReader(resp.Body)
+	event, err := body.ReadString('\n')
+	Ok(t, err)
+	Equals(t, "data: line1\n", event)
+	_, err = job.Write([]byte("line2\r\n"))
+	Ok(t, err)
+	store.Finish(job)
+	rest, err := ioutil.ReadAll(body)
+	Ok(t, err)
+	Equals(t, "\ndata: line2\n\nevent: done\ndata: \n\n", string(rest))
+}
+
+func TestStreamJob_None(t *testing.T) {
+	t.Log("If the job doesn't exist we should get a 404")
+	s := server.Server{
+		Jobs: jobs.NewStore(10),
+	}
+	eventsReq, _ = http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	s.StreamJob(w, eventsReq, "id")
+	responseContains(t, w, http.StatusNotFound, "No job found at that id")
+}
+
+func TestCancelJob_None(t *testing.T) {
+	t.Log("If the job doesn't exist we should get a 404")
+	s := server.Server{
+		Jobs:   jobs.NewStore(10),
+		Logger: logging.NewNoopLogger(),
+	}
+	eventsReq, _ = http.NewRequest("POST", "", bytes.NewBuffer(nil))
+	eventsReq.Header.Set("X-Requested-With", "XMLHttpRequest")
+	w := httptest.NewRecorder()
+	s.CancelJob(w, eventsReq, "id")
+	responseContains(t, w, http.StatusNotFound, "No job found at that id")
+}
+
+func TestCancelJob_NoRequestedWith(t *testing.T) {
+	t.Log("If the request doesn't set X-Requested-With, ex. a form on another site, we should get a 403")
+	store := jobs.NewStore(10)
+	job := store.Start(&jobs.Job{RepoFullName: "owner/repo", PullNum: 1, Command: "plan", Environment: "default"})
+	s := server.Server{
+		Jobs:   store,
+		Logger: logging.NewNoopLogger(),
+	}
+	eventsReq, _ = http.NewRequest("POST", "", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	s.CancelJob(w, eventsReq, job.ID)
+	responseContains(t, w, http.StatusForbidden, "requires the X-Requested-With header")
+}
+
+func TestCancelJob_NotRunning(t *testing.T) {
+	t.Log("If the job's command isn't running we should get a 409")
+	store := jobs.NewStore(10)
+	running := store.Start(&jobs.Job{RepoFullName: "owner/repo", PullNum: 1, Command: "plan", Environment: "default"})
+	finished := store.Start(&jobs.Job{RepoFullName: "owner/repo", PullNum: 1, Command: "plan", Environment: "default"})
+	store.Finish(finished)
+	s := server.Server{
+		Jobs:           store,
+		CommandHandler: &events.CommandHandler{},
+		Logger:         logging.NewNoopLogger(),
+	}
+	for _, id := range []string{running.ID, finished.ID} {
+		eventsReq, _ = http.NewRequest("POST", "", bytes.NewBuffer(nil))
+		eventsReq.Header.Set("X-Requested-With", "XMLHttpRequest")
+		w := httptest.NewRecorder()
+		s.CancelJob(w, eventsReq, id)
+		responseContains(t, w, http.StatusConflict, "isn't running")
+	}
+}
+
 func responseContains(t *testing.T, r *httptest.ResponseRecorder, status int, bodySubstr string) {
 	Equals(t, status, r.Result().StatusCode)
 	body, _ := ioutil.ReadAll(r.Result().Body)
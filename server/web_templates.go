@@ -55,7 +55,7 @@ var indexTemplate = template.Must(template.New("index.html.tmpl").Parse(`
   <div class="navbar-spacer"></div>
   <br>
   <section>
-    <p class="title-heading small"><strong>Environments</strong></p>
+    <p class="title-heading small"><strong>Environments</strong> <a class="heading-font-size" href="/jobs">View jobs</a></p>
     {{ if . }}
     {{ range . }}
       <a href="{{.LockURL}}">
@@ -185,3 +185,149 @@ var lockTemplate = template.Must(template.New("lock.html.tmpl").Parse(`
 </body>
 </html>
 `))
+
+type JobIndexData struct {
+	JobURL       string
+	RepoFullName string
+	PullNum      int
+	Command      string
+	Environment  string
+	Running      bool
+	Time         time.Time
+}
+
+var jobsTemplate = template.Must(template.New("jobs.html.tmpl").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>atlantis</title>
+  <meta name="description" content="">
+  <meta name="author" content="">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <link rel="stylesheet" href="/static/css/normalize.css">
+  <link rel="stylesheet" href="/static/css/skeleton.css">
+  <link rel="stylesheet" href="/static/css/custom.css">
+  <link rel="icon" type="image/png" href="/static/images/atlantis-icon.png">
+</head>
+<body>
+<div class="container">
+  <section class="header">
+    <a title="atlantis" href="/"><img src="/static/images/atlantis-icon.png"/></a>
+    <p class="title-heading">atlantis</p>
+  </section>
+  <div class="navbar-spacer"></div>
+  <br>
+  <section>
+    <p class="title-heading small"><strong>Jobs</strong></p>
+    {{ if . }}
+    {{ range . }}
+      <a href="{{.JobURL}}">
+        <div class="twelve columns button content lock-row">
+        <div class="list-title">{{.RepoFullName}} - <span class="heading-font-size">#{{.PullNum}} {{.Command}} {{.Environment}}</span></div>
+        <div class="list-status"><code>{{ if .Running }}Running{{ else }}Finished{{ end }}</code></div>
+        <div class="list-timestamp"><span class="heading-font-size">{{.Time}}</span></div>
+        </div>
+      </a>
+    {{ end }}
+    {{ else }}
+    <p class="placeholder">No jobs found.</p>
+    {{ end }}
+  </section>
+</div>
+</body>
+</html>
+`))
+
+type JobDetailData struct {
+	ID              string
+	RepoFullName    string
+	PullNum         int
+	PullRequestLink string
+	Command         string
+	Environment     string
+	Running         bool
+	Started         time.Time
+	Finished        time.Time
+	// Output is only set for finished jobs. Running jobs stream it.
+	Output string
+	// CanCancel is true if running jobs can be canceled from the UI.
+	CanCancel bool
+}
+
+var jobTemplate = template.Must(template.New("job.html.tmpl").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>atlantis</title>
+  <meta name="description" content="">
+  <meta name="author" content="">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <link rel="stylesheet" href="/static/css/normalize.css">
+  <link rel="stylesheet" href="/static/css/skeleton.css">
+  <link rel="stylesheet" href="/static/css/custom.css">
+  <link rel="icon" type="image/png" href="/static/images/atlantis-icon.png">
+  <script src="/static/js/jquery-3.2.1.min.js"></script>
+  <style>
+    #output { white-space: pre-wrap; word-wrap: break-word; font-size: 1.2rem; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <section class="header">
+    <a title="atlantis" href="/"><img src="/static/images/atlantis-icon.png"/></a>
+    <p class="title-heading">atlantis</p>
+    <p class="title-heading"><strong>{{.RepoFullName}} #{{.PullNum}}</strong> <code id="status">{{ if .Running }}Running{{ else }}Finished{{ end }}</code></p>
+    </section>
+    <div class="navbar-spacer"></div>
+    <br>
+    <section>
+      <h6><code>Pull Request Link</code>: <a href="{{.PullRequestLink}}" target="_blank"><strong>{{.PullRequestLink}}</strong></a></h6>
+      <h6><code>Command</code>: <strong>{{.Command}}</strong></h6>
+      <h6><code>Environment</code>: <strong>{{.Environment}}</strong></h6>
+      <h6><code>Started</code>: <strong>{{.Started}}</strong></h6>
+      {{ if not .Running }}<h6><code>Finished</code>: <strong>{{.Finished}}</strong></h6>{{ end }}
+      {{ if and .Running .CanCancel }}<a class="button button-default" id="cancelJob">Cancel</a>{{ end }}
+      <pre><code id="output">{{.Output}}</code></pre>
+    </section>
+  </div>
+{{ if .Running }}
+<script>
+  var output = document.getElementById("output");
+  var source = new EventSource("/jobs/{{.ID}}/stream");
+  // The whole output is sent each time we connect so start over.
+  source.onopen = function() {
+    output.textContent = "";
+  };
+  source.onmessage = function(event) {
+    var atBottom = window.innerHeight + window.scrollY >= document.body.offsetHeight;
+    output.textContent += event.data + "\n";
+    if (atBottom) {
+      window.scrollTo(0, document.body.scrollHeight);
+    }
+  };
+  source.addEventListener("done", function() {
+    source.close();
+    document.getElementById("status").textContent = "Finished";
+    $("#cancelJob").hide();
+  });
+
+  $("#cancelJob").click(function() {
+    if (!confirm("Are you sure you want to cancel this {{.Command}}?")) {
+      return;
+    }
+    $.ajax({
+        url: '/jobs/{{.ID}}/cancel',
+        type: 'POST',
+        success: function(result) {
+          $("#cancelJob").hide();
+          document.getElementById("status").textContent = "Canceling";
+        }
+    });
+  });
+</script>
+{{ end }}
+</body>
+</html>
+`))